@@ -0,0 +1,44 @@
+package agents
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"granth/internal/utils"
+)
+
+type serviceAccountContextKey struct{}
+
+// AgentAuthMiddleware authenticates requests carrying a service account API
+// key. The account is exposed to handlers through the request context, and
+// claims with token type "agent" are set so shared service code attributes
+// work to the account and can refuse agent-only-forbidden actions.
+func AgentAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader := r.Header.Get("Authorization")
+		if !strings.HasPrefix(authHeader, "Bearer ") {
+			http.Error(w, "API key required", http.StatusUnauthorized)
+			return
+		}
+
+		account, err := authenticateAPIKey(strings.TrimPrefix(authHeader, "Bearer "), r.Context())
+		if err != nil {
+			http.Error(w, "Invalid API key", http.StatusUnauthorized)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), serviceAccountContextKey{}, account)
+		ctx = utils.WithClaims(ctx, &utils.Claims{
+			UserID:     account.ID,
+			Authorized: true,
+			TokenType:  "agent",
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func getServiceAccountFromContext(ctx context.Context) (*ServiceAccount, bool) {
+	account, ok := ctx.Value(serviceAccountContextKey{}).(*ServiceAccount)
+	return account, ok
+}
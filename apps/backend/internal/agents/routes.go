@@ -0,0 +1,193 @@
+package agents
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"granth/internal/proposals"
+	"granth/internal/utils"
+	"granth/internal/workspaces"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ServiceAccountsRouter serves service account management for workspace
+// admins. It is mounted behind the regular user AuthMiddleware.
+func ServiceAccountsRouter() http.Handler {
+	r := chi.NewRouter()
+	r.Use(utils.RequireScope(utils.ScopeAdmin))
+
+	r.Get("/workspace/{workspaceID}", handleListServiceAccounts)
+	r.Post("/workspace/{workspaceID}", handleCreateServiceAccount)
+	r.Delete("/{id}", handleDeleteServiceAccount)
+	r.Get("/{id}/keys", handleListAPIKeys)
+	r.Post("/{id}/keys", handleCreateAPIKey)
+	r.Delete("/{id}/keys/{keyID}", handleDeleteAPIKey)
+
+	return r
+}
+
+// agentAPILimit is the overall request budget of one service account.
+var agentAPILimit = utils.RateLimit{Name: "agent_api", Limit: 300, Window: time.Minute}
+
+// AgentRouter is the stable, versioned surface for machine proposers. Agents
+// can read canonical blocks, submit proposals and poll them; they can never
+// accept or reject.
+func AgentRouter() http.Handler {
+	r := chi.NewRouter()
+	r.Use(AgentAuthMiddleware)
+	r.Use(utils.RateLimitMiddleware(agentAPILimit, utils.KeyByUser))
+
+	r.Get("/documents/{documentID}/blocks", handleAgentGetBlocks)
+	r.With(utils.RateLimitMiddleware(proposals.CreateLimit, utils.KeyByUser)).Post("/documents/{documentID}/proposals", handleAgentSubmitProposal)
+	r.Get("/proposals/{id}", handleAgentGetProposal)
+
+	return r
+}
+
+// ── Management handlers ───────────────────────────────────────────────────────
+
+func handleListServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	workspaceID := chi.URLParam(r, "workspaceID")
+	accounts, err := listServiceAccounts(workspaceID, r.Context())
+	if err != nil {
+		writeManagementError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, accounts)
+}
+
+func handleCreateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	workspaceID := chi.URLParam(r, "workspaceID")
+	var req struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+
+	account, err := createServiceAccount(workspaceID, req.Name, req.Description, r.Context())
+	if err != nil {
+		writeManagementError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, account)
+}
+
+func handleDeleteServiceAccount(w http.ResponseWriter, r *http.Request) {
+	if err := deleteServiceAccount(chi.URLParam(r, "id"), r.Context()); err != nil {
+		writeManagementError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func handleListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := listAPIKeys(chi.URLParam(r, "id"), r.Context())
+	if err != nil {
+		writeManagementError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, keys)
+}
+
+func handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	key, err := createAPIKey(chi.URLParam(r, "id"), r.Context())
+	if err != nil {
+		writeManagementError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, key)
+}
+
+func handleDeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	if err := deleteAPIKey(chi.URLParam(r, "id"), chi.URLParam(r, "keyID"), r.Context()); err != nil {
+		writeManagementError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeManagementError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, workspaces.ErrPermissionDenied) || errors.Is(err, workspaces.ErrTwoFactorRequired):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case err.Error() == "service account not found" || err.Error() == "API key not found":
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err.Error() == "service account has been revoked":
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// ── Agent handlers ────────────────────────────────────────────────────────────
+
+func handleAgentGetBlocks(w http.ResponseWriter, r *http.Request) {
+	documentID := chi.URLParam(r, "documentID")
+	blocks, err := getCanonicalBlocks(documentID, r.Context())
+	if err != nil {
+		writeAgentError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, blocks)
+}
+
+func handleAgentSubmitProposal(w http.ResponseWriter, r *http.Request) {
+	documentID := chi.URLParam(r, "documentID")
+
+	var req ProposalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	proposal, err := submitProposal(documentID, req, r.Context())
+	if err != nil {
+		writeAgentError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]string{
+		"proposal_id": proposal.ID,
+		"state":       proposal.State,
+	})
+}
+
+func handleAgentGetProposal(w http.ResponseWriter, r *http.Request) {
+	status, err := getProposalStatus(chi.URLParam(r, "id"), r.Context())
+	if err != nil {
+		writeAgentError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func writeAgentError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	switch {
+	case msg == "document not found" || msg == "proposal not found":
+		http.Error(w, msg, http.StatusNotFound)
+	case msg == "title and intent are required" || msg == "reasoning.rationale is required" ||
+		msg == "at least one change is required" || strings.HasPrefix(msg, "changes["):
+		http.Error(w, msg, http.StatusBadRequest)
+	default:
+		http.Error(w, msg, http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, "error encoding JSON: "+err.Error(), http.StatusInternalServerError)
+	}
+}
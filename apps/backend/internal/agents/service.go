@@ -0,0 +1,294 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"granth/internal/audit"
+	"granth/internal/blocks"
+	"granth/internal/proposals"
+	"granth/internal/utils"
+	"granth/internal/workspaces"
+)
+
+// apiKeyKind prefixes every service account key so they are recognisable in
+// logs and secret scanners.
+const apiKeyKind = "gak"
+
+// ── Management (human admins) ─────────────────────────────────────────────────
+
+func requireServiceAccountManager(workspaceID string, ctx context.Context) (string, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return "", fmt.Errorf("user ID not found in context")
+	}
+	// Service accounts are workspace principals, managed like members
+	if err := workspaces.RequirePermission(workspaceID, workspaces.PermManageMembers, ctx); err != nil {
+		return "", err
+	}
+	return userID, nil
+}
+
+func createServiceAccount(workspaceID, name, description string, ctx context.Context) (*ServiceAccount, error) {
+	userID, err := requireServiceAccountManager(workspaceID, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &ServiceAccount{
+		WorkspaceID: workspaceID,
+		Name:        name,
+		Description: description,
+		CreatedBy:   &userID,
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := insertServiceAccount(a, ctx); err != nil {
+		return nil, err
+	}
+	audit.Record(audit.Entry{WorkspaceID: workspaceID, Action: "service_account.created", TargetType: "service_account", TargetID: a.ID, After: a}, ctx)
+	return a, nil
+}
+
+func listServiceAccounts(workspaceID string, ctx context.Context) ([]*ServiceAccount, error) {
+	if _, err := requireServiceAccountManager(workspaceID, ctx); err != nil {
+		return nil, err
+	}
+	return fetchServiceAccountsForWorkspace(workspaceID, ctx)
+}
+
+// loadManagedAccount fetches an account and checks the caller administers
+// its workspace.
+func loadManagedAccount(accountID string, ctx context.Context) (*ServiceAccount, string, error) {
+	a, err := fetchServiceAccount(accountID, ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	userID, err := requireServiceAccountManager(a.WorkspaceID, ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	return a, userID, nil
+}
+
+func deleteServiceAccount(accountID string, ctx context.Context) error {
+	a, _, err := loadManagedAccount(accountID, ctx)
+	if err != nil {
+		return err
+	}
+	if err := revokeServiceAccount(accountID, time.Now().UTC().Format(time.RFC3339), ctx); err != nil {
+		return err
+	}
+	audit.Record(audit.Entry{WorkspaceID: a.WorkspaceID, Action: "service_account.revoked", TargetType: "service_account", TargetID: accountID, Before: a}, ctx)
+	return nil
+}
+
+func createAPIKey(accountID string, ctx context.Context) (*CreatedAPIKey, error) {
+	a, userID, err := loadManagedAccount(accountID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if a.RevokedAt != nil {
+		return nil, fmt.Errorf("service account has been revoked")
+	}
+
+	token, lookup, hash, err := utils.GenerateOpaqueToken(apiKeyKind)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &CreatedAPIKey{
+		APIKey: APIKey{
+			AccountID: accountID,
+			Prefix:    lookup,
+			CreatedBy: &userID,
+			CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		},
+		Key: token,
+	}
+	if err := insertAPIKey(&k.APIKey, hash, ctx); err != nil {
+		return nil, err
+	}
+	audit.Record(audit.Entry{WorkspaceID: a.WorkspaceID, Action: "api_key.created", TargetType: "service_account", TargetID: accountID, After: k.APIKey}, ctx)
+	return k, nil
+}
+
+func listAPIKeys(accountID string, ctx context.Context) ([]*APIKey, error) {
+	if _, _, err := loadManagedAccount(accountID, ctx); err != nil {
+		return nil, err
+	}
+	return fetchAPIKeysForAccount(accountID, ctx)
+}
+
+func deleteAPIKey(accountID, keyID string, ctx context.Context) error {
+	a, _, err := loadManagedAccount(accountID, ctx)
+	if err != nil {
+		return err
+	}
+	revoked, err := revokeAPIKey(accountID, keyID, time.Now().UTC().Format(time.RFC3339), ctx)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return fmt.Errorf("API key not found")
+	}
+	audit.Record(audit.Entry{
+		WorkspaceID: a.WorkspaceID, Action: "api_key.revoked", TargetType: "service_account", TargetID: accountID,
+		Before: map[string]string{"key_id": keyID},
+	}, ctx)
+	return nil
+}
+
+// authenticateAPIKey resolves a presented key to its live service account.
+func authenticateAPIKey(key string, ctx context.Context) (*ServiceAccount, error) {
+	lookup, ok := utils.ParseOpaqueToken(apiKeyKind, key)
+	if !ok {
+		return nil, fmt.Errorf("invalid API key")
+	}
+
+	keyID, keyHash, account, err := fetchActiveKeyByPrefix(lookup, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !utils.TokenMatchesHash(key, keyHash) {
+		return nil, fmt.Errorf("invalid API key")
+	}
+
+	if err := touchAPIKey(keyID, time.Now().UTC().Format(time.RFC3339), ctx); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// ── Agent API ─────────────────────────────────────────────────────────────────
+
+// requireDocumentInWorkspace confines an agent to documents of the workspace
+// its service account belongs to.
+func requireDocumentInWorkspace(documentID string, ctx context.Context) (*ServiceAccount, error) {
+	account, ok := getServiceAccountFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("service account not found in context")
+	}
+	workspaceID, err := fetchDocumentWorkspaceID(documentID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if workspaceID != account.WorkspaceID {
+		// Same response as a missing document, so agents cannot probe other workspaces
+		return nil, fmt.Errorf("document not found")
+	}
+	return account, nil
+}
+
+func submitProposal(documentID string, req ProposalRequest, ctx context.Context) (*proposals.Proposal, error) {
+	account, err := requireDocumentInWorkspace(documentID, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(req.Title) == "" || strings.TrimSpace(req.Intent) == "" {
+		return nil, fmt.Errorf("title and intent are required")
+	}
+	if strings.TrimSpace(req.Reasoning.Rationale) == "" {
+		return nil, fmt.Errorf("reasoning.rationale is required")
+	}
+	if len(req.Changes) == 0 {
+		return nil, fmt.Errorf("at least one change is required")
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	affected := make([]string, 0, len(req.Changes))
+	changes := make([]*proposals.ProposalBlockChange, 0, len(req.Changes))
+	for i, c := range req.Changes {
+		switch c.Action {
+		case "create":
+		case "update", "delete":
+			if c.BlockID == nil {
+				return nil, fmt.Errorf("changes[%d]: block_id is required for %s", i, c.Action)
+			}
+			block, err := blocks.FetchBlockByID(*c.BlockID, ctx)
+			if err != nil || block.DocumentID != documentID {
+				return nil, fmt.Errorf("changes[%d]: block not found in document", i)
+			}
+			affected = append(affected, *c.BlockID)
+		default:
+			return nil, fmt.Errorf("changes[%d]: action must be create, update or delete", i)
+		}
+		changes = append(changes, &proposals.ProposalBlockChange{
+			BlockID:   c.BlockID,
+			Action:    c.Action,
+			BlockType: c.BlockType,
+			OrderPath: c.OrderPath,
+			Content:   c.Content,
+			CreatedBy: account.ID,
+			CreatedAt: now,
+		})
+	}
+
+	reasoning, err := json.Marshal(req.Reasoning)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding reasoning: %w", err)
+	}
+
+	p := &proposals.Proposal{
+		DocumentID:       documentID,
+		AffectedBlockIDs: affected,
+		Title:            req.Title,
+		AuthorID:         account.ID,
+		Intent:           req.Intent,
+		Scope:            req.Scope,
+		State:            string(proposals.ProposalStatusOpen),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		AuthorType:       string(proposals.AuthorTypeAgent),
+		AgentAccountID:   &account.ID,
+		AgentReasoning:   reasoning,
+	}
+	if err := proposals.SubmitProposal(p, changes, ctx); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func getProposalStatus(proposalID string, ctx context.Context) (*ProposalStatus, error) {
+	account, ok := getServiceAccountFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("service account not found in context")
+	}
+
+	p, err := proposals.GetProposalByID(proposalID, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("proposal not found")
+	}
+	workspaceID, err := fetchDocumentWorkspaceID(p.DocumentID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if workspaceID != account.WorkspaceID {
+		return nil, fmt.Errorf("proposal not found")
+	}
+
+	decidedAt, err := fetchDecidedAt(p.ID, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProposalStatus{
+		ID:              p.ID,
+		DocumentID:      p.DocumentID,
+		State:           p.State,
+		RejectionReason: p.RejectionReason,
+		DecidedAt:       decidedAt,
+		CreatedAt:       p.CreatedAt,
+		UpdatedAt:       p.UpdatedAt,
+	}, nil
+}
+
+func getCanonicalBlocks(documentID string, ctx context.Context) ([]*blocks.Block, error) {
+	if _, err := requireDocumentInWorkspace(documentID, ctx); err != nil {
+		return nil, err
+	}
+	return blocks.FetchAllBlocksByDocumentID(documentID, ctx)
+}
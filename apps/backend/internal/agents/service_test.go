@@ -0,0 +1,72 @@
+package agents
+
+import (
+	"context"
+	"testing"
+
+	"granth/internal/config"
+	"granth/internal/testdb"
+)
+
+func TestAgentsAreConfinedToTheirWorkspace(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	otherWorkspaceID := testdb.CreateWorkspace(t, owner)
+	own := testdb.CreateDocument(t, workspaceID, owner)
+	trashed := testdb.CreateDocument(t, workspaceID, owner)
+	foreign := testdb.CreateDocument(t, otherWorkspaceID, owner)
+	if _, err := config.PostgresDB.Exec(`UPDATE documents SET deleted_at = now() WHERE id = $1`, trashed); err != nil {
+		t.Fatal(err)
+	}
+
+	account := &ServiceAccount{ID: "agent", WorkspaceID: workspaceID}
+	ctx := context.WithValue(context.Background(), serviceAccountContextKey{}, account)
+
+	if got, err := requireDocumentInWorkspace(own, ctx); err != nil || got != account {
+		t.Fatalf("own document: got %v, %v", got, err)
+	}
+	for name, documentID := range map[string]string{"another workspace": foreign, "trashed": trashed} {
+		if _, err := requireDocumentInWorkspace(documentID, ctx); err == nil || err.Error() != "document not found" {
+			t.Fatalf("%s: got %v, want document not found", name, err)
+		}
+	}
+	if _, err := requireDocumentInWorkspace(own, context.Background()); err == nil {
+		t.Fatal("a request without a service account was let through")
+	}
+}
+
+func TestServiceAccountsOutliveTheirCreator(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	creator := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	testdb.AddMember(t, workspaceID, creator, "admin")
+
+	a, err := createServiceAccount(workspaceID, "bot", "", testdb.As(creator))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := createAPIKey(a.ID, testdb.As(creator)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.PostgresDB.Exec(`DELETE FROM users WHERE id = $1`, creator); err != nil {
+		t.Fatalf("deleting the creator: %v", err)
+	}
+
+	ctx := context.Background()
+	got, err := fetchServiceAccount(a.ID, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.CreatedBy != nil {
+		t.Fatalf("account created_by = %s, want null", *got.CreatedBy)
+	}
+	keys, err := fetchAPIKeysForAccount(a.ID, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].CreatedBy != nil {
+		t.Fatalf("keys = %+v, want one key without a creator", keys)
+	}
+}
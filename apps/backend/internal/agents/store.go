@@ -0,0 +1,192 @@
+package agents
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"granth/internal/config"
+)
+
+// ── Service accounts ──────────────────────────────────────────────────────────
+
+func insertServiceAccount(a *ServiceAccount, ctx context.Context) error {
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`INSERT INTO service_accounts (workspace_id, name, description, created_by, created_at)
+		 VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		a.WorkspaceID, a.Name, a.Description, a.CreatedBy, a.CreatedAt,
+	).Scan(&a.ID)
+	if err != nil {
+		return fmt.Errorf("error inserting service account: %w", err)
+	}
+	return nil
+}
+
+func fetchServiceAccount(id string, ctx context.Context) (*ServiceAccount, error) {
+	a := &ServiceAccount{}
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT id, workspace_id, name, COALESCE(description, ''), created_by, created_at, revoked_at
+		 FROM service_accounts WHERE id = $1`, id,
+	).Scan(&a.ID, &a.WorkspaceID, &a.Name, &a.Description, &a.CreatedBy, &a.CreatedAt, &a.RevokedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("service account not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching service account: %w", err)
+	}
+	return a, nil
+}
+
+func fetchServiceAccountsForWorkspace(workspaceID string, ctx context.Context) ([]*ServiceAccount, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT id, workspace_id, name, COALESCE(description, ''), created_by, created_at, revoked_at
+		 FROM service_accounts WHERE workspace_id = $1 ORDER BY created_at ASC`,
+		workspaceID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error querying service accounts: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := make([]*ServiceAccount, 0)
+	for rows.Next() {
+		a := &ServiceAccount{}
+		if err := rows.Scan(&a.ID, &a.WorkspaceID, &a.Name, &a.Description, &a.CreatedBy, &a.CreatedAt, &a.RevokedAt); err != nil {
+			return nil, fmt.Errorf("error scanning service account: %w", err)
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, rows.Err()
+}
+
+// revokeServiceAccount disables an account and every key it holds.
+func revokeServiceAccount(id, revokedAt string, ctx context.Context) error {
+	tx, err := config.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE service_accounts SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL`, revokedAt, id); err != nil {
+		return fmt.Errorf("error revoking service account: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE service_account_keys SET revoked_at = $1 WHERE service_account_id = $2 AND revoked_at IS NULL`, revokedAt, id); err != nil {
+		return fmt.Errorf("error revoking service account keys: %w", err)
+	}
+	return tx.Commit()
+}
+
+// ── API keys ──────────────────────────────────────────────────────────────────
+
+func insertAPIKey(k *APIKey, keyHash string, ctx context.Context) error {
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`INSERT INTO service_account_keys (service_account_id, prefix, key_hash, created_by, created_at)
+		 VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		k.AccountID, k.Prefix, keyHash, k.CreatedBy, k.CreatedAt,
+	).Scan(&k.ID)
+	if err != nil {
+		return fmt.Errorf("error inserting API key: %w", err)
+	}
+	return nil
+}
+
+func fetchAPIKeysForAccount(accountID string, ctx context.Context) ([]*APIKey, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT id, service_account_id, prefix, created_by, created_at, last_used_at, revoked_at
+		 FROM service_account_keys WHERE service_account_id = $1 ORDER BY created_at ASC`,
+		accountID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error querying API keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]*APIKey, 0)
+	for rows.Next() {
+		k := &APIKey{}
+		if err := rows.Scan(&k.ID, &k.AccountID, &k.Prefix, &k.CreatedBy, &k.CreatedAt, &k.LastUsedAt, &k.RevokedAt); err != nil {
+			return nil, fmt.Errorf("error scanning API key: %w", err)
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func revokeAPIKey(accountID, keyID, revokedAt string, ctx context.Context) (bool, error) {
+	res, err := config.PostgresDB.ExecContext(ctx,
+		`UPDATE service_account_keys SET revoked_at = $1
+		 WHERE id = $2 AND service_account_id = $3 AND revoked_at IS NULL`,
+		revokedAt, keyID, accountID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error revoking API key: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error revoking API key: %w", err)
+	}
+	return n > 0, nil
+}
+
+// fetchActiveKeyByPrefix returns the key hash and owning account for a live
+// key on a live account.
+func fetchActiveKeyByPrefix(prefix string, ctx context.Context) (string, string, *ServiceAccount, error) {
+	var keyID, keyHash string
+	a := &ServiceAccount{}
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT k.id, k.key_hash, a.id, a.workspace_id, a.name, COALESCE(a.description, ''), a.created_by, a.created_at
+		 FROM service_account_keys k
+		 INNER JOIN service_accounts a ON a.id = k.service_account_id
+		 WHERE k.prefix = $1 AND k.revoked_at IS NULL AND a.revoked_at IS NULL`,
+		prefix,
+	).Scan(&keyID, &keyHash, &a.ID, &a.WorkspaceID, &a.Name, &a.Description, &a.CreatedBy, &a.CreatedAt)
+	if err == sql.ErrNoRows {
+		return "", "", nil, fmt.Errorf("invalid API key")
+	}
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error fetching API key: %w", err)
+	}
+	return keyID, keyHash, a, nil
+}
+
+func touchAPIKey(keyID, usedAt string, ctx context.Context) error {
+	_, err := config.PostgresDB.ExecContext(ctx,
+		`UPDATE service_account_keys SET last_used_at = $1 WHERE id = $2`, usedAt, keyID)
+	if err != nil {
+		return fmt.Errorf("error updating API key usage: %w", err)
+	}
+	return nil
+}
+
+// ── Agent reads ───────────────────────────────────────────────────────────────
+
+// fetchDocumentWorkspaceID returns the workspace a document belongs to, or
+// "" for legacy documents without one.
+func fetchDocumentWorkspaceID(documentID string, ctx context.Context) (string, error) {
+	var workspaceID sql.NullString
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT workspace_id FROM documents WHERE id = $1 AND deleted_at IS NULL`, documentID,
+	).Scan(&workspaceID)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("document not found")
+	}
+	if err != nil {
+		return "", fmt.Errorf("error fetching document: %w", err)
+	}
+	return workspaceID.String, nil
+}
+
+func fetchDecidedAt(proposalID string, ctx context.Context) (*string, error) {
+	var decidedAt string
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT decided_at FROM proposal_decisions WHERE proposal_id = $1`, proposalID,
+	).Scan(&decidedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching decision: %w", err)
+	}
+	return &decidedAt, nil
+}
@@ -0,0 +1,75 @@
+package agents
+
+import "encoding/json"
+
+// ServiceAccount is a non-human principal scoped to a single workspace. Agents
+// authenticate with one of its API keys and may only propose and read.
+type ServiceAccount struct {
+	ID          string  `json:"id"`
+	WorkspaceID string  `json:"workspace_id"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	CreatedBy   *string `json:"created_by"`
+	CreatedAt   string  `json:"created_at"`
+	RevokedAt   *string `json:"revoked_at,omitempty"`
+}
+
+type APIKey struct {
+	ID         string  `json:"id"`
+	AccountID  string  `json:"service_account_id"`
+	Prefix     string  `json:"prefix"`
+	CreatedBy  *string `json:"created_by"`
+	CreatedAt  string  `json:"created_at"`
+	LastUsedAt *string `json:"last_used_at,omitempty"`
+	RevokedAt  *string `json:"revoked_at,omitempty"`
+}
+
+// CreatedAPIKey is returned once, when a key is minted. The plaintext key
+// is never stored and cannot be retrieved again.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// Reasoning is the structured rationale an agent must attach to every
+// proposal it submits. It is stored verbatim on the proposal.
+type Reasoning struct {
+	Rationale  string          `json:"rationale"`
+	Evidence   []Evidence      `json:"evidence,omitempty"`
+	Confidence *float64        `json:"confidence,omitempty"`
+	Model      string          `json:"model,omitempty"`
+	RunID      string          `json:"run_id,omitempty"`
+	Extra      json.RawMessage `json:"extra,omitempty"`
+}
+
+type Evidence struct {
+	Source string `json:"source"`
+	Detail string `json:"detail"`
+}
+
+type ChangeRequest struct {
+	BlockID   *string `json:"block_id"`
+	Action    string  `json:"action"`
+	BlockType string  `json:"block_type"`
+	OrderPath []int64 `json:"order_path"`
+	Content   string  `json:"content"`
+}
+
+type ProposalRequest struct {
+	Title     string          `json:"title"`
+	Intent    string          `json:"intent"`
+	Scope     string          `json:"scope"`
+	Changes   []ChangeRequest `json:"changes"`
+	Reasoning Reasoning       `json:"reasoning"`
+}
+
+// ProposalStatus is what an agent sees when polling a proposal it submitted.
+type ProposalStatus struct {
+	ID              string  `json:"id"`
+	DocumentID      string  `json:"document_id"`
+	State           string  `json:"state"`
+	RejectionReason *string `json:"rejection_reason"`
+	DecidedAt       *string `json:"decided_at"`
+	CreatedAt       string  `json:"created_at"`
+	UpdatedAt       string  `json:"updated_at"`
+}
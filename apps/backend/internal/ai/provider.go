@@ -0,0 +1,120 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Provider is the single seam between Granth and a language model. Everything
+// AI-assisted (summaries, synthesis, conflict analysis) goes through it so the
+// model vendor can be swapped without touching the reasoning layer.
+type Provider interface {
+	Complete(req CompletionRequest, ctx context.Context) (*Completion, error)
+	Model() string
+}
+
+var DefaultProvider Provider
+
+var ErrNotConfigured = errors.New("AI provider not configured")
+
+// InitProvider configures DefaultProvider against an OpenAI-compatible
+// chat completions endpoint. An empty baseURL leaves AI features disabled.
+func InitProvider(baseURL, apiKey, model string) Provider {
+	if baseURL == "" || model == "" {
+		DefaultProvider = nil
+		return nil
+	}
+	DefaultProvider = &chatCompletionsProvider{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		client:  &http.Client{Timeout: 60 * time.Second},
+	}
+	return DefaultProvider
+}
+
+// Complete sends req to DefaultProvider.
+func Complete(req CompletionRequest, ctx context.Context) (*Completion, error) {
+	if DefaultProvider == nil {
+		return nil, ErrNotConfigured
+	}
+	return DefaultProvider.Complete(req, ctx)
+}
+
+type chatCompletionsProvider struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+func (p *chatCompletionsProvider) Model() string {
+	return p.model
+}
+
+func (p *chatCompletionsProvider) Complete(req CompletionRequest, ctx context.Context) (*Completion, error) {
+	messages := make([]Message, 0, len(req.Messages)+1)
+	if req.System != "" {
+		messages = append(messages, Message{Role: "system", Content: req.System})
+	}
+	messages = append(messages, req.Messages...)
+
+	body := map[string]interface{}{
+		"model":       p.model,
+		"messages":    messages,
+		"temperature": req.Temperature,
+	}
+	if req.MaxTokens > 0 {
+		body["max_tokens"] = req.MaxTokens
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding completion request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("error building completion request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("error calling AI provider: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("AI provider returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var out struct {
+		Model   string `json:"model"`
+		Choices []struct {
+			Message Message `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("error decoding completion response: %w", err)
+	}
+	if len(out.Choices) == 0 {
+		return nil, fmt.Errorf("AI provider returned no choices")
+	}
+
+	model := out.Model
+	if model == "" {
+		model = p.model
+	}
+	return &Completion{Text: strings.TrimSpace(out.Choices[0].Message.Content), Model: model}, nil
+}
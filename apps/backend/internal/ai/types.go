@@ -0,0 +1,20 @@
+package ai
+
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// CompletionRequest is a provider-neutral prompt. System is sent ahead of the
+// conversation messages by providers that distinguish the two.
+type CompletionRequest struct {
+	System      string
+	Messages    []Message
+	MaxTokens   int
+	Temperature float64
+}
+
+type Completion struct {
+	Text  string
+	Model string
+}
@@ -0,0 +1,142 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"granth/internal/config"
+	"granth/internal/utils"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 500
+)
+
+// Record appends an event for a change that has already been made. The
+// actor, client IP and request ID come from ctx. Failures are logged
+// rather than returned so a committed change is never reported as failed.
+func Record(entry Entry, ctx context.Context) {
+	e := &Event{
+		WorkspaceID: optional(entry.WorkspaceID),
+		DocumentID:  optional(entry.DocumentID),
+		ActorID:     optional(entry.ActorID),
+		ActorType:   "system",
+		Action:      entry.Action,
+		TargetType:  entry.TargetType,
+		TargetID:    optional(entry.TargetID),
+		RequestID:   optional(middleware.GetReqID(ctx)),
+	}
+	if claims, ok := utils.GetClaimsFromContext(ctx); ok {
+		e.ActorID = optional(claims.UserID)
+		e.ActorType = "user"
+		if claims.TokenType == "agent" {
+			e.ActorType = "agent"
+		}
+	} else if e.ActorID != nil {
+		e.ActorType = "user"
+	}
+	if ip, ok := utils.GetClientIPFromContext(ctx); ok {
+		e.IP = optional(ip)
+	}
+
+	var err error
+	if e.Before, err = marshal(entry.Before); err == nil {
+		e.After, err = marshal(entry.After)
+	}
+	if err == nil {
+		err = insertEvent(e, ctx)
+	}
+	if err != nil {
+		config.Logger.Printf("error recording audit event %s on %s %s: %v", entry.Action, entry.TargetType, entry.TargetID, err)
+	}
+}
+
+// HashContent is recorded in place of block, change and comment content,
+// which is encrypted at rest and must not be copied into payloads.
+func HashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func marshal(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// normalizeFilter applies the default page size and checks the time bounds.
+func normalizeFilter(f Filter) (Filter, error) {
+	if f.Limit == 0 {
+		f.Limit = defaultLimit
+	}
+	if f.Limit < 1 || f.Limit > maxLimit {
+		return f, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+	}
+	for _, t := range []string{f.Since, f.Until} {
+		if t == "" {
+			continue
+		}
+		if _, err := time.Parse(time.RFC3339, t); err != nil {
+			return f, fmt.Errorf("since and until must be RFC 3339 timestamps")
+		}
+	}
+	return f, nil
+}
+
+// ListForWorkspace returns a workspace's events. Callers check access.
+func ListForWorkspace(workspaceID string, f Filter, ctx context.Context) ([]*Event, error) {
+	f, err := normalizeFilter(f)
+	if err != nil {
+		return nil, err
+	}
+	return queryEvents("e.workspace_id = $1::uuid", workspaceID, f, ctx)
+}
+
+// ListForAccount returns the account events a user caused outside any
+// workspace, such as logins and password changes.
+func ListForAccount(userID string, f Filter, ctx context.Context) ([]*Event, error) {
+	f, err := normalizeFilter(f)
+	if err != nil {
+		return nil, err
+	}
+	f.ActorID = ""
+	return queryEvents("e.actor_id = $1::uuid AND e.workspace_id IS NULL", userID, f, ctx)
+}
+
+// FilterFromQuery reads a Filter from query parameters.
+func FilterFromQuery(q url.Values) (Filter, error) {
+	f := Filter{
+		ActorID:    q.Get("actor_id"),
+		Action:     q.Get("action"),
+		TargetType: q.Get("target_type"),
+		TargetID:   q.Get("target_id"),
+		DocumentID: q.Get("document_id"),
+		Since:      q.Get("since"),
+		Until:      q.Get("until"),
+	}
+	if raw := q.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return f, fmt.Errorf("limit must be a number")
+		}
+		f.Limit = n
+	}
+	return f, nil
+}
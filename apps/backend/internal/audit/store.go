@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"granth/internal/config"
+)
+
+func insertEvent(e *Event, ctx context.Context) error {
+	_, err := config.PostgresDB.ExecContext(ctx,
+		`INSERT INTO audit_events (workspace_id, document_id, actor_id, actor_type, action, target_type, target_id,
+		                           before, after, ip, request_id)
+		 VALUES (COALESCE($1::uuid, (SELECT workspace_id FROM documents WHERE id = $2::uuid)), $2, $3, $4, $5, $6, $7,
+		         $8, $9, $10, $11)`,
+		e.WorkspaceID, e.DocumentID, e.ActorID, e.ActorType, e.Action, e.TargetType, e.TargetID,
+		nullJSON(e.Before), nullJSON(e.After), e.IP, e.RequestID,
+	)
+	if err != nil {
+		return fmt.Errorf("error inserting audit event: %w", err)
+	}
+	return nil
+}
+
+// nullJSON stores an empty payload as NULL.
+func nullJSON(raw []byte) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
+
+// queryEvents lists events matching scope, which is a condition on the
+// first argument, and f, newest first.
+func queryEvents(scope string, scopeArg string, f Filter, ctx context.Context) ([]*Event, error) {
+	where := []string{scope}
+	args := []interface{}{scopeArg}
+	add := func(cond, value string) {
+		if value == "" {
+			return
+		}
+		args = append(args, value)
+		where = append(where, strings.ReplaceAll(cond, "$?", fmt.Sprintf("$%d", len(args))))
+	}
+	add("e.actor_id = $?::uuid", f.ActorID)
+	add("(e.action = $? OR e.action LIKE $? || '.%')", f.Action)
+	add("e.target_type = $?", f.TargetType)
+	add("e.target_id = $?", f.TargetID)
+	add("e.document_id = $?::uuid", f.DocumentID)
+	add("e.created_at >= $?", f.Since)
+	add("e.created_at < $?", f.Until)
+	args = append(args, f.Limit)
+
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT e.id, e.workspace_id, e.document_id, e.actor_id, u.username, e.actor_type, e.action, e.target_type, e.target_id,
+		        e.before, e.after, e.ip, e.request_id, e.created_at
+		 FROM audit_events e
+		 LEFT JOIN users u ON u.id = e.actor_id
+		 WHERE `+strings.Join(where, " AND ")+fmt.Sprintf(`
+		 ORDER BY e.created_at DESC LIMIT $%d`, len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying audit events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*Event{}
+	for rows.Next() {
+		e := &Event{}
+		var before, after []byte
+		if err := rows.Scan(&e.ID, &e.WorkspaceID, &e.DocumentID, &e.ActorID, &e.ActorName, &e.ActorType, &e.Action,
+			&e.TargetType, &e.TargetID, &before, &after, &e.IP, &e.RequestID, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning audit event: %w", err)
+		}
+		e.Before, e.After = before, after
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
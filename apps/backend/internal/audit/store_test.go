@@ -0,0 +1,63 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"granth/internal/config"
+	"granth/internal/testdb"
+)
+
+func TestAuditEventsAreAppendOnly(t *testing.T) {
+	testdb.Open(t)
+	ctx := context.Background()
+	var documentID string
+	if err := config.PostgresDB.QueryRow(`SELECT gen_random_uuid()`).Scan(&documentID); err != nil {
+		t.Fatal(err)
+	}
+	e := &Event{DocumentID: &documentID, ActorType: "system", Action: "test.recorded", TargetType: "document",
+		After: json.RawMessage(`{"note":"contains a secret value"}`)}
+	if err := insertEvent(e, ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, query := range map[string]string{
+		"update": `UPDATE audit_events SET action = 'test.rewritten' WHERE document_id = $1`,
+		"delete": `DELETE FROM audit_events WHERE document_id = $1`,
+	} {
+		if _, err := config.PostgresDB.Exec(query, documentID); err == nil || !strings.Contains(err.Error(), "append-only") {
+			t.Fatalf("%s: got %v, want the append-only error", name, err)
+		}
+	}
+
+	// Setting the old redaction flag no longer opens the table
+	tx, err := config.PostgresDB.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`SET LOCAL granth.redaction = 'on'`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec(`UPDATE audit_events SET after = NULL WHERE document_id = $1`, documentID); err == nil || !strings.Contains(err.Error(), "append-only") {
+		t.Fatalf("update with granth.redaction set: got %v, want the append-only error", err)
+	}
+	tx.Rollback()
+
+	var n int
+	if err := config.PostgresDB.QueryRow(`SELECT redact_audit_events($1, 'secret')`, documentID).Scan(&n); err != nil {
+		t.Fatalf("redacting: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("redacted %d events, want 1", n)
+	}
+	var after string
+	if err := config.PostgresDB.QueryRow(`SELECT after::text FROM audit_events WHERE document_id = $1`, documentID).Scan(&after); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(after, "secret") || !strings.Contains(after, "[REDACTED]") {
+		t.Fatalf("after = %s, want the secret redacted", after)
+	}
+}
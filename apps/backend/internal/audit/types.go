@@ -0,0 +1,49 @@
+package audit
+
+import "encoding/json"
+
+// Entry describes one change for Record. IDs may be "". When WorkspaceID is
+// empty it is looked up from DocumentID. Before and After are stored as JSON.
+type Entry struct {
+	WorkspaceID string
+	DocumentID  string
+	// ActorID is only needed before the actor is authenticated, e.g. at login
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     interface{}
+	After      interface{}
+}
+
+// Event is a stored audit entry.
+type Event struct {
+	ID          string          `json:"id"`
+	WorkspaceID *string         `json:"workspace_id"`
+	DocumentID  *string         `json:"document_id,omitempty"`
+	ActorID     *string         `json:"actor_id"`
+	ActorName   *string         `json:"actor_name,omitempty"`
+	ActorType   string          `json:"actor_type"`
+	Action      string          `json:"action"`
+	TargetType  string          `json:"target_type"`
+	TargetID    *string         `json:"target_id,omitempty"`
+	Before      json.RawMessage `json:"before,omitempty"`
+	After       json.RawMessage `json:"after,omitempty"`
+	IP          *string         `json:"ip,omitempty"`
+	RequestID   *string         `json:"request_id,omitempty"`
+	CreatedAt   string          `json:"created_at"`
+}
+
+// Filter narrows a listing. Empty fields match everything. Action matches
+// exactly or as a prefix, so "member" finds "member.added". Until is
+// exclusive and doubles as a cursor for the next page.
+type Filter struct {
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	DocumentID string
+	Since      string
+	Until      string
+	Limit      int
+}
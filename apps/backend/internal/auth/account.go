@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"time"
+
+	"granth/internal/config"
+	"granth/internal/mail"
+	"granth/internal/utils"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	purposePasswordReset     = "password_reset"
+	purposeEmailVerification = "email_verification"
+
+	passwordResetTTL     = 1 * time.Hour
+	emailVerificationTTL = 72 * time.Hour
+)
+
+// issueActionToken records a new single-use token server-side and returns
+// its signed form.
+func issueActionToken(userID, purpose string, ttl time.Duration, ctx context.Context) (string, error) {
+	tokenID, err := newRandomID()
+	if err != nil {
+		return "", err
+	}
+	expiresAt := time.Now().UTC().Add(ttl)
+	if err := CreateActionToken(tokenID, userID, purpose, expiresAt.Format(time.RFC3339), ctx); err != nil {
+		return "", err
+	}
+	return utils.CreateActionToken(userID, purpose, tokenID, ttl)
+}
+
+// parseActionToken checks the signature, expiry and purpose of an action
+// token. Whether it has been used is checked when it is consumed.
+func parseActionToken(token, purpose string) (*utils.Claims, error) {
+	claims, err := utils.ValidateToken(token)
+	if err != nil || claims.TokenType != purpose || claims.ID == "" {
+		return nil, fmt.Errorf("invalid or expired token")
+	}
+	return claims, nil
+}
+
+func sendVerificationEmail(userID, email string, ctx context.Context) error {
+	token, err := issueActionToken(userID, purposeEmailVerification, emailVerificationTTL, ctx)
+	if err != nil {
+		return err
+	}
+	link := config.AppURL + "/verify-email?token=" + url.QueryEscape(token)
+	return mail.Send(mail.Message{
+		To:      email,
+		Subject: "Verify your Granth email address",
+		Body: "Confirm your email address by opening the link below. It expires in 72 hours.\n\n" +
+			link + "\n\nIf you did not create a Granth account you can ignore this email.",
+	}, ctx)
+}
+
+// sendVerificationEmailAsync keeps registration fast and independent of the
+// mail server; failures are logged and the user can ask for a new link.
+func sendVerificationEmailAsync(userID, email string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := sendVerificationEmail(userID, email, ctx); err != nil {
+			config.Logger.Printf("error sending verification email to user %s: %v", userID, err)
+		}
+	}()
+}
+
+func resendVerificationEmail(ctx context.Context) error {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("user ID not found in context")
+	}
+	verified, err := IsEmailVerified(userID, ctx)
+	if err != nil {
+		return err
+	}
+	if verified {
+		return fmt.Errorf("email already verified")
+	}
+	_, email, _, err := GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("error fetching user: %w", err)
+	}
+	return sendVerificationEmail(userID, email, ctx)
+}
+
+func verifyEmail(token string, ctx context.Context) error {
+	claims, err := parseActionToken(token, purposeEmailVerification)
+	if err != nil {
+		return err
+	}
+	ok, err := VerifyEmailWithToken(claims.ID, claims.UserID, ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("invalid or expired token")
+	}
+	recordAccountEvent(claims.UserID, "email.verified", nil, ctx)
+	return nil
+}
+
+// requestPasswordReset emails a reset link. It succeeds silently for unknown
+// addresses so the endpoint cannot be used to discover accounts.
+func requestPasswordReset(email string, ctx context.Context) error {
+	userID, _, _, err := GetUserByEmail(email)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error fetching user: %w", err)
+	}
+
+	token, err := issueActionToken(userID, purposePasswordReset, passwordResetTTL, ctx)
+	if err != nil {
+		return err
+	}
+	link := config.AppURL + "/reset-password?token=" + url.QueryEscape(token)
+	return mail.Send(mail.Message{
+		To:      email,
+		Subject: "Reset your Granth password",
+		Body: "Someone asked to reset the password for your Granth account. Open the link below to choose a new one. " +
+			"It expires in 1 hour and can only be used once.\n\n" + link +
+			"\n\nIf this wasn't you, you can ignore this email; your password has not changed.",
+	}, ctx)
+}
+
+// resetPassword sets a new password from a reset token, signs the user out
+// of every session and revokes their personal access tokens, since a reset
+// usually means the account was exposed. Service account keys are kept:
+// they belong to the workspace and act as the agent, not as this user, and
+// workspace admins revoke them.
+func resetPassword(token, newPassword string, ctx context.Context) error {
+	claims, err := parseActionToken(token, purposePasswordReset)
+	if err != nil {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("error hashing password: %w", err)
+	}
+	ok, err := ResetPasswordWithToken(claims.ID, claims.UserID, string(hash), ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("invalid or expired token")
+	}
+	revoked, err := RevokePersonalAccessTokensByUser(claims.UserID, time.Now().UTC().Format(time.RFC3339), ctx)
+	if err != nil {
+		return err
+	}
+	recordAccountEvent(claims.UserID, "password.reset", map[string]int64{"tokens_revoked": revoked}, ctx)
+	return revokeAllSessions(claims.UserID, "", ctx)
+}
+
+// changePassword updates the caller's password after checking the current
+// one, signs out every other session and revokes the caller's personal
+// access tokens.
+func changePassword(currentPassword, newPassword string, ctx context.Context) error {
+	claims, ok := utils.GetClaimsFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("user ID not found in context")
+	}
+
+	_, _, passwordHash, err := GetUserByID(claims.UserID)
+	if err != nil {
+		return fmt.Errorf("error fetching user: %w", err)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(currentPassword)); err != nil {
+		return fmt.Errorf("current password is incorrect")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("error hashing password: %w", err)
+	}
+	if err := UpdateUserPassword(claims.UserID, string(hash)); err != nil {
+		return err
+	}
+	revoked, err := RevokePersonalAccessTokensByUser(claims.UserID, time.Now().UTC().Format(time.RFC3339), ctx)
+	if err != nil {
+		return err
+	}
+	recordAccountEvent(claims.UserID, "password.changed", map[string]int64{"tokens_revoked": revoked}, ctx)
+	return revokeAllSessions(claims.UserID, claims.SessionID, ctx)
+}
@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"granth/internal/config"
+	"granth/internal/mail"
+	"granth/internal/utils"
+)
+
+const (
+	purposeAccountUnlock = "account_unlock"
+	accountUnlockTTL     = 24 * time.Hour
+
+	// lockoutThreshold failed passwords in a row lock the account for
+	// lockoutBase; every further failure doubles the lock up to lockoutMax.
+	lockoutThreshold = 5
+	lockoutBase      = 1 * time.Minute
+	lockoutMax       = 24 * time.Hour
+	// failures are forgotten after a quiet day
+	loginFailureTTL = 24 * time.Hour
+)
+
+// Sliding-window limits for the unauthenticated auth endpoints. The per-IP
+// limits are applied as middleware; the per-account ones are checked in the
+// service once the account is known.
+var (
+	loginIPLimit      = utils.RateLimit{Name: "login_ip", Limit: 20, Window: time.Minute}
+	loginAccountLimit = utils.RateLimit{Name: "login_account", Limit: 10, Window: 15 * time.Minute}
+
+	registerIPLimit      = utils.RateLimit{Name: "register_ip", Limit: 10, Window: time.Hour}
+	registerAccountLimit = utils.RateLimit{Name: "register_account", Limit: 3, Window: time.Hour}
+
+	refreshIPLimit      = utils.RateLimit{Name: "refresh_ip", Limit: 60, Window: time.Minute}
+	refreshAccountLimit = utils.RateLimit{Name: "refresh_account", Limit: 30, Window: time.Minute}
+
+	// password reset and unlock emails, verification and challenge codes
+	emailIPLimit = utils.RateLimit{Name: "email_ip", Limit: 10, Window: time.Hour}
+	codeIPLimit  = utils.RateLimit{Name: "code_ip", Limit: 30, Window: 15 * time.Minute}
+)
+
+// RateLimitedError is returned when a per-account limit or lockout refuses
+// a request; handlers turn it into a 429 with Retry-After.
+type RateLimitedError struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return e.Message
+}
+
+// checkAccountLimit applies limit to an account-level subject. Redis
+// failures are logged and allowed so an outage does not block sign-in.
+func checkAccountLimit(subject string, limit utils.RateLimit, ctx context.Context) error {
+	allowed, retryAfter, err := utils.AllowRequest(subject, limit, ctx)
+	if err != nil {
+		config.Logger.Printf("rate limit %s: %v", limit.Name, err)
+		return nil
+	}
+	if !allowed {
+		return &RateLimitedError{Message: "too many attempts, try again later", RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func loginFailuresKey(userID string) string {
+	return "login_failures:" + userID
+}
+
+func loginLockKey(userID string) string {
+	return "login_lock:" + userID
+}
+
+// checkAccountLock refuses sign-in while the account is locked out.
+func checkAccountLock(userID string, ctx context.Context) error {
+	ttl, err := config.RedisClient.PTTL(ctx, loginLockKey(userID)).Result()
+	if err != nil {
+		config.Logger.Printf("error checking lockout for user %s: %v", userID, err)
+		return nil
+	}
+	if ttl > 0 {
+		return &RateLimitedError{Message: "account temporarily locked", RetryAfter: ttl}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a wrong password and locks the account once
+// the threshold is reached. The first lock also emails an unlock link so
+// the owner can get back in without waiting.
+func recordLoginFailure(userID, email string, ctx context.Context) {
+	failures, err := config.RedisClient.Incr(ctx, loginFailuresKey(userID)).Result()
+	if err != nil {
+		config.Logger.Printf("error recording login failure for user %s: %v", userID, err)
+		return
+	}
+	config.RedisClient.Expire(ctx, loginFailuresKey(userID), loginFailureTTL)
+	if failures < lockoutThreshold {
+		return
+	}
+
+	lock := lockoutBase << (failures - lockoutThreshold)
+	if lock > lockoutMax || lock <= 0 {
+		lock = lockoutMax
+	}
+	if err := config.RedisClient.Set(ctx, loginLockKey(userID), "1", lock).Err(); err != nil {
+		config.Logger.Printf("error locking user %s: %v", userID, err)
+		return
+	}
+	config.Logger.Printf("user %s locked for %s after %d failed logins", userID, lock, failures)
+
+	if failures == lockoutThreshold {
+		sendUnlockEmailAsync(userID, email)
+	}
+}
+
+// clearLoginFailures resets the lockout state after a successful sign-in
+// or an unlock.
+func clearLoginFailures(userID string, ctx context.Context) {
+	if err := config.RedisClient.Del(ctx, loginFailuresKey(userID), loginLockKey(userID)).Err(); err != nil {
+		config.Logger.Printf("error clearing login failures for user %s: %v", userID, err)
+	}
+}
+
+func sendUnlockEmail(userID, email string, ctx context.Context) error {
+	token, err := issueActionToken(userID, purposeAccountUnlock, accountUnlockTTL, ctx)
+	if err != nil {
+		return err
+	}
+	link := config.AppURL + "/unlock-account?token=" + url.QueryEscape(token)
+	return mail.Send(mail.Message{
+		To:      email,
+		Subject: "Your Granth account has been locked",
+		Body: "We locked your Granth account after several failed sign-in attempts. It unlocks on its own " +
+			"after a while, or you can unlock it now with the link below (valid for 24 hours).\n\n" + link +
+			"\n\nIf these attempts weren't you, consider changing your password once you are back in.",
+	}, ctx)
+}
+
+func sendUnlockEmailAsync(userID, email string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := sendUnlockEmail(userID, email, ctx); err != nil {
+			config.Logger.Printf("error sending unlock email to user %s: %v", userID, err)
+		}
+	}()
+}
+
+// unlockAccount consumes an unlock token and clears the lockout.
+func unlockAccount(token string, ctx context.Context) error {
+	claims, err := parseActionToken(token, purposeAccountUnlock)
+	if err != nil {
+		return err
+	}
+	ok, err := ConsumeActionToken(claims.ID, claims.UserID, purposeAccountUnlock, ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("invalid or expired token")
+	}
+	clearLoginFailures(claims.UserID, ctx)
+	recordAccountEvent(claims.UserID, "account.unlocked", nil, ctx)
+	return nil
+}
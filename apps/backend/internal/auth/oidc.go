@@ -0,0 +1,518 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"granth/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+// OpenID Connect login uses the authorization code flow with PKCE. The
+// browser is sent to the provider, comes back to the callback, and is then
+// redirected to the frontend with a one-time login code that the frontend
+// exchanges for tokens, so tokens never appear in a URL.
+
+const (
+	oidcStateTTL     = 10 * time.Minute
+	oidcLoginCodeTTL = time.Minute
+	// oidcKeyRefreshInterval limits JWKS refetches when an unknown key ID
+	// shows up
+	oidcKeyRefreshInterval = time.Minute
+)
+
+var (
+	oidcProviders       = map[string]*oidcProvider{}
+	oidcRedirectBaseURL string
+	oidcHTTPClient      = &http.Client{Timeout: 10 * time.Second}
+)
+
+type oidcProvider struct {
+	cfg OIDCProviderConfig
+
+	mu            sync.Mutex
+	discovery     *oidcDiscovery
+	keys          map[string]*rsa.PublicKey
+	keysFetchedAt time.Time
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcState is what the login step remembers for the callback.
+type oidcState struct {
+	Provider     string `json:"provider"`
+	CodeVerifier string `json:"code_verifier"`
+	Nonce        string `json:"nonce"`
+	Redirect     string `json:"redirect"`
+}
+
+type oidcIDTokenClaims struct {
+	Nonce             string   `json:"nonce"`
+	Email             string   `json:"email"`
+	EmailVerified     oidcBool `json:"email_verified"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+	jwt.RegisteredClaims
+}
+
+// oidcBool accepts email_verified as either a JSON boolean or the string
+// "true", which some providers send.
+type oidcBool bool
+
+func (b *oidcBool) UnmarshalJSON(data []byte) error {
+	*b = oidcBool(strings.Trim(string(data), `"`) == "true")
+	return nil
+}
+
+// InitOIDC registers the configured providers. redirectBaseURL is the
+// public URL of this API; callbacks are served under it.
+func InitOIDC(providers []OIDCProviderConfig, redirectBaseURL string) {
+	oidcProviders = map[string]*oidcProvider{}
+	for _, p := range providers {
+		if len(p.Scopes) == 0 {
+			p.Scopes = []string{"openid", "email", "profile"}
+		}
+		if p.Name == "" {
+			p.Name = p.ID
+		}
+		oidcProviders[p.ID] = &oidcProvider{cfg: p}
+	}
+	oidcRedirectBaseURL = strings.TrimRight(redirectBaseURL, "/")
+}
+
+func listOIDCProviders() []OIDCProvider {
+	providers := make([]OIDCProvider, 0, len(oidcProviders))
+	for _, p := range oidcProviders {
+		providers = append(providers, OIDCProvider{ID: p.cfg.ID, Name: p.cfg.Name})
+	}
+	sort.Slice(providers, func(i, j int) bool { return providers[i].Name < providers[j].Name })
+	return providers
+}
+
+func (p *oidcProvider) redirectURI() string {
+	return oidcRedirectBaseURL + "/api/auth/oidc/" + url.PathEscape(p.cfg.ID) + "/callback"
+}
+
+func (p *oidcProvider) getDiscovery(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	d := &oidcDiscovery{}
+	if err := getJSON(strings.TrimRight(p.cfg.Issuer, "/")+"/.well-known/openid-configuration", d, ctx); err != nil {
+		return nil, fmt.Errorf("error fetching provider configuration: %w", err)
+	}
+	if d.Issuer != strings.TrimRight(p.cfg.Issuer, "/") && d.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("provider issuer mismatch: %s", d.Issuer)
+	}
+	p.discovery = d
+	return d, nil
+}
+
+// publicKey returns the signing key for kid, refetching the JWKS when the
+// provider has rotated keys.
+func (p *oidcProvider) publicKey(kid string, ctx context.Context) (*rsa.PublicKey, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < oidcKeyRefreshInterval && p.keys != nil {
+		return nil, fmt.Errorf("unknown signing key")
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := getJSON(d.JWKSURI, &jwks, ctx); err != nil {
+		return nil, fmt.Errorf("error fetching provider keys: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	key, ok := keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key")
+	}
+	return key, nil
+}
+
+// beginOIDCLogin returns the provider URL to send the browser to.
+func beginOIDCLogin(providerID, redirect string, ctx context.Context) (string, error) {
+	p, ok := oidcProviders[providerID]
+	if !ok {
+		return "", fmt.Errorf("unknown provider")
+	}
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := randomURLToken()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomURLToken()
+	if err != nil {
+		return "", err
+	}
+	verifier, err := randomURLToken()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(oidcState{
+		Provider:     providerID,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		Redirect:     safeRedirect(redirect),
+	})
+	if err != nil {
+		return "", fmt.Errorf("error encoding state: %w", err)
+	}
+	if err := config.RedisClient.Set(ctx, "oidc_state:"+state, data, oidcStateTTL).Err(); err != nil {
+		return "", fmt.Errorf("error storing state: %w", err)
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", p.redirectURI())
+	q.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return d.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// completeOIDCLogin handles the provider callback. It returns a one-time
+// login code for the frontend and the path the user started from.
+func completeOIDCLogin(providerID, code, state string, client ClientInfo, ctx context.Context) (string, string, error) {
+	p, ok := oidcProviders[providerID]
+	if !ok {
+		return "", "", fmt.Errorf("unknown provider")
+	}
+
+	raw, err := config.RedisClient.GetDel(ctx, "oidc_state:"+state).Result()
+	if err == redis.Nil {
+		return "", "", fmt.Errorf("login session expired")
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("error fetching state: %w", err)
+	}
+	var st oidcState
+	if err := json.Unmarshal([]byte(raw), &st); err != nil || st.Provider != providerID {
+		return "", "", fmt.Errorf("login session expired")
+	}
+
+	idToken, err := p.exchangeCode(code, st.CodeVerifier, ctx)
+	if err != nil {
+		return "", "", err
+	}
+	claims, err := p.verifyIDToken(idToken, st.Nonce, ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	userID, err := resolveOIDCUser(providerID, claims, ctx)
+	if err != nil {
+		return "", "", err
+	}
+	if bool(claims.EmailVerified) {
+		if at := strings.LastIndex(claims.Email, "@"); at >= 0 {
+			if err := ApplyDomainJoinRules(userID, strings.ToLower(claims.Email[at+1:]), ctx); err != nil {
+				return "", "", err
+			}
+		}
+	}
+
+	username, _, _, err := GetUserByID(userID)
+	if err != nil {
+		return "", "", fmt.Errorf("error fetching user: %w", err)
+	}
+	resp := AuthResponse{UserID: userID, Username: username}
+	login := map[string]string{"method": "oidc", "provider": providerID}
+	// the identity provider stands in for the password, not for the
+	// second factor the user enrolled here
+	twoFactor, err := isTwoFactorEnabled(userID, ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("error during login: %w", err)
+	}
+	if twoFactor {
+		resp.TwoFactorRequired = true
+		if resp.ChallengeToken, err = issueTwoFactorChallenge(userID, login, ctx); err != nil {
+			return "", "", fmt.Errorf("error creating challenge: %w", err)
+		}
+	} else {
+		if resp.AccessToken, resp.RefreshToken, err = startSession(userID, client, ctx); err != nil {
+			return "", "", err
+		}
+		recordAccountEvent(userID, "login.succeeded", login, ctx)
+	}
+
+	loginCode, err := randomURLToken()
+	if err != nil {
+		return "", "", err
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return "", "", fmt.Errorf("error encoding login: %w", err)
+	}
+	if err := config.RedisClient.Set(ctx, "oidc_login:"+loginCode, data, oidcLoginCodeTTL).Err(); err != nil {
+		return "", "", fmt.Errorf("error storing login: %w", err)
+	}
+	return loginCode, st.Redirect, nil
+}
+
+// exchangeOIDCLoginCode hands the frontend the tokens of a completed SSO
+// login. Each code works once.
+func exchangeOIDCLoginCode(code string, ctx context.Context) (AuthResponse, error) {
+	raw, err := config.RedisClient.GetDel(ctx, "oidc_login:"+code).Result()
+	if err == redis.Nil {
+		return AuthResponse{}, fmt.Errorf("invalid or expired token")
+	}
+	if err != nil {
+		return AuthResponse{}, fmt.Errorf("error fetching login: %w", err)
+	}
+	var resp AuthResponse
+	if err := json.Unmarshal([]byte(raw), &resp); err != nil {
+		return AuthResponse{}, fmt.Errorf("error decoding login: %w", err)
+	}
+	return resp, nil
+}
+
+func (p *oidcProvider) exchangeCode(code, verifier string, ctx context.Context) (string, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.redirectURI())
+	form.Set("client_id", p.cfg.ClientID)
+	form.Set("code_verifier", verifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("error building token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	res, err := oidcHTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error exchanging code: %w", err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("error reading token response: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("provider rejected code: %s", strings.TrimSpace(string(body)))
+	}
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &tokens); err != nil || tokens.IDToken == "" {
+		return "", fmt.Errorf("provider did not return an ID token")
+	}
+	return tokens.IDToken, nil
+}
+
+func (p *oidcProvider) verifyIDToken(idToken, nonce string, ctx context.Context) (*oidcIDTokenClaims, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &oidcIDTokenClaims{}
+	_, err = jwt.ParseWithClaims(idToken, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.publicKey(kid, ctx)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512"}),
+		jwt.WithIssuer(d.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("invalid ID token: nonce mismatch")
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("invalid ID token: missing subject")
+	}
+	return claims, nil
+}
+
+// resolveOIDCUser finds or provisions the local user for an identity.
+// An existing password account is only linked when both sides have
+// verified the address; otherwise someone who registered the address first
+// could keep a password into the SSO user's account.
+func resolveOIDCUser(providerID string, claims *oidcIDTokenClaims, ctx context.Context) (string, error) {
+	userID, err := GetUserIDByIdentity(providerID, claims.Subject, ctx)
+	if err != nil || userID != "" {
+		return userID, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(claims.Email))
+	if email == "" {
+		return "", fmt.Errorf("provider did not share an email address")
+	}
+
+	existingID, _, _, err := GetUserByEmail(email)
+	if err == nil {
+		verified, err := IsEmailVerified(existingID, ctx)
+		if err != nil {
+			return "", err
+		}
+		if !bool(claims.EmailVerified) || !verified {
+			return "", fmt.Errorf("an account with this email already exists; sign in with your password and verify your email first")
+		}
+		if err := LinkIdentity(existingID, providerID, claims.Subject, email, ctx); err != nil {
+			return "", err
+		}
+		return existingID, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", fmt.Errorf("error checking existing user: %w", err)
+	}
+
+	username, err := availableUsername(claims, email, ctx)
+	if err != nil {
+		return "", err
+	}
+	return CreateSSOUser(username, email, bool(claims.EmailVerified), providerID, claims.Subject, ctx)
+}
+
+// availableUsername derives a username from the identity, adding a short
+// suffix when it is taken.
+func availableUsername(claims *oidcIDTokenClaims, email string, ctx context.Context) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base = email[:strings.Index(email, "@")]
+	}
+	base = strings.TrimSpace(base)
+	if len(base) > 40 {
+		base = base[:40]
+	}
+
+	candidate := base
+	for i := 0; i < 5; i++ {
+		exists, err := UsernameExists(candidate, ctx)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+		suffix, err := newRandomID()
+		if err != nil {
+			return "", err
+		}
+		candidate = base + "-" + suffix[:6]
+	}
+	return "", fmt.Errorf("could not find an available username")
+}
+
+// safeRedirect only allows same-site paths, so the login flow cannot be
+// used as an open redirect.
+func safeRedirect(redirect string) string {
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") || strings.HasPrefix(redirect, "/\\") {
+		return "/"
+	}
+	return redirect
+}
+
+func randomURLToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func getJSON(rawURL string, v interface{}, ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	res, err := oidcHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(v)
+}
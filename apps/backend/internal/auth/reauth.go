@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Reauthenticate checks freshly entered credentials for userID and returns
+// how they were confirmed: "password", "password+totp", or "totp" for
+// single sign-on accounts without a local password. Wrong passwords count
+// towards the login lockout.
+func Reauthenticate(userID string, creds StepUpCredentials, ctx context.Context) (string, error) {
+	if err := checkAccountLock(userID, ctx); err != nil {
+		return "", err
+	}
+
+	_, email, passwordHash, err := GetUserByID(userID)
+	if err != nil {
+		return "", fmt.Errorf("error fetching user: %w", err)
+	}
+	twoFactor, err := isTwoFactorEnabled(userID, ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// SSO-provisioned accounts have no usable password hash
+	hasPassword := passwordHash != "" && passwordHash != "!"
+	if !hasPassword && !twoFactor {
+		return "", fmt.Errorf("set up two-factor authentication to confirm this action")
+	}
+
+	method := ""
+	if hasPassword {
+		if creds.Password == "" {
+			return "", fmt.Errorf("re-authentication failed")
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(creds.Password)); err != nil {
+			recordLoginFailure(userID, email, ctx)
+			return "", fmt.Errorf("re-authentication failed")
+		}
+		method = "password"
+	}
+	if twoFactor {
+		if creds.Code == "" && creds.RecoveryCode == "" {
+			return "", fmt.Errorf("re-authentication failed")
+		}
+		if err := verifySecondFactor(userID, creds.Code, creds.RecoveryCode, ctx); err != nil {
+			if err.Error() == "invalid two-factor code" {
+				return "", fmt.Errorf("re-authentication failed")
+			}
+			return "", err
+		}
+		if method == "" {
+			method = "totp"
+		} else {
+			method += "+totp"
+		}
+	}
+	return method, nil
+}
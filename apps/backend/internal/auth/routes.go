@@ -2,10 +2,12 @@ package auth
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
 	"strings"
-	"time"
 
+	"granth/internal/audit"
 	"granth/internal/config"
 	"granth/internal/utils"
 
@@ -16,12 +18,39 @@ import (
 func AuthRouter() http.Handler {
 	r := chi.NewRouter()
 
-	r.Post("/login", handleLogin)
-	r.Post("/register", handleRegister)
+	r.With(utils.RateLimitMiddleware(loginIPLimit, utils.KeyByIP)).Post("/login", handleLogin)
+	r.With(utils.RateLimitMiddleware(codeIPLimit, utils.KeyByIP)).Post("/login/2fa", handleLoginTwoFactor)
+	r.Get("/oidc/providers", handleListOIDCProviders)
+	r.Get("/oidc/{provider}/login", handleOIDCLogin)
+	r.Get("/oidc/{provider}/callback", handleOIDCCallback)
+	r.Post("/oidc/exchange", handleOIDCExchange)
+	r.With(utils.RateLimitMiddleware(registerIPLimit, utils.KeyByIP)).Post("/register", handleRegister)
 	r.With(utils.AuthMiddleware).Post("/logout", handleLogout)
-	r.Post("/refreshToken", handleRefreshToken)
-	r.With(utils.AuthMiddleware).Get("/profile", handleGetProfile)
-	r.With(utils.AuthMiddleware).Put("/profile", handleUpdateProfile)
+	r.With(utils.RateLimitMiddleware(refreshIPLimit, utils.KeyByIP)).Post("/refreshToken", handleRefreshToken)
+	r.With(utils.RateLimitMiddleware(emailIPLimit, utils.KeyByIP)).Post("/forgot-password", handleForgotPassword)
+	r.With(utils.RateLimitMiddleware(codeIPLimit, utils.KeyByIP)).Post("/reset-password", handleResetPassword)
+	r.With(utils.RateLimitMiddleware(codeIPLimit, utils.KeyByIP)).Post("/verify-email", handleVerifyEmail)
+	r.With(utils.RateLimitMiddleware(codeIPLimit, utils.KeyByIP)).Post("/unlock", handleUnlockAccount)
+	r.With(utils.AuthMiddleware, utils.RequireScope(utils.ScopeAdmin), utils.RateLimitMiddleware(emailIPLimit, utils.KeyByUser)).Post("/verify-email/resend", handleResendVerificationEmail)
+	r.With(utils.AuthMiddleware, utils.RequireScope(utils.ScopeAdmin)).Post("/change-password", handleChangePassword)
+	r.With(utils.AuthMiddleware, utils.RequireScope(utils.ScopeRead)).Get("/profile", handleGetProfile)
+	r.With(utils.AuthMiddleware, utils.RequireScope(utils.ScopeAdmin)).Put("/profile", handleUpdateProfile)
+
+	r.With(utils.AuthMiddleware).Get("/tokens", handleListPersonalAccessTokens)
+	r.With(utils.AuthMiddleware).Post("/tokens", handleCreatePersonalAccessToken)
+	r.With(utils.AuthMiddleware).Delete("/tokens/{id}", handleRevokePersonalAccessToken)
+
+	r.With(utils.AuthMiddleware, utils.RequireScope(utils.ScopeRead)).Get("/2fa", handleGetTwoFactorStatus)
+	r.With(utils.AuthMiddleware, utils.RequireScope(utils.ScopeAdmin)).Post("/2fa/enroll", handleBeginTwoFactorEnrollment)
+	r.With(utils.AuthMiddleware, utils.RequireScope(utils.ScopeAdmin)).Post("/2fa/confirm", handleConfirmTwoFactorEnrollment)
+	r.With(utils.AuthMiddleware, utils.RequireScope(utils.ScopeAdmin)).Post("/2fa/recovery-codes", handleRegenerateRecoveryCodes)
+	r.With(utils.AuthMiddleware, utils.RequireScope(utils.ScopeAdmin)).Post("/2fa/disable", handleDisableTwoFactor)
+
+	r.With(utils.AuthMiddleware, utils.RequireScope(utils.ScopeRead)).Get("/sessions", handleListSessions)
+	r.With(utils.AuthMiddleware, utils.RequireScope(utils.ScopeAdmin)).Delete("/sessions", handleRevokeOtherSessions)
+	r.With(utils.AuthMiddleware, utils.RequireScope(utils.ScopeAdmin)).Delete("/sessions/{id}", handleRevokeSession)
+
+	r.With(utils.AuthMiddleware, utils.RequireScope(utils.ScopeRead)).Get("/audit", handleListAccountAuditEvents)
 
 	return r
 }
@@ -47,18 +76,15 @@ func handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	data, err := login(req.Email, req.Password)
+	data, err := login(req.Email, req.Password, clientInfo(r), r.Context())
 	if err != nil {
+		if writeRateLimitError(w, err) {
+			return
+		}
 		http.Error(w, "Login failed: "+err.Error(), http.StatusUnauthorized)
 		return
 	}
 
-	ok := config.RedisClient.Set(r.Context(), "refresh:"+data.UserID, data.RefreshToken, time.Hour*24)
-	if ok.Err() != nil {
-		http.Error(w, "Error storing refresh token: "+ok.Err().Error(), http.StatusInternalServerError)
-		return
-	}
-
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(data)
 }
@@ -86,16 +112,14 @@ func handleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	data, err := registerUser(req.Name, req.Email, req.Password)
+	data, err := registerUser(req.Name, req.Email, req.Password, clientInfo(r), r.Context())
 	if err != nil {
+		if writeRateLimitError(w, err) {
+			return
+		}
 		http.Error(w, "Registration failed: "+err.Error(), http.StatusBadRequest)
 		return
 	}
-	ok := config.RedisClient.Set(r.Context(), "refresh:"+data.UserID, data.RefreshToken, time.Hour*24)
-	if ok.Err() != nil {
-		http.Error(w, "Error storing refresh token: "+ok.Err().Error(), http.StatusInternalServerError)
-		return
-	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(data)
@@ -108,13 +132,12 @@ func handleLogout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID := claims.UserID
-
-	// Delete refresh token from Redis
-	okRedis := config.RedisClient.Del(r.Context(), "refresh:"+userID)
-	if okRedis.Err() != nil {
-		http.Error(w, "Error deleting refresh token: "+okRedis.Err().Error(), http.StatusInternalServerError)
-		return
+	// Ending the session invalidates its refresh token family
+	if claims.SessionID != "" {
+		if err := deleteSession(claims.UserID, claims.SessionID, r.Context()); err != nil {
+			http.Error(w, "Error deleting session: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -135,11 +158,18 @@ func handleGetProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	verified, err := IsEmailVerified(claims.UserID, r.Context())
+	if err != nil {
+		http.Error(w, "Error fetching profile: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"id":       claims.UserID,
-		"username": username,
-		"email":    email,
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":             claims.UserID,
+		"username":       username,
+		"email":          email,
+		"email_verified": verified,
 	})
 }
 
@@ -199,16 +229,17 @@ func handleRefreshToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	accessToken, newRefreshToken, userID, err := utils.RefreshToken(req.RefreshToken)
+	accessToken, newRefreshToken, err := rotateRefreshToken(req.RefreshToken, r.Context())
 	if err != nil {
-		http.Error(w, "Token refresh failed: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	// Update stored refresh token
-	ok := config.RedisClient.Set(r.Context(), "refresh:"+userID, newRefreshToken, time.Hour*24)
-	if ok.Err() != nil {
-		http.Error(w, "Error storing new refresh token: "+ok.Err().Error(), http.StatusInternalServerError)
+		if writeRateLimitError(w, err) {
+			return
+		}
+		switch err.Error() {
+		case "invalid refresh token", "session has been revoked", "refresh token reuse detected":
+			http.Error(w, "Token refresh failed: "+err.Error(), http.StatusUnauthorized)
+		default:
+			http.Error(w, "Token refresh failed: "+err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -220,3 +251,580 @@ func handleRefreshToken(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+func handleListPersonalAccessTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := listPersonalAccessTokens(r.Context())
+	if err != nil {
+		http.Error(w, "Error fetching tokens: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+func handleCreatePersonalAccessToken(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" || !strings.HasPrefix(contentType, "application/json") {
+		http.Error(w, "Content-Type must be application/json", http.StatusBadRequest)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Name == "" {
+		http.Error(w, "Missing required fields", http.StatusBadRequest)
+		return
+	}
+
+	token, err := createPersonalAccessToken(req.Name, req.Scopes, req.ExpiresInDays, r.Context())
+	if err != nil {
+		if err.Error() == "personal access tokens cannot manage tokens" {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		http.Error(w, "Token creation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(token)
+}
+
+func handleRevokePersonalAccessToken(w http.ResponseWriter, r *http.Request) {
+	tokenID := chi.URLParam(r, "id")
+
+	if err := revokePersonalAccessToken(tokenID, r.Context()); err != nil {
+		switch err.Error() {
+		case "personal access tokens cannot manage tokens":
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case "token not found":
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, "Error revoking token: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func handleListSessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := listSessions(r.Context())
+	if err != nil {
+		http.Error(w, "Error fetching sessions: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
+}
+
+func handleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	sessionID := chi.URLParam(r, "id")
+
+	if err := revokeSession(sessionID, r.Context()); err != nil {
+		if err.Error() == "session not found" {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Error revoking session: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func handleRevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	if err := revokeOtherSessions(r.Context()); err != nil {
+		http.Error(w, "Error revoking sessions: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleListAccountAuditEvents returns the caller's own sign-ins and
+// account changes.
+func handleListAccountAuditEvents(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utils.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	filter, err := audit.FilterFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, err := audit.ListForAccount(userID, filter, r.Context())
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "limit must") || strings.HasPrefix(err.Error(), "since and until") {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Error fetching audit events: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
+
+// clientInfo captures the device details shown in the sessions list.
+func clientInfo(r *http.Request) ClientInfo {
+	return ClientInfo{
+		UserAgent: r.UserAgent(),
+		IP:        utils.ClientIP(r),
+	}
+}
+
+// writeRateLimitError answers per-account limits and lockouts with 429.
+func writeRateLimitError(w http.ResponseWriter, err error) bool {
+	var limited *RateLimitedError
+	if !errors.As(err, &limited) {
+		return false
+	}
+	utils.WriteTooManyRequests(w, limited.Message, limited.RetryAfter)
+	return true
+}
+
+func handleUnlockAccount(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token string `json:"token"`
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" || !strings.HasPrefix(contentType, "application/json") {
+		http.Error(w, "Content-Type must be application/json", http.StatusBadRequest)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Token == "" {
+		http.Error(w, "Missing required fields", http.StatusBadRequest)
+		return
+	}
+
+	if err := unlockAccount(req.Token, r.Context()); err != nil {
+		if err.Error() == "invalid or expired token" {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Error unlocking account: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Account unlocked"})
+}
+
+func handleForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email"`
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" || !strings.HasPrefix(contentType, "application/json") {
+		http.Error(w, "Content-Type must be application/json", http.StatusBadRequest)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Email == "" {
+		http.Error(w, "Missing required fields", http.StatusBadRequest)
+		return
+	}
+
+	if err := requestPasswordReset(req.Email, r.Context()); err != nil {
+		http.Error(w, "Error requesting password reset: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Same response whether or not the account exists
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "If an account exists for that email, a reset link has been sent"})
+}
+
+func handleResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" || !strings.HasPrefix(contentType, "application/json") {
+		http.Error(w, "Content-Type must be application/json", http.StatusBadRequest)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Token == "" || req.Password == "" {
+		http.Error(w, "Missing required fields", http.StatusBadRequest)
+		return
+	}
+
+	if err := resetPassword(req.Token, req.Password, r.Context()); err != nil {
+		if err.Error() == "invalid or expired token" {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Error resetting password: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Password reset successfully"})
+}
+
+func handleChangePassword(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" || !strings.HasPrefix(contentType, "application/json") {
+		http.Error(w, "Content-Type must be application/json", http.StatusBadRequest)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		http.Error(w, "Missing required fields", http.StatusBadRequest)
+		return
+	}
+
+	if err := changePassword(req.CurrentPassword, req.NewPassword, r.Context()); err != nil {
+		if err.Error() == "current password is incorrect" {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		http.Error(w, "Error changing password: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Password changed successfully"})
+}
+
+func handleVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token string `json:"token"`
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" || !strings.HasPrefix(contentType, "application/json") {
+		http.Error(w, "Content-Type must be application/json", http.StatusBadRequest)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Token == "" {
+		http.Error(w, "Missing required fields", http.StatusBadRequest)
+		return
+	}
+
+	if err := verifyEmail(req.Token, r.Context()); err != nil {
+		if err.Error() == "invalid or expired token" {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Error verifying email: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Email verified"})
+}
+
+func handleResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	if err := resendVerificationEmail(r.Context()); err != nil {
+		if err.Error() == "email already verified" {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Error sending verification email: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Verification email sent"})
+}
+
+func handleLoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ChallengeToken string `json:"challengeToken"`
+		Code           string `json:"code"`
+		RecoveryCode   string `json:"recoveryCode"`
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" || !strings.HasPrefix(contentType, "application/json") {
+		http.Error(w, "Content-Type must be application/json", http.StatusBadRequest)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.ChallengeToken == "" || (req.Code == "" && req.RecoveryCode == "") {
+		http.Error(w, "Missing required fields", http.StatusBadRequest)
+		return
+	}
+
+	data, err := completeTwoFactorLogin(req.ChallengeToken, req.Code, req.RecoveryCode, clientInfo(r), r.Context())
+	if err != nil {
+		switch err.Error() {
+		case "invalid two-factor code", "invalid or expired token", "too many attempts":
+			http.Error(w, "Login failed: "+err.Error(), http.StatusUnauthorized)
+		default:
+			http.Error(w, "Login failed: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(data)
+}
+
+func handleGetTwoFactorStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := getTwoFactorStatus(r.Context())
+	if err != nil {
+		http.Error(w, "Error fetching two-factor status: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+func handleBeginTwoFactorEnrollment(w http.ResponseWriter, r *http.Request) {
+	enrollment, err := beginTwoFactorEnrollment(r.Context())
+	if err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(enrollment)
+}
+
+func handleConfirmTwoFactorEnrollment(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Code string `json:"code"`
+	}
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" || !strings.HasPrefix(contentType, "application/json") {
+		http.Error(w, "Content-Type must be application/json", http.StatusBadRequest)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Code == "" {
+		http.Error(w, "Missing required fields", http.StatusBadRequest)
+		return
+	}
+
+	codes, err := confirmTwoFactorEnrollment(req.Code, r.Context())
+	if err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]string{"recovery_codes": codes})
+}
+
+func handleRegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Code string `json:"code"`
+	}
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" || !strings.HasPrefix(contentType, "application/json") {
+		http.Error(w, "Content-Type must be application/json", http.StatusBadRequest)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Code == "" {
+		http.Error(w, "Missing required fields", http.StatusBadRequest)
+		return
+	}
+
+	codes, err := regenerateRecoveryCodes(req.Code, r.Context())
+	if err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]string{"recovery_codes": codes})
+}
+
+func handleDisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Password     string `json:"password"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" || !strings.HasPrefix(contentType, "application/json") {
+		http.Error(w, "Content-Type must be application/json", http.StatusBadRequest)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Password == "" || (req.Code == "" && req.RecoveryCode == "") {
+		http.Error(w, "Missing required fields", http.StatusBadRequest)
+		return
+	}
+
+	if err := disableTwoFactor(req.Password, req.Code, req.RecoveryCode, r.Context()); err != nil {
+		writeTwoFactorError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeTwoFactorError(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case "invalid two-factor code", "current password is incorrect":
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case "two-factor authentication is already enabled", "two-factor authentication is not enabled",
+		"no two-factor enrollment in progress":
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "Two-factor error: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func handleListOIDCProviders(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(listOIDCProviders())
+}
+
+func handleOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	authURL, err := beginOIDCLogin(chi.URLParam(r, "provider"), r.URL.Query().Get("redirect"), r.Context())
+	if err != nil {
+		if err.Error() == "unknown provider" {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "SSO login failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// handleOIDCCallback is reached by the browser, so failures redirect back
+// to the login page instead of rendering a plain error.
+func handleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if providerErr := q.Get("error"); providerErr != "" {
+		msg := providerErr
+		if desc := q.Get("error_description"); desc != "" {
+			msg = desc
+		}
+		redirectSSOError(w, r, msg)
+		return
+	}
+	if q.Get("code") == "" || q.Get("state") == "" {
+		redirectSSOError(w, r, "missing code or state")
+		return
+	}
+
+	loginCode, redirect, err := completeOIDCLogin(chi.URLParam(r, "provider"), q.Get("code"), q.Get("state"), clientInfo(r), r.Context())
+	if err != nil {
+		config.Logger.Printf("SSO login failed: %v", err)
+		redirectSSOError(w, r, err.Error())
+		return
+	}
+
+	fragment := url.Values{}
+	fragment.Set("code", loginCode)
+	fragment.Set("redirect", redirect)
+	http.Redirect(w, r, config.AppURL+"/auth/callback#"+fragment.Encode(), http.StatusFound)
+}
+
+func redirectSSOError(w http.ResponseWriter, r *http.Request, msg string) {
+	http.Redirect(w, r, config.AppURL+"/login?sso_error="+url.QueryEscape(msg), http.StatusFound)
+}
+
+func handleOIDCExchange(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Code string `json:"code"`
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" || !strings.HasPrefix(contentType, "application/json") {
+		http.Error(w, "Content-Type must be application/json", http.StatusBadRequest)
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Code == "" {
+		http.Error(w, "Missing required fields", http.StatusBadRequest)
+		return
+	}
+
+	data, err := exchangeOIDCLoginCode(req.Code, r.Context())
+	if err != nil {
+		if err.Error() == "invalid or expired token" {
+			http.Error(w, "Login failed: "+err.Error(), http.StatusUnauthorized)
+			return
+		}
+		http.Error(w, "Login failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(data)
+}
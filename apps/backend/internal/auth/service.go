@@ -1,15 +1,28 @@
 package auth
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
+	"granth/internal/audit"
 	"granth/internal/utils"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
-func registerUser(username, email, passwordHash string) (AuthResponse, error) {
+// recordAccountEvent audits a change to a user's own account. userID is
+// passed explicitly because many of these happen before sign-in.
+func recordAccountEvent(userID, action string, after interface{}, ctx context.Context) {
+	audit.Record(audit.Entry{ActorID: userID, Action: action, TargetType: "user", TargetID: userID, After: after}, ctx)
+}
+
+func registerUser(username, email, passwordHash string, client ClientInfo, ctx context.Context) (AuthResponse, error) {
+	if err := checkAccountLimit(normalizeEmail(email), registerAccountLimit, ctx); err != nil {
+		return AuthResponse{}, err
+	}
+
 	_, existingUsername, _, err := GetUserByEmail(email)
 	if err == nil {
 		// email already exists
@@ -33,15 +46,14 @@ func registerUser(username, email, passwordHash string) (AuthResponse, error) {
 		return AuthResponse{}, fmt.Errorf("error creating user: %w", err)
 	}
 
-	accessToken, err := utils.CreateUserToken(id)
-	if err != nil {
-		return AuthResponse{}, fmt.Errorf("error creating access token: %w", err)
-	}
+	recordAccountEvent(id, "user.registered", map[string]string{"username": username, "email": email}, ctx)
+	sendVerificationEmailAsync(id, email)
 
-	refreshToken, err := utils.CreateRefreshToken(id)
+	accessToken, refreshToken, err := startSession(id, client, ctx)
 	if err != nil {
-		return AuthResponse{}, fmt.Errorf("error creating refresh token: %w", err)
+		return AuthResponse{}, err
 	}
+	recordAccountEvent(id, "login.succeeded", map[string]string{"method": "password"}, ctx)
 	return AuthResponse{
 		UserID:       id,
 		Username:     username,
@@ -50,24 +62,48 @@ func registerUser(username, email, passwordHash string) (AuthResponse, error) {
 	}, nil
 }
 
-func login(email, password string) (AuthResponse, error) {
+func login(email, password string, client ClientInfo, ctx context.Context) (AuthResponse, error) {
+	if err := checkAccountLimit(normalizeEmail(email), loginAccountLimit, ctx); err != nil {
+		return AuthResponse{}, err
+	}
+
 	id, username, passwordHash, err := GetUserByEmail(email)
 	if err != nil {
 		return AuthResponse{}, fmt.Errorf("Error during login: %w", err)
 	}
+	if err := checkAccountLock(id, ctx); err != nil {
+		return AuthResponse{}, err
+	}
 	err = bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password))
 	if err != nil {
+		recordLoginFailure(id, email, ctx)
+		recordAccountEvent(id, "login.failed", nil, ctx)
 		return AuthResponse{}, fmt.Errorf("Invalid Password")
 	}
-	accessToken, err := utils.CreateUserToken(id)
+	clearLoginFailures(id, ctx)
+
+	twoFactor, err := isTwoFactorEnabled(id, ctx)
 	if err != nil {
-		return AuthResponse{}, fmt.Errorf("error creating access token: %w", err)
+		return AuthResponse{}, fmt.Errorf("Error during login: %w", err)
+	}
+	if twoFactor {
+		challenge, err := issueTwoFactorChallenge(id, map[string]string{"method": "password"}, ctx)
+		if err != nil {
+			return AuthResponse{}, fmt.Errorf("error creating challenge: %w", err)
+		}
+		return AuthResponse{
+			UserID:            id,
+			Username:          username,
+			TwoFactorRequired: true,
+			ChallengeToken:    challenge,
+		}, nil
 	}
 
-	refreshToken, err := utils.CreateRefreshToken(id)
+	accessToken, refreshToken, err := startSession(id, client, ctx)
 	if err != nil {
-		return AuthResponse{}, fmt.Errorf("error creating refresh token: %w", err)
+		return AuthResponse{}, err
 	}
+	recordAccountEvent(id, "login.succeeded", map[string]string{"method": "password"}, ctx)
 	return AuthResponse{
 		UserID:       id,
 		Username:     username,
@@ -75,3 +111,91 @@ func login(email, password string) (AuthResponse, error) {
 		RefreshToken: refreshToken,
 	}, nil
 }
+
+// maxPersonalAccessTokenLifetime caps how long a personal access token can
+// live; long-running automation is expected to rotate.
+const maxPersonalAccessTokenLifetime = 366 * 24 * time.Hour
+
+func createPersonalAccessToken(name string, scopes []string, expiresInDays int, ctx context.Context) (*CreatedPersonalAccessToken, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+	if utils.IsPersonalAccessTokenContext(ctx) {
+		return nil, fmt.Errorf("personal access tokens cannot manage tokens")
+	}
+
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+	seen := make(map[string]bool, len(scopes))
+	unique := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !utils.IsValidScope(scope) {
+			return nil, fmt.Errorf("invalid scope: %s", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+
+	if expiresInDays <= 0 {
+		expiresInDays = 90
+	}
+	lifetime := time.Duration(expiresInDays) * 24 * time.Hour
+	if lifetime > maxPersonalAccessTokenLifetime {
+		return nil, fmt.Errorf("expiry cannot be more than 366 days")
+	}
+
+	plaintext, lookup, hash, err := utils.GenerateOpaqueToken(utils.PersonalAccessTokenKind)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(lifetime).Format(time.RFC3339)
+	token := &CreatedPersonalAccessToken{
+		PersonalAccessToken: PersonalAccessToken{
+			Name:      name,
+			Prefix:    lookup,
+			Scopes:    unique,
+			ExpiresAt: &expiresAt,
+			CreatedAt: now.Format(time.RFC3339),
+		},
+		Token: plaintext,
+	}
+	if err := CreatePersonalAccessToken(userID, hash, &token.PersonalAccessToken, ctx); err != nil {
+		return nil, fmt.Errorf("error creating personal access token: %w", err)
+	}
+	recordAccountEvent(userID, "token.created", token.PersonalAccessToken, ctx)
+	return token, nil
+}
+
+func listPersonalAccessTokens(ctx context.Context) ([]*PersonalAccessToken, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+	return GetPersonalAccessTokensByUser(userID, ctx)
+}
+
+func revokePersonalAccessToken(tokenID string, ctx context.Context) error {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("user ID not found in context")
+	}
+	if utils.IsPersonalAccessTokenContext(ctx) {
+		return fmt.Errorf("personal access tokens cannot manage tokens")
+	}
+
+	revoked, err := RevokePersonalAccessToken(userID, tokenID, time.Now().UTC().Format(time.RFC3339), ctx)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return fmt.Errorf("token not found")
+	}
+	audit.Record(audit.Entry{Action: "token.revoked", TargetType: "personal_access_token", TargetID: tokenID}, ctx)
+	return nil
+}
@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"granth/internal/audit"
+	"granth/internal/config"
+	"granth/internal/utils"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Sessions live in Redis:
+//
+//	session:<sid>     hash of user_id, jti (current refresh token), device info
+//	sessions:<userID> set of the user's session IDs
+//
+// The session hash expires with its latest refresh token, so an idle device
+// drops out on its own.
+
+func sessionKey(sessionID string) string { return "session:" + sessionID }
+
+func userSessionsKey(userID string) string { return "sessions:" + userID }
+
+// rotateScript swaps the session's current jti for a new one only if the
+// presented jti is the current one. A stale jti means an old refresh token
+// was replayed, so the whole session is deleted. Returns 1 on rotation, 0 on
+// reuse and -1 when the session no longer exists.
+var rotateScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], 'jti')
+if not current then
+	return -1
+end
+if current ~= ARGV[1] then
+	redis.call('DEL', KEYS[1])
+	redis.call('SREM', KEYS[2], ARGV[4])
+	return 0
+end
+redis.call('HSET', KEYS[1], 'jti', ARGV[2], 'last_used_at', ARGV[3])
+redis.call('EXPIRE', KEYS[1], ARGV[5])
+return 1
+`)
+
+func newRandomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// startSession opens a new session for userID and issues its first token pair.
+func startSession(userID string, client ClientInfo, ctx context.Context) (string, string, error) {
+	sessionID, err := newRandomID()
+	if err != nil {
+		return "", "", err
+	}
+	jti, err := newRandomID()
+	if err != nil {
+		return "", "", err
+	}
+
+	accessToken, err := utils.CreateUserToken(userID, sessionID)
+	if err != nil {
+		return "", "", fmt.Errorf("error creating access token: %w", err)
+	}
+	refreshToken, err := utils.CreateRefreshToken(userID, sessionID, jti)
+	if err != nil {
+		return "", "", fmt.Errorf("error creating refresh token: %w", err)
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	pipe := config.RedisClient.TxPipeline()
+	pipe.HSet(ctx, sessionKey(sessionID), map[string]interface{}{
+		"user_id":      userID,
+		"jti":          jti,
+		"user_agent":   client.UserAgent,
+		"ip":           client.IP,
+		"created_at":   now,
+		"last_used_at": now,
+	})
+	pipe.Expire(ctx, sessionKey(sessionID), utils.RefreshTokenLifetime)
+	pipe.SAdd(ctx, userSessionsKey(userID), sessionID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", "", fmt.Errorf("error storing session: %w", err)
+	}
+	return accessToken, refreshToken, nil
+}
+
+// rotateRefreshToken exchanges a refresh token for a new pair within the
+// same session. Presenting a token that has already been rotated revokes
+// the session, since either the client or an attacker holds a stolen copy.
+func rotateRefreshToken(refreshToken string, ctx context.Context) (string, string, error) {
+	claims, err := utils.ValidateToken(refreshToken)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid refresh token")
+	}
+	if claims.TokenType != "refresh" || claims.SessionID == "" || claims.ID == "" {
+		return "", "", fmt.Errorf("invalid refresh token")
+	}
+	if err := checkAccountLimit(claims.UserID, refreshAccountLimit, ctx); err != nil {
+		return "", "", err
+	}
+
+	newJTI, err := newRandomID()
+	if err != nil {
+		return "", "", err
+	}
+
+	result, err := rotateScript.Run(ctx, config.RedisClient,
+		[]string{sessionKey(claims.SessionID), userSessionsKey(claims.UserID)},
+		claims.ID, newJTI, time.Now().UTC().Format(time.RFC3339), claims.SessionID,
+		int(utils.RefreshTokenLifetime.Seconds()),
+	).Int()
+	if err != nil {
+		return "", "", fmt.Errorf("error rotating refresh token: %w", err)
+	}
+	switch result {
+	case -1:
+		return "", "", fmt.Errorf("session has been revoked")
+	case 0:
+		config.Logger.Printf("refresh token reuse detected for user %s; session %s revoked", claims.UserID, claims.SessionID)
+		return "", "", fmt.Errorf("refresh token reuse detected")
+	}
+
+	accessToken, err := utils.CreateUserToken(claims.UserID, claims.SessionID)
+	if err != nil {
+		return "", "", fmt.Errorf("error creating access token: %w", err)
+	}
+	newRefreshToken, err := utils.CreateRefreshToken(claims.UserID, claims.SessionID, newJTI)
+	if err != nil {
+		return "", "", fmt.Errorf("error creating refresh token: %w", err)
+	}
+	return accessToken, newRefreshToken, nil
+}
+
+// listSessions returns the caller's live sessions, newest first, pruning
+// IDs whose session has expired.
+func listSessions(ctx context.Context) ([]*Session, error) {
+	claims, ok := utils.GetClaimsFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+
+	ids, err := config.RedisClient.SMembers(ctx, userSessionsKey(claims.UserID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error listing sessions: %w", err)
+	}
+
+	sessions := make([]*Session, 0, len(ids))
+	for _, id := range ids {
+		fields, err := config.RedisClient.HGetAll(ctx, sessionKey(id)).Result()
+		if err != nil {
+			return nil, fmt.Errorf("error fetching session: %w", err)
+		}
+		if len(fields) == 0 || fields["user_id"] != claims.UserID {
+			config.RedisClient.SRem(ctx, userSessionsKey(claims.UserID), id)
+			continue
+		}
+		sessions = append(sessions, &Session{
+			ID:         id,
+			UserAgent:  fields["user_agent"],
+			IP:         fields["ip"],
+			CreatedAt:  fields["created_at"],
+			LastUsedAt: fields["last_used_at"],
+			Current:    id == claims.SessionID,
+		})
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt > sessions[j].LastUsedAt
+	})
+	return sessions, nil
+}
+
+// revokeSession ends one of the caller's sessions. Access tokens already
+// issued for it stay valid until they expire, which is at most a minute.
+func revokeSession(sessionID string, ctx context.Context) error {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("user ID not found in context")
+	}
+
+	owner, err := config.RedisClient.HGet(ctx, sessionKey(sessionID), "user_id").Result()
+	if err == redis.Nil || (err == nil && owner != userID) {
+		return fmt.Errorf("session not found")
+	}
+	if err != nil {
+		return fmt.Errorf("error fetching session: %w", err)
+	}
+	if err := deleteSession(userID, sessionID, ctx); err != nil {
+		return err
+	}
+	audit.Record(audit.Entry{Action: "session.revoked", TargetType: "session", TargetID: sessionID}, ctx)
+	return nil
+}
+
+// revokeOtherSessions signs the caller out everywhere except the current
+// session.
+func revokeOtherSessions(ctx context.Context) error {
+	claims, ok := utils.GetClaimsFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("user ID not found in context")
+	}
+	if err := revokeAllSessions(claims.UserID, claims.SessionID, ctx); err != nil {
+		return err
+	}
+	recordAccountEvent(claims.UserID, "session.revoked_others", nil, ctx)
+	return nil
+}
+
+// revokeAllSessions ends every session of userID except keepSessionID, which
+// may be empty.
+func revokeAllSessions(userID, keepSessionID string, ctx context.Context) error {
+	ids, err := config.RedisClient.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return fmt.Errorf("error listing sessions: %w", err)
+	}
+	for _, id := range ids {
+		if id == keepSessionID {
+			continue
+		}
+		if err := deleteSession(userID, id, ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deleteSession(userID, sessionID string, ctx context.Context) error {
+	pipe := config.RedisClient.TxPipeline()
+	pipe.Del(ctx, sessionKey(sessionID))
+	pipe.SRem(ctx, userSessionsKey(userID), sessionID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("error revoking session: %w", err)
+	}
+	return nil
+}
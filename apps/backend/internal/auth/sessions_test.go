@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"granth/internal/testdb"
+	"granth/internal/utils"
+)
+
+func TestRefreshTokenRotationAndReuse(t *testing.T) {
+	testdb.Open(t)
+	testdb.OpenRedis(t)
+	ctx := context.Background()
+	if err := utils.InitKeyring(utils.KeyringConfig{}, ctx); err != nil {
+		t.Fatal(err)
+	}
+	userID := testdb.CreateUser(t)
+
+	access, first, err := startSession(userID, ClientInfo{UserAgent: "test"}, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := rotateRefreshToken(access, ctx); err == nil || err.Error() != "invalid refresh token" {
+		t.Fatalf("rotating with an access token: got %v", err)
+	}
+
+	_, second, err := rotateRefreshToken(first, ctx)
+	if err != nil {
+		t.Fatalf("rotating: %v", err)
+	}
+	if second == first {
+		t.Fatal("rotation returned the same refresh token")
+	}
+
+	if _, _, err := rotateRefreshToken(first, ctx); err == nil || err.Error() != "refresh token reuse detected" {
+		t.Fatalf("reusing a rotated token: got %v", err)
+	}
+	if _, _, err := rotateRefreshToken(second, ctx); err == nil || err.Error() != "session has been revoked" {
+		t.Fatalf("rotating after reuse: got %v", err)
+	}
+}
+
+func TestRefreshTokenReuseRevokesOnlyThatSession(t *testing.T) {
+	testdb.Open(t)
+	testdb.OpenRedis(t)
+	ctx := context.Background()
+	if err := utils.InitKeyring(utils.KeyringConfig{}, ctx); err != nil {
+		t.Fatal(err)
+	}
+	userID := testdb.CreateUser(t)
+
+	_, stolen, err := startSession(userID, ClientInfo{}, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, other, err := startSession(userID, ClientInfo{}, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := rotateRefreshToken(stolen, ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := rotateRefreshToken(stolen, ctx); err == nil || err.Error() != "refresh token reuse detected" {
+		t.Fatalf("reusing a rotated token: got %v", err)
+	}
+	if _, _, err := rotateRefreshToken(other, ctx); err != nil {
+		t.Fatalf("rotating the user's other session: %v", err)
+	}
+}
@@ -1,9 +1,12 @@
 package auth
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"granth/internal/config"
+
+	"github.com/lib/pq"
 )
 
 func CreateUser(username, email, passwordHash string) (string, string, error) {
@@ -65,3 +68,380 @@ func DeleteUser(id string) error {
 	}
 	return nil
 }
+
+func CreatePersonalAccessToken(userID, tokenHash string, token *PersonalAccessToken, ctx context.Context) error {
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`INSERT INTO personal_access_tokens (user_id, name, prefix, token_hash, scopes, expires_at, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		userID, token.Name, token.Prefix, tokenHash, pq.Array(token.Scopes), token.ExpiresAt, token.CreatedAt,
+	).Scan(&token.ID)
+	if err != nil {
+		return fmt.Errorf("createPersonalAccessToken query: %w", err)
+	}
+	return nil
+}
+
+func GetPersonalAccessTokensByUser(userID string, ctx context.Context) ([]*PersonalAccessToken, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT id, name, prefix, scopes, expires_at, last_used_at, revoked_at, created_at
+		 FROM personal_access_tokens WHERE user_id = $1 ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("getPersonalAccessTokensByUser query: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := make([]*PersonalAccessToken, 0)
+	for rows.Next() {
+		t := &PersonalAccessToken{}
+		var scopes pq.StringArray
+		if err := rows.Scan(&t.ID, &t.Name, &t.Prefix, &scopes, &t.ExpiresAt, &t.LastUsedAt, &t.RevokedAt, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("getPersonalAccessTokensByUser scan: %w", err)
+		}
+		t.Scopes = []string(scopes)
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+func RevokePersonalAccessToken(userID, tokenID, revokedAt string, ctx context.Context) (bool, error) {
+	res, err := config.PostgresDB.ExecContext(ctx,
+		`UPDATE personal_access_tokens SET revoked_at = $1 WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL`,
+		revokedAt, tokenID, userID)
+	if err != nil {
+		return false, fmt.Errorf("revokePersonalAccessToken exec: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("revokePersonalAccessToken exec: %w", err)
+	}
+	return n > 0, nil
+}
+
+// RevokePersonalAccessTokensByUser revokes every live token of userID and
+// returns how many it revoked.
+func RevokePersonalAccessTokensByUser(userID, revokedAt string, ctx context.Context) (int64, error) {
+	res, err := config.PostgresDB.ExecContext(ctx,
+		`UPDATE personal_access_tokens SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL`,
+		revokedAt, userID)
+	if err != nil {
+		return 0, fmt.Errorf("revokePersonalAccessTokensByUser exec: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("revokePersonalAccessTokensByUser exec: %w", err)
+	}
+	return n, nil
+}
+
+func CreateActionToken(tokenID, userID, purpose, expiresAt string, ctx context.Context) error {
+	_, err := config.PostgresDB.ExecContext(ctx,
+		`INSERT INTO auth_action_tokens (id, user_id, purpose, expires_at) VALUES ($1, $2, $3, $4)`,
+		tokenID, userID, purpose, expiresAt)
+	if err != nil {
+		return fmt.Errorf("createActionToken exec: %w", err)
+	}
+	return nil
+}
+
+// consumeActionTokenInTx marks a live token as used. It reports false when
+// the token is unknown, expired or already used.
+func consumeActionTokenInTx(tx *sql.Tx, tokenID, userID, purpose string, ctx context.Context) (bool, error) {
+	res, err := tx.ExecContext(ctx,
+		`UPDATE auth_action_tokens SET used_at = now()
+		 WHERE id = $1 AND user_id = $2 AND purpose = $3 AND used_at IS NULL AND expires_at > now()`,
+		tokenID, userID, purpose)
+	if err != nil {
+		return false, fmt.Errorf("consumeActionToken exec: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("consumeActionToken exec: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ConsumeActionToken marks a live token as used outside of any other
+// change.
+func ConsumeActionToken(tokenID, userID, purpose string, ctx context.Context) (bool, error) {
+	tx, err := config.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("consumeActionToken begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	ok, err := consumeActionTokenInTx(tx, tokenID, userID, purpose, ctx)
+	if err != nil || !ok {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// ResetPasswordWithToken consumes a password reset token and sets the new
+// password hash atomically. Any other outstanding reset tokens for the user
+// are invalidated as well.
+func ResetPasswordWithToken(tokenID, userID, newPasswordHash string, ctx context.Context) (bool, error) {
+	tx, err := config.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("resetPasswordWithToken begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	ok, err := consumeActionTokenInTx(tx, tokenID, userID, "password_reset", ctx)
+	if err != nil || !ok {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE users SET password_hash = $1 WHERE id = $2`, newPasswordHash, userID); err != nil {
+		return false, fmt.Errorf("resetPasswordWithToken exec: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE auth_action_tokens SET used_at = now()
+		 WHERE user_id = $1 AND purpose = 'password_reset' AND used_at IS NULL`, userID); err != nil {
+		return false, fmt.Errorf("resetPasswordWithToken exec: %w", err)
+	}
+	return true, tx.Commit()
+}
+
+// VerifyEmailWithToken consumes a verification token and marks the user's
+// email as verified.
+func VerifyEmailWithToken(tokenID, userID string, ctx context.Context) (bool, error) {
+	tx, err := config.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("verifyEmailWithToken begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	ok, err := consumeActionTokenInTx(tx, tokenID, userID, "email_verification", ctx)
+	if err != nil || !ok {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE users SET email_verified_at = COALESCE(email_verified_at, now()) WHERE id = $1`, userID); err != nil {
+		return false, fmt.Errorf("verifyEmailWithToken exec: %w", err)
+	}
+	return true, tx.Commit()
+}
+
+// IsEmailVerified reports whether the user has confirmed their email address.
+func IsEmailVerified(userID string, ctx context.Context) (bool, error) {
+	var verified bool
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT email_verified_at IS NOT NULL FROM users WHERE id = $1`, userID,
+	).Scan(&verified)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("isEmailVerified query: %w", err)
+	}
+	return verified, nil
+}
+
+func GetTOTP(userID string, ctx context.Context) (*totpRecord, error) {
+	t := &totpRecord{}
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT secret, enabled_at, last_used_step FROM user_totp WHERE user_id = $1`, userID,
+	).Scan(&t.Secret, &t.EnabledAt, &t.LastUsedStep)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getTOTP query: %w", err)
+	}
+	return t, nil
+}
+
+// SavePendingTOTP stores a new, not yet enabled secret, replacing any
+// earlier unfinished enrollment.
+func SavePendingTOTP(userID, secret string, ctx context.Context) error {
+	_, err := config.PostgresDB.ExecContext(ctx,
+		`INSERT INTO user_totp (user_id, secret) VALUES ($1, $2)
+		 ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, last_used_step = 0, created_at = now()
+		 WHERE user_totp.enabled_at IS NULL`,
+		userID, secret)
+	if err != nil {
+		return fmt.Errorf("savePendingTOTP exec: %w", err)
+	}
+	return nil
+}
+
+// EnableTOTP turns on a pending enrollment and replaces the user's recovery
+// codes in one transaction.
+func EnableTOTP(userID string, step int64, codeHashes []string, ctx context.Context) error {
+	tx, err := config.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("enableTOTP begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE user_totp SET enabled_at = now(), last_used_step = $2 WHERE user_id = $1`, userID, step); err != nil {
+		return fmt.Errorf("enableTOTP exec: %w", err)
+	}
+	if err := replaceRecoveryCodesInTx(tx, userID, codeHashes, ctx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MarkTOTPStepUsed records a successful code. It reports false if the step
+// (or a later one) was already used, which means the code is being replayed.
+func MarkTOTPStepUsed(userID string, step int64, ctx context.Context) (bool, error) {
+	res, err := config.PostgresDB.ExecContext(ctx,
+		`UPDATE user_totp SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2`, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("markTOTPStepUsed exec: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("markTOTPStepUsed exec: %w", err)
+	}
+	return n > 0, nil
+}
+
+func DeleteTOTP(userID string, ctx context.Context) error {
+	tx, err := config.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("deleteTOTP begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("deleteTOTP exec: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("deleteTOTP exec: %w", err)
+	}
+	return tx.Commit()
+}
+
+func ReplaceRecoveryCodes(userID string, codeHashes []string, ctx context.Context) error {
+	tx, err := config.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("replaceRecoveryCodes begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodesInTx(tx, userID, codeHashes, ctx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replaceRecoveryCodesInTx(tx *sql.Tx, userID string, codeHashes []string, ctx context.Context) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("replaceRecoveryCodes exec: %w", err)
+	}
+	for _, h := range codeHashes {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, h); err != nil {
+			return fmt.Errorf("replaceRecoveryCodes exec: %w", err)
+		}
+	}
+	return nil
+}
+
+// ConsumeRecoveryCode marks an unused recovery code as used.
+func ConsumeRecoveryCode(userID, codeHash string, ctx context.Context) (bool, error) {
+	res, err := config.PostgresDB.ExecContext(ctx,
+		`UPDATE user_recovery_codes SET used_at = now()
+		 WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("consumeRecoveryCode exec: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("consumeRecoveryCode exec: %w", err)
+	}
+	return n > 0, nil
+}
+
+func CountRecoveryCodes(userID string, ctx context.Context) (int, error) {
+	var count int
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = $1 AND used_at IS NULL`, userID,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("countRecoveryCodes query: %w", err)
+	}
+	return count, nil
+}
+
+// GetUserIDByIdentity returns the user linked to an external identity, or
+// "" when there is none.
+func GetUserIDByIdentity(provider, subject string, ctx context.Context) (string, error) {
+	var userID string
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`UPDATE user_identities SET last_login_at = now() WHERE provider = $1 AND subject = $2 RETURNING user_id`,
+		provider, subject,
+	).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("getUserIDByIdentity query: %w", err)
+	}
+	return userID, nil
+}
+
+func LinkIdentity(userID, provider, subject, email string, ctx context.Context) error {
+	_, err := config.PostgresDB.ExecContext(ctx,
+		`INSERT INTO user_identities (user_id, provider, subject, email, last_login_at) VALUES ($1, $2, $3, $4, now())`,
+		userID, provider, subject, email)
+	if err != nil {
+		return fmt.Errorf("linkIdentity exec: %w", err)
+	}
+	return nil
+}
+
+func UsernameExists(username string, ctx context.Context) (bool, error) {
+	var exists bool
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)`, username,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("usernameExists query: %w", err)
+	}
+	return exists, nil
+}
+
+// CreateSSOUser provisions a user that signs in through an identity
+// provider. The password hash is unusable, so password login fails until
+// the user sets one through the reset flow.
+func CreateSSOUser(username, email string, emailVerified bool, provider, subject string, ctx context.Context) (string, error) {
+	tx, err := config.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("createSSOUser begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id string
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO users (username, email, password_hash, email_verified_at)
+		 VALUES ($1, $2, '!', CASE WHEN $3 THEN now() END) RETURNING id`,
+		username, email, emailVerified,
+	).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("createSSOUser query: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO user_identities (user_id, provider, subject, email, last_login_at) VALUES ($1, $2, $3, $4, now())`,
+		id, provider, subject, email); err != nil {
+		return "", fmt.Errorf("createSSOUser exec: %w", err)
+	}
+	return id, tx.Commit()
+}
+
+// ApplyDomainJoinRules adds the user to every workspace with a rule for the
+// email's domain. Existing memberships are left untouched.
+func ApplyDomainJoinRules(userID, domain string, ctx context.Context) error {
+	_, err := config.PostgresDB.ExecContext(ctx,
+		`INSERT INTO workspace_members (workspace_id, user_id, role, joined_at)
+		 SELECT workspace_id, $1, role, now() FROM workspace_domain_rules WHERE domain = $2
+		 ON CONFLICT (workspace_id, user_id) DO NOTHING`,
+		userID, domain)
+	if err != nil {
+		return fmt.Errorf("applyDomainJoinRules exec: %w", err)
+	}
+	return nil
+}
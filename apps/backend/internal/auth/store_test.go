@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"granth/internal/config"
+	"granth/internal/testdb"
+)
+
+// TestRevokePersonalAccessTokensByUser checks that the revocation done on a
+// password reset or change reaches every live token of the user and no one
+// else's.
+func TestRevokePersonalAccessTokensByUser(t *testing.T) {
+	testdb.Open(t)
+	ctx := context.Background()
+	user := testdb.CreateUser(t)
+	other := testdb.CreateUser(t)
+	insert := func(userID, prefix string) string {
+		var id string
+		err := config.PostgresDB.QueryRow(
+			`INSERT INTO personal_access_tokens (user_id, name, prefix, token_hash, scopes) VALUES ($1, 't', $2, 'x', '{read}') RETURNING id`,
+			userID, prefix+time.Now().Format("150405.000000000"),
+		).Scan(&id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	insert(user, "a")
+	insert(user, "b")
+	otherToken := insert(other, "c")
+
+	n, err := RevokePersonalAccessTokensByUser(user, time.Now().UTC().Format(time.RFC3339), ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("revoked %d tokens, want 2", n)
+	}
+	var revoked bool
+	if err := config.PostgresDB.QueryRow(`SELECT revoked_at IS NOT NULL FROM personal_access_tokens WHERE id = $1`, otherToken).Scan(&revoked); err != nil {
+		t.Fatal(err)
+	}
+	if revoked {
+		t.Fatal("another user's token was revoked")
+	}
+}
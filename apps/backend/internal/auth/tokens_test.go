@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"granth/internal/config"
+	"granth/internal/testdb"
+	"granth/internal/utils"
+)
+
+func TestCreatePersonalAccessTokenValidation(t *testing.T) {
+	pat := utils.WithClaims(context.Background(), &utils.Claims{UserID: "u", TokenType: "pat", Scopes: []string{utils.ScopeAdmin}})
+	if _, err := createPersonalAccessToken("t", []string{utils.ScopeRead}, 1, pat); err == nil || err.Error() != "personal access tokens cannot manage tokens" {
+		t.Fatalf("creating from a token: got %v", err)
+	}
+	session := testdb.As("u")
+	for _, tt := range []struct {
+		scopes []string
+		days   int
+		want   string
+	}{
+		{nil, 1, "at least one scope is required"},
+		{[]string{"write"}, 1, "invalid scope: write"},
+		{[]string{utils.ScopeRead}, 400, "expiry cannot be more than 366 days"},
+	} {
+		if _, err := createPersonalAccessToken("t", tt.scopes, tt.days, session); err == nil || err.Error() != tt.want {
+			t.Errorf("scopes %v, %d days: got %v, want %q", tt.scopes, tt.days, err, tt.want)
+		}
+	}
+}
+
+func TestPersonalAccessTokenScopesAndExpiry(t *testing.T) {
+	testdb.Open(t)
+	user := testdb.CreateUser(t)
+	ctx := context.Background()
+	created, err := createPersonalAccessToken("ci", []string{utils.ScopeRead, utils.ScopeRead}, 1, testdb.As(user))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created.Scopes) != 1 {
+		t.Fatalf("scopes = %v, want duplicates removed", created.Scopes)
+	}
+
+	claims, err := utils.ValidatePersonalAccessToken(created.Token, ctx)
+	if err != nil {
+		t.Fatalf("validating: %v", err)
+	}
+	if claims.UserID != user || claims.TokenType != "pat" {
+		t.Fatalf("claims = %+v", claims)
+	}
+	tokenCtx := utils.WithClaims(ctx, claims)
+	if !utils.HasScope(tokenCtx, utils.ScopeRead) || utils.HasScope(tokenCtx, utils.ScopePropose) {
+		t.Fatalf("scopes on the validated token = %v, want only read", claims.Scopes)
+	}
+
+	if _, err := utils.ValidatePersonalAccessToken(created.Token+"x", ctx); err == nil {
+		t.Fatal("a tampered token was accepted")
+	}
+	if _, err := config.PostgresDB.Exec(`UPDATE personal_access_tokens SET expires_at = now() - interval '1 minute' WHERE id = $1`, created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := utils.ValidatePersonalAccessToken(created.Token, ctx); err == nil || err.Error() != "token is invalid" {
+		t.Fatalf("expired token: got %v, want token is invalid", err)
+	}
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"granth/internal/config"
+	"granth/internal/testdb"
+)
+
+// rfc6238Secret is the SHA-1 key of the RFC 6238 test vectors.
+var rfc6238Secret = totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestMatchTOTP(t *testing.T) {
+	// RFC 6238: at T=59 the eight-digit code is 94287082
+	at := time.Unix(59, 0)
+	tests := []struct {
+		name string
+		code string
+		now  time.Time
+		step int64
+		ok   bool
+	}{
+		{"current step", "287082", at, 1, true},
+		{"one step late", "287082", at.Add(30 * time.Second), 1, true},
+		{"two steps late", "287082", at.Add(60 * time.Second), 0, false},
+		{"wrong code", "287083", at, 0, false},
+		{"wrong length", "94287082", at, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := matchTOTP(rfc6238Secret, tt.code, tt.now)
+			if step != tt.step || ok != tt.ok {
+				t.Fatalf("got %d, %v; want %d, %v", step, ok, tt.step, tt.ok)
+			}
+		})
+	}
+}
+
+// TestVerifySecondFactorRejectsReplay checks that a code works once, and
+// that a code from an earlier step fails after a later one was used.
+func TestVerifySecondFactorRejectsReplay(t *testing.T) {
+	testdb.Open(t)
+	ctx := context.Background()
+	userID := testdb.CreateUser(t)
+	_, err := config.PostgresDB.Exec(
+		`INSERT INTO user_totp (user_id, secret, enabled_at) VALUES ($1, $2, now())`, userID, rfc6238Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := totpEncoding.DecodeString(rfc6238Secret)
+	step := time.Now().Unix() / totpPeriod
+
+	if err := verifySecondFactor(userID, totpCode(key, step), "", ctx); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	if err := verifySecondFactor(userID, totpCode(key, step), "", ctx); err == nil {
+		t.Fatal("replayed code accepted")
+	}
+	if err := verifySecondFactor(userID, totpCode(key, step-1), "", ctx); err == nil {
+		t.Fatal("code from an earlier step accepted")
+	}
+}
+
+func TestTwoFactorHandlersRequireJSON(t *testing.T) {
+	for name, handler := range map[string]http.HandlerFunc{
+		"confirm":        handleConfirmTwoFactorEnrollment,
+		"recovery codes": handleRegenerateRecoveryCodes,
+		"disable":        handleDisableTwoFactor,
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"code":"123456"}`))
+			req.Header.Set("Content-Type", "text/plain")
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Content-Type must be application/json") {
+				t.Fatalf("got %d (%s), want 400 for the content type", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"granth/internal/config"
+	"granth/internal/utils"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	purposeTwoFactorChallenge = "2fa_challenge"
+
+	// twoFactorChallengeTTL bounds how long the password step stays valid
+	twoFactorChallengeTTL = 5 * time.Minute
+	// maxTwoFactorAttempts is the number of wrong codes a challenge absorbs
+	// before the user has to start over with their password
+	maxTwoFactorAttempts = 5
+	recoveryCodeCount    = 10
+)
+
+func challengeKey(tokenID string) string { return "2fa_challenge:" + tokenID }
+
+// challengeLoginKey holds how the first factor was passed, for the login
+// event recorded once the code is in.
+func challengeLoginKey(tokenID string) string { return "2fa_challenge_login:" + tokenID }
+
+// isTwoFactorEnabled reports whether the user has a confirmed authenticator.
+func isTwoFactorEnabled(userID string, ctx context.Context) (bool, error) {
+	t, err := GetTOTP(userID, ctx)
+	if err != nil {
+		return false, err
+	}
+	return t != nil && t.EnabledAt != nil, nil
+}
+
+// verifySecondFactor accepts either a current TOTP code or an unused
+// recovery code.
+func verifySecondFactor(userID, code, recoveryCode string, ctx context.Context) error {
+	if recoveryCode != "" {
+		ok, err := ConsumeRecoveryCode(userID, utils.HashToken(normalizeRecoveryCode(recoveryCode)), ctx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("invalid two-factor code")
+		}
+		return nil
+	}
+
+	t, err := GetTOTP(userID, ctx)
+	if err != nil {
+		return err
+	}
+	if t == nil || t.EnabledAt == nil {
+		return fmt.Errorf("two-factor authentication is not enabled")
+	}
+	step, ok := matchTOTP(t.Secret, code, time.Now())
+	if !ok {
+		return fmt.Errorf("invalid two-factor code")
+	}
+	fresh, err := MarkTOTPStepUsed(userID, step, ctx)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return fmt.Errorf("invalid two-factor code")
+	}
+	return nil
+}
+
+// issueTwoFactorChallenge is the first half of a 2FA login: the password or
+// SSO login was right, and the returned token lets the client submit a code
+// next. login describes the first factor for the login event.
+func issueTwoFactorChallenge(userID string, login map[string]string, ctx context.Context) (string, error) {
+	tokenID, err := newRandomID()
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(login)
+	if err != nil {
+		return "", fmt.Errorf("error encoding challenge: %w", err)
+	}
+	if err := config.RedisClient.Set(ctx, challengeLoginKey(tokenID), data, twoFactorChallengeTTL).Err(); err != nil {
+		return "", fmt.Errorf("error storing challenge: %w", err)
+	}
+	// The counter starts at 1 so that an INCR on a missing key is detectable
+	if err := config.RedisClient.Set(ctx, challengeKey(tokenID), 1, twoFactorChallengeTTL).Err(); err != nil {
+		return "", fmt.Errorf("error storing challenge: %w", err)
+	}
+	return utils.CreateActionToken(userID, purposeTwoFactorChallenge, tokenID, twoFactorChallengeTTL)
+}
+
+// completeTwoFactorLogin exchanges a challenge token and a code for a
+// session. Challenges are single use and die after too many wrong codes.
+func completeTwoFactorLogin(challengeToken, code, recoveryCode string, client ClientInfo, ctx context.Context) (AuthResponse, error) {
+	claims, err := parseActionToken(challengeToken, purposeTwoFactorChallenge)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+
+	attempts, err := config.RedisClient.Incr(ctx, challengeKey(claims.ID)).Result()
+	if err != nil {
+		return AuthResponse{}, fmt.Errorf("error checking challenge: %w", err)
+	}
+	// A spent or expired challenge comes back from INCR as a new key at 1
+	if attempts == 1 {
+		config.RedisClient.Del(ctx, challengeKey(claims.ID))
+		return AuthResponse{}, fmt.Errorf("invalid or expired token")
+	}
+	if attempts > maxTwoFactorAttempts+1 {
+		config.RedisClient.Del(ctx, challengeKey(claims.ID))
+		return AuthResponse{}, fmt.Errorf("too many attempts")
+	}
+
+	if err := verifySecondFactor(claims.UserID, code, recoveryCode, ctx); err != nil {
+		return AuthResponse{}, err
+	}
+
+	// Only one request can win the delete, so the challenge is used once
+	deleted, err := config.RedisClient.Del(ctx, challengeKey(claims.ID)).Result()
+	if err != nil {
+		return AuthResponse{}, fmt.Errorf("error consuming challenge: %w", err)
+	}
+	if deleted == 0 {
+		return AuthResponse{}, fmt.Errorf("invalid or expired token")
+	}
+
+	username, _, _, err := GetUserByID(claims.UserID)
+	if err != nil {
+		return AuthResponse{}, fmt.Errorf("error fetching user: %w", err)
+	}
+	accessToken, refreshToken, err := startSession(claims.UserID, client, ctx)
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	login := map[string]string{"method": "password"}
+	if raw, err := config.RedisClient.GetDel(ctx, challengeLoginKey(claims.ID)).Result(); err == nil {
+		json.Unmarshal([]byte(raw), &login)
+	}
+	login["second_factor"] = secondFactorMethod(recoveryCode)
+	recordAccountEvent(claims.UserID, "login.succeeded", login, ctx)
+	return AuthResponse{
+		UserID:       claims.UserID,
+		Username:     username,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+func getTwoFactorStatus(ctx context.Context) (*TwoFactorStatus, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+	enabled, err := isTwoFactorEnabled(userID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	status := &TwoFactorStatus{Enabled: enabled}
+	if enabled {
+		if status.RecoveryCodesRemaining, err = CountRecoveryCodes(userID, ctx); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// beginTwoFactorEnrollment creates a pending secret. Nothing changes for the
+// login flow until the enrollment is confirmed with a first code.
+func beginTwoFactorEnrollment(ctx context.Context) (*TwoFactorEnrollment, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+	enabled, err := isTwoFactorEnabled(userID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, fmt.Errorf("two-factor authentication is already enabled")
+	}
+
+	_, email, _, err := GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching user: %w", err)
+	}
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := SavePendingTOTP(userID, secret, ctx); err != nil {
+		return nil, err
+	}
+	return &TwoFactorEnrollment{Secret: secret, OTPAuthURI: totpURI(secret, email)}, nil
+}
+
+// confirmTwoFactorEnrollment enables 2FA and returns the recovery codes.
+// They are shown once; only their hashes are kept.
+func confirmTwoFactorEnrollment(code string, ctx context.Context) ([]string, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+	t, err := GetTOTP(userID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, fmt.Errorf("no two-factor enrollment in progress")
+	}
+	if t.EnabledAt != nil {
+		return nil, fmt.Errorf("two-factor authentication is already enabled")
+	}
+	step, ok := matchTOTP(t.Secret, code, time.Now())
+	if !ok {
+		return nil, fmt.Errorf("invalid two-factor code")
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := EnableTOTP(userID, step, hashes, ctx); err != nil {
+		return nil, err
+	}
+	recordAccountEvent(userID, "two_factor.enabled", nil, ctx)
+	return codes, nil
+}
+
+func regenerateRecoveryCodes(code string, ctx context.Context) ([]string, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+	if err := verifySecondFactor(userID, code, "", ctx); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := ReplaceRecoveryCodes(userID, hashes, ctx); err != nil {
+		return nil, err
+	}
+	recordAccountEvent(userID, "two_factor.recovery_codes_regenerated", nil, ctx)
+	return codes, nil
+}
+
+// disableTwoFactor needs both the password and a second factor, so neither
+// a stolen session nor a stolen phone is enough on its own.
+func disableTwoFactor(password, code, recoveryCode string, ctx context.Context) error {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("user ID not found in context")
+	}
+	_, _, passwordHash, err := GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("error fetching user: %w", err)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)); err != nil {
+		return fmt.Errorf("current password is incorrect")
+	}
+	if err := verifySecondFactor(userID, code, recoveryCode, ctx); err != nil {
+		return err
+	}
+	if err := DeleteTOTP(userID, ctx); err != nil {
+		return err
+	}
+	recordAccountEvent(userID, "two_factor.disabled", nil, ctx)
+	return nil
+}
+
+func secondFactorMethod(recoveryCode string) string {
+	if recoveryCode != "" {
+		return "recovery_code"
+	}
+	return "totp"
+}
+
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := generateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, c := range codes {
+		hashes[i] = utils.HashToken(normalizeRecoveryCode(c))
+	}
+	return codes, hashes, nil
+}
@@ -3,6 +3,89 @@ package auth
 type AuthResponse struct {
 	UserID       string `json:"userID"`
 	Username     string `json:"username"`
-	AccessToken  string `json:"accessToken"`
-	RefreshToken string `json:"refreshToken"`
+	AccessToken  string `json:"accessToken,omitempty"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+	// TwoFactorRequired is set instead of tokens when the account has 2FA;
+	// ChallengeToken must then be exchanged at /login/2fa with a code.
+	TwoFactorRequired bool   `json:"twoFactorRequired,omitempty"`
+	ChallengeToken    string `json:"challengeToken,omitempty"`
+}
+
+type PersonalAccessToken struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	ExpiresAt  *string  `json:"expires_at"`
+	LastUsedAt *string  `json:"last_used_at"`
+	RevokedAt  *string  `json:"revoked_at,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+// CreatedPersonalAccessToken carries the plaintext token. It is only ever
+// returned from the create call.
+type CreatedPersonalAccessToken struct {
+	PersonalAccessToken
+	Token string `json:"token"`
+}
+
+// Session is one signed-in device. Each session owns a family of refresh
+// tokens; only the most recently issued one is valid.
+type Session struct {
+	ID         string `json:"id"`
+	UserAgent  string `json:"user_agent"`
+	IP         string `json:"ip"`
+	CreatedAt  string `json:"created_at"`
+	LastUsedAt string `json:"last_used_at"`
+	Current    bool   `json:"current"`
+}
+
+// ClientInfo describes the device a session is being started from.
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+type TwoFactorStatus struct {
+	Enabled                bool `json:"enabled"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorEnrollment is returned when enrollment starts. The secret is
+// shown for manual entry; otpauth_uri is rendered as a QR code.
+type TwoFactorEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type totpRecord struct {
+	Secret       string
+	EnabledAt    *string
+	LastUsedStep int64
+}
+
+// OIDCProviderConfig configures one OpenID Connect issuer. Providers are
+// loaded from the OIDC_PROVIDERS environment variable as a JSON array.
+type OIDCProviderConfig struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	Scopes       []string `json:"scopes"`
+}
+
+// OIDCProvider is the public view of a configured provider, used by the
+// login page to render sign-in buttons.
+type OIDCProvider struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// StepUpCredentials are re-entered to confirm a sensitive action. Code or
+// RecoveryCode is needed when the account has 2FA.
+type StepUpCredentials struct {
+	Password     string `json:"password"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
 }
@@ -3,9 +3,13 @@ package internal
 import (
 	"net/http"
 
+	"granth/internal/agents"
 	"granth/internal/auth"
+	"granth/internal/conflicts"
 	"granth/internal/documents"
 	"granth/internal/proposals"
+	"granth/internal/retention"
+	"granth/internal/users"
 	"granth/internal/utils"
 	"granth/internal/workspaces"
 
@@ -28,7 +32,7 @@ func BaseRouter() http.Handler {
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	}))
 
-	r.Use(middleware.Logger, middleware.Recoverer, middleware.RealIP, middleware.Heartbeat("/api/health"))
+	r.Use(middleware.RequestID, middleware.Logger, middleware.Recoverer, utils.ClientIPMiddleware, middleware.Heartbeat("/api/health"))
 
 	r.With(utils.AuthMiddleware).Get("/api", func(w http.ResponseWriter, r *http.Request) {
 		userID, _ := utils.GetUserIDFromContext(r.Context())
@@ -36,10 +40,20 @@ func BaseRouter() http.Handler {
 		w.Write([]byte(`{"message": "Welcome to Granth, authenticated user!", "userID": "` + userID + `"}`))
 	})
 
+	// public keys for verifying Granth-issued tokens
+	r.Get("/.well-known/jwks.json", utils.JWKSHandler)
+
 	r.Mount("/api/auth", auth.AuthRouter())
+	r.With(utils.AuthMiddleware).Mount("/api/users", users.UsersRouter())
 	r.With(utils.AuthMiddleware).Mount("/api/workspaces", workspaces.WorkspacesRouter())
 	r.With(utils.AuthMiddleware).Mount("/api/documents", documents.DocumentsRouter())
 	r.With(utils.AuthMiddleware).Mount("/api/proposals", proposals.ProposalsRouter())
+	r.With(utils.AuthMiddleware).Mount("/api/conflicts", conflicts.ConflictsRouter())
+	r.With(utils.AuthMiddleware).Mount("/api/trash", retention.TrashRouter())
+	r.With(utils.AuthMiddleware).Mount("/api/service-accounts", agents.ServiceAccountsRouter())
+
+	// machine proposers authenticate with service account API keys, not JWTs
+	r.Mount("/api/agent/v1", agents.AgentRouter())
 
 	return r
 }
@@ -0,0 +1,5 @@
+package config
+
+// AppURL is the public base URL of the frontend, used to build links in
+// outgoing email.
+var AppURL = "http://localhost:3000"
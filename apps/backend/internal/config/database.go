@@ -3,7 +3,11 @@ package config
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
@@ -61,3 +65,37 @@ func RunMigrations(db *sql.DB) error {
 	}
 	return nil
 }
+
+// CheckMigrations returns an error unless every migration has been
+// applied. Unlike RunMigrations it never writes.
+func CheckMigrations(db *sql.DB) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	files, err := os.ReadDir(filepath.Join(wd, "migrations"))
+	if err != nil {
+		return err
+	}
+	var latest uint64
+	for _, f := range files {
+		prefix, _, _ := strings.Cut(f.Name(), "_")
+		if v, err := strconv.ParseUint(prefix, 10, 64); err == nil && v > latest {
+			latest = v
+		}
+	}
+
+	var version uint64
+	var dirty bool
+	err = db.QueryRow("SELECT version, dirty FROM schema_migrations").Scan(&version, &dirty)
+	if err != nil {
+		return fmt.Errorf("database has not been migrated: %w", err)
+	}
+	if dirty {
+		return fmt.Errorf("migration %d did not finish", version)
+	}
+	if version < latest {
+		return fmt.Errorf("database is at migration %d of %d; start the server once to migrate it", version, latest)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package conflicts
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"granth/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func ConflictsRouter() http.Handler {
+	r := chi.NewRouter()
+
+	read := utils.RequireScope(utils.ScopeRead)
+	review := utils.RequireScope(utils.ScopeReview)
+
+	r.With(read).Get("/proposal/{proposalID}", handleGetFindingsForProposal)
+	r.With(read).Get("/document/{documentID}", handleGetFindingsForDocument)
+	r.With(review).Post("/{id}/confirm", handleConfirmFinding)
+	r.With(review).Post("/{id}/dismiss", handleDismissFinding)
+
+	return r
+}
+
+func handleGetFindingsForProposal(w http.ResponseWriter, r *http.Request) {
+	proposalID := chi.URLParam(r, "proposalID")
+	findings, err := getFindingsForProposal(proposalID, r.Context())
+	if err != nil {
+		http.Error(w, "Error fetching conflicts: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(findings)
+}
+
+func handleGetFindingsForDocument(w http.ResponseWriter, r *http.Request) {
+	documentID := chi.URLParam(r, "documentID")
+	findings, err := getFindingsForDocument(documentID, r.Context())
+	if err != nil {
+		http.Error(w, "Error fetching conflicts: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(findings)
+}
+
+func handleConfirmFinding(w http.ResponseWriter, r *http.Request) {
+	handleReviewFinding(w, r, FindingStatusConfirmed)
+}
+
+func handleDismissFinding(w http.ResponseWriter, r *http.Request) {
+	handleReviewFinding(w, r, FindingStatusDismissed)
+}
+
+func handleReviewFinding(w http.ResponseWriter, r *http.Request, status FindingStatus) {
+	findingID := chi.URLParam(r, "id")
+
+	var req struct {
+		Note string `json:"note"`
+	}
+	// Note is optional
+	json.NewDecoder(r.Body).Decode(&req)
+
+	finding, err := reviewFinding(findingID, status, req.Note, r.Context())
+	if err != nil {
+		switch err.Error() {
+		case "finding not found":
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case "access denied":
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case "finding has already been reviewed":
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Error reviewing conflict: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(finding)
+}
@@ -0,0 +1,128 @@
+package conflicts
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ruleFinding is the outcome of a deterministic check on typed blocks.
+type ruleFinding struct {
+	Kind        string
+	Explanation string
+	// Subject is the canonical block the finding is about; together with the
+	// pair and kind it identifies the finding across analyzer passes.
+	Subject string
+}
+
+const (
+	kindSectionDependency    = "section_dependency"
+	kindDefinitionDependency = "definition_dependency"
+	kindSemantic             = "semantic"
+)
+
+var (
+	// "Term" means ..., Term is defined as ..., Term refers to ...
+	definitionPattern = regexp.MustCompile(`(?i)^\s*["“']?([\p{L}][\p{L}\p{N} \-]{1,48}?)["”']?\s+(?:means|shall mean|refers to|is defined as)\b`)
+	// Term: definition (glossary style, only trusted on list blocks)
+	glossaryPattern = regexp.MustCompile(`^\s*[-*]?\s*([\p{L}][\p{L}\p{N} \-]{1,48}?)\s*:\s+\S`)
+)
+
+// checkRules runs the rule-based checks for proposal a affecting proposal b.
+// Changes that target the same block are deliberately skipped: overlap is a
+// different class of conflict and does not need inference.
+func checkRules(a, b *openProposal) []ruleFinding {
+	var findings []ruleFinding
+	sameDocument := a.DocumentID == b.DocumentID
+
+	for _, ca := range a.Changes {
+		if ca.BlockID == nil || (ca.Action != "update" && ca.Action != "delete") {
+			continue
+		}
+
+		if sameDocument && ca.CanonicalType == "header" {
+			for _, cb := range b.Changes {
+				if touchesSameBlock(ca, cb) || !isDescendant(cb.OrderPath, ca.OrderPath) {
+					continue
+				}
+				findings = append(findings, ruleFinding{
+					Kind: kindSectionDependency,
+					Explanation: fmt.Sprintf("%q %ss the heading %q, while %q changes content inside that section.",
+						a.Title, ca.Action, firstLine(ca.CanonicalContent), b.Title),
+					Subject: *ca.BlockID,
+				})
+				break
+			}
+		}
+
+		term := definedTerm(ca.CanonicalType, ca.CanonicalContent)
+		if term == "" {
+			continue
+		}
+		if ca.Action == "update" && normalize(ca.Content) == normalize(ca.CanonicalContent) {
+			continue
+		}
+		for _, cb := range b.Changes {
+			if cb.Action == "delete" || touchesSameBlock(ca, cb) || !mentionsTerm(cb.Content, term) {
+				continue
+			}
+			findings = append(findings, ruleFinding{
+				Kind: kindDefinitionDependency,
+				Explanation: fmt.Sprintf("%q %ss the definition of %q, and %q relies on that term.",
+					a.Title, ca.Action, term, b.Title),
+				Subject: *ca.BlockID,
+			})
+			break
+		}
+	}
+	return findings
+}
+
+func definedTerm(blockType, content string) string {
+	line := firstLine(content)
+	if m := definitionPattern.FindStringSubmatch(line); m != nil {
+		return strings.TrimSpace(m[1])
+	}
+	if blockType == "list" {
+		if m := glossaryPattern.FindStringSubmatch(line); m != nil {
+			return strings.TrimSpace(m[1])
+		}
+	}
+	return ""
+}
+
+func mentionsTerm(content, term string) bool {
+	pattern := `(?i)(^|[^\p{L}\p{N}])` + regexp.QuoteMeta(term) + `($|[^\p{L}\p{N}])`
+	matched, err := regexp.MatchString(pattern, content)
+	return err == nil && matched
+}
+
+func touchesSameBlock(a, b *analyzedChange) bool {
+	return a.BlockID != nil && b.BlockID != nil && *a.BlockID == *b.BlockID
+}
+
+// isDescendant reports whether path sits strictly below ancestor in the
+// order_path hierarchy.
+func isDescendant(path, ancestor []int64) bool {
+	if len(ancestor) == 0 || len(path) <= len(ancestor) {
+		return false
+	}
+	for i := range ancestor {
+		if path[i] != ancestor[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+func normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
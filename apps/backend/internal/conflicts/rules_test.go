@@ -0,0 +1,99 @@
+package conflicts
+
+import "testing"
+
+func TestDefinedTerm(t *testing.T) {
+	tests := []struct {
+		blockType, content, want string
+	}{
+		{"paragraph", `"Confidential Information" means any data disclosed`, "Confidential Information"},
+		{"paragraph", "Affiliate shall mean an entity controlling a party", "Affiliate"},
+		{"paragraph", "The parties agree as follows", ""},
+		{"list", "- Term: the period of this agreement", "Term"},
+		{"paragraph", "Term: the period of this agreement", ""},
+	}
+	for _, tt := range tests {
+		if got := definedTerm(tt.blockType, tt.content); got != tt.want {
+			t.Errorf("definedTerm(%q, %q) = %q, want %q", tt.blockType, tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestMentionsTerm(t *testing.T) {
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{"Each Affiliate must comply.", true},
+		{"each affiliate must comply", true},
+		{"Affiliated companies are excluded.", false},
+		{"Nothing relevant here.", false},
+	}
+	for _, tt := range tests {
+		if got := mentionsTerm(tt.content, "Affiliate"); got != tt.want {
+			t.Errorf("mentionsTerm(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestIsDescendant(t *testing.T) {
+	tests := []struct {
+		path, ancestor []int64
+		want           bool
+	}{
+		{[]int64{1, 2}, []int64{1}, true},
+		{[]int64{1}, []int64{1}, false},
+		{[]int64{2, 1}, []int64{1}, false},
+		{[]int64{1, 2}, nil, false},
+	}
+	for _, tt := range tests {
+		if got := isDescendant(tt.path, tt.ancestor); got != tt.want {
+			t.Errorf("isDescendant(%v, %v) = %v, want %v", tt.path, tt.ancestor, got, tt.want)
+		}
+	}
+}
+
+func TestCheckRules(t *testing.T) {
+	heading, definition, other := "h", "d", "o"
+	editHeading := &openProposal{ID: "a", DocumentID: "doc", Title: "Rename section", Changes: []*analyzedChange{{
+		Action: "update", BlockID: &heading, OrderPath: []int64{1}, Content: "Payment",
+		CanonicalType: "header", CanonicalContent: "Fees",
+	}}}
+	editInSection := &openProposal{ID: "b", DocumentID: "doc", Title: "Change fee", Changes: []*analyzedChange{{
+		Action: "update", BlockID: &other, OrderPath: []int64{1, 3}, Content: "Fees are due monthly.",
+	}}}
+	editDefinition := &openProposal{ID: "c", DocumentID: "doc", Title: "Narrow affiliates", Changes: []*analyzedChange{{
+		Action: "update", BlockID: &definition, OrderPath: []int64{2}, Content: `"Affiliate" means a subsidiary`,
+		CanonicalType: "paragraph", CanonicalContent: `"Affiliate" means any entity under common control`,
+	}}}
+	usesTerm := &openProposal{ID: "d", DocumentID: "other", Title: "Add audit rights", Changes: []*analyzedChange{{
+		Action: "create", OrderPath: []int64{5}, Content: "Each Affiliate may be audited.",
+	}}}
+	sameBlock := &openProposal{ID: "e", DocumentID: "doc", Title: "Reword heading", Changes: []*analyzedChange{{
+		Action: "update", BlockID: &heading, OrderPath: []int64{1}, Content: "Charges",
+	}}}
+
+	tests := []struct {
+		name string
+		a, b *openProposal
+		want []string
+	}{
+		{"heading over section content", editHeading, editInSection, []string{kindSectionDependency}},
+		{"section content does not depend back", editInSection, editHeading, nil},
+		{"definition used elsewhere", editDefinition, usesTerm, []string{kindDefinitionDependency}},
+		{"same block is overlap, not a rule finding", editHeading, sameBlock, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := checkRules(tt.a, tt.b)
+			if len(findings) != len(tt.want) {
+				t.Fatalf("got %+v, want kinds %v", findings, tt.want)
+			}
+			for i, f := range findings {
+				if f.Kind != tt.want[i] {
+					t.Fatalf("got kind %q, want %q", f.Kind, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package conflicts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"granth/internal/ai"
+	"granth/internal/blocks"
+	"granth/internal/config"
+	"granth/internal/proposals"
+	"granth/internal/utils"
+	"granth/internal/workspaces"
+)
+
+// ConflictPromptVersion identifies the pairwise comparison prompt below.
+const ConflictPromptVersion = "semantic-conflict/v1"
+
+// maxAIComparisonsPerRun bounds provider calls per analyzer pass. Pairs that
+// are skipped are picked up on the next pass because their hash is not stored.
+const maxAIComparisonsPerRun = 50
+
+const conflictSystemPrompt = `You review two open proposals against the same body of shared truth.
+Decide whether accepting both would leave the content contradictory, even if they change different blocks.
+Examples: one changes a definition another relies on, one narrows a rule another extends, one removes a premise another builds on.
+Respond with JSON only: {"conflict": true|false, "direction": "a_affects_b"|"b_affects_a"|"mutual", "explanation": "..."}
+Keep the explanation to two sentences and refer to the proposals as A and B.`
+
+// StartAnalyzer runs AnalyzeOpenProposals every interval until ctx is done.
+func StartAnalyzer(interval time.Duration, ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				runCtx, cancel := context.WithTimeout(ctx, interval)
+				if err := AnalyzeOpenProposals(runCtx); err != nil {
+					config.Logger.Printf("conflict analyzer: %v", err)
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// AnalyzeOpenProposals compares every pair of open proposals that share a
+// document or a workspace and records potential semantic conflicts. Rule
+// checks run on every pass; AI comparisons only run for pairs whose content
+// changed since they were last compared.
+func AnalyzeOpenProposals(ctx context.Context) error {
+	open, err := fetchOpenProposals(ctx)
+	if err != nil {
+		return err
+	}
+
+	blockCache := make(map[string]*blocks.Block)
+	for _, p := range open {
+		if err := loadChanges(p, blockCache, ctx); err != nil {
+			return err
+		}
+	}
+
+	groups := make(map[string][]*openProposal)
+	for _, p := range open {
+		key := "document:" + p.DocumentID
+		if p.WorkspaceID != nil {
+			key = "workspace:" + *p.WorkspaceID
+		}
+		groups[key] = append(groups[key], p)
+	}
+
+	aiBudget := maxAIComparisonsPerRun
+	for _, group := range groups {
+		for i, a := range group {
+			for j, b := range group {
+				if i == j {
+					continue
+				}
+				for _, rf := range checkRules(a, b) {
+					if err := recordFinding(a, b, FindingSourceRule, rf.Kind, rf.Explanation, rf.Subject, ctx); err != nil {
+						return err
+					}
+				}
+				if i < j && aiBudget > 0 && ai.DefaultProvider != nil {
+					compared, err := comparePair(a, b, ctx)
+					if err != nil {
+						config.Logger.Printf("conflict analyzer: comparing %s and %s: %v", a.ID, b.ID, err)
+						continue
+					}
+					if compared {
+						aiBudget--
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func loadChanges(p *openProposal, blockCache map[string]*blocks.Block, ctx context.Context) error {
+	changes, err := proposals.GetChangesByProposal(p.ID, ctx)
+	if err != nil {
+		return fmt.Errorf("error fetching changes for proposal %s: %w", p.ID, err)
+	}
+
+	for _, c := range changes {
+		ac := &analyzedChange{
+			Action:    c.Action,
+			BlockID:   c.BlockID,
+			BlockType: c.BlockType,
+			OrderPath: []int64(c.OrderPath),
+			Content:   c.Content,
+		}
+		if c.BlockID != nil {
+			block, ok := blockCache[*c.BlockID]
+			if !ok {
+				block, err = blocks.FetchBlockByID(*c.BlockID, ctx)
+				if err != nil {
+					// The block may have been removed by an accepted proposal;
+					// rule checks simply have less to go on.
+					block = nil
+				}
+				blockCache[*c.BlockID] = block
+			}
+			if block != nil {
+				ac.CanonicalType = block.BlockType
+				ac.CanonicalContent = block.Content
+				if len(ac.OrderPath) == 0 {
+					ac.OrderPath = []int64(block.OrderPath)
+				}
+			}
+		}
+		p.Changes = append(p.Changes, ac)
+	}
+	return nil
+}
+
+// comparePair asks the AI provider whether a and b conflict. It reports
+// whether a provider call was made.
+func comparePair(a, b *openProposal, ctx context.Context) (bool, error) {
+	prompt := "Proposal A:\n" + renderProposal(a) + "\nProposal B:\n" + renderProposal(b)
+	sum := sha256.Sum256([]byte(ConflictPromptVersion + "\n" + prompt))
+	inputHash := hex.EncodeToString(sum[:])
+
+	previous, err := fetchAnalysisHash(a.ID, b.ID, ctx)
+	if err != nil {
+		return false, err
+	}
+	if previous == inputHash {
+		return false, nil
+	}
+
+	completion, err := ai.Complete(ai.CompletionRequest{
+		System:      conflictSystemPrompt,
+		Messages:    []ai.Message{{Role: "user", Content: prompt}},
+		MaxTokens:   300,
+		Temperature: 0,
+	}, ctx)
+	if err != nil {
+		return true, err
+	}
+
+	var verdict struct {
+		Conflict    bool   `json:"conflict"`
+		Direction   string `json:"direction"`
+		Explanation string `json:"explanation"`
+	}
+	text := completion.Text
+	if start, end := strings.Index(text, "{"), strings.LastIndex(text, "}"); start >= 0 && end > start {
+		text = text[start : end+1]
+	}
+	if err := json.Unmarshal([]byte(text), &verdict); err != nil {
+		return true, fmt.Errorf("AI provider returned malformed verdict: %w", err)
+	}
+
+	if verdict.Conflict && strings.TrimSpace(verdict.Explanation) != "" {
+		from, to := a, b
+		if verdict.Direction == "b_affects_a" {
+			from, to = b, a
+		}
+		explanation := fmt.Sprintf("%s (model %s, prompt %s)", strings.TrimSpace(verdict.Explanation), completion.Model, ConflictPromptVersion)
+		if err := recordFinding(from, to, FindingSourceAI, kindSemantic, explanation, inputHash, ctx); err != nil {
+			return true, err
+		}
+	}
+
+	return true, upsertAnalysisHash(a.ID, b.ID, inputHash, time.Now().UTC().Format(time.RFC3339), ctx)
+}
+
+func renderProposal(p *openProposal) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Title: %s\nIntent: %s\nScope: %s\n", p.Title, p.Intent, p.Scope)
+	for _, c := range p.Changes {
+		if c.CanonicalContent != "" {
+			fmt.Fprintf(&b, "- %s %s block, before:\n%s\n", c.Action, c.BlockType, c.CanonicalContent)
+			if c.Action != "delete" {
+				fmt.Fprintf(&b, "  after:\n%s\n", c.Content)
+			}
+			continue
+		}
+		fmt.Fprintf(&b, "- %s %s block:\n%s\n", c.Action, c.BlockType, c.Content)
+	}
+	return b.String()
+}
+
+// recordFinding stores a finding unless one with the same pair, source, kind
+// and subject already exists, whatever its review status.
+func recordFinding(a, b *openProposal, source FindingSource, kind, explanation, subject string, ctx context.Context) error {
+	sum := sha256.Sum256([]byte(strings.Join([]string{a.ID, b.ID, string(source), kind, subject}, "\x00")))
+	workspaceID := a.WorkspaceID
+	if workspaceID == nil {
+		workspaceID = b.WorkspaceID
+	}
+	f := &Finding{
+		WorkspaceID: workspaceID,
+		ProposalAID: a.ID,
+		ProposalBID: b.ID,
+		Source:      string(source),
+		Kind:        kind,
+		Explanation: explanation,
+		Status:      string(FindingStatusOpen),
+		Fingerprint: hex.EncodeToString(sum[:]),
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+	}
+	_, err := insertFinding(f, ctx)
+	return err
+}
+
+func getFindingsForProposal(proposalID string, ctx context.Context) ([]*Finding, error) {
+	return fetchFindingsForProposal(proposalID, ctx)
+}
+
+func getFindingsForDocument(documentID string, ctx context.Context) ([]*Finding, error) {
+	return fetchFindingsForDocument(documentID, ctx)
+}
+
+// reviewFinding lets a workspace member confirm or dismiss a finding.
+// Dismissed findings keep their fingerprint, so the analyzer will not raise
+// the same finding again.
+func reviewFinding(findingID string, status FindingStatus, note string, ctx context.Context) (*Finding, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+
+	f, err := fetchFindingByID(findingID, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.WorkspaceID != nil {
+		isMember, err := workspaces.IsMember(*f.WorkspaceID, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error checking membership: %w", err)
+		}
+		if !isMember {
+			return nil, fmt.Errorf("access denied")
+		}
+	}
+
+	if f.Status != string(FindingStatusOpen) {
+		return nil, fmt.Errorf("finding has already been reviewed")
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	f.Status = string(status)
+	f.ReviewedBy = &userID
+	f.ReviewedAt = &now
+	if note != "" {
+		f.ReviewNote = &note
+	}
+	if err := updateFindingReview(f, ctx); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
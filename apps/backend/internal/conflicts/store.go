@@ -0,0 +1,143 @@
+package conflicts
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"granth/internal/config"
+)
+
+func fetchOpenProposals(ctx context.Context) ([]*openProposal, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT p.id, p.document_id, d.workspace_id, COALESCE(p.title, ''), COALESCE(p.intent, ''), COALESCE(p.scope, '')
+		 FROM proposals p
+		 INNER JOIN documents d ON d.id = p.document_id
+		 WHERE p.state = 'open' AND p.deleted_at IS NULL
+		 ORDER BY p.created_at ASC`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error querying open proposals: %w", err)
+	}
+	defer rows.Close()
+
+	var proposals []*openProposal
+	for rows.Next() {
+		p := &openProposal{}
+		if err := rows.Scan(&p.ID, &p.DocumentID, &p.WorkspaceID, &p.Title, &p.Intent, &p.Scope); err != nil {
+			return nil, fmt.Errorf("error scanning open proposal: %w", err)
+		}
+		proposals = append(proposals, p)
+	}
+	return proposals, rows.Err()
+}
+
+func insertFinding(f *Finding, ctx context.Context) (bool, error) {
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`INSERT INTO conflict_findings (workspace_id, proposal_a_id, proposal_b_id, source, kind, explanation, status, fingerprint, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		 ON CONFLICT (fingerprint) DO NOTHING
+		 RETURNING id`,
+		f.WorkspaceID, f.ProposalAID, f.ProposalBID, f.Source, f.Kind, f.Explanation, f.Status, f.Fingerprint, f.CreatedAt,
+	).Scan(&f.ID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error inserting conflict finding: %w", err)
+	}
+	return true, nil
+}
+
+const findingColumns = `id, workspace_id, proposal_a_id, proposal_b_id, source, kind, explanation, status, fingerprint, reviewed_by, review_note, reviewed_at, created_at`
+
+func scanFinding(row interface{ Scan(...interface{}) error }) (*Finding, error) {
+	f := &Finding{}
+	err := row.Scan(&f.ID, &f.WorkspaceID, &f.ProposalAID, &f.ProposalBID, &f.Source, &f.Kind, &f.Explanation, &f.Status, &f.Fingerprint, &f.ReviewedBy, &f.ReviewNote, &f.ReviewedAt, &f.CreatedAt)
+	return f, err
+}
+
+func fetchFindingByID(id string, ctx context.Context) (*Finding, error) {
+	f, err := scanFinding(config.PostgresDB.QueryRowContext(ctx,
+		`SELECT `+findingColumns+` FROM conflict_findings WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("finding not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching conflict finding: %w", err)
+	}
+	return f, nil
+}
+
+func fetchFindingsForProposal(proposalID string, ctx context.Context) ([]*Finding, error) {
+	return queryFindings(ctx,
+		`SELECT `+findingColumns+` FROM conflict_findings
+		 WHERE proposal_a_id = $1 OR proposal_b_id = $1
+		 ORDER BY created_at DESC`, proposalID)
+}
+
+func fetchFindingsForDocument(documentID string, ctx context.Context) ([]*Finding, error) {
+	return queryFindings(ctx,
+		`SELECT `+findingColumns+` FROM conflict_findings
+		 WHERE proposal_a_id IN (SELECT id FROM proposals WHERE document_id = $1)
+		    OR proposal_b_id IN (SELECT id FROM proposals WHERE document_id = $1)
+		 ORDER BY created_at DESC`, documentID)
+}
+
+func queryFindings(ctx context.Context, query string, args ...interface{}) ([]*Finding, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying conflict findings: %w", err)
+	}
+	defer rows.Close()
+
+	findings := make([]*Finding, 0)
+	for rows.Next() {
+		f, err := scanFinding(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning conflict finding: %w", err)
+		}
+		findings = append(findings, f)
+	}
+	return findings, rows.Err()
+}
+
+func updateFindingReview(f *Finding, ctx context.Context) error {
+	_, err := config.PostgresDB.ExecContext(ctx,
+		`UPDATE conflict_findings SET status = $1, reviewed_by = $2, review_note = $3, reviewed_at = $4 WHERE id = $5`,
+		f.Status, f.ReviewedBy, f.ReviewNote, f.ReviewedAt, f.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating conflict finding: %w", err)
+	}
+	return nil
+}
+
+// fetchAnalysisHash returns the input hash of the last AI comparison of a
+// proposal pair, or "" if the pair has never been compared.
+func fetchAnalysisHash(proposalAID, proposalBID string, ctx context.Context) (string, error) {
+	var hash string
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT input_hash FROM conflict_analyses WHERE proposal_a_id = $1 AND proposal_b_id = $2`,
+		proposalAID, proposalBID,
+	).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error fetching conflict analysis: %w", err)
+	}
+	return hash, nil
+}
+
+func upsertAnalysisHash(proposalAID, proposalBID, hash, analyzedAt string, ctx context.Context) error {
+	_, err := config.PostgresDB.ExecContext(ctx,
+		`INSERT INTO conflict_analyses (proposal_a_id, proposal_b_id, input_hash, analyzed_at)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (proposal_a_id, proposal_b_id) DO UPDATE SET input_hash = EXCLUDED.input_hash, analyzed_at = EXCLUDED.analyzed_at`,
+		proposalAID, proposalBID, hash, analyzedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error recording conflict analysis: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package conflicts
+
+type FindingStatus string
+
+const (
+	FindingStatusOpen      FindingStatus = "open"
+	FindingStatusConfirmed FindingStatus = "confirmed"
+	FindingStatusDismissed FindingStatus = "dismissed"
+)
+
+type FindingSource string
+
+const (
+	FindingSourceRule FindingSource = "rule"
+	FindingSourceAI   FindingSource = "ai"
+)
+
+// Finding is a potential semantic conflict between two open proposals that
+// do not necessarily touch the same block. ProposalA is the proposal whose
+// change may invalidate something ProposalB relies on.
+type Finding struct {
+	ID          string  `json:"id"`
+	WorkspaceID *string `json:"workspace_id"`
+	ProposalAID string  `json:"proposal_a_id"`
+	ProposalBID string  `json:"proposal_b_id"`
+	Source      string  `json:"source"`
+	Kind        string  `json:"kind"`
+	Explanation string  `json:"explanation"`
+	Status      string  `json:"status"`
+	Fingerprint string  `json:"-"`
+	ReviewedBy  *string `json:"reviewed_by"`
+	ReviewNote  *string `json:"review_note"`
+	ReviewedAt  *string `json:"reviewed_at"`
+	CreatedAt   string  `json:"created_at"`
+}
+
+// openProposal is the slice of proposal state the analyzer compares.
+type openProposal struct {
+	ID          string
+	DocumentID  string
+	WorkspaceID *string
+	Title       string
+	Intent      string
+	Scope       string
+	Changes     []*analyzedChange
+}
+
+// analyzedChange is a proposal block change enriched with the canonical
+// block it targets, if any.
+type analyzedChange struct {
+	Action    string
+	BlockID   *string
+	BlockType string
+	OrderPath []int64
+	Content   string
+
+	// Canonical state of the targeted block before the change.
+	CanonicalType    string
+	CanonicalContent string
+}
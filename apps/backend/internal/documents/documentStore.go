@@ -2,15 +2,23 @@ package documents
 
 import (
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
 	"fmt"
 	"granth/internal/config"
+	"granth/internal/encryption"
+	"granth/internal/proposals"
+	"strings"
+
+	"github.com/lib/pq"
 )
 
 func FetchDocumentByID(id string, ctx context.Context) (*Document, error) {
 	document := &Document{}
 	err := config.PostgresDB.QueryRowContext(ctx,
 		`SELECT id, title, workspace_id, created_by, created_at, updated_at, updated_by
-		 FROM documents WHERE id = $1`, id,
+		 FROM documents WHERE id = $1 AND deleted_at IS NULL`, id,
 	).Scan(&document.ID, &document.Title, &document.WorkspaceID, &document.CreatedBy, &document.CreatedAt, &document.UpdatedAt, &document.UpdatedBy)
 	if err != nil {
 		return nil, err
@@ -22,7 +30,7 @@ func FetchDocumentByTitle(title string, ctx context.Context) (*Document, error)
 	document := &Document{}
 	err := config.PostgresDB.QueryRowContext(ctx,
 		`SELECT id, title, workspace_id, created_by, created_at, updated_at, updated_by
-		 FROM documents WHERE title = $1`, title,
+		 FROM documents WHERE title = $1 AND deleted_at IS NULL`, title,
 	).Scan(&document.ID, &document.Title, &document.WorkspaceID, &document.CreatedBy, &document.CreatedAt, &document.UpdatedAt, &document.UpdatedBy)
 	if err != nil {
 		return nil, err
@@ -41,21 +49,90 @@ func CreateDocument(document *Document, ctx context.Context) error {
 
 func UpdateDocument(document *Document, ctx context.Context) error {
 	_, err := config.PostgresDB.ExecContext(ctx,
-		`UPDATE documents SET title = $1, updated_at = $2, updated_by = $3 WHERE id = $4`,
+		`UPDATE documents SET title = $1, updated_at = $2, updated_by = $3 WHERE id = $4 AND deleted_at IS NULL`,
 		document.Title, document.UpdatedAt, document.UpdatedBy, document.ID,
 	)
 	return err
 }
 
+// FetchDocumentIncludingTrashed is FetchDocumentByID for documents that may
+// be in the trash.
+func FetchDocumentIncludingTrashed(id string, ctx context.Context) (*Document, error) {
+	document := &Document{}
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT id, title, workspace_id, created_by, created_at, updated_at, updated_by, deleted_at, deleted_by
+		 FROM documents WHERE id = $1`, id,
+	).Scan(&document.ID, &document.Title, &document.WorkspaceID, &document.CreatedBy, &document.CreatedAt, &document.UpdatedAt, &document.UpdatedBy, &document.DeletedAt, &document.DeletedBy)
+	if err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+// DeleteDocument removes a document and, by cascade, everything in it.
 func DeleteDocument(id string, ctx context.Context) error {
 	_, err := config.PostgresDB.ExecContext(ctx, `DELETE FROM documents WHERE id = $1`, id)
 	return err
 }
 
+// TrashDocument moves a document and its live proposals to the trash. It
+// reports false when the document was already trashed.
+func TrashDocument(id, userID string, ctx context.Context) (bool, error) {
+	tx, err := config.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE documents SET deleted_at = now(), deleted_by = $2 WHERE id = $1 AND deleted_at IS NULL`, id, userID)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	// now() is fixed for the transaction, so both share one deleted_at
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE proposals SET deleted_at = now(), deleted_by = $2 WHERE document_id = $1 AND deleted_at IS NULL`,
+		id, userID,
+	); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// RestoreTrashedDocument takes a document out of the trash together with the
+// proposals that were trashed with it. It reports false when the document
+// was not in the trash.
+func RestoreTrashedDocument(id string, ctx context.Context) (bool, error) {
+	tx, err := config.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE proposals p SET deleted_at = NULL, deleted_by = NULL
+		 FROM documents d WHERE d.id = $1 AND p.document_id = d.id AND p.deleted_at = d.deleted_at`, id,
+	); err != nil {
+		return false, err
+	}
+	res, err := tx.ExecContext(ctx,
+		`UPDATE documents SET deleted_at = NULL, deleted_by = NULL WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
 func FetchAllDocumentsByOwnerID(ownerID string, ctx context.Context) ([]*Document, error) {
 	rows, err := config.PostgresDB.QueryContext(ctx,
 		`SELECT id, title, workspace_id, created_by, created_at, updated_at, updated_by
-		 FROM documents WHERE created_by = $1 ORDER BY created_at DESC`, ownerID,
+		 FROM documents WHERE created_by = $1 AND deleted_at IS NULL ORDER BY created_at DESC`, ownerID,
 	)
 	if err != nil {
 		return nil, err
@@ -79,7 +156,7 @@ func FetchAllDocumentsByOwnerID(ownerID string, ctx context.Context) ([]*Documen
 func fetchLatestDocuments(limit int, userid string, ctx context.Context) ([]*Document, error) {
 	rows, err := config.PostgresDB.QueryContext(ctx,
 		`SELECT id, title, workspace_id, created_by, created_at, updated_at, updated_by
-		 FROM documents WHERE created_by = $1 ORDER BY created_at DESC LIMIT $2`,
+		 FROM documents WHERE created_by = $1 AND deleted_at IS NULL ORDER BY created_at DESC LIMIT $2`,
 		userid, limit,
 	)
 	if err != nil {
@@ -100,3 +177,263 @@ func fetchLatestDocuments(limit int, userid string, ctx context.Context) ([]*Doc
 	}
 	return documents, nil
 }
+
+// redactDocumentContent replaces text with RedactionMarker in the document's
+// blocks, its proposals and their block changes, summaries, comments and
+// conflict findings, and its audit payloads, then inserts r with the
+// counts. Nothing is written when text occurs nowhere.
+func redactDocumentContent(r *Redaction, text string, ctx context.Context) error {
+	tx, err := config.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRowContext(ctx, `SELECT gen_random_uuid()`).Scan(&r.ID); err != nil {
+		return fmt.Errorf("error creating redaction ID: %w", err)
+	}
+
+	// block, change and artifact content may be encrypted, so it is
+	// rewritten here rather than with replace() in SQL
+	redactedBlocks, err := fetchRedactable(tx,
+		`SELECT id, content FROM blocks WHERE document_id = $1 FOR UPDATE`,
+		r.DocumentID, text, ctx)
+	if err != nil {
+		return fmt.Errorf("error redacting blocks: %w", err)
+	}
+	for id, content := range redactedBlocks {
+		if _, err := tx.ExecContext(ctx, `UPDATE blocks SET content = $2 WHERE id = $1`, id, content.sealed); err != nil {
+			return fmt.Errorf("error redacting blocks: %w", err)
+		}
+	}
+	r.BlocksRedacted = len(redactedBlocks)
+
+	// decided changes get the hash of their new content, and the chain a
+	// redaction entry linking it to the old one
+	redactedChanges, err := fetchRedactable(tx,
+		`SELECT c.id, c.content FROM proposal_block_changes c
+		 JOIN proposals p ON p.id = c.proposal_id
+		 WHERE p.document_id = $1 AND c.content IS NOT NULL
+		 FOR UPDATE OF c`,
+		r.DocumentID, text, ctx)
+	if err != nil {
+		return fmt.Errorf("error redacting block changes: %w", err)
+	}
+	chained, err := fetchDecidedChanges(tx, redactedChanges, ctx)
+	if err != nil {
+		return fmt.Errorf("error redacting block changes: %w", err)
+	}
+	for id, content := range redactedChanges {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE proposal_block_changes
+			 SET content = $2, redaction_id = $3, content_hash = CASE WHEN content_hash IS NULL THEN NULL ELSE $4 END
+			 WHERE id = $1`,
+			id, content.sealed, r.ID, content.hash); err != nil {
+			return fmt.Errorf("error redacting block changes: %w", err)
+		}
+	}
+	for _, c := range chained {
+		c.NewContentHash = redactedChanges[c.ID].hash
+	}
+	if err := proposals.ChainRedactionInTx(tx, r.DocumentID, r.ID, chained, ctx); err != nil {
+		return fmt.Errorf("error chaining redaction: %w", err)
+	}
+	r.ChangesRedacted = len(redactedChanges)
+
+	redactedArtifacts, err := fetchRedactable(tx,
+		`SELECT a.id, a.content FROM reasoning_artifacts a
+		 JOIN proposals p ON p.id = a.proposal_id
+		 WHERE p.document_id = $1
+		 FOR UPDATE OF a`,
+		r.DocumentID, text, ctx)
+	if err != nil {
+		return fmt.Errorf("error redacting reasoning artifacts: %w", err)
+	}
+	for id, content := range redactedArtifacts {
+		if _, err := tx.ExecContext(ctx, `UPDATE reasoning_artifacts SET content = $2 WHERE id = $1`, id, content.sealed); err != nil {
+			return fmt.Errorf("error redacting reasoning artifacts: %w", err)
+		}
+	}
+	r.ArtifactsRedacted = len(redactedArtifacts)
+
+	redactedComments, err := fetchRedactable(tx,
+		`SELECT c.id, c.body FROM proposal_comments c
+		 JOIN proposals p ON p.id = c.proposal_id
+		 WHERE p.document_id = $1
+		 FOR UPDATE OF c`,
+		r.DocumentID, text, ctx)
+	if err != nil {
+		return fmt.Errorf("error redacting comments: %w", err)
+	}
+	for id, content := range redactedComments {
+		if _, err := tx.ExecContext(ctx, `UPDATE proposal_comments SET body = $2 WHERE id = $1`, id, content.sealed); err != nil {
+			return fmt.Errorf("error redacting comments: %w", err)
+		}
+	}
+	r.CommentsRedacted = len(redactedComments)
+
+	// the rest is stored as plaintext; redact_jsonb comes from migration 32
+	res, err := tx.ExecContext(ctx,
+		`UPDATE proposals
+		 SET title = replace(title, $2, $3), intent = replace(intent, $2, $3), scope = replace(scope, $2, $3),
+		     rejection_reason = replace(rejection_reason, $2, $3), agent_reasoning = redact_jsonb(agent_reasoning, $2)
+		 WHERE document_id = $1
+		   AND (strpos(title, $2) > 0 OR strpos(intent, $2) > 0 OR strpos(scope, $2) > 0
+		        OR strpos(rejection_reason, $2) > 0 OR agent_reasoning IS DISTINCT FROM redact_jsonb(agent_reasoning, $2))`,
+		r.DocumentID, text, RedactionMarker)
+	if err != nil {
+		return fmt.Errorf("error redacting proposals: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error redacting proposals: %w", err)
+	}
+	r.ProposalsRedacted = int(n)
+
+	res, err = tx.ExecContext(ctx,
+		`UPDATE conflict_findings f
+		 SET explanation = replace(f.explanation, $2, $3), review_note = replace(f.review_note, $2, $3)
+		 FROM proposals p
+		 WHERE p.document_id = $1 AND p.id IN (f.proposal_a_id, f.proposal_b_id)
+		   AND (strpos(f.explanation, $2) > 0 OR strpos(f.review_note, $2) > 0)`,
+		r.DocumentID, text, RedactionMarker)
+	if err != nil {
+		return fmt.Errorf("error redacting conflict findings: %w", err)
+	}
+	if n, err = res.RowsAffected(); err != nil {
+		return fmt.Errorf("error redacting conflict findings: %w", err)
+	}
+	r.FindingsRedacted = int(n)
+
+	// audit events can only be rewritten through this function, see
+	// migration 32
+	err = tx.QueryRowContext(ctx, `SELECT redact_audit_events($1, $2)`, r.DocumentID, text).Scan(&r.EventsRedacted)
+	if err != nil {
+		return fmt.Errorf("error redacting audit events: %w", err)
+	}
+	if r.BlocksRedacted+r.ChangesRedacted+r.ArtifactsRedacted+r.CommentsRedacted+
+		r.ProposalsRedacted+r.FindingsRedacted+r.EventsRedacted == 0 {
+		return fmt.Errorf("text does not occur in this document")
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO redactions (id, workspace_id, document_id, reason, text_hash,
+		                         blocks_redacted, changes_redacted, artifacts_redacted, comments_redacted,
+		                         proposals_redacted, findings_redacted, events_redacted, redacted_by)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		 RETURNING created_at`,
+		r.ID, r.WorkspaceID, r.DocumentID, r.Reason, r.TextHash,
+		r.BlocksRedacted, r.ChangesRedacted, r.ArtifactsRedacted, r.CommentsRedacted,
+		r.ProposalsRedacted, r.FindingsRedacted, r.EventsRedacted, r.RedactedBy,
+	).Scan(&r.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error inserting redaction: %w", err)
+	}
+	return tx.Commit()
+}
+
+// redactedContent is content with the text replaced, sealed again, and the
+// SHA-256 of its plaintext.
+type redactedContent struct {
+	sealed string
+	hash   string
+}
+
+// fetchRedactable runs query, which selects (id, content) for documentID,
+// and returns the rows whose decrypted content contains text, mapped to
+// their redacted content.
+func fetchRedactable(tx *sql.Tx, query, documentID, text string, ctx context.Context) (map[string]*redactedContent, error) {
+	rows, err := tx.QueryContext(ctx, query, documentID)
+	if err != nil {
+		return nil, err
+	}
+	found := map[string]string{}
+	for rows.Next() {
+		var id, content string
+		if err := rows.Scan(&id, &content); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		found[id] = content
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	redacted := map[string]*redactedContent{}
+	for id, content := range found {
+		plaintext, err := encryption.Open(content, ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.Contains(plaintext, text) {
+			continue
+		}
+		plaintext = strings.ReplaceAll(plaintext, text, RedactionMarker)
+		sealed, err := encryption.SealForDocument(documentID, plaintext, ctx)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256([]byte(plaintext))
+		redacted[id] = &redactedContent{sealed: sealed, hash: hex.EncodeToString(sum[:])}
+	}
+	return redacted, nil
+}
+
+// fetchDecidedChanges returns the changes among redacted that belong to a
+// decided proposal, with their current content hash.
+func fetchDecidedChanges(tx *sql.Tx, redacted map[string]*redactedContent, ctx context.Context) ([]*proposals.RedactedChange, error) {
+	ids := make([]string, 0, len(redacted))
+	for id := range redacted {
+		ids = append(ids, id)
+	}
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, proposal_id, content_hash FROM proposal_block_changes
+		 WHERE id = ANY($1) AND content_hash IS NOT NULL`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []*proposals.RedactedChange{}
+	for rows.Next() {
+		c := &proposals.RedactedChange{}
+		if err := rows.Scan(&c.ID, &c.ProposalID, &c.OldContentHash); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// FetchRedactions lists the document's redactions, newest first.
+func FetchRedactions(documentID string, ctx context.Context) ([]*Redaction, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT r.id, r.workspace_id, r.document_id, r.reason, r.text_hash, r.blocks_redacted,
+		        r.changes_redacted, r.artifacts_redacted, r.comments_redacted, r.proposals_redacted,
+		        r.findings_redacted, r.events_redacted, r.redacted_by, u.username, r.created_at
+		 FROM redactions r
+		 LEFT JOIN users u ON u.id = r.redacted_by
+		 WHERE r.document_id = $1
+		 ORDER BY r.created_at DESC`, documentID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying redactions: %w", err)
+	}
+	defer rows.Close()
+
+	redactions := []*Redaction{}
+	for rows.Next() {
+		r := &Redaction{}
+		if err := rows.Scan(&r.ID, &r.WorkspaceID, &r.DocumentID, &r.Reason, &r.TextHash, &r.BlocksRedacted,
+			&r.ChangesRedacted, &r.ArtifactsRedacted, &r.CommentsRedacted, &r.ProposalsRedacted,
+			&r.FindingsRedacted, &r.EventsRedacted, &r.RedactedBy, &r.RedactedByName, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning redaction: %w", err)
+		}
+		redactions = append(redactions, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error for redactions: %w", err)
+	}
+	return redactions, nil
+}
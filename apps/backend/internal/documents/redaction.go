@@ -0,0 +1,75 @@
+package documents
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"granth/internal/audit"
+	"granth/internal/utils"
+	"granth/internal/workspaces"
+)
+
+// RedactionMarker replaces redacted text. Migration 32 uses it too.
+const RedactionMarker = "[REDACTED]"
+
+// minRedactionLength is enforced by redact_audit_events as well
+const minRedactionLength = 3
+
+// requireRedactionAccess allows workspace admins with manage_policy, and
+// the creator of a legacy document without a workspace.
+func requireRedactionAccess(documentID string, ctx context.Context) (*Document, error) {
+	doc, err := FetchDocumentIncludingTrashed(documentID, ctx)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("document not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching document: %w", err)
+	}
+	if err := requireDocumentPermission(doc, workspaces.PermManagePolicy, ctx); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// redactDocument scrubs text from everything the document keeps: its
+// blocks, its proposals and everything attached to them, and its audit
+// payloads. Trashed documents can be redacted too.
+func redactDocument(documentID, text, reason string, ctx context.Context) (*Redaction, error) {
+	doc, err := requireRedactionAccess(documentID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required")
+	}
+	if len([]rune(strings.TrimSpace(text))) < minRedactionLength {
+		return nil, fmt.Errorf("text must be at least %d characters", minRedactionLength)
+	}
+
+	sum := sha256.Sum256([]byte(text))
+	r := &Redaction{WorkspaceID: doc.WorkspaceID, DocumentID: doc.ID, Reason: reason, TextHash: hex.EncodeToString(sum[:])}
+	if userID, ok := utils.GetUserIDFromContext(ctx); ok {
+		r.RedactedBy = &userID
+	}
+	if err := redactDocumentContent(r, text, ctx); err != nil {
+		return nil, err
+	}
+	entry := audit.Entry{DocumentID: doc.ID, Action: "document.redacted", TargetType: "redaction", TargetID: r.ID, After: r}
+	if doc.WorkspaceID != nil {
+		entry.WorkspaceID = *doc.WorkspaceID
+	}
+	audit.Record(entry, ctx)
+	return r, nil
+}
+
+func listRedactions(documentID string, ctx context.Context) ([]*Redaction, error) {
+	if _, err := requireRedactionAccess(documentID, ctx); err != nil {
+		return nil, err
+	}
+	return FetchRedactions(documentID, ctx)
+}
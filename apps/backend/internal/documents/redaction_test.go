@@ -0,0 +1,151 @@
+package documents
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"granth/internal/config"
+	"granth/internal/proposals"
+	"granth/internal/testdb"
+)
+
+// TestRedactionKeepsChainVerifiable redacts a decided change, checks the
+// chain still verifies, then edits the change behind the app's back.
+func TestRedactionKeepsChainVerifiable(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	documentID := testdb.CreateDocument(t, workspaceID, owner)
+	proposalID := testdb.CreateProposal(t, documentID, owner)
+	ctx := testdb.As(owner)
+
+	router := proposals.ProposalsRouter()
+	post := func(path, body string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/"+proposalID+path, strings.NewReader(body)).WithContext(ctx)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code >= 300 {
+			t.Fatalf("POST %s: got %d (%s)", path, rec.Code, rec.Body.String())
+		}
+	}
+	post("/changes", `{"action":"add","block_type":"text","order_path":[1],"content":"call 555-0100 today"}`)
+	post("/reject", `{"reason":"not now"}`)
+
+	verify := func() *proposals.ChainVerification {
+		t.Helper()
+		result, err := proposals.VerifyChain(documentID, context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	if result := verify(); !result.Valid {
+		t.Fatalf("chain broken before redaction: %s", result.Reason)
+	}
+
+	r, err := redactDocument(documentID, "555-0100", "phone number", ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ChangesRedacted != 1 {
+		t.Fatalf("redacted %d changes, want 1", r.ChangesRedacted)
+	}
+	result := verify()
+	if !result.Valid {
+		t.Fatalf("chain broken after redaction: %s", result.Reason)
+	}
+	if result.Entries != 2 {
+		t.Fatalf("chain has %d entries, want the decision and the redaction", result.Entries)
+	}
+
+	if _, err := config.PostgresDB.Exec(
+		`UPDATE proposal_block_changes SET content = 'call 555-0199 today' WHERE proposal_id = $1`, proposalID); err != nil {
+		t.Fatal(err)
+	}
+	if result := verify(); result.Valid {
+		t.Fatal("chain still verifies after the redacted change was edited")
+	}
+}
+
+func TestRedactionCoversProposalsCommentsAndAudit(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	documentID := testdb.CreateDocument(t, workspaceID, owner)
+	proposalID := testdb.CreateProposal(t, documentID, owner)
+	ctx := testdb.As(owner)
+	const secret = "acct 4417-1234"
+
+	req := httptest.NewRequest(http.MethodPost, "/"+proposalID+"/comments",
+		strings.NewReader(`{"body":"see `+secret+`"}`)).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	proposals.ProposalsRouter().ServeHTTP(rec, req)
+	if rec.Code >= 300 {
+		t.Fatalf("POST /comments: got %d (%s)", rec.Code, rec.Body.String())
+	}
+	if _, err := config.PostgresDB.Exec(
+		`UPDATE proposals SET title = $2, agent_reasoning = $3 WHERE id = $1`,
+		proposalID, "fix "+secret, `{"notes":["`+secret+`"]}`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.PostgresDB.Exec(
+		`INSERT INTO audit_events (document_id, actor_type, action, target_type, after)
+		 VALUES ($1, 'system', 'test.event', 'document', $2)`,
+		documentID, `{"note":"`+secret+`"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := redactDocument(documentID, secret, "account number", ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.CommentsRedacted != 1 || r.ProposalsRedacted != 1 || r.EventsRedacted != 1 {
+		t.Fatalf("redacted %d comments, %d proposals, %d events; want 1 each",
+			r.CommentsRedacted, r.ProposalsRedacted, r.EventsRedacted)
+	}
+
+	var title, reasoning string
+	if err := config.PostgresDB.QueryRow(
+		`SELECT title, agent_reasoning::text FROM proposals WHERE id = $1`, proposalID).Scan(&title, &reasoning); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(title+reasoning, secret) {
+		t.Fatalf("proposal still holds the text: %q %q", title, reasoning)
+	}
+	var leaked bool
+	if err := config.PostgresDB.QueryRow(
+		`SELECT EXISTS(SELECT 1 FROM audit_events WHERE document_id = $1 AND strpos(after::text, $2) > 0)`,
+		documentID, secret).Scan(&leaked); err != nil {
+		t.Fatal(err)
+	}
+	if leaked {
+		t.Fatal("audit payload still holds the text")
+	}
+}
+
+func TestAuditEventsRejectDirectRewrites(t *testing.T) {
+	testdb.Open(t)
+	tx, err := config.PostgresDB.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	var id string
+	if err := tx.QueryRow(
+		`INSERT INTO audit_events (actor_type, action, target_type, after)
+		 VALUES ('system', 'test.event', 'document', '{"note":"kept"}') RETURNING id`).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	// the setting that used to unlock rewrites no longer does
+	if _, err := tx.Exec(`SET LOCAL granth.redaction = 'on'`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec(`UPDATE audit_events SET after = '{}' WHERE id = $1`, id); err == nil {
+		t.Fatal("audit events were rewritten outside redact_audit_events")
+	}
+}
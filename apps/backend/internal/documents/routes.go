@@ -2,10 +2,15 @@ package documents
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"granth/internal/blocks"
+	"granth/internal/proposals"
+	"granth/internal/utils"
+	"granth/internal/workspaces"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -13,17 +18,25 @@ import (
 func DocumentsRouter() http.Handler {
 	r := chi.NewRouter()
 
-	r.Get("/{id}", handleGetDocument)
-	r.Get("/all", handleGetAllDocuments)
-	r.Post("/create", handleCreateDocument)
-	r.Put("/{id}", handleUpdateDocument)
-	r.Delete("/{id}", handleDeleteDocument)
-	r.Get("/latest", handleGetLatestDocuments)
+	read := utils.RequireScope(utils.ScopeRead)
+	propose := utils.RequireScope(utils.ScopePropose)
+	// Direct block edits bypass review, so tokens need admin scope for them
+	admin := utils.RequireScope(utils.ScopeAdmin)
 
-	r.Get("/{id}/blocks", handleGetAllBlocksForDocument)
-	r.Post("/{id}/blocks/create", handleCreateBlockForDocument)
-	r.Put("/{id}/blocks/update", handleUpdateBlockForDocument)
-	r.Delete("/{id}/blocks/delete", handleDeleteBlockForDocument)
+	r.With(read).Get("/{id}", handleGetDocument)
+	r.With(read).Get("/all", handleGetAllDocuments)
+	r.With(propose).Post("/create", handleCreateDocument)
+	r.With(propose).Put("/{id}", handleUpdateDocument)
+	r.With(admin).Delete("/{id}", handleDeleteDocument)
+	r.With(read).Get("/latest", handleGetLatestDocuments)
+
+	r.With(read).Get("/{id}/blocks", handleGetAllBlocksForDocument)
+	r.With(admin).Post("/{id}/blocks/create", handleCreateBlockForDocument)
+	r.With(admin).Put("/{id}/blocks/update", handleUpdateBlockForDocument)
+	r.With(admin).Delete("/{id}/blocks/delete", handleDeleteBlockForDocument)
+
+	r.With(admin).Get("/{id}/redactions", handleGetRedactions)
+	r.With(admin).Post("/{id}/redactions", handleRedactDocument)
 
 	return r
 }
@@ -69,7 +82,7 @@ func handleGetDocument(w http.ResponseWriter, r *http.Request) {
 	documentID := chi.URLParam(r, "id")
 	document, err := getDocument(documentID, r.Context())
 	if err != nil {
-		http.Error(w, "Error fetching document: "+err.Error(), http.StatusInternalServerError)
+		writeDocumentError(w, "Error fetching document: ", err)
 		return
 	}
 
@@ -135,9 +148,16 @@ func handleUpdateDocument(w http.ResponseWriter, r *http.Request) {
 func handleDeleteDocument(w http.ResponseWriter, r *http.Request) {
 	documentID := chi.URLParam(r, "id")
 
-	err := DeleteDocument(documentID, r.Context())
+	err := deleteDocumentByID(documentID, r.Context())
 	if err != nil {
-		http.Error(w, "Error deleting document: "+err.Error(), http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, workspaces.ErrLegalHold) || errors.Is(err, workspaces.ErrRetained):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, workspaces.ErrPermissionDenied) || errors.Is(err, workspaces.ErrTwoFactorRequired):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			writeDocumentError(w, "Error deleting document: ", err)
+		}
 		return
 	}
 
@@ -148,7 +168,7 @@ func handleGetAllBlocksForDocument(w http.ResponseWriter, r *http.Request) {
 	documentID := chi.URLParam(r, "id")
 	blocks, err := getAllBlocksForDocument(documentID, r.Context())
 	if err != nil {
-		http.Error(w, "Error fetching blocks: "+err.Error(), http.StatusInternalServerError)
+		writeDocumentError(w, "Error fetching blocks: ", err)
 		return
 	}
 
@@ -173,7 +193,7 @@ func handleCreateBlockForDocument(w http.ResponseWriter, r *http.Request) {
 
 	err = createBlockForDocument(&block, r.Context())
 	if err != nil {
-		http.Error(w, "Error creating block: "+err.Error(), http.StatusInternalServerError)
+		writeDocumentError(w, "Error creating block: ", err)
 		return
 	}
 
@@ -186,10 +206,11 @@ func handleUpdateBlockForDocument(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
 		return
 	}
+	block.DocumentID = chi.URLParam(r, "id")
 
-	err = blocks.UpdateBlock(&block, r.Context())
+	err = updateBlockForDocument(&block, r.Context())
 	if err != nil {
-		http.Error(w, "Error updating block: "+err.Error(), http.StatusInternalServerError)
+		writeDocumentError(w, "Error updating block: ", err)
 		return
 	}
 
@@ -210,11 +231,75 @@ func handleDeleteBlockForDocument(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = blocks.DeleteBlock(req.BlockID, r.Context())
+	err = deleteBlockForDocument(chi.URLParam(r, "id"), req.BlockID, r.Context())
 	if err != nil {
-		http.Error(w, "Error deleting block: "+err.Error(), http.StatusInternalServerError)
+		writeDocumentError(w, "Error deleting block: ", err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
+
+func handleGetRedactions(w http.ResponseWriter, r *http.Request) {
+	redactions, err := listRedactions(chi.URLParam(r, "id"), r.Context())
+	if err != nil {
+		writeRedactionError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(redactions)
+}
+
+func handleRedactDocument(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Text   string `json:"text"`
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	redaction, err := redactDocument(chi.URLParam(r, "id"), req.Text, req.Reason, r.Context())
+	if err != nil {
+		writeRedactionError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(redaction)
+}
+
+func writeRedactionError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	switch {
+	case errors.Is(err, workspaces.ErrPermissionDenied) || errors.Is(err, workspaces.ErrTwoFactorRequired):
+		http.Error(w, msg, http.StatusForbidden)
+	case msg == "a reason is required", strings.HasPrefix(msg, "text must be"):
+		http.Error(w, msg, http.StatusBadRequest)
+	case msg == "text does not occur in this document":
+		http.Error(w, msg, http.StatusConflict)
+	default:
+		writeDocumentError(w, "Error redacting document: ", err)
+	}
+}
+
+// writeDocumentError answers 404 for missing or trashed documents, 403 for
+// missing permissions and 500 otherwise.
+func writeDocumentError(w http.ResponseWriter, prefix string, err error) {
+	if errors.Is(err, workspaces.ErrPermissionDenied) || errors.Is(err, workspaces.ErrTwoFactorRequired) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err.Error() == "document not found" || err.Error() == "block not found" {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, proposals.ErrStewardedBlock) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	http.Error(w, prefix+err.Error(), http.StatusInternalServerError)
+}
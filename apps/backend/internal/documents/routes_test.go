@@ -0,0 +1,99 @@
+package documents
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"granth/internal/blocks"
+	"granth/internal/config"
+	"granth/internal/testdb"
+)
+
+func TestDocumentReadsRequireMembership(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	member := testdb.CreateUser(t)
+	outsider := testdb.CreateUser(t)
+	guest := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	testdb.AddMember(t, workspaceID, member, "contributor")
+	documentID := testdb.CreateDocument(t, workspaceID, owner)
+	if _, err := config.PostgresDB.Exec(
+		`INSERT INTO guest_grants (workspace_id, user_id, document_id, permissions, granted_by, expires_at)
+		 VALUES ($1, $2, $3, ARRAY['read'], $4, now() + interval '1 day')`,
+		workspaceID, guest, documentID, owner); err != nil {
+		t.Fatalf("creating guest grant: %v", err)
+	}
+
+	router := DocumentsRouter()
+	for _, path := range []string{"/" + documentID, "/" + documentID + "/blocks"} {
+		t.Run(path, func(t *testing.T) {
+			for _, tt := range []struct {
+				userID string
+				want   int
+			}{{outsider, http.StatusForbidden}, {member, http.StatusOK}, {guest, http.StatusOK}} {
+				req := httptest.NewRequest(http.MethodGet, path, nil).WithContext(testdb.As(tt.userID))
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, req)
+				if rec.Code != tt.want {
+					t.Fatalf("got %d (%s), want %d", rec.Code, rec.Body.String(), tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBlockWritesRequirePolicyAndRespectStewards(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	contributor := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	testdb.AddMember(t, workspaceID, contributor, "contributor")
+	documentID := testdb.CreateDocument(t, workspaceID, owner)
+	otherDocumentID := testdb.CreateDocument(t, workspaceID, owner)
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	stewarded := &blocks.Block{DocumentID: documentID, OrderPath: []int64{1}, BlockType: "paragraph", Content: "kept",
+		CreatedBy: owner, CreatedAt: now, UpdatedAt: now, UpdatedBy: owner}
+	if err := blocks.CreateBlock(stewarded, testdb.As(owner)); err != nil {
+		t.Fatalf("creating block: %v", err)
+	}
+	if _, err := config.PostgresDB.Exec(
+		`INSERT INTO block_stewards (document_id, path_prefix, steward_user_id, created_by, created_at)
+		 VALUES ($1, '{1}', $2, $2, now())`, documentID, owner); err != nil {
+		t.Fatalf("creating steward: %v", err)
+	}
+
+	router := DocumentsRouter()
+	for _, tt := range []struct {
+		name, userID, method, path, body string
+		want                             int
+	}{
+		{"contributor create", contributor, http.MethodPost, "/" + documentID + "/blocks/create", `{"order_path":[2],"block_type":"paragraph","content":"x"}`, http.StatusForbidden},
+		{"admin create", owner, http.MethodPost, "/" + documentID + "/blocks/create", `{"order_path":[2],"block_type":"paragraph","content":"x"}`, http.StatusCreated},
+		{"create under steward", owner, http.MethodPost, "/" + documentID + "/blocks/create", `{"order_path":[1,3],"block_type":"paragraph","content":"x"}`, http.StatusConflict},
+		{"update stewarded", owner, http.MethodPut, "/" + documentID + "/blocks/update", `{"id":"` + stewarded.ID + `","order_path":[1],"block_type":"paragraph","content":"changed"}`, http.StatusConflict},
+		{"delete stewarded", owner, http.MethodDelete, "/" + documentID + "/blocks/delete", `{"block_id":"` + stewarded.ID + `"}`, http.StatusConflict},
+		{"delete via other document", owner, http.MethodDelete, "/" + otherDocumentID + "/blocks/delete", `{"block_id":"` + stewarded.ID + `"}`, http.StatusNotFound},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)).WithContext(testdb.As(tt.userID))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("got %d (%s), want %d", rec.Code, rec.Body.String(), tt.want)
+			}
+		})
+	}
+
+	after, err := blocks.FetchBlockByID(stewarded.ID, testdb.As(owner))
+	if err != nil {
+		t.Fatalf("fetching block: %v", err)
+	}
+	if after.Content != "kept" {
+		t.Fatalf("stewarded block content = %q, want it unchanged", after.Content)
+	}
+}
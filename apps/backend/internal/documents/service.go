@@ -2,9 +2,14 @@ package documents
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"granth/internal/audit"
 	"granth/internal/blocks"
+	"granth/internal/proposals"
 	"granth/internal/utils"
+	"granth/internal/workspaces"
 	"time"
 )
 
@@ -25,15 +30,12 @@ func createNewDocument(title string, workspaceID *string, ctx context.Context) (
 	if err := CreateDocument(newDocument, ctx); err != nil {
 		return "", fmt.Errorf("Error creating document: %w", err)
 	}
+	audit.Record(audit.Entry{DocumentID: newDocument.ID, Action: "document.created", TargetType: "document", TargetID: newDocument.ID, After: newDocument}, ctx)
 	return newDocument.ID, nil
 }
 
 func getDocument(documentID string, ctx context.Context) (*Document, error) {
-	document, err := FetchDocumentByID(documentID, ctx)
-	if err != nil {
-		return nil, fmt.Errorf("Error fetching document: %w", err)
-	}
-	return document, nil
+	return requireLiveDocument(documentID, workspaces.PermRead, ctx)
 }
 
 func getAllDocuments(ctx context.Context) ([]*Document, error) {
@@ -53,16 +55,133 @@ func updateDocumentByID(document *Document, ctx context.Context) error {
 	if !ok {
 		return fmt.Errorf("User ID not found in context")
 	}
+	before, err := FetchDocumentByID(document.ID, ctx)
+	if err != nil {
+		return fmt.Errorf("Error fetching document: %w", err)
+	}
 	document.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
 	document.UpdatedBy = userId
-	err := UpdateDocument(document, ctx)
+	err = UpdateDocument(document, ctx)
 	if err != nil {
 		return fmt.Errorf("Error updating document: %w", err)
 	}
+	audit.Record(audit.Entry{DocumentID: document.ID, Action: "document.updated", TargetType: "document", TargetID: document.ID, Before: before, After: document}, ctx)
+	return nil
+}
+
+// deleteDocumentByID moves a document, with its proposals, to the trash.
+// Documents under legal hold or still retained cannot be deleted at all.
+func deleteDocumentByID(documentID string, ctx context.Context) error {
+	userId, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("User ID not found in context")
+	}
+	before, err := FetchDocumentByID(documentID, ctx)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("document not found")
+	}
+	if err != nil {
+		return fmt.Errorf("Error fetching document: %w", err)
+	}
+	if err := requireDocumentPermission(before, workspaces.PermManagePolicy, ctx); err != nil {
+		return err
+	}
+	if err := workspaces.CheckDocumentDeletable(documentID, ctx); err != nil {
+		return err
+	}
+	if _, err := TrashDocument(documentID, userId, ctx); err != nil {
+		return fmt.Errorf("Error deleting document: %w", err)
+	}
+	recordDocumentEvent(before, "document.deleted", ctx)
+	return nil
+}
+
+// RestoreDocument takes a document out of the trash. Callers check the
+// restore window and permissions.
+func RestoreDocument(documentID string, ctx context.Context) error {
+	before, err := FetchDocumentIncludingTrashed(documentID, ctx)
+	if err != nil {
+		return fmt.Errorf("Error fetching document: %w", err)
+	}
+	restored, err := RestoreTrashedDocument(documentID, ctx)
+	if err != nil {
+		return fmt.Errorf("Error restoring document: %w", err)
+	}
+	if !restored {
+		return fmt.Errorf("document is not in the trash")
+	}
+	recordDocumentEvent(before, "document.restored", ctx)
 	return nil
 }
 
+// PurgeDocument deletes a document for good, trashed or not, unless a
+// legal hold or retention policy still covers it. Decided proposals must
+// be purged first so they leave removal entries in the history chain.
+func PurgeDocument(documentID string, ctx context.Context) error {
+	before, err := FetchDocumentIncludingTrashed(documentID, ctx)
+	if err != nil {
+		return fmt.Errorf("Error fetching document: %w", err)
+	}
+	if err := workspaces.CheckDocumentDeletable(documentID, ctx); err != nil {
+		return err
+	}
+	if err := DeleteDocument(documentID, ctx); err != nil {
+		return err
+	}
+	recordDocumentEvent(before, "document.purged", ctx)
+	return nil
+}
+
+// recordDocumentEvent audits a change to a whole document. The workspace is
+// passed along because the audit log cannot look up a purged document.
+func recordDocumentEvent(before *Document, action string, ctx context.Context) {
+	entry := audit.Entry{DocumentID: before.ID, Action: action, TargetType: "document", TargetID: before.ID, Before: before}
+	if before.WorkspaceID != nil {
+		entry.WorkspaceID = *before.WorkspaceID
+	}
+	audit.Record(entry, ctx)
+}
+
+// requireDocumentPermission checks perm in the document's workspace, or a
+// guest grant on the document. Only the creator may act on legacy documents
+// without one.
+func requireDocumentPermission(doc *Document, perm workspaces.Permission, ctx context.Context) error {
+	if doc.WorkspaceID != nil {
+		err := workspaces.RequirePermission(*doc.WorkspaceID, perm, ctx)
+		if errors.Is(err, workspaces.ErrPermissionDenied) {
+			if guestErr := workspaces.RequireGuestPermission(*doc.WorkspaceID, doc.ID, "", perm, ctx); guestErr == nil {
+				return nil
+			}
+		}
+		return err
+	}
+	userID, _ := utils.GetUserIDFromContext(ctx)
+	if userID != doc.CreatedBy {
+		return workspaces.ErrPermissionDenied
+	}
+	return nil
+}
+
+// requireLiveDocument fails for documents that are missing or in the trash,
+// and unless the caller holds perm on them.
+func requireLiveDocument(documentID string, perm workspaces.Permission, ctx context.Context) (*Document, error) {
+	document, err := FetchDocumentByID(documentID, ctx)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("document not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching document: %w", err)
+	}
+	if err := requireDocumentPermission(document, perm, ctx); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
 func getAllBlocksForDocument(documentID string, ctx context.Context) ([]*blocks.Block, error) {
+	if _, err := requireLiveDocument(documentID, workspaces.PermRead, ctx); err != nil {
+		return nil, err
+	}
 	blocks, err := blocks.FetchAllBlocksByDocumentID(documentID, ctx)
 	if err != nil {
 		return nil, fmt.Errorf("Error fetching blocks for document %s: %w", documentID, err)
@@ -74,6 +193,12 @@ func createBlockForDocument(block *blocks.Block, ctx context.Context) error {
 	if !ok {
 		return fmt.Errorf("User ID not found in context")
 	}
+	if _, err := requireLiveDocument(block.DocumentID, workspaces.PermManagePolicy, ctx); err != nil {
+		return err
+	}
+	if err := proposals.RequireUnstewarded(block.DocumentID, "", [][]int64{block.OrderPath}, ctx); err != nil {
+		return err
+	}
 	block.CreatedBy = userId
 	block.CreatedAt = time.Now().UTC().Format(time.RFC3339)
 	block.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
@@ -82,28 +207,71 @@ func createBlockForDocument(block *blocks.Block, ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("Error creating block: %w", err)
 	}
+	audit.Record(audit.Entry{DocumentID: block.DocumentID, Action: "block.created", TargetType: "block", TargetID: block.ID, After: newBlockEvent(block)}, ctx)
 	return nil
 }
 
+// fetchDocumentBlock loads a block and fails unless it belongs to documentID,
+// so a permission on one document cannot be used to edit another's blocks.
+func fetchDocumentBlock(documentID, blockID string, ctx context.Context) (*blocks.Block, error) {
+	block, err := blocks.FetchBlockByID(blockID, ctx)
+	if err == sql.ErrNoRows || (err == nil && block.DocumentID != documentID) {
+		return nil, fmt.Errorf("block not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching block: %w", err)
+	}
+	return block, nil
+}
+
+func newBlockEvent(b *blocks.Block) *blockEvent {
+	return &blockEvent{
+		ID: b.ID, DocumentID: b.DocumentID, ContentHash: audit.HashContent(b.Content),
+		BlockType: b.BlockType, OrderPath: b.OrderPath, UpdatedBy: b.UpdatedBy, UpdatedAt: b.UpdatedAt,
+	}
+}
+
 func updateBlockForDocument(block *blocks.Block, ctx context.Context) error {
 	userId, ok := utils.GetUserIDFromContext(ctx)
 	if !ok {
 		return fmt.Errorf("User ID not found in context")
 	}
+	before, err := fetchDocumentBlock(block.DocumentID, block.ID, ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := requireLiveDocument(before.DocumentID, workspaces.PermManagePolicy, ctx); err != nil {
+		return err
+	}
+	if err := proposals.RequireUnstewarded(before.DocumentID, before.ID, [][]int64{before.OrderPath, block.OrderPath}, ctx); err != nil {
+		return err
+	}
 	block.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
 	block.UpdatedBy = userId
-	err := blocks.UpdateBlock(block, ctx)
+	err = blocks.UpdateBlock(block, ctx)
 	if err != nil {
 		return fmt.Errorf("Error updating block: %w", err)
 	}
+	audit.Record(audit.Entry{DocumentID: block.DocumentID, Action: "block.updated", TargetType: "block", TargetID: block.ID, Before: newBlockEvent(before), After: newBlockEvent(block)}, ctx)
 	return nil
 }
 
-func deleteBlockForDocument(blockID string, ctx context.Context) error {
-	err := blocks.DeleteBlock(blockID, ctx)
+func deleteBlockForDocument(documentID, blockID string, ctx context.Context) error {
+	before, err := fetchDocumentBlock(documentID, blockID, ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := requireLiveDocument(before.DocumentID, workspaces.PermManagePolicy, ctx); err != nil {
+		return err
+	}
+	if err := proposals.RequireUnstewarded(before.DocumentID, before.ID, [][]int64{before.OrderPath}, ctx); err != nil {
+		return err
+	}
+	err = blocks.DeleteBlock(blockID, ctx)
 	if err != nil {
 		return fmt.Errorf("Error deleting block: %w", err)
 	}
+	audit.Record(audit.Entry{DocumentID: before.DocumentID, Action: "block.deleted", TargetType: "block", TargetID: blockID, Before: newBlockEvent(before)}, ctx)
 	return nil
 }
 
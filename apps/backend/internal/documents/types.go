@@ -1,5 +1,7 @@
 package documents
 
+import "github.com/lib/pq"
+
 type Document struct {
 	ID          string  `json:"id"`
 	Title       string  `json:"title"`
@@ -8,4 +10,39 @@ type Document struct {
 	CreatedAt   string  `json:"created_at"`
 	UpdatedAt   string  `json:"updated_at"`
 	UpdatedBy   string  `json:"updated_by"`
+	DeletedAt   *string `json:"deleted_at,omitempty"`
+	DeletedBy   *string `json:"deleted_by,omitempty"`
+}
+
+// blockEvent is a block as recorded in audit payloads, with a hash of its
+// content instead of the content.
+type blockEvent struct {
+	ID          string        `json:"id"`
+	DocumentID  string        `json:"document_id"`
+	ContentHash string        `json:"content_hash"`
+	BlockType   string        `json:"block_type"`
+	OrderPath   pq.Int64Array `json:"order_path"`
+	UpdatedBy   string        `json:"updated_by"`
+	UpdatedAt   string        `json:"updated_at"`
+}
+
+// Redaction records that text was scrubbed from a document's blocks, its
+// proposals with their changes, summaries, comments and conflict findings,
+// and its audit payloads. The text is not kept.
+type Redaction struct {
+	ID                string  `json:"id"`
+	WorkspaceID       *string `json:"workspace_id,omitempty"`
+	DocumentID        string  `json:"document_id"`
+	Reason            string  `json:"reason"`
+	TextHash          string  `json:"text_hash"`
+	BlocksRedacted    int     `json:"blocks_redacted"`
+	ChangesRedacted   int     `json:"changes_redacted"`
+	ArtifactsRedacted int     `json:"artifacts_redacted"`
+	CommentsRedacted  int     `json:"comments_redacted"`
+	ProposalsRedacted int     `json:"proposals_redacted"`
+	FindingsRedacted  int     `json:"findings_redacted"`
+	EventsRedacted    int     `json:"events_redacted"`
+	RedactedBy        *string `json:"redacted_by"`
+	RedactedByName    *string `json:"redacted_by_name,omitempty"`
+	CreatedAt         string  `json:"created_at"`
 }
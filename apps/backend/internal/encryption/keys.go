@@ -0,0 +1,93 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const keySize = 32
+
+// masterKey wraps data keys. Its ID is derived from the key itself, so each
+// wrapped key records which master key it needs.
+type masterKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// parseMasterKey decodes a base64 master key of 32 bytes.
+func parseMasterKey(encoded string) (*masterKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("master key is not valid base64: %w", err)
+	}
+	if len(raw) != keySize {
+		return nil, fmt.Errorf("master key must be %d bytes, got %d", keySize, len(raw))
+	}
+	aead, err := newAEAD(raw)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(raw)
+	return &masterKey{id: hex.EncodeToString(sum[:8]), aead: aead}, nil
+}
+
+// wrap encrypts a data key, bound to its ID.
+func (m *masterKey) wrap(keyID string, dataKey []byte) (string, error) {
+	sealed, err := seal(m.aead, dataKey, []byte(keyID))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (m *masterKey) unwrap(keyID, wrapped string) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("wrapped key %s is not valid base64: %w", keyID, err)
+	}
+	dataKey, err := open(m.aead, sealed, []byte(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("error unwrapping key %s: %w", keyID, err)
+	}
+	return dataKey, nil
+}
+
+func generateDataKey() ([]byte, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("error generating data key: %w", err)
+	}
+	return key, nil
+}
+
+// newAEAD returns AES-256-GCM for key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext under a random nonce, which it prepends.
+func seal(aead cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("error generating nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(aead cipher.AEAD, sealed, aad []byte) ([]byte, error) {
+	n := aead.NonceSize()
+	if len(sealed) < n {
+		return nil, fmt.Errorf("ciphertext is too short")
+	}
+	return aead.Open(nil, sealed[:n], sealed[n:], aad)
+}
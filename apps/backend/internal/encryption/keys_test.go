@@ -0,0 +1,164 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, keySize))
+}
+
+func TestParseMasterKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoded string
+		wantErr string
+	}{
+		{"valid", testKey(1), ""},
+		{"surrounding whitespace", " " + testKey(1) + "\n", ""},
+		{"not base64", "not base64!", "not valid base64"},
+		{"too short", base64.StdEncoding.EncodeToString([]byte("short")), "must be 32 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := parseMasterKey(tt.encoded)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(m.id) != 16 {
+				t.Fatalf("id %q is not 8 hex bytes", m.id)
+			}
+		})
+	}
+
+	a, _ := parseMasterKey(testKey(1))
+	b, _ := parseMasterKey(testKey(2))
+	if a.id == b.id {
+		t.Fatal("different keys share an id")
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	aead, err := newAEAD(bytes.Repeat([]byte{7}, keySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := newAEAD(bytes.Repeat([]byte{8}, keySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		plaintext string
+	}{
+		{"empty", ""},
+		{"ascii", "The quick brown fox"},
+		{"unicode", "ग्रन्थ — résumé"},
+		{"large", strings.Repeat("x", 1<<16)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sealed, err := seal(aead, []byte(tt.plaintext), []byte("key-a"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.plaintext != "" && bytes.Contains(sealed, []byte(tt.plaintext)) {
+				t.Fatal("sealed value contains the plaintext")
+			}
+			got, err := open(aead, sealed, []byte("key-a"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.plaintext {
+				t.Fatalf("got %q, want %q", got, tt.plaintext)
+			}
+
+			if _, err := open(aead, sealed, []byte("key-b")); err == nil {
+				t.Fatal("opened with the wrong key ID")
+			}
+			if _, err := open(other, sealed, []byte("key-a")); err == nil {
+				t.Fatal("opened with the wrong key")
+			}
+			tampered := append([]byte(nil), sealed...)
+			tampered[len(tampered)-1] ^= 1
+			if _, err := open(aead, tampered, []byte("key-a")); err == nil {
+				t.Fatal("opened a tampered value")
+			}
+			if _, err := open(aead, sealed[:aead.NonceSize()-1], []byte("key-a")); err == nil {
+				t.Fatal("opened a truncated value")
+			}
+		})
+	}
+
+	a, _ := seal(aead, []byte("same"), nil)
+	b, _ := seal(aead, []byte("same"), nil)
+	if bytes.Equal(a, b) {
+		t.Fatal("sealing twice gave the same ciphertext")
+	}
+}
+
+func TestWrapUnwrap(t *testing.T) {
+	m, err := parseMasterKey(testKey(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _ := parseMasterKey(testKey(4))
+	raw, err := generateDataKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped, err := m.wrap("key-1", raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := m.unwrap("key-1", wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, raw) {
+		t.Fatal("unwrapped a different key")
+	}
+	if _, err := m.unwrap("key-2", wrapped); err == nil {
+		t.Fatal("unwrapped under another key ID")
+	}
+	if _, err := other.unwrap("key-1", wrapped); err == nil {
+		t.Fatal("unwrapped with another master key")
+	}
+}
+
+func TestOpenWithoutPrefix(t *testing.T) {
+	tests := []struct {
+		name, value, want, wantErr string
+	}{
+		{"plaintext passes through", "hello", "hello", ""},
+		{"empty", "", "", ""},
+		{"lookalike prefix", "enc:v2:abc", "enc:v2:abc", ""},
+		{"missing key ID", "enc:v1:abc", "", "malformed encrypted content"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Open(tt.value, context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("got %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
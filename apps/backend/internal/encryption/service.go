@@ -0,0 +1,305 @@
+package encryption
+
+import (
+	"context"
+	"crypto/cipher"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// prefix marks a sealed value as enc:v1:<key id>:<base64 nonce+ciphertext>.
+// Anything else is plaintext written before encryption was turned on.
+const prefix = "enc:v1:"
+
+const reencryptBatchSize = 500
+
+// ErrNoMasterKey is returned when reading sealed content without a master key.
+var ErrNoMasterKey = errors.New("content is encrypted but no master key is configured")
+
+type Config struct {
+	// MasterKey is base64 of 32 random bytes. Without it content is
+	// stored as plaintext.
+	MasterKey string
+	// PreviousMasterKeys still unwrap data keys, which Init rewraps with
+	// MasterKey.
+	PreviousMasterKeys []string
+}
+
+var (
+	mu       sync.RWMutex
+	current  *masterKey
+	previous = map[string]*masterKey{}
+	// dataKeys caches unwrapped keys by ID; a key never changes once made
+	dataKeys = map[string]cipher.AEAD{}
+)
+
+// Init loads the master keys. It does not write; RewrapDataKeys moves data
+// keys off previous master keys.
+func Init(cfg Config) error {
+	if cfg.MasterKey == "" {
+		if len(cfg.PreviousMasterKeys) > 0 {
+			return fmt.Errorf("previous master keys are set without a master key")
+		}
+		return nil
+	}
+	m, err := parseMasterKey(cfg.MasterKey)
+	if err != nil {
+		return err
+	}
+	prev := map[string]*masterKey{}
+	for _, encoded := range cfg.PreviousMasterKeys {
+		p, err := parseMasterKey(encoded)
+		if err != nil {
+			return fmt.Errorf("previous %w", err)
+		}
+		prev[p.id] = p
+	}
+
+	mu.Lock()
+	current, previous = m, prev
+	mu.Unlock()
+	return nil
+}
+
+// Enabled reports whether new content is encrypted.
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current != nil
+}
+
+// SealForDocument encrypts content that belongs to documentID with its
+// workspace's key. It returns plaintext unchanged when encryption is off.
+func SealForDocument(documentID, plaintext string, ctx context.Context) (string, error) {
+	if !Enabled() {
+		return plaintext, nil
+	}
+	workspaceID, err := fetchDocumentWorkspace(documentID, ctx)
+	if err != nil {
+		return "", err
+	}
+	return sealForWorkspace(workspaceID, plaintext, ctx)
+}
+
+// SealForProposal is SealForDocument for content of a proposal.
+func SealForProposal(proposalID, plaintext string, ctx context.Context) (string, error) {
+	if !Enabled() {
+		return plaintext, nil
+	}
+	workspaceID, err := fetchProposalWorkspace(proposalID, ctx)
+	if err != nil {
+		return "", err
+	}
+	return sealForWorkspace(workspaceID, plaintext, ctx)
+}
+
+// Open decrypts a value written by a Seal function. Plaintext values pass
+// through, so rows written before encryption stay readable.
+func Open(value string, ctx context.Context) (string, error) {
+	if !strings.HasPrefix(value, prefix) {
+		return value, nil
+	}
+	keyID, body, ok := strings.Cut(value[len(prefix):], ":")
+	if !ok {
+		return "", fmt.Errorf("malformed encrypted content")
+	}
+	aead, err := dataKey(keyID, ctx)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted content: %w", err)
+	}
+	plaintext, err := open(aead, sealed, []byte(keyID))
+	if err != nil {
+		return "", fmt.Errorf("error decrypting content: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+func sealForWorkspace(workspaceID *string, plaintext string, ctx context.Context) (string, error) {
+	keyID, err := activeKeyID(workspaceID, ctx)
+	if err != nil {
+		return "", err
+	}
+	aead, err := dataKey(keyID, ctx)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := seal(aead, []byte(plaintext), []byte(keyID))
+	if err != nil {
+		return "", err
+	}
+	return prefix + keyID + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// activeKeyID returns the workspace's active data key, creating it the
+// first time content is sealed for the workspace.
+func activeKeyID(workspaceID *string, ctx context.Context) (string, error) {
+	id, err := fetchActiveKeyID(workspaceID, ctx)
+	if err == sql.ErrNoRows {
+		if err := createDataKey(workspaceID, ctx); err != nil {
+			return "", err
+		}
+		id, err = fetchActiveKeyID(workspaceID, ctx)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error fetching data key: %w", err)
+	}
+	return id, nil
+}
+
+func createDataKey(workspaceID *string, ctx context.Context) error {
+	mu.RLock()
+	m := current
+	mu.RUnlock()
+
+	id, err := newKeyID(ctx)
+	if err != nil {
+		return err
+	}
+	raw, err := generateDataKey()
+	if err != nil {
+		return err
+	}
+	wrapped, err := m.wrap(id, raw)
+	if err != nil {
+		return err
+	}
+	return insertDataKey(workspaceID, &storedKey{ID: id, WrappedKey: wrapped, MasterKeyID: m.id}, ctx)
+}
+
+// dataKey returns the unwrapped key keyID.
+func dataKey(keyID string, ctx context.Context) (cipher.AEAD, error) {
+	mu.RLock()
+	aead, ok := dataKeys[keyID]
+	mu.RUnlock()
+	if ok {
+		return aead, nil
+	}
+
+	k, err := fetchDataKey(keyID, ctx)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("data key %s not found", keyID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching data key: %w", err)
+	}
+	raw, err := unwrap(k)
+	if err != nil {
+		return nil, err
+	}
+	aead, err = newAEAD(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	dataKeys[keyID] = aead
+	mu.Unlock()
+	return aead, nil
+}
+
+// unwrap opens k with the master key it was wrapped by.
+func unwrap(k *storedKey) ([]byte, error) {
+	mu.RLock()
+	m := current
+	if m != nil && m.id != k.MasterKeyID {
+		m = previous[k.MasterKeyID]
+	}
+	enabled := current != nil
+	mu.RUnlock()
+
+	if !enabled {
+		return nil, ErrNoMasterKey
+	}
+	if m == nil {
+		return nil, fmt.Errorf("data key %s needs master key %s, which is not configured", k.ID, k.MasterKeyID)
+	}
+	return m.unwrap(k.ID, k.WrappedKey)
+}
+
+// RewrapDataKeys wraps every data key with the current master key and
+// returns how many it rewrapped.
+func RewrapDataKeys(ctx context.Context) (int, error) {
+	mu.RLock()
+	m := current
+	mu.RUnlock()
+	if m == nil {
+		return 0, nil
+	}
+
+	keys, err := fetchKeysNotWrappedBy(m.id, ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, k := range keys {
+		raw, err := unwrap(k)
+		if err != nil {
+			return 0, err
+		}
+		if k.WrappedKey, err = m.wrap(k.ID, raw); err != nil {
+			return 0, err
+		}
+		k.MasterKeyID = m.id
+		if err := updateWrappedKey(k, ctx); err != nil {
+			return 0, err
+		}
+	}
+	return len(keys), nil
+}
+
+// RotateDataKeys retires the active data keys of workspaceIDs (of every
+// workspace when empty) and re-encrypts their content with new keys. It
+// returns how many values were re-encrypted.
+func RotateDataKeys(workspaceIDs []string, ctx context.Context) (int, error) {
+	if !Enabled() {
+		return 0, fmt.Errorf("encryption is not configured")
+	}
+	if _, err := retireActiveKeys(workspaceIDs, ctx); err != nil {
+		return 0, err
+	}
+	return Reencrypt(ctx)
+}
+
+// Reencrypt seals every value not yet sealed with its workspace's active
+// key: plaintext from before encryption was turned on, and values under
+// retired keys. It returns how many values it rewrote.
+func Reencrypt(ctx context.Context) (int, error) {
+	if !Enabled() {
+		return 0, nil
+	}
+	total := 0
+	for _, col := range sealedColumns {
+		for {
+			values, err := fetchStaleValues(col, reencryptBatchSize, ctx)
+			if err != nil {
+				return total, err
+			}
+			if len(values) == 0 {
+				break
+			}
+			for _, v := range values {
+				plaintext, err := Open(v.Value, ctx)
+				if err != nil {
+					return total, fmt.Errorf("error decrypting %s %s: %w", col.name, v.ID, err)
+				}
+				sealed, err := sealForWorkspace(v.WorkspaceID, plaintext, ctx)
+				if err != nil {
+					return total, err
+				}
+				// a value changed meanwhile was sealed by its writer
+				if err := replaceValue(col, v, sealed, ctx); err != nil {
+					return total, err
+				}
+				total++
+			}
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,83 @@
+package encryption
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+
+	"granth/internal/config"
+	"granth/internal/testdb"
+)
+
+// TestRotateDataKeys seals block content, rotates the workspace's key and
+// checks the stored value moved to the new key and still opens.
+func TestRotateDataKeys(t *testing.T) {
+	testdb.Open(t)
+	ctx := context.Background()
+	if err := Init(Config{MasterKey: testKey(9)}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		mu.Lock()
+		current, previous = nil, map[string]*masterKey{}
+		mu.Unlock()
+	})
+
+	owner := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	documentID := testdb.CreateDocument(t, workspaceID, owner)
+
+	tests := []string{"first block", "", "ग्रन्थ"}
+	ids := make([]string, len(tests))
+	for i, plaintext := range tests {
+		sealed, err := SealForDocument(documentID, plaintext, ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(sealed, prefix) {
+			t.Fatalf("%q was not sealed", plaintext)
+		}
+		err = config.PostgresDB.QueryRow(
+			`INSERT INTO blocks (document_id, order_path, type, content, created_by, created_at, updated_at, updated_by)
+			 VALUES ($1, $2, 'text', $3, $4, now(), now(), $4) RETURNING id`,
+			documentID, pq.Array([]int64{int64(i)}), sealed, owner,
+		).Scan(&ids[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	before, err := fetchActiveKeyID(&workspaceID, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := RotateDataKeys([]string{workspaceID}, ctx); err != nil {
+		t.Fatal(err)
+	}
+	after, err := fetchActiveKeyID(&workspaceID, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after == before {
+		t.Fatal("active key did not change")
+	}
+
+	for i, plaintext := range tests {
+		var stored string
+		if err := config.PostgresDB.QueryRow(`SELECT content FROM blocks WHERE id = $1`, ids[i]).Scan(&stored); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(stored, prefix+after+":") {
+			t.Fatalf("block %d is not sealed with the new key", i)
+		}
+		got, err := Open(stored, ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != plaintext {
+			t.Fatalf("got %q, want %q", got, plaintext)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package encryption
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"granth/internal/config"
+)
+
+// storedKey is a row of workspace_data_keys.
+type storedKey struct {
+	ID          string
+	WrappedKey  string
+	MasterKeyID string
+}
+
+func fetchDocumentWorkspace(documentID string, ctx context.Context) (*string, error) {
+	var workspaceID *string
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT workspace_id FROM documents WHERE id = $1`, documentID,
+	).Scan(&workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching document workspace: %w", err)
+	}
+	return workspaceID, nil
+}
+
+func fetchProposalWorkspace(proposalID string, ctx context.Context) (*string, error) {
+	var workspaceID *string
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT d.workspace_id FROM proposals p JOIN documents d ON d.id = p.document_id WHERE p.id = $1`, proposalID,
+	).Scan(&workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching proposal workspace: %w", err)
+	}
+	return workspaceID, nil
+}
+
+// fetchActiveKeyID returns sql.ErrNoRows while the workspace has no key.
+func fetchActiveKeyID(workspaceID *string, ctx context.Context) (string, error) {
+	var id string
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT id FROM workspace_data_keys
+		 WHERE workspace_id IS NOT DISTINCT FROM $1::uuid AND retired_at IS NULL`, workspaceID,
+	).Scan(&id)
+	return id, err
+}
+
+func fetchDataKey(id string, ctx context.Context) (*storedKey, error) {
+	k := &storedKey{}
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT id, wrapped_key, master_key_id FROM workspace_data_keys WHERE id = $1`, id,
+	).Scan(&k.ID, &k.WrappedKey, &k.MasterKeyID)
+	if err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// insertDataKey adds an active key unless the workspace got one meanwhile.
+func insertDataKey(workspaceID *string, k *storedKey, ctx context.Context) error {
+	_, err := config.PostgresDB.ExecContext(ctx,
+		`INSERT INTO workspace_data_keys (id, workspace_id, wrapped_key, master_key_id)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT ((COALESCE(workspace_id, '00000000-0000-0000-0000-000000000000'::uuid)))
+		 WHERE retired_at IS NULL DO NOTHING`,
+		k.ID, workspaceID, k.WrappedKey, k.MasterKeyID)
+	if err != nil {
+		return fmt.Errorf("error inserting data key: %w", err)
+	}
+	return nil
+}
+
+func newKeyID(ctx context.Context) (string, error) {
+	var id string
+	if err := config.PostgresDB.QueryRowContext(ctx, `SELECT gen_random_uuid()`).Scan(&id); err != nil {
+		return "", fmt.Errorf("error creating key ID: %w", err)
+	}
+	return id, nil
+}
+
+// fetchKeysNotWrappedBy lists keys wrapped by any master key but masterKeyID.
+func fetchKeysNotWrappedBy(masterKeyID string, ctx context.Context) ([]*storedKey, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT id, wrapped_key, master_key_id FROM workspace_data_keys WHERE master_key_id <> $1`, masterKeyID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying data keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []*storedKey{}
+	for rows.Next() {
+		k := &storedKey{}
+		if err := rows.Scan(&k.ID, &k.WrappedKey, &k.MasterKeyID); err != nil {
+			return nil, fmt.Errorf("error scanning data key: %w", err)
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func updateWrappedKey(k *storedKey, ctx context.Context) error {
+	_, err := config.PostgresDB.ExecContext(ctx,
+		`UPDATE workspace_data_keys SET wrapped_key = $2, master_key_id = $3 WHERE id = $1`,
+		k.ID, k.WrappedKey, k.MasterKeyID)
+	if err != nil {
+		return fmt.Errorf("error rewrapping data key: %w", err)
+	}
+	return nil
+}
+
+// retireActiveKeys retires the active keys of workspaceIDs, or of every
+// workspace and of documents outside one when workspaceIDs is empty.
+func retireActiveKeys(workspaceIDs []string, ctx context.Context) (int, error) {
+	var res sql.Result
+	var err error
+	if len(workspaceIDs) == 0 {
+		res, err = config.PostgresDB.ExecContext(ctx,
+			`UPDATE workspace_data_keys SET retired_at = now() WHERE retired_at IS NULL`)
+	} else {
+		res, err = config.PostgresDB.ExecContext(ctx,
+			`UPDATE workspace_data_keys SET retired_at = now()
+			 WHERE retired_at IS NULL AND workspace_id = ANY($1)`, pq.Array(workspaceIDs))
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error retiring data keys: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// sealedColumn is a column whose values are encrypted. staleQuery selects
+// (id, workspace_id, value) of rows not sealed with their workspace's
+// active key, plaintext included; updateQuery replaces a value ($3) only
+// if it is still the one read ($2).
+type sealedColumn struct {
+	name        string
+	staleQuery  string
+	updateQuery string
+}
+
+// staleCondition matches values of column not sealed with the active key k.
+const staleCondition = `(k.id IS NULL OR %[1]s NOT LIKE 'enc:v1:' || k.id::text || ':%%')`
+
+const activeKeyJoin = `LEFT JOIN workspace_data_keys k
+	ON k.workspace_id IS NOT DISTINCT FROM d.workspace_id AND k.retired_at IS NULL`
+
+var sealedColumns = []sealedColumn{
+	{
+		name: "block content",
+		staleQuery: `SELECT b.id, d.workspace_id, b.content
+			FROM blocks b JOIN documents d ON d.id = b.document_id ` + activeKeyJoin + `
+			WHERE ` + fmt.Sprintf(staleCondition, "b.content") + ` LIMIT $1`,
+		updateQuery: `UPDATE blocks SET content = $3 WHERE id = $1 AND content = $2`,
+	},
+	{
+		name: "block change content",
+		staleQuery: `SELECT c.id, d.workspace_id, c.content
+			FROM proposal_block_changes c
+			JOIN proposals p ON p.id = c.proposal_id
+			JOIN documents d ON d.id = p.document_id ` + activeKeyJoin + `
+			WHERE c.content IS NOT NULL AND ` + fmt.Sprintf(staleCondition, "c.content") + ` LIMIT $1`,
+		updateQuery: `UPDATE proposal_block_changes SET content = $3 WHERE id = $1 AND content = $2`,
+	},
+	{
+		name: "comment body",
+		staleQuery: `SELECT c.id, d.workspace_id, c.body
+			FROM proposal_comments c
+			JOIN proposals p ON p.id = c.proposal_id
+			JOIN documents d ON d.id = p.document_id ` + activeKeyJoin + `
+			WHERE ` + fmt.Sprintf(staleCondition, "c.body") + ` LIMIT $1`,
+		updateQuery: `UPDATE proposal_comments SET body = $3 WHERE id = $1 AND body = $2`,
+	},
+	{
+		name: "reasoning artifact content",
+		staleQuery: `SELECT a.id, d.workspace_id, a.content
+			FROM reasoning_artifacts a
+			JOIN proposals p ON p.id = a.proposal_id
+			JOIN documents d ON d.id = p.document_id ` + activeKeyJoin + `
+			WHERE ` + fmt.Sprintf(staleCondition, "a.content") + ` LIMIT $1`,
+		updateQuery: `UPDATE reasoning_artifacts SET content = $3 WHERE id = $1 AND content = $2`,
+	},
+}
+
+// staleValue is a row returned by a sealedColumn's staleQuery.
+type staleValue struct {
+	ID          string
+	WorkspaceID *string
+	Value       string
+}
+
+func fetchStaleValues(col sealedColumn, limit int, ctx context.Context) ([]*staleValue, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx, col.staleQuery, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying %s: %w", col.name, err)
+	}
+	defer rows.Close()
+
+	values := []*staleValue{}
+	for rows.Next() {
+		v := &staleValue{}
+		if err := rows.Scan(&v.ID, &v.WorkspaceID, &v.Value); err != nil {
+			return nil, fmt.Errorf("error scanning %s: %w", col.name, err)
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
+
+func replaceValue(col sealedColumn, v *staleValue, sealed string, ctx context.Context) error {
+	if _, err := config.PostgresDB.ExecContext(ctx, col.updateQuery, v.ID, v.Value, sealed); err != nil {
+		return fmt.Errorf("error updating %s: %w", col.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"granth/internal/config"
+)
+
+// logMailer is the development stand-in: messages are printed to the log
+// and, when dir is set, written there as one .eml file each.
+type logMailer struct {
+	dir string
+}
+
+func (m *logMailer) Send(msg Message, ctx context.Context) error {
+	config.Logger.Printf("mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	if m.dir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("error creating mail directory: %w", err)
+	}
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), sanitizeFilename(msg.To))
+	content := "To: " + msg.To + "\nSubject: " + msg.Subject + "\n\n" + msg.Body + "\n"
+	if err := os.WriteFile(filepath.Join(m.dir, name), []byte(content), 0o644); err != nil {
+		return fmt.Errorf("error writing mail: %w", err)
+	}
+	return nil
+}
+
+func sanitizeFilename(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
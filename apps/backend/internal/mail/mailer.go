@@ -0,0 +1,45 @@
+package mail
+
+import (
+	"context"
+	"errors"
+)
+
+// Mailer delivers transactional email (verification links, password
+// resets, invitations). Local development uses the log mailer so no SMTP
+// server is needed.
+type Mailer interface {
+	Send(msg Message, ctx context.Context) error
+}
+
+var DefaultMailer Mailer
+
+var ErrNotConfigured = errors.New("mailer not configured")
+
+// InitMailer configures DefaultMailer from cfg and returns it.
+func InitMailer(cfg Config) Mailer {
+	if cfg.Host != "" {
+		port := cfg.Port
+		if port == "" {
+			port = "587"
+		}
+		DefaultMailer = &smtpMailer{
+			addr:     cfg.Host + ":" + port,
+			host:     cfg.Host,
+			username: cfg.Username,
+			password: cfg.Password,
+			from:     cfg.From,
+		}
+		return DefaultMailer
+	}
+	DefaultMailer = &logMailer{dir: cfg.Dir}
+	return DefaultMailer
+}
+
+// Send delivers msg through DefaultMailer.
+func Send(msg Message, ctx context.Context) error {
+	if DefaultMailer == nil {
+		return ErrNotConfigured
+	}
+	return DefaultMailer.Send(msg, ctx)
+}
@@ -0,0 +1,18 @@
+package mail
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Config selects and configures the mailer. An empty Host falls back to the
+// log mailer, which also writes messages to Dir when it is set.
+type Config struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	Dir      string
+}
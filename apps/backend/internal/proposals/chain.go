@@ -0,0 +1,539 @@
+package proposals
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"granth/internal/audit"
+	"granth/internal/config"
+	"granth/internal/utils"
+	"granth/internal/workspaces"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lib/pq"
+)
+
+// Each document has a hash chain of its decisions. An entry's content hash
+// covers its manifest, and its entry hash covers the previous entry hash
+// plus its content hash, so changing or dropping any decision, block
+// change or earlier entry shows up when the chain is walked again. A
+// redaction of decided changes is an entry of its own; the decision entry
+// is never rewritten.
+
+// genesisHash is the prev_hash of the first entry of every chain.
+var genesisHash = strings.Repeat("0", 64)
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func chainHash(prevHash, contentHash string) string {
+	return sha256Hex([]byte(prevHash + contentHash))
+}
+
+// canonicalTime formats a stored timestamp in UTC so manifests rebuilt
+// from the database match the ones written at decision time.
+func canonicalTime(s string) string {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return s
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// chainChange is a block change as it appears in a manifest. The content
+// is represented by its hash, so the chain holds no document text.
+type chainChange struct {
+	ID          string        `json:"id"`
+	BlockID     *string       `json:"block_id"`
+	Action      string        `json:"action"`
+	BlockType   string        `json:"block_type"`
+	OrderPath   pq.Int64Array `json:"order_path"`
+	ContentHash string        `json:"content_hash"`
+}
+
+// decisionManifest is what a decision entry attests to. Field order is
+// fixed so the hash can be recomputed from the stored records.
+type decisionManifest struct {
+	DocumentID    string        `json:"document_id"`
+	ProposalID    string        `json:"proposal_id"`
+	DecisionID    string        `json:"decision_id"`
+	Outcome       string        `json:"outcome"`
+	DecidedBy     string        `json:"decided_by"`
+	Reason        *string       `json:"reason"`
+	DecidedAt     string        `json:"decided_at"`
+	SignatureHash *string       `json:"signature_hash"`
+	Changes       []chainChange `json:"changes"`
+}
+
+// removalManifest records that a decided proposal was deleted through the
+// app, so its missing records do not count as tampering.
+type removalManifest struct {
+	DocumentID string `json:"document_id"`
+	ProposalID string `json:"proposal_id"`
+	DecisionID string `json:"decision_id"`
+	RemovedBy  string `json:"removed_by"`
+	RemovedAt  string `json:"removed_at"`
+}
+
+// redactionManifest records that a redaction rewrote changes of a decided
+// proposal.
+type redactionManifest struct {
+	DocumentID  string           `json:"document_id"`
+	ProposalID  string           `json:"proposal_id"`
+	DecisionID  string           `json:"decision_id"`
+	RedactionID string           `json:"redaction_id"`
+	RedactedBy  string           `json:"redacted_by"`
+	RedactedAt  string           `json:"redacted_at"`
+	Changes     []RedactedChange `json:"changes"`
+}
+
+func buildDecisionManifest(documentID string, decision *Decision, changes []*ProposalBlockChange) ([]byte, error) {
+	entries := make([]chainChange, 0, len(changes))
+	for _, c := range changes {
+		var hash string
+		if c.ContentHash != nil {
+			hash = *c.ContentHash
+		}
+		entries = append(entries, chainChange{
+			ID:          c.ID,
+			BlockID:     c.BlockID,
+			Action:      c.Action,
+			BlockType:   c.BlockType,
+			OrderPath:   c.OrderPath,
+			ContentHash: hash,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+
+	var signatureHash *string
+	if decision.Signature != nil {
+		signatureHash = &decision.Signature.ManifestHash
+	}
+	manifest, err := json.Marshal(decisionManifest{
+		DocumentID:    documentID,
+		ProposalID:    decision.ProposalID,
+		DecisionID:    decision.ID,
+		Outcome:       decision.Outcome,
+		DecidedBy:     decision.DecidedBy,
+		Reason:        decision.Reason,
+		DecidedAt:     canonicalTime(decision.DecidedAt),
+		SignatureHash: signatureHash,
+		Changes:       entries,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error encoding chain manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// appendChainEntryInTx links manifest to the end of the document's chain.
+func appendChainEntryInTx(tx *sql.Tx, documentID, kind, proposalID, decisionID string, manifest []byte, ctx context.Context) error {
+	if err := LockChainInTx(tx, documentID, ctx); err != nil {
+		return fmt.Errorf("error locking chain: %w", err)
+	}
+	seq, prevHash, err := GetChainHeadInTx(tx, documentID, ctx)
+	if err != nil {
+		return fmt.Errorf("error fetching chain head: %w", err)
+	}
+	if seq == 0 {
+		prevHash = genesisHash
+	}
+
+	contentHash := sha256Hex(manifest)
+	entry := &ChainEntry{
+		DocumentID:  documentID,
+		Seq:         seq + 1,
+		Kind:        kind,
+		ProposalID:  proposalID,
+		DecisionID:  decisionID,
+		Manifest:    manifest,
+		ContentHash: contentHash,
+		PrevHash:    prevHash,
+		EntryHash:   chainHash(prevHash, contentHash),
+	}
+	if err := CreateChainEntryInTx(tx, entry, ctx); err != nil {
+		return fmt.Errorf("error appending to chain: %w", err)
+	}
+	return nil
+}
+
+// chainDecisionInTx fixes the content hash of each change and appends the
+// decision to its document's chain, in the decision's transaction.
+func chainDecisionInTx(tx *sql.Tx, documentID string, decision *Decision, changes []*ProposalBlockChange, ctx context.Context) error {
+	for _, c := range changes {
+		hash := sha256Hex([]byte(c.Content))
+		if err := SetChangeContentHashInTx(tx, c.ID, hash, ctx); err != nil {
+			return fmt.Errorf("error hashing block change: %w", err)
+		}
+		c.ContentHash = &hash
+	}
+	manifest, err := buildDecisionManifest(documentID, decision, changes)
+	if err != nil {
+		return err
+	}
+	return appendChainEntryInTx(tx, documentID, "decision", decision.ProposalID, decision.ID, manifest, ctx)
+}
+
+// chainRemovalInTx notes in the chain that a decided proposal is being
+// deleted.
+func chainRemovalInTx(tx *sql.Tx, documentID string, decision *Decision, ctx context.Context) error {
+	userID, _ := utils.GetUserIDFromContext(ctx)
+	manifest, err := json.Marshal(removalManifest{
+		DocumentID: documentID,
+		ProposalID: decision.ProposalID,
+		DecisionID: decision.ID,
+		RemovedBy:  userID,
+		RemovedAt:  time.Now().UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return fmt.Errorf("error encoding chain manifest: %w", err)
+	}
+	return appendChainEntryInTx(tx, documentID, "removal", decision.ProposalID, decision.ID, manifest, ctx)
+}
+
+// ChainRedactionInTx appends a redaction entry for each decided proposal
+// whose changes a redaction rewrote, in the redaction's transaction.
+func ChainRedactionInTx(tx *sql.Tx, documentID, redactionID string, changes []*RedactedChange, ctx context.Context) error {
+	byProposal := map[string][]RedactedChange{}
+	for _, c := range changes {
+		byProposal[c.ProposalID] = append(byProposal[c.ProposalID], *c)
+	}
+	proposalIDs := make([]string, 0, len(byProposal))
+	for id := range byProposal {
+		proposalIDs = append(proposalIDs, id)
+	}
+	sort.Strings(proposalIDs)
+
+	userID, _ := utils.GetUserIDFromContext(ctx)
+	redactedAt := time.Now().UTC().Format(time.RFC3339Nano)
+	for _, proposalID := range proposalIDs {
+		decisionID, err := GetDecisionIDInTx(tx, proposalID, ctx)
+		if err != nil {
+			return fmt.Errorf("error fetching decision: %w", err)
+		}
+		entries := byProposal[proposalID]
+		sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+		manifest, err := json.Marshal(redactionManifest{
+			DocumentID:  documentID,
+			ProposalID:  proposalID,
+			DecisionID:  decisionID,
+			RedactionID: redactionID,
+			RedactedBy:  userID,
+			RedactedAt:  redactedAt,
+			Changes:     entries,
+		})
+		if err != nil {
+			return fmt.Errorf("error encoding chain manifest: %w", err)
+		}
+		if err := appendChainEntryInTx(tx, documentID, "redaction", proposalID, decisionID, manifest, ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BackfillChain appends decisions made before chaining existed, oldest
+// first, and returns how many it added. Instances may run it concurrently.
+func BackfillChain(ctx context.Context) (int, error) {
+	decisions, err := GetUnchainedDecisions("", ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error listing unchained decisions: %w", err)
+	}
+	added := 0
+	for _, decision := range decisions {
+		ok, err := backfillDecision(decision, ctx)
+		if err != nil {
+			return added, fmt.Errorf("decision %s: %w", decision.ID, err)
+		}
+		if ok {
+			added++
+		}
+	}
+	return added, nil
+}
+
+func backfillDecision(decision *Decision, ctx context.Context) (bool, error) {
+	proposal, err := GetProposalIncludingTrashed(decision.ProposalID, ctx)
+	if err != nil {
+		return false, fmt.Errorf("error fetching proposal: %w", err)
+	}
+	changes, err := GetChangesByProposal(decision.ProposalID, ctx)
+	if err != nil {
+		return false, fmt.Errorf("error fetching block changes: %w", err)
+	}
+	if decision.Signature, err = GetDecisionSignature(decision.ID, ctx); err != nil {
+		return false, fmt.Errorf("error fetching signature: %w", err)
+	}
+
+	tx, err := config.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := LockChainInTx(tx, proposal.DocumentID, ctx); err != nil {
+		return false, fmt.Errorf("error locking chain: %w", err)
+	}
+	// another instance may have got here first
+	chained, err := IsDecisionChainedInTx(tx, decision.ID, ctx)
+	if err != nil || chained {
+		return false, err
+	}
+	if err := chainDecisionInTx(tx, proposal.DocumentID, decision, changes, ctx); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// VerifyChain walks a document's chain from the start. Besides the links
+// themselves it rebuilds each decision's manifest and signature from the
+// live records, checks every block change against its content hash, looks
+// for decisions missing from the chain, and checks that stored
+// checkpoints still match.
+func VerifyChain(documentID string, ctx context.Context) (*ChainVerification, error) {
+	entries, err := GetChainEntries(documentID, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching chain: %w", err)
+	}
+	result := &ChainVerification{
+		DocumentID: documentID,
+		Entries:    len(entries),
+		HeadHash:   genesisHash,
+		CheckedAt:  time.Now().UTC().Format(time.RFC3339),
+	}
+
+	removed := make(map[string]bool)
+	// the hash changes of each redacted change, oldest first
+	redactions := make(map[string][]RedactedChange)
+	for _, e := range entries {
+		switch e.Kind {
+		case "removal":
+			removed[e.DecisionID] = true
+		case "redaction":
+			var m redactionManifest
+			if json.Unmarshal(e.Manifest, &m) == nil {
+				for _, c := range m.Changes {
+					redactions[c.ID] = append(redactions[c.ID], c)
+				}
+			}
+		}
+	}
+
+	prevHash := genesisHash
+	for i, e := range entries {
+		seq := int64(i + 1)
+		reason, err := checkChainEntry(e, seq, prevHash, removed[e.DecisionID], redactions, ctx)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			result.BrokenAt = &seq
+			result.Reason = reason
+			return result, nil
+		}
+		prevHash = e.EntryHash
+	}
+	result.HeadHash = prevHash
+
+	unchained, err := GetUnchainedDecisions(documentID, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing unchained decisions: %w", err)
+	}
+	if len(unchained) > 0 {
+		result.Reason = fmt.Sprintf("decision %s is not in the chain", unchained[0].ID)
+		return result, nil
+	}
+
+	checkpoints, err := GetChainCheckpoints(documentID, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching checkpoints: %w", err)
+	}
+	for _, c := range checkpoints {
+		if c.Seq < 1 || c.Seq > int64(len(entries)) || entries[c.Seq-1].EntryHash != c.HeadHash {
+			result.Reason = fmt.Sprintf("checkpoint %s does not match entry %d", c.ID, c.Seq)
+			return result, nil
+		}
+	}
+
+	result.Valid = true
+	return result, nil
+}
+
+// checkChainEntry returns why e is broken, or "" when it holds. redactions
+// holds the hash changes the chain records for each change.
+func checkChainEntry(e *ChainEntry, seq int64, prevHash string, removed bool, redactions map[string][]RedactedChange, ctx context.Context) (string, error) {
+	switch {
+	case e.Seq != seq:
+		return fmt.Sprintf("entry %d is missing", seq), nil
+	case e.PrevHash != prevHash:
+		return "prev_hash does not match the previous entry", nil
+	case sha256Hex(e.Manifest) != e.ContentHash:
+		return "manifest does not match its content hash", nil
+	case chainHash(e.PrevHash, e.ContentHash) != e.EntryHash:
+		return "entry_hash does not match prev_hash and content_hash", nil
+	}
+	if e.Kind == "redaction" {
+		var m redactionManifest
+		if err := json.Unmarshal(e.Manifest, &m); err != nil || m.ProposalID != e.ProposalID || m.DecisionID != e.DecisionID {
+			return "redaction manifest does not match its entry", nil
+		}
+	}
+	if e.Kind != "decision" || removed {
+		return "", nil
+	}
+
+	decision, err := GetDecisionByProposal(e.ProposalID, ctx)
+	if err == sql.ErrNoRows || (err == nil && decision.ID != e.DecisionID) {
+		return fmt.Sprintf("decision %s is missing", e.DecisionID), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error fetching decision: %w", err)
+	}
+	proposal, err := GetProposalIncludingTrashed(e.ProposalID, ctx)
+	if err != nil {
+		return "", fmt.Errorf("error fetching proposal: %w", err)
+	}
+	changes, err := GetChangesByProposal(e.ProposalID, ctx)
+	if err != nil {
+		return "", fmt.Errorf("error fetching block changes: %w", err)
+	}
+	decided := make([]*ProposalBlockChange, 0, len(changes))
+	for _, c := range changes {
+		if c.ContentHash == nil || sha256Hex([]byte(c.Content)) != *c.ContentHash {
+			return fmt.Sprintf("content of change %s does not match its hash", c.ID), nil
+		}
+		// the manifest holds the hash the change was decided with
+		hash, ok := hashBeforeRedactions(*c.ContentHash, redactions[c.ID])
+		if !ok {
+			return fmt.Sprintf("change %s does not match its redactions", c.ID), nil
+		}
+		d := *c
+		d.ContentHash = &hash
+		decided = append(decided, &d)
+	}
+	if decision.Signature, err = GetDecisionSignature(decision.ID, ctx); err != nil {
+		return "", fmt.Errorf("error fetching signature: %w", err)
+	}
+	if reason, err := checkSignature(decision, decided); err != nil || reason != "" {
+		return reason, err
+	}
+
+	manifest, err := buildDecisionManifest(proposal.DocumentID, decision, decided)
+	if err != nil {
+		return "", err
+	}
+	if sha256Hex(manifest) != e.ContentHash {
+		return fmt.Sprintf("decision %s no longer matches the chain", e.DecisionID), nil
+	}
+	return "", nil
+}
+
+// hashBeforeRedactions walks a change's redactions back from its current
+// hash to the one it was decided with. It fails when they do not link up.
+func hashBeforeRedactions(current string, history []RedactedChange) (string, bool) {
+	hash := current
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].NewContentHash != hash {
+			return "", false
+		}
+		hash = history[i].OldContentHash
+	}
+	return hash, true
+}
+
+func getDocumentChain(documentID string, ctx context.Context) ([]*ChainEntry, error) {
+	if err := requireDocumentPermission(documentID, workspaces.PermRead, ctx); err != nil {
+		return nil, err
+	}
+	return GetChainEntries(documentID, ctx)
+}
+
+func verifyDocumentChain(documentID string, ctx context.Context) (*ChainVerification, error) {
+	if err := requireDocumentPermission(documentID, workspaces.PermRead, ctx); err != nil {
+		return nil, err
+	}
+	return VerifyChain(documentID, ctx)
+}
+
+// createChainCheckpoint verifies the chain and signs its head with the
+// token signing key.
+func createChainCheckpoint(documentID string, ctx context.Context) (*ChainCheckpoint, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+	if err := requireDocumentPermission(documentID, workspaces.PermExport, ctx); err != nil {
+		return nil, err
+	}
+	result, err := VerifyChain(documentID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Valid {
+		return nil, fmt.Errorf("cannot checkpoint a broken chain")
+	}
+	if result.Entries == 0 {
+		return nil, fmt.Errorf("nothing to checkpoint yet")
+	}
+
+	seq := int64(result.Entries)
+	token, key, err := utils.SignStatement(jwt.MapClaims{
+		"iss":        "granth",
+		"sub":        documentID,
+		"token_type": "chain_checkpoint",
+		"seq":        seq,
+		"head_hash":  result.HeadHash,
+		"iat":        time.Now().Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error signing checkpoint: %w", err)
+	}
+	publicKey, err := json.Marshal(key)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding public key: %w", err)
+	}
+
+	checkpoint := &ChainCheckpoint{
+		DocumentID: documentID,
+		Seq:        seq,
+		HeadHash:   result.HeadHash,
+		Token:      token,
+		PublicKey:  publicKey,
+		CreatedBy:  &userID,
+	}
+	if err := CreateChainCheckpoint(checkpoint, ctx); err != nil {
+		return nil, fmt.Errorf("error storing checkpoint: %w", err)
+	}
+	audit.Record(audit.Entry{
+		DocumentID: documentID, Action: "chain.checkpoint_created", TargetType: "chain_checkpoint", TargetID: checkpoint.ID,
+		After: map[string]interface{}{"seq": seq, "head_hash": result.HeadHash},
+	}, ctx)
+	return checkpoint, nil
+}
+
+func listChainCheckpoints(documentID string, ctx context.Context) ([]*ChainCheckpoint, error) {
+	if err := requireDocumentPermission(documentID, workspaces.PermExport, ctx); err != nil {
+		return nil, err
+	}
+	return GetChainCheckpoints(documentID, ctx)
+}
+
+func getChainCheckpoint(documentID, checkpointID string, ctx context.Context) (*ChainCheckpoint, error) {
+	if err := requireDocumentPermission(documentID, workspaces.PermExport, ctx); err != nil {
+		return nil, err
+	}
+	checkpoint, err := GetChainCheckpoint(documentID, checkpointID, ctx)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("checkpoint not found")
+	}
+	return checkpoint, err
+}
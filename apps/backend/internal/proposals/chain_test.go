@@ -0,0 +1,112 @@
+package proposals
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestHashBeforeRedactions(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		history []RedactedChange
+		want    string
+		ok      bool
+	}{
+		{"never redacted", "a", nil, "a", true},
+		{"redacted once", "b", []RedactedChange{{OldContentHash: "a", NewContentHash: "b"}}, "a", true},
+		{"redacted twice", "c", []RedactedChange{
+			{OldContentHash: "a", NewContentHash: "b"},
+			{OldContentHash: "b", NewContentHash: "c"},
+		}, "a", true},
+		{"content changed after redaction", "x", []RedactedChange{{OldContentHash: "a", NewContentHash: "b"}}, "", false},
+		{"gap between redactions", "c", []RedactedChange{
+			{OldContentHash: "a", NewContentHash: "b"},
+			{OldContentHash: "z", NewContentHash: "c"},
+		}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := hashBeforeRedactions(tt.current, tt.history)
+			if got != tt.want || ok != tt.ok {
+				t.Fatalf("got %q, %v; want %q, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+// testChain links manifests the way appendChainEntryInTx does.
+func testChain(t *testing.T, kinds ...string) []*ChainEntry {
+	t.Helper()
+	prevHash := genesisHash
+	entries := make([]*ChainEntry, 0, len(kinds))
+	for i, kind := range kinds {
+		manifest, err := json.Marshal(redactionManifest{
+			ProposalID: "p", DecisionID: fmt.Sprintf("d%d", i), RedactionID: "r",
+			Changes: []RedactedChange{{ID: "c", OldContentHash: "a", NewContentHash: "b"}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		contentHash := sha256Hex(manifest)
+		e := &ChainEntry{
+			Seq: int64(i + 1), Kind: kind, ProposalID: "p", DecisionID: fmt.Sprintf("d%d", i),
+			Manifest: manifest, ContentHash: contentHash, PrevHash: prevHash, EntryHash: chainHash(prevHash, contentHash),
+		}
+		entries = append(entries, e)
+		prevHash = e.EntryHash
+	}
+	return entries
+}
+
+func TestCheckChainEntryDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(entries []*ChainEntry)
+		want   string
+	}{
+		{"intact", func([]*ChainEntry) {}, ""},
+		{"manifest edited", func(e []*ChainEntry) {
+			e[1].Manifest = json.RawMessage(`{"proposal_id":"p","decision_id":"d1"}`)
+		}, "manifest does not match its content hash"},
+		{"content hash recomputed", func(e []*ChainEntry) {
+			e[1].Manifest = json.RawMessage(`{"proposal_id":"p","decision_id":"d1"}`)
+			e[1].ContentHash = sha256Hex(e[1].Manifest)
+		}, "entry_hash does not match prev_hash and content_hash"},
+		{"entry relinked", func(e []*ChainEntry) {
+			e[1].PrevHash = genesisHash
+			e[1].EntryHash = chainHash(genesisHash, e[1].ContentHash)
+		}, "prev_hash does not match the previous entry"},
+		{"entry dropped", func(e []*ChainEntry) {
+			e[1].Seq = 3
+		}, "entry 2 is missing"},
+		{"redaction moved to another decision", func(e []*ChainEntry) {
+			e[1].DecisionID = "other"
+		}, "redaction manifest does not match its entry"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := testChain(t, "removal", "redaction", "removal")
+			tt.tamper(entries)
+
+			var got string
+			prevHash := genesisHash
+			for i, e := range entries {
+				reason, err := checkChainEntry(e, int64(i+1), prevHash, false, nil, context.Background())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if reason != "" {
+					got = reason
+					break
+				}
+				prevHash = e.EntryHash
+			}
+			if got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package proposals
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"granth/internal/audit"
+	"granth/internal/utils"
+	"granth/internal/workspaces"
+)
+
+// requireReviewRequester lets the author, or anyone who may review,
+// manage who is asked to review a proposal.
+func requireReviewRequester(proposalID string, ctx context.Context) (*Proposal, string, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return nil, "", fmt.Errorf("user ID not found in context")
+	}
+	proposal, err := GetProposalByID(proposalID, ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("error fetching proposal: %w", err)
+	}
+	perm := workspaces.PermReview
+	if proposal.AuthorID == userID {
+		perm = workspaces.PermPropose
+	}
+	if err := requireDocumentPermission(proposal.DocumentID, perm, ctx); err != nil {
+		return nil, "", err
+	}
+	return proposal, userID, nil
+}
+
+func listReviewRequests(proposalID string, ctx context.Context) ([]*ReviewRequest, error) {
+	if _, err := requireProposalPermission(proposalID, workspaces.PermRead, ctx); err != nil {
+		return nil, err
+	}
+	requests, err := GetReviewRequestsByProposal(proposalID, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching review requests: %w", err)
+	}
+	return requests, nil
+}
+
+func listMyReviewRequests(ctx context.Context) ([]*ReviewRequest, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+	requests, err := GetOpenReviewRequestsForUser(userID, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching review requests: %w", err)
+	}
+	return requests, nil
+}
+
+// requestReview asks a workspace member whose role can review, a guest
+// with review access, or a team of the proposal's workspace, to review an
+// open proposal.
+func requestReview(proposalID string, reviewerUserID, reviewerTeamID *string, ctx context.Context) (*ReviewRequest, error) {
+	if (reviewerUserID == nil) == (reviewerTeamID == nil) {
+		return nil, fmt.Errorf("exactly one of user_id and team_id is required")
+	}
+	proposal, userID, err := requireReviewRequester(proposalID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if proposal.State != string(ProposalStatusOpen) {
+		return nil, fmt.Errorf("proposal is not open")
+	}
+	workspaceID, err := GetDocumentWorkspaceID(proposal.DocumentID, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching document workspace: %w", err)
+	}
+	if workspaceID == "" {
+		return nil, fmt.Errorf("review requests need a document in a workspace")
+	}
+
+	if reviewerUserID != nil {
+		canReview, err := workspaces.MemberHasPermission(workspaceID, *reviewerUserID, workspaces.PermReview, ctx)
+		if err == nil && !canReview {
+			canReview, err = workspaces.GuestHasPermission(workspaceID, *reviewerUserID, proposal.DocumentID, proposal.ID, workspaces.PermReview, ctx)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error checking reviewer: %w", err)
+		}
+		if !canReview {
+			return nil, fmt.Errorf("requested reviewer cannot review in this workspace")
+		}
+	} else {
+		team, err := workspaces.GetTeam(*reviewerTeamID, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching team: %w", err)
+		}
+		if team == nil || team.WorkspaceID != workspaceID {
+			return nil, fmt.Errorf("team not found")
+		}
+	}
+
+	rr := &ReviewRequest{
+		ProposalID:     proposalID,
+		ReviewerUserID: reviewerUserID,
+		ReviewerTeamID: reviewerTeamID,
+		RequestedBy:    &userID,
+		CreatedAt:      time.Now().UTC().Format(time.RFC3339),
+	}
+	created, err := CreateReviewRequest(rr, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error creating review request: %w", err)
+	}
+	if !created {
+		return nil, fmt.Errorf("review already requested")
+	}
+	audit.Record(audit.Entry{WorkspaceID: workspaceID, Action: "review_request.created", TargetType: "proposal", TargetID: proposalID, After: rr}, ctx)
+	return rr, nil
+}
+
+func cancelReviewRequest(proposalID, requestID string, ctx context.Context) error {
+	proposal, _, err := requireReviewRequester(proposalID, ctx)
+	if err != nil {
+		return err
+	}
+	rr, err := GetReviewRequest(proposalID, requestID, ctx)
+	if err != nil {
+		return fmt.Errorf("error fetching review request: %w", err)
+	}
+	if rr == nil {
+		return fmt.Errorf("review request not found")
+	}
+	if rr.Steward {
+		return fmt.Errorf("steward review requests cannot be cancelled")
+	}
+	deleted, err := DeleteReviewRequest(proposalID, requestID, ctx)
+	if err != nil {
+		return fmt.Errorf("error deleting review request: %w", err)
+	}
+	if !deleted {
+		return fmt.Errorf("review request not found")
+	}
+	audit.Record(audit.Entry{DocumentID: proposal.DocumentID, Action: "review_request.cancelled", TargetType: "proposal", TargetID: proposalID, Before: rr}, ctx)
+	return nil
+}
+
+// answerReviewRequest records the caller's approval or decline of a request
+// addressed to them directly or to one of their teams. Guests answer the
+// requests made of them the same way.
+func answerReviewRequest(proposalID, requestID string, approve bool, reason string, ctx context.Context) (*ReviewRequest, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+	if utils.IsAgentContext(ctx) {
+		return nil, fmt.Errorf("agents cannot answer review requests")
+	}
+	if err := requireVerifiedReviewer(userID, ctx); err != nil {
+		return nil, err
+	}
+	proposal, err := requireProposalPermission(proposalID, workspaces.PermReview, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if proposal.State != string(ProposalStatusOpen) {
+		return nil, fmt.Errorf("proposal is not open")
+	}
+	if proposal.AuthorID == userID {
+		return nil, fmt.Errorf("authors cannot review their own proposal")
+	}
+
+	rr, err := GetReviewRequest(proposalID, requestID, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching review request: %w", err)
+	}
+	if rr == nil {
+		return nil, fmt.Errorf("review request not found")
+	}
+	addressed := rr.ReviewerUserID != nil && *rr.ReviewerUserID == userID
+	if rr.ReviewerTeamID != nil {
+		team, err := workspaces.GetTeam(*rr.ReviewerTeamID, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching team: %w", err)
+		}
+		if team != nil {
+			for _, m := range team.Members {
+				if m.UserID == userID {
+					addressed = true
+				}
+			}
+		}
+	}
+	if !addressed {
+		return nil, fmt.Errorf("%w: this review was requested from someone else", workspaces.ErrPermissionDenied)
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	var updated bool
+	if approve {
+		updated, err = ApproveReviewRequest(rr.ID, userID, now, ctx)
+	} else {
+		updated, err = DeclineReviewRequest(rr.ID, userID, reason, now, ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error answering review request: %w", err)
+	}
+	if !updated {
+		return nil, fmt.Errorf("review request was already answered")
+	}
+	action := "review_request.approved"
+	if approve {
+		rr.ApprovedBy, rr.ApprovedAt = &userID, &now
+	} else {
+		action = "review_request.declined"
+		rr.DeclinedBy, rr.DeclinedAt = &userID, &now
+		if reason != "" {
+			rr.DeclineReason = &reason
+		}
+	}
+	audit.Record(audit.Entry{DocumentID: proposal.DocumentID, Action: action, TargetType: "proposal", TargetID: proposalID, After: rr}, ctx)
+	return rr, nil
+}
@@ -0,0 +1,68 @@
+package proposals
+
+import (
+	"errors"
+	"testing"
+
+	"granth/internal/config"
+	"granth/internal/testdb"
+	"granth/internal/workspaces"
+)
+
+func createTeam(t *testing.T, workspaceID string, members ...string) string {
+	t.Helper()
+	var id string
+	if err := config.PostgresDB.QueryRow(
+		`INSERT INTO teams (workspace_id, name) VALUES ($1, $2) RETURNING id`, workspaceID, "team "+workspaceID,
+	).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range members {
+		if _, err := config.PostgresDB.Exec(`INSERT INTO team_members (team_id, user_id) VALUES ($1, $2)`, id, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return id
+}
+
+func TestTeamReviewRequestIsAnsweredByATeamMember(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	author := testdb.CreateUser(t)
+	onTeam := testdb.CreateUser(t)
+	offTeam := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	otherWorkspaceID := testdb.CreateWorkspace(t, owner)
+	testdb.AddMember(t, workspaceID, author, "contributor")
+	testdb.AddMember(t, workspaceID, onTeam, "reviewer")
+	testdb.AddMember(t, workspaceID, offTeam, "reviewer")
+	documentID := testdb.CreateDocument(t, workspaceID, owner)
+	proposalID := testdb.CreateProposal(t, documentID, author)
+	teamID := createTeam(t, workspaceID, onTeam)
+	foreignTeamID := createTeam(t, otherWorkspaceID, onTeam)
+
+	if _, err := requestReview(proposalID, nil, &foreignTeamID, testdb.As(author)); err == nil || err.Error() != "team not found" {
+		t.Fatalf("requesting another workspace's team: got %v", err)
+	}
+	rr, err := requestReview(proposalID, nil, &teamID, testdb.As(author))
+	if err != nil {
+		t.Fatalf("requesting team review: %v", err)
+	}
+	if _, err := requestReview(proposalID, nil, &teamID, testdb.As(author)); err == nil || err.Error() != "review already requested" {
+		t.Fatalf("requesting the same team twice: got %v", err)
+	}
+
+	if _, err := answerReviewRequest(proposalID, rr.ID, true, "", testdb.As(offTeam)); !errors.Is(err, workspaces.ErrPermissionDenied) {
+		t.Fatalf("reviewer outside the team: got %v, want permission denied", err)
+	}
+	answered, err := answerReviewRequest(proposalID, rr.ID, true, "", testdb.As(onTeam))
+	if err != nil {
+		t.Fatalf("team member approving: %v", err)
+	}
+	if answered.ApprovedBy == nil || *answered.ApprovedBy != onTeam {
+		t.Fatalf("approved by %v, want %s", answered.ApprovedBy, onTeam)
+	}
+	if _, err := answerReviewRequest(proposalID, rr.ID, false, "late", testdb.As(onTeam)); err == nil || err.Error() != "review request was already answered" {
+		t.Fatalf("answering twice: got %v", err)
+	}
+}
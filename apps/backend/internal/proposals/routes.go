@@ -2,23 +2,64 @@ package proposals
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
+
+	"granth/internal/ai"
+	"granth/internal/auth"
+	"granth/internal/utils"
+	"granth/internal/workspaces"
 
 	"github.com/go-chi/chi/v5"
 )
 
+// CreateLimit caps how many proposals one user, token or agent can open,
+// so a runaway script cannot flood reviewers.
+var CreateLimit = utils.RateLimit{Name: "proposal_create", Limit: 60, Window: time.Hour}
+
 func ProposalsRouter() http.Handler {
 	r := chi.NewRouter()
 
-	r.Get("/document/{documentID}", handleGetProposalsForDocument)
-	r.Post("/document/{documentID}", handleCreateProposal)
-	r.Get("/{id}", handleGetProposal)
-	r.Put("/{id}", handleUpdateProposal)
-	r.Delete("/{id}", handleDeleteProposal)
-	r.Post("/{id}/accept", handleAcceptProposal)
-	r.Post("/{id}/reject", handleRejectProposal)
-	r.Get("/{id}/changes", handleGetBlockChangesForProposal)
-	r.Post("/{id}/changes", handleAddBlockChangeToProposal)
+	read := utils.RequireScope(utils.ScopeRead)
+	propose := utils.RequireScope(utils.ScopePropose)
+	review := utils.RequireScope(utils.ScopeReview)
+	admin := utils.RequireScope(utils.ScopeAdmin)
+
+	r.With(read).Get("/review-requests", handleListMyReviewRequests)
+	r.With(read).Get("/stewards/document/{documentID}", handleListStewards)
+	r.With(admin).Post("/stewards/document/{documentID}", handleCreateSteward)
+	r.With(admin).Delete("/stewards/document/{documentID}/{stewardID}", handleDeleteSteward)
+	r.With(read).Get("/chain/document/{documentID}", handleGetDocumentChain)
+	r.With(read).Get("/chain/document/{documentID}/verify", handleVerifyDocumentChain)
+	r.With(read).Get("/chain/document/{documentID}/checkpoints", handleListChainCheckpoints)
+	r.With(admin).Post("/chain/document/{documentID}/checkpoints", handleCreateChainCheckpoint)
+	r.With(read).Get("/chain/document/{documentID}/checkpoints/{checkpointID}", handleExportChainCheckpoint)
+	r.With(read).Get("/document/{documentID}", handleGetProposalsForDocument)
+	r.With(propose, utils.RateLimitMiddleware(CreateLimit, utils.KeyByUser)).Post("/document/{documentID}", handleCreateProposal)
+	r.With(read).Get("/{id}", handleGetProposal)
+	r.With(propose).Put("/{id}", handleUpdateProposal)
+	r.With(propose).Delete("/{id}", handleDeleteProposal)
+	r.With(review).Post("/{id}/accept", handleAcceptProposal)
+	r.With(review).Post("/{id}/reject", handleRejectProposal)
+	r.With(read).Get("/{id}/changes", handleGetBlockChangesForProposal)
+	r.With(propose).Post("/{id}/changes", handleAddBlockChangeToProposal)
+	r.With(read).Get("/{id}/summary", handleGetProposalSummary)
+	r.With(read).Post("/{id}/summary", handleSummarizeProposal)
+	r.With(read).Get("/{id}/summary/flags", handleGetProposalSummaryFlags)
+	r.With(review).Post("/{id}/summary/flags", handleFlagProposalSummary)
+	r.With(read).Get("/{id}/comments", handleListProposalComments)
+	r.With(review).Post("/{id}/comments", handleCreateProposalComment)
+	r.With(read).Get("/{id}/synthesis", handleGetProposalSynthesis)
+	r.With(review).Post("/{id}/synthesis", handleSynthesizeProposalDiscussion)
+	r.With(read).Get("/{id}/synthesis/versions", handleListProposalSyntheses)
+	r.With(read).Get("/{id}/decision", handleGetProposalDecision)
+	r.With(read).Get("/{id}/review-requests", handleListReviewRequests)
+	r.With(propose).Post("/{id}/review-requests", handleRequestReview)
+	r.With(propose).Delete("/{id}/review-requests/{requestID}", handleCancelReviewRequest)
+	r.With(review).Post("/{id}/review-requests/{requestID}/approve", handleApproveReviewRequest)
+	r.With(review).Post("/{id}/review-requests/{requestID}/decline", handleDeclineReviewRequest)
 
 	return r
 }
@@ -27,6 +68,9 @@ func handleGetProposalsForDocument(w http.ResponseWriter, r *http.Request) {
 	documentID := chi.URLParam(r, "documentID")
 	proposals, err := getProposalsForDocument(documentID, r.Context())
 	if err != nil {
+		if writePermissionError(w, err) {
+			return
+		}
 		http.Error(w, "Error fetching proposals: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -51,6 +95,9 @@ func handleCreateProposal(w http.ResponseWriter, r *http.Request) {
 
 	proposalID, err := createProposal(documentID, req.Title, req.Intent, req.Scope, req.AffectedBlockIDs, r.Context())
 	if err != nil {
+		if writePermissionError(w, err) {
+			return
+		}
 		http.Error(w, "Error creating proposal: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -64,6 +111,9 @@ func handleGetProposal(w http.ResponseWriter, r *http.Request) {
 	proposalID := chi.URLParam(r, "id")
 	proposal, err := getProposal(proposalID, r.Context())
 	if err != nil {
+		if writePermissionError(w, err) {
+			return
+		}
 		http.Error(w, "Error fetching proposal: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -98,27 +148,47 @@ func handleUpdateProposal(w http.ResponseWriter, r *http.Request) {
 func handleDeleteProposal(w http.ResponseWriter, r *http.Request) {
 	proposalID := chi.URLParam(r, "id")
 
-	// TODO: Check permissions
-	err := DeleteProposal(proposalID, r.Context())
+	err := deleteProposal(proposalID, r.Context())
 	if err != nil {
+		if writePermissionError(w, err) {
+			return
+		}
+		if err.Error() == "signed proposals cannot be deleted" ||
+			errors.Is(err, workspaces.ErrLegalHold) || errors.Is(err, workspaces.ErrRetained) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, "Error deleting proposal: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	err = DeleteChangesByProposal(proposalID, r.Context())
-	if err != nil {
-		http.Error(w, "Error deleting changes: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
 	w.WriteHeader(http.StatusOK)
 }
 
 func handleAcceptProposal(w http.ResponseWriter, r *http.Request) {
 	proposalID := chi.URLParam(r, "id")
 
-	err := acceptProposal(proposalID, r.Context())
+	var req struct {
+		SynthesisID *string           `json:"synthesis_id"`
+		Signature   *SignatureRequest `json:"signature"`
+	}
+	// Body is optional; without synthesis_id the latest synthesis is attached
+	json.NewDecoder(r.Body).Decode(&req)
+
+	err := acceptProposal(proposalID, req.SynthesisID, req.Signature, r.Context())
 	if err != nil {
+		if err.Error() == "agents cannot accept proposals" || err.Error() == "email address must be verified to review proposals" ||
+			errors.Is(err, workspaces.ErrPermissionDenied) || errors.Is(err, workspaces.ErrTwoFactorRequired) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if err.Error() == "proposal is not open" || errors.Is(err, ErrStewardSignOffRequired) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if writeSignatureError(w, err) {
+			return
+		}
 		http.Error(w, "Error accepting proposal: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -130,13 +200,27 @@ func handleRejectProposal(w http.ResponseWriter, r *http.Request) {
 	proposalID := chi.URLParam(r, "id")
 
 	var req struct {
-		Reason string `json:"reason"`
+		Reason      string            `json:"reason"`
+		SynthesisID *string           `json:"synthesis_id"`
+		Signature   *SignatureRequest `json:"signature"`
 	}
 	// Reason is enforced by the UI; decode best-effort here
 	json.NewDecoder(r.Body).Decode(&req)
 
-	err := rejectProposal(proposalID, req.Reason, r.Context())
+	err := rejectProposal(proposalID, req.Reason, req.SynthesisID, req.Signature, r.Context())
 	if err != nil {
+		if err.Error() == "agents cannot reject proposals" || err.Error() == "email address must be verified to review proposals" ||
+			errors.Is(err, workspaces.ErrPermissionDenied) || errors.Is(err, workspaces.ErrTwoFactorRequired) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if err.Error() == "proposal is not open" {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if writeSignatureError(w, err) {
+			return
+		}
 		http.Error(w, "Error rejecting proposal: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -144,10 +228,42 @@ func handleRejectProposal(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// writePermissionError sends 403 when the caller's workspace role does not
+// allow the action. It reports whether err was such a failure.
+func writePermissionError(w http.ResponseWriter, err error) bool {
+	if errors.Is(err, workspaces.ErrPermissionDenied) || errors.Is(err, workspaces.ErrTwoFactorRequired) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return true
+	}
+	return false
+}
+
+// writeSignatureError maps electronic signature failures. It reports
+// whether err was one of them.
+func writeSignatureError(w http.ResponseWriter, err error) bool {
+	var limited *auth.RateLimitedError
+	switch {
+	case errors.As(err, &limited):
+		utils.WriteTooManyRequests(w, limited.Message, limited.RetryAfter)
+	case errors.Is(err, ErrSignatureRequired) || err.Error() == "re-authentication failed" ||
+		err.Error() == "electronic signatures require an interactive session" ||
+		err.Error() == "set up two-factor authentication to confirm this action":
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case err.Error() == "signature meaning is required" || err.Error() == "signature meaning is not allowed in this workspace":
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		return false
+	}
+	return true
+}
+
 func handleGetBlockChangesForProposal(w http.ResponseWriter, r *http.Request) {
 	proposalID := chi.URLParam(r, "id")
 	changes, err := getBlockChangesForProposal(proposalID, r.Context())
 	if err != nil {
+		if writePermissionError(w, err) {
+			return
+		}
 		http.Error(w, "Error fetching changes: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -173,9 +289,476 @@ func handleAddBlockChangeToProposal(w http.ResponseWriter, r *http.Request) {
 
 	err := addBlockChangeToProposal(proposalID, req.BlockID, req.Action, req.BlockType, req.OrderPath, req.Content, r.Context())
 	if err != nil {
+		if writePermissionError(w, err) {
+			return
+		}
+		if err.Error() == "changes can only be added to open proposals" {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, "Error adding change: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
 }
+
+func handleGetProposalSummary(w http.ResponseWriter, r *http.Request) {
+	proposalID := chi.URLParam(r, "id")
+	summary, err := getProposalSummary(proposalID, r.Context())
+	if err != nil {
+		if writePermissionError(w, err) {
+			return
+		}
+		if err.Error() == "summary not found" {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Error fetching summary: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
+func handleSummarizeProposal(w http.ResponseWriter, r *http.Request) {
+	proposalID := chi.URLParam(r, "id")
+	summary, err := summarizeProposal(proposalID, r.Context())
+	if err != nil {
+		if writePermissionError(w, err) {
+			return
+		}
+		if errors.Is(err, ai.ErrNotConfigured) {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		http.Error(w, "Error generating summary: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
+func handleGetProposalSummaryFlags(w http.ResponseWriter, r *http.Request) {
+	proposalID := chi.URLParam(r, "id")
+	flags, err := listProposalSummaryFlags(proposalID, r.Context())
+	if err != nil {
+		if writePermissionError(w, err) {
+			return
+		}
+		if err.Error() == "summary not found" {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Error fetching flags: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(flags)
+}
+
+func handleFlagProposalSummary(w http.ResponseWriter, r *http.Request) {
+	proposalID := chi.URLParam(r, "id")
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	flag, err := flagProposalSummary(proposalID, req.Reason, r.Context())
+	if err != nil {
+		if writePermissionError(w, err) {
+			return
+		}
+		switch err.Error() {
+		case "summary not found":
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case "reason is required":
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case "email address must be verified to review proposals":
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			http.Error(w, "Error flagging summary: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(flag)
+}
+
+func handleListProposalComments(w http.ResponseWriter, r *http.Request) {
+	proposalID := chi.URLParam(r, "id")
+	comments, err := listProposalComments(proposalID, r.Context())
+	if err != nil {
+		if writePermissionError(w, err) {
+			return
+		}
+		http.Error(w, "Error fetching comments: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comments)
+}
+
+func handleCreateProposalComment(w http.ResponseWriter, r *http.Request) {
+	proposalID := chi.URLParam(r, "id")
+
+	var req struct {
+		ParentID *string `json:"parent_id"`
+		Body     string  `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	comment, err := createProposalComment(proposalID, req.ParentID, req.Body, r.Context())
+	if err != nil {
+		if writePermissionError(w, err) {
+			return
+		}
+		switch err.Error() {
+		case "comment body is required", "comment not found", "parent comment belongs to a different proposal":
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Error creating comment: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(comment)
+}
+
+func handleGetProposalSynthesis(w http.ResponseWriter, r *http.Request) {
+	proposalID := chi.URLParam(r, "id")
+	synthesis, err := getProposalSynthesis(proposalID, r.Context())
+	if err != nil {
+		if writePermissionError(w, err) {
+			return
+		}
+		if err.Error() == "synthesis not found" {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Error fetching synthesis: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(synthesis)
+}
+
+func handleSynthesizeProposalDiscussion(w http.ResponseWriter, r *http.Request) {
+	proposalID := chi.URLParam(r, "id")
+	synthesis, err := synthesizeProposalDiscussion(proposalID, r.Context())
+	if err != nil {
+		if writePermissionError(w, err) {
+			return
+		}
+		if errors.Is(err, ai.ErrNotConfigured) {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if err.Error() == "no discussion to synthesize" {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Error generating synthesis: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(synthesis)
+}
+
+func handleListProposalSyntheses(w http.ResponseWriter, r *http.Request) {
+	proposalID := chi.URLParam(r, "id")
+	versions, err := listProposalSyntheses(proposalID, r.Context())
+	if err != nil {
+		if writePermissionError(w, err) {
+			return
+		}
+		http.Error(w, "Error fetching syntheses: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(versions)
+}
+
+func handleGetProposalDecision(w http.ResponseWriter, r *http.Request) {
+	proposalID := chi.URLParam(r, "id")
+	decision, err := getProposalDecision(proposalID, r.Context())
+	if err != nil {
+		if writePermissionError(w, err) {
+			return
+		}
+		if err.Error() == "decision not found" {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Error fetching decision: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(decision)
+}
+
+func handleListMyReviewRequests(w http.ResponseWriter, r *http.Request) {
+	requests, err := listMyReviewRequests(r.Context())
+	if err != nil {
+		http.Error(w, "Error fetching review requests: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(requests)
+}
+
+func handleListReviewRequests(w http.ResponseWriter, r *http.Request) {
+	requests, err := listReviewRequests(chi.URLParam(r, "id"), r.Context())
+	if err != nil {
+		if writePermissionError(w, err) {
+			return
+		}
+		http.Error(w, "Error fetching review requests: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(requests)
+}
+
+func handleRequestReview(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID *string `json:"user_id"`
+		TeamID *string `json:"team_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	request, err := requestReview(chi.URLParam(r, "id"), req.UserID, req.TeamID, r.Context())
+	if err != nil {
+		writeReviewRequestError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(request)
+}
+
+func handleCancelReviewRequest(w http.ResponseWriter, r *http.Request) {
+	if err := cancelReviewRequest(chi.URLParam(r, "id"), chi.URLParam(r, "requestID"), r.Context()); err != nil {
+		writeReviewRequestError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeReviewRequestError(w http.ResponseWriter, err error) {
+	if writePermissionError(w, err) {
+		return
+	}
+	switch err.Error() {
+	case "review request not found", "team not found":
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case "review already requested", "proposal is not open", "review request was already answered",
+		"steward review requests cannot be cancelled":
+		http.Error(w, err.Error(), http.StatusConflict)
+	case "agents cannot answer review requests", "authors cannot review their own proposal",
+		"email address must be verified to review proposals":
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case "exactly one of user_id and team_id is required", "requested reviewer cannot review in this workspace",
+		"review requests need a document in a workspace":
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Error updating review requests: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func handleApproveReviewRequest(w http.ResponseWriter, r *http.Request) {
+	request, err := answerReviewRequest(chi.URLParam(r, "id"), chi.URLParam(r, "requestID"), true, "", r.Context())
+	if err != nil {
+		writeReviewRequestError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(request)
+}
+
+func handleDeclineReviewRequest(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	// Reason is optional
+	json.NewDecoder(r.Body).Decode(&req)
+
+	request, err := answerReviewRequest(chi.URLParam(r, "id"), chi.URLParam(r, "requestID"), false, req.Reason, r.Context())
+	if err != nil {
+		writeReviewRequestError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(request)
+}
+
+func handleListStewards(w http.ResponseWriter, r *http.Request) {
+	stewards, err := listStewards(chi.URLParam(r, "documentID"), r.Context())
+	if err != nil {
+		if writePermissionError(w, err) {
+			return
+		}
+		http.Error(w, "Error fetching stewards: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stewards)
+}
+
+func handleCreateSteward(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		BlockID    *string `json:"block_id"`
+		PathPrefix []int64 `json:"path_prefix"`
+		UserID     *string `json:"user_id"`
+		TeamID     *string `json:"team_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	steward, err := createSteward(chi.URLParam(r, "documentID"), req.BlockID, req.PathPrefix, req.UserID, req.TeamID, r.Context())
+	if err != nil {
+		writeStewardError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(steward)
+}
+
+func handleDeleteSteward(w http.ResponseWriter, r *http.Request) {
+	if err := deleteSteward(chi.URLParam(r, "documentID"), chi.URLParam(r, "stewardID"), r.Context()); err != nil {
+		writeStewardError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeStewardError(w http.ResponseWriter, err error) {
+	if writePermissionError(w, err) {
+		return
+	}
+	switch err.Error() {
+	case "steward not found", "block not found", "team not found":
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case "exactly one of user_id and team_id is required", "a steward rule covers a block or a path prefix, not both",
+		"path_prefix entries cannot be negative", "stewards need a document in a workspace",
+		"steward cannot review in this workspace":
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Error updating stewards: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func handleGetDocumentChain(w http.ResponseWriter, r *http.Request) {
+	entries, err := getDocumentChain(chi.URLParam(r, "documentID"), r.Context())
+	if err != nil {
+		if writePermissionError(w, err) {
+			return
+		}
+		http.Error(w, "Error fetching chain: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+func handleVerifyDocumentChain(w http.ResponseWriter, r *http.Request) {
+	result, err := verifyDocumentChain(chi.URLParam(r, "documentID"), r.Context())
+	if err != nil {
+		if writePermissionError(w, err) {
+			return
+		}
+		http.Error(w, "Error verifying chain: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func handleListChainCheckpoints(w http.ResponseWriter, r *http.Request) {
+	checkpoints, err := listChainCheckpoints(chi.URLParam(r, "documentID"), r.Context())
+	if err != nil {
+		if writePermissionError(w, err) {
+			return
+		}
+		http.Error(w, "Error fetching checkpoints: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(checkpoints)
+}
+
+func handleCreateChainCheckpoint(w http.ResponseWriter, r *http.Request) {
+	checkpoint, err := createChainCheckpoint(chi.URLParam(r, "documentID"), r.Context())
+	if err != nil {
+		if writePermissionError(w, err) {
+			return
+		}
+		switch err.Error() {
+		case "cannot checkpoint a broken chain", "nothing to checkpoint yet":
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Error creating checkpoint: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(checkpoint)
+}
+
+// handleExportChainCheckpoint serves one checkpoint as a file to keep
+// outside Granth.
+func handleExportChainCheckpoint(w http.ResponseWriter, r *http.Request) {
+	checkpoint, err := getChainCheckpoint(chi.URLParam(r, "documentID"), chi.URLParam(r, "checkpointID"), r.Context())
+	if err != nil {
+		if writePermissionError(w, err) {
+			return
+		}
+		if err.Error() == "checkpoint not found" {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Error fetching checkpoint: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="checkpoint-%s-%d.json"`, checkpoint.DocumentID, checkpoint.Seq))
+	json.NewEncoder(w).Encode(checkpoint)
+}
@@ -0,0 +1,332 @@
+package proposals
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"granth/internal/config"
+	"granth/internal/testdb"
+	"granth/internal/utils"
+)
+
+func TestDeleteProposalRequiresAuthorOrPropose(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	author := testdb.CreateUser(t)
+	outsider := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	testdb.AddMember(t, workspaceID, author, "contributor")
+	documentID := testdb.CreateDocument(t, workspaceID, owner)
+	byAuthor := testdb.CreateProposal(t, documentID, author)
+	byOwner := testdb.CreateProposal(t, documentID, owner)
+
+	tests := []struct {
+		name       string
+		userID     string
+		proposalID string
+		want       int
+	}{
+		{"non-member", outsider, byAuthor, http.StatusForbidden},
+		{"author", author, byAuthor, http.StatusOK},
+		{"member with propose", author, byOwner, http.StatusOK},
+	}
+	router := ProposalsRouter()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, "/"+tt.proposalID, nil).WithContext(testdb.As(tt.userID))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("DELETE as %s: got %d (%s), want %d", tt.name, rec.Code, rec.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscussionEndpointsRequireMembership(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	outsider := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	documentID := testdb.CreateDocument(t, workspaceID, owner)
+	proposalID := testdb.CreateProposal(t, documentID, owner)
+
+	endpoints := []struct {
+		method, path string
+	}{
+		{http.MethodGet, "/comments"},
+		{http.MethodGet, "/synthesis"},
+		{http.MethodPost, "/synthesis"},
+		{http.MethodGet, "/synthesis/versions"},
+		{http.MethodGet, "/decision"},
+	}
+	router := ProposalsRouter()
+	for _, e := range endpoints {
+		t.Run(e.method+" "+e.path, func(t *testing.T) {
+			req := httptest.NewRequest(e.method, "/"+proposalID+e.path, nil).WithContext(testdb.As(outsider))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusForbidden {
+				t.Fatalf("outsider: got %d (%s), want 403", rec.Code, rec.Body.String())
+			}
+
+			req = httptest.NewRequest(e.method, "/"+proposalID+e.path, nil).WithContext(testdb.As(owner))
+			rec = httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code == http.StatusForbidden {
+				t.Fatalf("member: got 403 (%s)", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestDiscussionWritesNeedReviewScope(t *testing.T) {
+	router := ProposalsRouter()
+	for _, path := range []string{"/comments", "/synthesis"} {
+		for _, scopes := range [][]string{{utils.ScopeRead}, {utils.ScopePropose}} {
+			ctx := utils.WithClaims(context.Background(), &utils.Claims{UserID: "u", Authorized: true, TokenType: "pat", Scopes: scopes})
+			req := httptest.NewRequest(http.MethodPost, "/p"+path, strings.NewReader(`{"body":"x"}`)).WithContext(ctx)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "scope: review") {
+				t.Fatalf("POST %s with %v: got %d (%s), want 403 for the review scope", path, scopes, rec.Code, rec.Body.String())
+			}
+		}
+	}
+}
+
+func TestSummaryEndpointsRequireMembership(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	outsider := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	documentID := testdb.CreateDocument(t, workspaceID, owner)
+	proposalID := testdb.CreateProposal(t, documentID, owner)
+
+	endpoints := []struct {
+		method, path, body string
+	}{
+		{http.MethodGet, "/summary", ""},
+		{http.MethodPost, "/summary", ""},
+		{http.MethodGet, "/summary/flags", ""},
+		{http.MethodPost, "/summary/flags", `{"reason":"wrong"}`},
+	}
+	router := ProposalsRouter()
+	for _, e := range endpoints {
+		t.Run(e.method+" "+e.path, func(t *testing.T) {
+			req := httptest.NewRequest(e.method, "/"+proposalID+e.path, strings.NewReader(e.body)).WithContext(testdb.As(outsider))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusForbidden {
+				t.Fatalf("outsider: got %d (%s), want 403", rec.Code, rec.Body.String())
+			}
+
+			req = httptest.NewRequest(e.method, "/"+proposalID+e.path, strings.NewReader(e.body)).WithContext(testdb.As(owner))
+			rec = httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code == http.StatusForbidden {
+				t.Fatalf("member: got 403 (%s)", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestUnverifiedReviewerIsRefused(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	reviewer := testdb.CreateUnverifiedUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	testdb.AddMember(t, workspaceID, reviewer, "admin")
+	documentID := testdb.CreateDocument(t, workspaceID, owner)
+	proposalID := testdb.CreateProposal(t, documentID, owner)
+	var requestID string
+	err := config.PostgresDB.QueryRow(
+		`INSERT INTO proposal_review_requests (proposal_id, reviewer_user_id, requested_by) VALUES ($1, $2, $3) RETURNING id`,
+		proposalID, reviewer, owner,
+	).Scan(&requestID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	endpoints := []struct {
+		path, body string
+	}{
+		{"/accept", ""},
+		{"/reject", `{"reason":"no"}`},
+		{"/summary/flags", `{"reason":"wrong"}`},
+		{"/review-requests/" + requestID + "/approve", ""},
+		{"/review-requests/" + requestID + "/decline", `{"reason":"no"}`},
+	}
+	router := ProposalsRouter()
+	for _, e := range endpoints {
+		t.Run(e.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/"+proposalID+e.path, strings.NewReader(e.body)).WithContext(testdb.As(reviewer))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "must be verified") {
+				t.Fatalf("got %d (%s), want 403 for an unverified email", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestProposalReadsRequireMembership(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	member := testdb.CreateUser(t)
+	outsider := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	testdb.AddMember(t, workspaceID, member, "contributor")
+	documentID := testdb.CreateDocument(t, workspaceID, owner)
+	proposalID := testdb.CreateProposal(t, documentID, owner)
+
+	router := ProposalsRouter()
+	for _, path := range []string{"/" + proposalID, "/" + proposalID + "/changes", "/document/" + documentID} {
+		t.Run(path, func(t *testing.T) {
+			for _, tt := range []struct {
+				userID string
+				want   int
+			}{{outsider, http.StatusForbidden}, {member, http.StatusOK}} {
+				req := httptest.NewRequest(http.MethodGet, path, nil).WithContext(testdb.As(tt.userID))
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, req)
+				if rec.Code != tt.want {
+					t.Fatalf("got %d (%s), want %d", rec.Code, rec.Body.String(), tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestProposalGuestGrantIsScopedAndExpires(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	guest := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	documentID := testdb.CreateDocument(t, workspaceID, owner)
+	shared := testdb.CreateProposal(t, documentID, owner)
+	other := testdb.CreateProposal(t, documentID, owner)
+	var grantID string
+	err := config.PostgresDB.QueryRow(
+		`INSERT INTO guest_grants (workspace_id, user_id, proposal_id, permissions, granted_by, expires_at)
+		 VALUES ($1, $2, $3, ARRAY['read'], $4, now() + interval '1 day') RETURNING id`,
+		workspaceID, guest, shared, owner,
+	).Scan(&grantID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := ProposalsRouter()
+	get := func(path string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil).WithContext(testdb.As(guest))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	for _, tt := range []struct {
+		path string
+		want int
+	}{
+		{"/" + shared, http.StatusOK},
+		{"/" + shared + "/changes", http.StatusOK},
+		{"/" + other, http.StatusForbidden},
+		{"/document/" + documentID, http.StatusForbidden},
+	} {
+		if got := get(tt.path); got != tt.want {
+			t.Fatalf("GET %s: got %d, want %d", tt.path, got, tt.want)
+		}
+	}
+	var uses int
+	err = config.PostgresDB.QueryRow(
+		`SELECT count(*) FROM guest_grant_events WHERE grant_id = $1 AND action = 'used' AND target_id = $2`, grantID, shared,
+	).Scan(&uses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uses == 0 {
+		t.Fatal("guest reads were not recorded as grant uses")
+	}
+
+	if _, err := config.PostgresDB.Exec(`UPDATE guest_grants SET expires_at = now() - interval '1 minute' WHERE id = $1`, grantID); err != nil {
+		t.Fatal(err)
+	}
+	if got := get("/" + shared); got != http.StatusForbidden {
+		t.Fatalf("expired grant: got %d, want 403", got)
+	}
+
+	if _, err := config.PostgresDB.Exec(
+		`UPDATE guest_grants SET expires_at = now() + interval '1 day', revoked_at = now(), revoked_by = $2 WHERE id = $1`, grantID, owner,
+	); err != nil {
+		t.Fatal(err)
+	}
+	if got := get("/" + shared); got != http.StatusForbidden {
+		t.Fatalf("revoked grant: got %d, want 403", got)
+	}
+}
+
+func TestDocumentGuestGrantCoversItsProposals(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	guest := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	documentID := testdb.CreateDocument(t, workspaceID, owner)
+	otherDocumentID := testdb.CreateDocument(t, workspaceID, owner)
+	proposalID := testdb.CreateProposal(t, documentID, owner)
+	_, err := config.PostgresDB.Exec(
+		`INSERT INTO guest_grants (workspace_id, user_id, document_id, permissions, granted_by, expires_at)
+		 VALUES ($1, $2, $3, ARRAY['read'], $4, now() + interval '1 day')`,
+		workspaceID, guest, documentID, owner,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := ProposalsRouter()
+	for _, tt := range []struct {
+		path string
+		want int
+	}{
+		{"/document/" + documentID, http.StatusOK},
+		{"/" + proposalID, http.StatusOK},
+		{"/document/" + otherDocumentID, http.StatusForbidden},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil).WithContext(testdb.As(guest))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Fatalf("GET %s: got %d (%s), want %d", tt.path, rec.Code, rec.Body.String(), tt.want)
+		}
+	}
+}
+
+func TestDecidingAClosedProposalConflicts(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	documentID := testdb.CreateDocument(t, workspaceID, owner)
+	proposalID := testdb.CreateProposal(t, documentID, owner)
+	if _, err := config.PostgresDB.Exec(`UPDATE proposals SET state = 'rejected' WHERE id = $1`, proposalID); err != nil {
+		t.Fatal(err)
+	}
+
+	router := ProposalsRouter()
+	for _, e := range []struct {
+		path, body string
+	}{
+		{"/accept", ""},
+		{"/reject", `{"reason":"again"}`},
+	} {
+		t.Run(e.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/"+proposalID+e.path, strings.NewReader(e.body)).WithContext(testdb.As(owner))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "proposal is not open") {
+				t.Fatalf("got %d (%s), want 409 proposal is not open", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -2,9 +2,17 @@ package proposals
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"granth/internal/ai"
+	"granth/internal/audit"
+	"granth/internal/auth"
 	"granth/internal/config"
+	"granth/internal/encryption"
+	"granth/internal/reasoning"
 	"granth/internal/utils"
+	"granth/internal/workspaces"
 	"time"
 
 	"github.com/lib/pq"
@@ -15,6 +23,9 @@ func createProposal(documentID string, title string, intent string, scope string
 	if !ok {
 		return "", fmt.Errorf("user ID not found in context")
 	}
+	if err := requireDocumentPermission(documentID, workspaces.PermPropose, ctx); err != nil {
+		return "", err
+	}
 
 	proposal := &Proposal{
 		DocumentID:       documentID,
@@ -32,19 +43,55 @@ func createProposal(documentID string, title string, intent string, scope string
 	if err != nil {
 		return "", fmt.Errorf("error creating proposal: %w", err)
 	}
+	audit.Record(audit.Entry{DocumentID: documentID, Action: "proposal.created", TargetType: "proposal", TargetID: proposal.ID, After: proposal}, ctx)
+
+	requestStewardReviews(proposal.ID, ctx)
+	go refreshProposalSummary(proposal.ID)
 
 	return proposal.ID, nil
 }
 
-func getProposal(proposalID string, ctx context.Context) (*Proposal, error) {
-	proposal, err := GetProposalByID(proposalID, ctx)
-	if err != nil {
-		return nil, fmt.Errorf("error fetching proposal: %w", err)
+// SubmitProposal stores a proposal together with its full change set. It is
+// used by callers that submit proposals as data in one request, such as the
+// agent API, rather than building them up change by change.
+func SubmitProposal(proposal *Proposal, changes []*ProposalBlockChange, ctx context.Context) error {
+	if err := CreateProposalWithChanges(proposal, changes, ctx); err != nil {
+		return fmt.Errorf("error creating proposal: %w", err)
 	}
-	return proposal, nil
+	audit.Record(audit.Entry{
+		DocumentID: proposal.DocumentID, Action: "proposal.created", TargetType: "proposal", TargetID: proposal.ID,
+		After: map[string]interface{}{"proposal": proposal, "changes": newChangeEvents(changes)},
+	}, ctx)
+
+	requestStewardReviews(proposal.ID, ctx)
+	go refreshProposalSummary(proposal.ID)
+
+	return nil
+}
+
+func newChangeEvent(c *ProposalBlockChange) *changeEvent {
+	return &changeEvent{
+		ID: c.ID, ProposalID: c.ProposalID, BlockID: c.BlockID, Action: c.Action, BlockType: c.BlockType,
+		OrderPath: c.OrderPath, ContentHash: audit.HashContent(c.Content), CreatedBy: c.CreatedBy,
+	}
+}
+
+func newChangeEvents(changes []*ProposalBlockChange) []*changeEvent {
+	events := make([]*changeEvent, 0, len(changes))
+	for _, c := range changes {
+		events = append(events, newChangeEvent(c))
+	}
+	return events
+}
+
+func getProposal(proposalID string, ctx context.Context) (*Proposal, error) {
+	return requireProposalPermission(proposalID, workspaces.PermRead, ctx)
 }
 
 func getProposalsForDocument(documentID string, ctx context.Context) ([]*Proposal, error) {
+	if err := requireDocumentPermission(documentID, workspaces.PermRead, ctx); err != nil {
+		return nil, err
+	}
 	proposals, err := GetProposalsByDocument(documentID, ctx)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching proposals for document: %w", err)
@@ -67,6 +114,7 @@ func updateProposal(proposalID string, title string, intent string, scope string
 		return fmt.Errorf("only author can update proposal")
 	}
 
+	before := *proposal
 	proposal.Title = title
 	proposal.Intent = intent
 	proposal.Scope = scope
@@ -77,19 +125,102 @@ func updateProposal(proposalID string, title string, intent string, scope string
 	if err != nil {
 		return fmt.Errorf("error updating proposal: %w", err)
 	}
+	audit.Record(audit.Entry{DocumentID: proposal.DocumentID, Action: "proposal.updated", TargetType: "proposal", TargetID: proposalID, Before: before, After: proposal}, ctx)
+
+	requestStewardReviews(proposalID, ctx)
+	go refreshProposalSummary(proposalID)
+
+	return nil
+}
 
+// requireVerifiedReviewer keeps accounts that have not confirmed their email
+// address from making decisions.
+func requireVerifiedReviewer(userID string, ctx context.Context) error {
+	verified, err := auth.IsEmailVerified(userID, ctx)
+	if err != nil {
+		return fmt.Errorf("error checking email verification: %w", err)
+	}
+	if !verified {
+		return fmt.Errorf("email address must be verified to review proposals")
+	}
 	return nil
 }
 
-func acceptProposal(proposalID string, ctx context.Context) error {
+// requireDocumentPermission checks the caller's workspace role, and with
+// it the workspace 2FA policy, before acting on a document. Guests whose
+// grant covers the document may read it; they comment and review through
+// requireProposalPermission. Documents outside any workspace are not
+// restricted.
+func requireDocumentPermission(documentID string, perm workspaces.Permission, ctx context.Context) error {
+	workspaceID, err := GetDocumentWorkspaceID(documentID, ctx)
+	if err != nil {
+		return fmt.Errorf("error fetching document workspace: %w", err)
+	}
+	if workspaceID == "" {
+		return nil
+	}
+	err = workspaces.RequirePermission(workspaceID, perm, ctx)
+	if perm == workspaces.PermRead && errors.Is(err, workspaces.ErrPermissionDenied) {
+		if guestErr := workspaces.RequireGuestPermission(workspaceID, documentID, "", perm, ctx); guestErr == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// requireProposalPermission is requireDocumentPermission for the document
+// a proposal targets. Guests whose grant covers the proposal or its
+// document pass as well.
+func requireProposalPermission(proposalID string, perm workspaces.Permission, ctx context.Context) (*Proposal, error) {
+	proposal, err := GetProposalByID(proposalID, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching proposal: %w", err)
+	}
+	workspaceID, err := GetDocumentWorkspaceID(proposal.DocumentID, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching document workspace: %w", err)
+	}
+	if workspaceID == "" {
+		return proposal, nil
+	}
+	err = workspaces.RequirePermission(workspaceID, perm, ctx)
+	if errors.Is(err, workspaces.ErrPermissionDenied) {
+		if guestErr := workspaces.RequireGuestPermission(workspaceID, proposal.DocumentID, proposal.ID, perm, ctx); guestErr == nil {
+			return proposal, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return proposal, nil
+}
+
+func acceptProposal(proposalID string, synthesisID *string, signatureReq *SignatureRequest, ctx context.Context) error {
 	userID, ok := utils.GetUserIDFromContext(ctx)
 	if !ok {
 		return fmt.Errorf("user ID not found in context")
 	}
+	// Humans retain the decision at all times (NORTHSTAR §9)
+	if utils.IsAgentContext(ctx) {
+		return fmt.Errorf("agents cannot accept proposals")
+	}
+	if err := requireVerifiedReviewer(userID, ctx); err != nil {
+		return err
+	}
 
-	proposal, err := GetProposalByID(proposalID, ctx)
+	proposal, err := requireProposalPermission(proposalID, workspaces.PermAccept, ctx)
 	if err != nil {
-		return fmt.Errorf("error fetching proposal: %w", err)
+		return err
+	}
+	if proposal.State != string(ProposalStatusOpen) {
+		return fmt.Errorf("proposal is not open")
+	}
+	if err := requireStewardSignOff(proposal, ctx); err != nil {
+		return err
+	}
+	signature, err := verifySignature(proposal, signatureReq, ctx)
+	if err != nil {
+		return err
 	}
 
 	changes, err := GetChangesByProposal(proposalID, ctx)
@@ -97,6 +228,11 @@ func acceptProposal(proposalID string, ctx context.Context) error {
 		return fmt.Errorf("error fetching block changes: %w", err)
 	}
 
+	attachedSynthesis, err := reasoning.ResolveDecisionSynthesis(proposalID, synthesisID, ctx)
+	if err != nil {
+		return fmt.Errorf("error resolving synthesis: %w", err)
+	}
+
 	tx, err := config.PostgresDB.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("error starting transaction: %w", err)
@@ -106,18 +242,24 @@ func acceptProposal(proposalID string, ctx context.Context) error {
 	now := time.Now().UTC().Format(time.RFC3339)
 
 	for _, change := range changes {
+		var content string
+		if change.Action == "create" || change.Action == "update" {
+			if content, err = encryption.SealForDocument(proposal.DocumentID, change.Content, ctx); err != nil {
+				return fmt.Errorf("error encrypting block change: %w", err)
+			}
+		}
 		switch change.Action {
 		case "create":
 			_, err = tx.ExecContext(ctx,
 				"INSERT INTO blocks (document_id, order_path, type, content, created_by, created_at, updated_at, updated_by) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
-				proposal.DocumentID, pq.Array(change.OrderPath), change.BlockType, change.Content, userID, now, now, userID)
+				proposal.DocumentID, pq.Array(change.OrderPath), change.BlockType, content, userID, now, now, userID)
 		case "update":
 			if change.BlockID == nil {
 				continue
 			}
 			_, err = tx.ExecContext(ctx,
 				"UPDATE blocks SET type = $1, content = $2, updated_at = $3, updated_by = $4 WHERE id = $5",
-				change.BlockType, change.Content, now, userID, *change.BlockID)
+				change.BlockType, content, now, userID, *change.BlockID)
 		case "delete":
 			if change.BlockID == nil {
 				continue
@@ -138,29 +280,114 @@ func acceptProposal(proposalID string, ctx context.Context) error {
 		return fmt.Errorf("error updating proposal state: %w", err)
 	}
 
-	return tx.Commit()
+	decision := &Decision{
+		ProposalID:          proposalID,
+		Outcome:             string(ProposalStatusAccepted),
+		DecidedBy:           userID,
+		SynthesisArtifactID: attachedSynthesis,
+		DecidedAt:           now,
+	}
+	if err := CreateDecisionInTx(tx, decision, ctx); err != nil {
+		return fmt.Errorf("error recording decision: %w", err)
+	}
+	if err := recordSignatureInTx(tx, signature, decision, changes, ctx); err != nil {
+		return err
+	}
+	if err := chainDecisionInTx(tx, proposal.DocumentID, decision, changes, ctx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	audit.Record(audit.Entry{
+		DocumentID: proposal.DocumentID, Action: "proposal.accepted", TargetType: "proposal", TargetID: proposalID,
+		Before: map[string]string{"state": proposal.State},
+		After:  map[string]interface{}{"state": decision.Outcome, "decision_id": decision.ID, "changes": newChangeEvents(changes)},
+	}, ctx)
+	return nil
 }
 
-func rejectProposal(proposalID string, reason string, ctx context.Context) error {
-	_, ok := utils.GetUserIDFromContext(ctx)
+func rejectProposal(proposalID string, reason string, synthesisID *string, signatureReq *SignatureRequest, ctx context.Context) error {
+	userID, ok := utils.GetUserIDFromContext(ctx)
 	if !ok {
 		return fmt.Errorf("user ID not found in context")
 	}
+	if utils.IsAgentContext(ctx) {
+		return fmt.Errorf("agents cannot reject proposals")
+	}
+	if err := requireVerifiedReviewer(userID, ctx); err != nil {
+		return err
+	}
 
-	proposal, err := GetProposalByID(proposalID, ctx)
+	proposal, err := requireProposalPermission(proposalID, workspaces.PermAccept, ctx)
 	if err != nil {
-		return fmt.Errorf("error fetching proposal: %w", err)
+		return err
+	}
+	if proposal.State != string(ProposalStatusOpen) {
+		return fmt.Errorf("proposal is not open")
+	}
+	signature, err := verifySignature(proposal, signatureReq, ctx)
+	if err != nil {
+		return err
+	}
+	// The chain, and a signature when given, attest to the changes that
+	// were turned down
+	changes, err := GetChangesByProposal(proposalID, ctx)
+	if err != nil {
+		return fmt.Errorf("error fetching block changes: %w", err)
+	}
+
+	attachedSynthesis, err := reasoning.ResolveDecisionSynthesis(proposalID, synthesisID, ctx)
+	if err != nil {
+		return fmt.Errorf("error resolving synthesis: %w", err)
 	}
 
+	tx, err := config.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	previousState := proposal.State
 	proposal.State = string(ProposalStatusRejected)
 	proposal.RejectionReason = &reason
-	proposal.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	proposal.UpdatedAt = now
 
-	err = UpdateProposal(proposal, ctx)
+	_, err = tx.ExecContext(ctx,
+		"UPDATE proposals SET state = $1, rejection_reason = $2, updated_at = $3 WHERE id = $4",
+		proposal.State, proposal.RejectionReason, proposal.UpdatedAt, proposalID)
 	if err != nil {
 		return fmt.Errorf("error rejecting proposal: %w", err)
 	}
 
+	decision := &Decision{
+		ProposalID:          proposalID,
+		Outcome:             string(ProposalStatusRejected),
+		DecidedBy:           userID,
+		Reason:              &reason,
+		SynthesisArtifactID: attachedSynthesis,
+		DecidedAt:           now,
+	}
+	if err := CreateDecisionInTx(tx, decision, ctx); err != nil {
+		return fmt.Errorf("error recording decision: %w", err)
+	}
+	if err := recordSignatureInTx(tx, signature, decision, changes, ctx); err != nil {
+		return err
+	}
+	if err := chainDecisionInTx(tx, proposal.DocumentID, decision, changes, ctx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	audit.Record(audit.Entry{
+		DocumentID: proposal.DocumentID, Action: "proposal.rejected", TargetType: "proposal", TargetID: proposalID,
+		Before: map[string]string{"state": previousState},
+		After:  map[string]interface{}{"state": decision.Outcome, "decision_id": decision.ID, "reason": reason},
+	}, ctx)
 	return nil
 }
 
@@ -169,6 +396,14 @@ func addBlockChangeToProposal(proposalID string, blockID *string, action string,
 	if !ok {
 		return fmt.Errorf("user ID not found in context")
 	}
+	proposal, err := requireProposalPermission(proposalID, workspaces.PermPropose, ctx)
+	if err != nil {
+		return err
+	}
+	// decided change sets are sealed in the document's chain
+	if proposal.State != string(ProposalStatusOpen) {
+		return fmt.Errorf("changes can only be added to open proposals")
+	}
 
 	change := &ProposalBlockChange{
 		ProposalID: proposalID,
@@ -181,18 +416,289 @@ func addBlockChangeToProposal(proposalID string, blockID *string, action string,
 		CreatedAt:  time.Now().UTC().Format(time.RFC3339),
 	}
 
-	err := CreateProposalBlockChange(change, ctx)
+	err = CreateProposalBlockChange(change, ctx)
 	if err != nil {
 		return fmt.Errorf("error adding block change: %w", err)
 	}
+	audit.Record(audit.Entry{DocumentID: proposal.DocumentID, Action: "proposal.change_added", TargetType: "proposal", TargetID: proposalID, After: newChangeEvent(change)}, ctx)
+
+	requestStewardReviews(proposalID, ctx)
+	go refreshProposalSummary(proposalID)
 
 	return nil
 }
 
 func getBlockChangesForProposal(proposalID string, ctx context.Context) ([]*ProposalBlockChange, error) {
+	if _, err := requireProposalPermission(proposalID, workspaces.PermRead, ctx); err != nil {
+		return nil, err
+	}
 	changes, err := GetChangesByProposal(proposalID, ctx)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching block changes: %w", err)
 	}
 	return changes, nil
 }
+
+func getProposalSummary(proposalID string, ctx context.Context) (*reasoning.Artifact, error) {
+	if _, err := requireProposalPermission(proposalID, workspaces.PermRead, ctx); err != nil {
+		return nil, err
+	}
+	return latestSummary(proposalID, ctx)
+}
+
+func latestSummary(proposalID string, ctx context.Context) (*reasoning.Artifact, error) {
+	summary, err := reasoning.GetLatestSummary(proposalID, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching summary: %w", err)
+	}
+	if summary == nil {
+		return nil, fmt.Errorf("summary not found")
+	}
+	return summary, nil
+}
+
+func summarizeProposal(proposalID string, ctx context.Context) (*reasoning.Artifact, error) {
+	if _, err := requireProposalPermission(proposalID, workspaces.PermRead, ctx); err != nil {
+		return nil, err
+	}
+	return generateSummary(proposalID, ctx)
+}
+
+// generateSummary has no permission check; it also runs after edits.
+func generateSummary(proposalID string, ctx context.Context) (*reasoning.Artifact, error) {
+	proposal, err := GetProposalByID(proposalID, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching proposal: %w", err)
+	}
+
+	changes, err := GetChangesByProposal(proposalID, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching block changes: %w", err)
+	}
+
+	input := reasoning.SummaryInput{
+		ProposalID: proposal.ID,
+		Title:      proposal.Title,
+		Intent:     proposal.Intent,
+		Scope:      proposal.Scope,
+		Changes:    make([]reasoning.ChangeInput, 0, len(changes)),
+	}
+	for _, c := range changes {
+		input.Changes = append(input.Changes, reasoning.ChangeInput{
+			Action:    c.Action,
+			BlockType: c.BlockType,
+			BlockID:   c.BlockID,
+			Content:   c.Content,
+		})
+	}
+
+	return reasoning.SummarizeProposal(input, ctx)
+}
+
+// refreshProposalSummary regenerates the stored summary after the proposal's
+// intent, scope or change set was edited. It runs detached from the request
+// so a slow or unavailable provider never blocks proposal editing.
+func refreshProposalSummary(proposalID string) {
+	if ai.DefaultProvider == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	if _, err := generateSummary(proposalID, ctx); err != nil {
+		config.Logger.Printf("error refreshing summary for proposal %s: %v", proposalID, err)
+	}
+}
+
+func flagProposalSummary(proposalID, reason string, ctx context.Context) (*reasoning.ArtifactFlag, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+	if err := requireVerifiedReviewer(userID, ctx); err != nil {
+		return nil, err
+	}
+	if _, err := requireProposalPermission(proposalID, workspaces.PermReview, ctx); err != nil {
+		return nil, err
+	}
+	summary, err := latestSummary(proposalID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return reasoning.FlagArtifact(proposalID, summary.ID, reason, ctx)
+}
+
+func listProposalSummaryFlags(proposalID string, ctx context.Context) ([]*reasoning.ArtifactFlag, error) {
+	summary, err := getProposalSummary(proposalID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return reasoning.GetArtifactFlags(summary.ID, ctx)
+}
+
+func createProposalComment(proposalID string, parentID *string, body string, ctx context.Context) (*reasoning.Comment, error) {
+	proposal, err := requireProposalPermission(proposalID, workspaces.PermComment, ctx)
+	if err != nil {
+		return nil, err
+	}
+	comment, err := reasoning.CreateComment(proposalID, parentID, body, ctx)
+	if err != nil {
+		return nil, err
+	}
+	audit.Record(audit.Entry{DocumentID: proposal.DocumentID, Action: "comment.created", TargetType: "proposal", TargetID: proposalID, After: newCommentEvent(comment)}, ctx)
+	return comment, nil
+}
+
+func newCommentEvent(c *reasoning.Comment) *commentEvent {
+	return &commentEvent{ID: c.ID, ProposalID: c.ProposalID, ParentID: c.ParentID, AuthorID: c.AuthorID, BodyHash: audit.HashContent(c.Body)}
+}
+
+func listProposalComments(proposalID string, ctx context.Context) ([]*reasoning.Comment, error) {
+	if _, err := requireProposalPermission(proposalID, workspaces.PermRead, ctx); err != nil {
+		return nil, err
+	}
+	return reasoning.ListComments(proposalID, ctx)
+}
+
+func getProposalSynthesis(proposalID string, ctx context.Context) (*reasoning.Artifact, error) {
+	if _, err := requireProposalPermission(proposalID, workspaces.PermRead, ctx); err != nil {
+		return nil, err
+	}
+	synthesis, err := reasoning.GetLatestSynthesis(proposalID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if synthesis == nil {
+		return nil, fmt.Errorf("synthesis not found")
+	}
+	return synthesis, nil
+}
+
+func listProposalSyntheses(proposalID string, ctx context.Context) ([]*reasoning.Artifact, error) {
+	if _, err := requireProposalPermission(proposalID, workspaces.PermRead, ctx); err != nil {
+		return nil, err
+	}
+	return reasoning.ListSyntheses(proposalID, ctx)
+}
+
+func getProposalDecision(proposalID string, ctx context.Context) (*Decision, error) {
+	if _, err := requireProposalPermission(proposalID, workspaces.PermRead, ctx); err != nil {
+		return nil, err
+	}
+	decision, err := GetDecisionByProposal(proposalID, ctx)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("decision not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching decision: %w", err)
+	}
+	if decision.Signature, err = GetDecisionSignature(decision.ID, ctx); err != nil {
+		return nil, fmt.Errorf("error fetching signature: %w", err)
+	}
+	return decision, nil
+}
+
+// deleteProposal moves a proposal to the trash unless its decision was
+// signed, a legal hold covers it, or a retention policy still keeps it.
+func deleteProposal(proposalID string, ctx context.Context) error {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("user ID not found in context")
+	}
+	proposal, err := GetProposalByID(proposalID, ctx)
+	if err != nil {
+		return fmt.Errorf("error fetching proposal: %w", err)
+	}
+	// authors may withdraw their own proposals; anyone else needs propose
+	if proposal.AuthorID != userID {
+		if _, err := requireProposalPermission(proposalID, workspaces.PermPropose, ctx); err != nil {
+			return err
+		}
+	}
+	if err := checkProposalRemovable(proposalID, ctx); err != nil {
+		return err
+	}
+	if _, err := TrashProposal(proposalID, userID, ctx); err != nil {
+		return fmt.Errorf("error deleting proposal: %w", err)
+	}
+	audit.Record(audit.Entry{DocumentID: proposal.DocumentID, Action: "proposal.deleted", TargetType: "proposal", TargetID: proposalID, Before: proposal}, ctx)
+	return nil
+}
+
+// RestoreProposal takes a proposal out of the trash. Callers check the
+// restore window and permissions.
+func RestoreProposal(proposalID string, ctx context.Context) error {
+	proposal, err := GetProposalIncludingTrashed(proposalID, ctx)
+	if err != nil {
+		return fmt.Errorf("error fetching proposal: %w", err)
+	}
+	restored, err := RestoreTrashedProposal(proposalID, ctx)
+	if err != nil {
+		return fmt.Errorf("error restoring proposal: %w", err)
+	}
+	if !restored {
+		return fmt.Errorf("proposal is not in the trash")
+	}
+	audit.Record(audit.Entry{DocumentID: proposal.DocumentID, Action: "proposal.restored", TargetType: "proposal", TargetID: proposalID, Before: proposal}, ctx)
+	return nil
+}
+
+// PurgeProposal deletes a proposal for good, trashed or not, under the same
+// rules as deleteProposal. A decided proposal leaves a removal entry in the
+// document's chain.
+func PurgeProposal(proposalID string, ctx context.Context) error {
+	if err := checkProposalRemovable(proposalID, ctx); err != nil {
+		return err
+	}
+	proposal, err := GetProposalIncludingTrashed(proposalID, ctx)
+	if err != nil {
+		return fmt.Errorf("error fetching proposal: %w", err)
+	}
+	decision, err := GetDecisionByProposal(proposalID, ctx)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("error fetching decision: %w", err)
+	}
+
+	tx, err := config.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if decision != nil {
+		if err := chainRemovalInTx(tx, proposal.DocumentID, decision, ctx); err != nil {
+			return err
+		}
+	}
+	if err := DeleteProposalInTx(tx, proposalID, ctx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	audit.Record(audit.Entry{DocumentID: proposal.DocumentID, Action: "proposal.purged", TargetType: "proposal", TargetID: proposalID, Before: proposal}, ctx)
+	return nil
+}
+
+// checkProposalRemovable returns why a proposal may not be deleted, if it
+// may not.
+func checkProposalRemovable(proposalID string, ctx context.Context) error {
+	signed, err := HasDecisionSignature(proposalID, ctx)
+	if err != nil {
+		return fmt.Errorf("error checking signature: %w", err)
+	}
+	if signed {
+		return fmt.Errorf("signed proposals cannot be deleted")
+	}
+	return workspaces.CheckProposalDeletable(proposalID, ctx)
+}
+
+// synthesizeProposalDiscussion calls the AI provider, so it needs comment
+// rather than read.
+func synthesizeProposalDiscussion(proposalID string, ctx context.Context) (*reasoning.Artifact, error) {
+	proposal, err := requireProposalPermission(proposalID, workspaces.PermComment, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return reasoning.SynthesizeDiscussion(proposal.ID, proposal.Title, proposal.Intent, ctx)
+}
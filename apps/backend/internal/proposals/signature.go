@@ -0,0 +1,184 @@
+package proposals
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"granth/internal/auth"
+	"granth/internal/utils"
+	"granth/internal/workspaces"
+)
+
+// ErrSignatureRequired is returned when a workspace is in electronic
+// signature mode and a decision arrives without a signature.
+var ErrSignatureRequired = errors.New("this workspace requires an electronic signature for decisions")
+
+// verifySignature re-authenticates the reviewer and checks the meaning
+// against the workspace policy. It returns nil when the workspace does not
+// require a signature and none was given; the caller seals the result
+// inside the decision transaction.
+func verifySignature(proposal *Proposal, req *SignatureRequest, ctx context.Context) (*DecisionSignature, error) {
+	workspaceID, err := GetDocumentWorkspaceID(proposal.DocumentID, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching document workspace: %w", err)
+	}
+	policy := &workspaces.SignaturePolicy{}
+	if workspaceID != "" {
+		if policy, err = workspaces.GetSignaturePolicy(workspaceID, ctx); err != nil {
+			return nil, err
+		}
+	}
+	if req == nil {
+		if policy.Required {
+			return nil, ErrSignatureRequired
+		}
+		return nil, nil
+	}
+
+	claims, ok := utils.GetClaimsFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+	// A signature attests that the person was present, which a stored
+	// token cannot
+	if claims.TokenType != "access" {
+		return nil, fmt.Errorf("electronic signatures require an interactive session")
+	}
+
+	meaning := strings.TrimSpace(req.Meaning)
+	if meaning == "" {
+		return nil, fmt.Errorf("signature meaning is required")
+	}
+	if len(policy.Meanings) > 0 && !slices.Contains(policy.Meanings, meaning) {
+		return nil, fmt.Errorf("signature meaning is not allowed in this workspace")
+	}
+
+	method, err := auth.Reauthenticate(claims.UserID, req.StepUpCredentials, ctx)
+	if err != nil {
+		return nil, err
+	}
+	signerName, _, _, err := auth.GetUserByID(claims.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching signer: %w", err)
+	}
+
+	return &DecisionSignature{
+		ProposalID: proposal.ID,
+		SignerID:   claims.UserID,
+		SignerName: signerName,
+		Meaning:    meaning,
+		Method:     method,
+	}, nil
+}
+
+// signatureManifestVersion is written with each signature so its
+// manifest_hash can be rebuilt with the layout it was sealed with.
+// Version 0 hashed the API representation of the changes and cannot be
+// rebuilt.
+const signatureManifestVersion = 1
+
+// signatureManifest is what a signature attests to. Field order is fixed
+// so the hash can be recomputed from the stored records.
+type signatureManifest struct {
+	Version    int            `json:"version"`
+	ProposalID string         `json:"proposal_id"`
+	DecisionID string         `json:"decision_id"`
+	Outcome    string         `json:"outcome"`
+	Reason     *string        `json:"reason"`
+	SignerID   string         `json:"signer_id"`
+	Meaning    string         `json:"meaning"`
+	SignedAt   string         `json:"signed_at"`
+	Changes    []signedChange `json:"changes"`
+}
+
+// signedChange is a block change as a signature covers it.
+type signedChange struct {
+	ID          string `json:"id"`
+	ContentHash string `json:"content_hash"`
+}
+
+// signatureManifestHash hashes what signature attests to. Changes without
+// a content hash yet are hashed from their content.
+func signatureManifestHash(signature *DecisionSignature, decision *Decision, changes []*ProposalBlockChange) (string, error) {
+	entries := make([]signedChange, 0, len(changes))
+	for _, c := range changes {
+		hash := sha256Hex([]byte(c.Content))
+		if c.ContentHash != nil {
+			hash = *c.ContentHash
+		}
+		entries = append(entries, signedChange{ID: c.ID, ContentHash: hash})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+
+	manifest, err := json.Marshal(signatureManifest{
+		Version:    signatureManifestVersion,
+		ProposalID: signature.ProposalID,
+		DecisionID: decision.ID,
+		Outcome:    decision.Outcome,
+		Reason:     decision.Reason,
+		SignerID:   signature.SignerID,
+		Meaning:    signature.Meaning,
+		SignedAt:   canonicalTime(signature.SignedAt),
+		Changes:    entries,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error encoding signature manifest: %w", err)
+	}
+	return sha256Hex(manifest), nil
+}
+
+// sealSignature binds the signature to the decision content at the time
+// of the decision.
+func sealSignature(signature *DecisionSignature, decision *Decision, changes []*ProposalBlockChange) error {
+	signature.DecisionID = decision.ID
+	signature.SignedAt = decision.DecidedAt
+	signature.ManifestVersion = signatureManifestVersion
+	hash, err := signatureManifestHash(signature, decision, changes)
+	if err != nil {
+		return err
+	}
+	signature.ManifestHash = hash
+	return nil
+}
+
+// checkSignature returns why decision's signature no longer matches the
+// decided changes, or "" when it does or cannot be rebuilt.
+func checkSignature(decision *Decision, changes []*ProposalBlockChange) (string, error) {
+	signature := decision.Signature
+	if signature == nil || signature.ManifestVersion != signatureManifestVersion {
+		return "", nil
+	}
+	if signature.ProposalID != decision.ProposalID {
+		return fmt.Sprintf("signature %s belongs to another proposal", signature.ID), nil
+	}
+	hash, err := signatureManifestHash(signature, decision, changes)
+	if err != nil {
+		return "", err
+	}
+	if hash != signature.ManifestHash {
+		return fmt.Sprintf("signature %s no longer matches its decision", signature.ID), nil
+	}
+	return "", nil
+}
+
+// recordSignatureInTx seals and stores signature with decision. A nil
+// signature is a no-op.
+func recordSignatureInTx(tx *sql.Tx, signature *DecisionSignature, decision *Decision, changes []*ProposalBlockChange, ctx context.Context) error {
+	if signature == nil {
+		return nil
+	}
+	if err := sealSignature(signature, decision, changes); err != nil {
+		return err
+	}
+	if err := CreateDecisionSignatureInTx(tx, signature, ctx); err != nil {
+		return fmt.Errorf("error recording signature: %w", err)
+	}
+	decision.Signature = signature
+	return nil
+}
@@ -0,0 +1,83 @@
+package proposals
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckSignature(t *testing.T) {
+	reason := "looks right"
+	newDecision := func() (*Decision, []*ProposalBlockChange) {
+		decision := &Decision{ID: "d1", ProposalID: "p1", Outcome: "accepted", Reason: &reason, DecidedAt: "2026-01-02T03:04:05Z"}
+		changes := []*ProposalBlockChange{
+			{ID: "c2", Content: "second"},
+			{ID: "c1", Content: "first"},
+		}
+		signature := &DecisionSignature{ProposalID: "p1", SignerID: "u1", Meaning: "approved"}
+		if err := sealSignature(signature, decision, changes); err != nil {
+			t.Fatal(err)
+		}
+		decision.Signature = signature
+		return decision, changes
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(*Decision, []*ProposalBlockChange) []*ProposalBlockChange
+		want   string
+	}{
+		{"untouched", func(d *Decision, c []*ProposalBlockChange) []*ProposalBlockChange { return c }, ""},
+		{"changes reordered", func(d *Decision, c []*ProposalBlockChange) []*ProposalBlockChange {
+			return []*ProposalBlockChange{c[1], c[0]}
+		}, ""},
+		{"hashed after the decision", func(d *Decision, c []*ProposalBlockChange) []*ProposalBlockChange {
+			for _, ch := range c {
+				hash := sha256Hex([]byte(ch.Content))
+				ch.ContentHash = &hash
+			}
+			return c
+		}, ""},
+		{"signed_at stored in another zone", func(d *Decision, c []*ProposalBlockChange) []*ProposalBlockChange {
+			d.Signature.SignedAt = "2026-01-02T05:04:05+02:00"
+			return c
+		}, ""},
+		{"content changed", func(d *Decision, c []*ProposalBlockChange) []*ProposalBlockChange {
+			c[0].Content = "edited"
+			return c
+		}, "no longer matches"},
+		{"change dropped", func(d *Decision, c []*ProposalBlockChange) []*ProposalBlockChange { return c[:1] }, "no longer matches"},
+		{"meaning changed", func(d *Decision, c []*ProposalBlockChange) []*ProposalBlockChange {
+			d.Signature.Meaning = "reviewed"
+			return c
+		}, "no longer matches"},
+		{"signer changed", func(d *Decision, c []*ProposalBlockChange) []*ProposalBlockChange {
+			d.Signature.SignerID = "u2"
+			return c
+		}, "no longer matches"},
+		{"outcome changed", func(d *Decision, c []*ProposalBlockChange) []*ProposalBlockChange {
+			d.Outcome = "rejected"
+			return c
+		}, "no longer matches"},
+		{"moved to another proposal", func(d *Decision, c []*ProposalBlockChange) []*ProposalBlockChange {
+			d.Signature.ProposalID = "p2"
+			return c
+		}, "another proposal"},
+		{"version 0 is not rebuilt", func(d *Decision, c []*ProposalBlockChange) []*ProposalBlockChange {
+			d.Signature.ManifestVersion = 0
+			d.Signature.Meaning = "reviewed"
+			return c
+		}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, changes := newDecision()
+			got, err := checkSignature(decision, tt.tamper(decision, changes))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
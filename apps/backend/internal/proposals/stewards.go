@@ -0,0 +1,311 @@
+package proposals
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"granth/internal/audit"
+	"granth/internal/config"
+	"granth/internal/utils"
+	"granth/internal/workspaces"
+)
+
+// ErrStewardSignOffRequired is returned when a workspace requires steward
+// sign-off and a steward of a touched block has not approved yet.
+var ErrStewardSignOffRequired = errors.New("steward sign-off is required")
+
+// ErrStewardedBlock is returned for direct edits to a stewarded block.
+var ErrStewardedBlock = errors.New("block is stewarded; change it through a proposal")
+
+func listStewards(documentID string, ctx context.Context) ([]*Steward, error) {
+	if err := requireDocumentPermission(documentID, workspaces.PermRead, ctx); err != nil {
+		return nil, err
+	}
+	stewards, err := GetStewardsByDocument(documentID, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching stewards: %w", err)
+	}
+	return stewards, nil
+}
+
+// createSteward adds a steward rule to a document. Stewards have to be able
+// to sign off, so a user steward needs the review permission.
+func createSteward(documentID string, blockID *string, pathPrefix []int64, stewardUserID, stewardTeamID *string, ctx context.Context) (*Steward, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+	if (stewardUserID == nil) == (stewardTeamID == nil) {
+		return nil, fmt.Errorf("exactly one of user_id and team_id is required")
+	}
+	if blockID != nil && len(pathPrefix) > 0 {
+		return nil, fmt.Errorf("a steward rule covers a block or a path prefix, not both")
+	}
+	for _, p := range pathPrefix {
+		if p < 0 {
+			return nil, fmt.Errorf("path_prefix entries cannot be negative")
+		}
+	}
+
+	workspaceID, err := GetDocumentWorkspaceID(documentID, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching document workspace: %w", err)
+	}
+	if workspaceID == "" {
+		return nil, fmt.Errorf("stewards need a document in a workspace")
+	}
+	if err := workspaces.RequirePermission(workspaceID, workspaces.PermManagePolicy, ctx); err != nil {
+		return nil, err
+	}
+
+	if blockID != nil {
+		paths, err := GetBlockPaths(documentID, []string{*blockID}, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching block: %w", err)
+		}
+		if _, ok := paths[*blockID]; !ok {
+			return nil, fmt.Errorf("block not found")
+		}
+	}
+	if stewardUserID != nil {
+		canReview, err := workspaces.MemberHasPermission(workspaceID, *stewardUserID, workspaces.PermReview, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error checking steward: %w", err)
+		}
+		if !canReview {
+			return nil, fmt.Errorf("steward cannot review in this workspace")
+		}
+	} else {
+		team, err := workspaces.GetTeam(*stewardTeamID, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching team: %w", err)
+		}
+		if team == nil || team.WorkspaceID != workspaceID {
+			return nil, fmt.Errorf("team not found")
+		}
+	}
+
+	s := &Steward{
+		DocumentID:    documentID,
+		BlockID:       blockID,
+		StewardUserID: stewardUserID,
+		StewardTeamID: stewardTeamID,
+		CreatedBy:     &userID,
+		CreatedAt:     time.Now().UTC().Format(time.RFC3339),
+	}
+	if len(pathPrefix) > 0 {
+		s.PathPrefix = pathPrefix
+	}
+	if err := CreateSteward(s, ctx); err != nil {
+		return nil, fmt.Errorf("error creating steward: %w", err)
+	}
+	audit.Record(audit.Entry{WorkspaceID: workspaceID, DocumentID: documentID, Action: "steward.created", TargetType: "steward", TargetID: s.ID, After: s}, ctx)
+	return s, nil
+}
+
+func deleteSteward(documentID, stewardID string, ctx context.Context) error {
+	if err := requireDocumentPermission(documentID, workspaces.PermManagePolicy, ctx); err != nil {
+		return err
+	}
+	deleted, err := DeleteSteward(documentID, stewardID, ctx)
+	if err != nil {
+		return fmt.Errorf("error deleting steward: %w", err)
+	}
+	if !deleted {
+		return fmt.Errorf("steward not found")
+	}
+	audit.Record(audit.Entry{DocumentID: documentID, Action: "steward.deleted", TargetType: "steward", TargetID: stewardID}, ctx)
+	return nil
+}
+
+// hasPathPrefix reports whether path lies at or under prefix.
+func hasPathPrefix(path, prefix []int64) bool {
+	if len(path) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// stewardsForProposal returns the document's steward rules that cover a
+// block the proposal touches: its affected blocks, the blocks its changes
+// update or delete, and the positions of blocks it creates.
+func stewardsForProposal(proposal *Proposal, changes []*ProposalBlockChange, ctx context.Context) ([]*Steward, error) {
+	rules, err := GetStewardsByDocument(proposal.DocumentID, ctx)
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+
+	blockIDs := append([]string{}, proposal.AffectedBlockIDs...)
+	var paths [][]int64
+	for _, c := range changes {
+		if c.BlockID != nil {
+			blockIDs = append(blockIDs, *c.BlockID)
+		} else if c.Action == "create" {
+			paths = append(paths, c.OrderPath)
+		}
+	}
+	blockPaths, err := GetBlockPaths(proposal.DocumentID, blockIDs, ctx)
+	if err != nil {
+		return nil, err
+	}
+	touched := make(map[string]bool, len(blockIDs))
+	for _, id := range blockIDs {
+		touched[id] = true
+	}
+	for _, p := range blockPaths {
+		paths = append(paths, p)
+	}
+
+	return matchStewards(rules, touched, paths), nil
+}
+
+// matchStewards returns the rules that cover a touched block ID or one of
+// paths. A rule with neither a block nor a prefix covers the whole document.
+func matchStewards(rules []*Steward, touched map[string]bool, paths [][]int64) []*Steward {
+	var matched []*Steward
+	for _, rule := range rules {
+		switch {
+		case rule.BlockID != nil:
+			if touched[*rule.BlockID] {
+				matched = append(matched, rule)
+			}
+		case len(rule.PathPrefix) > 0:
+			for _, p := range paths {
+				if hasPathPrefix(p, rule.PathPrefix) {
+					matched = append(matched, rule)
+					break
+				}
+			}
+		default:
+			matched = append(matched, rule)
+		}
+	}
+	return matched
+}
+
+// RequireUnstewarded fails with ErrStewardedBlock when a steward rule covers
+// blockID or any of paths. Direct block edits bypass steward sign-off, so
+// stewarded blocks can only change through a proposal.
+func RequireUnstewarded(documentID, blockID string, paths [][]int64, ctx context.Context) error {
+	rules, err := GetStewardsByDocument(documentID, ctx)
+	if err != nil {
+		return fmt.Errorf("error fetching stewards: %w", err)
+	}
+	touched := map[string]bool{}
+	if blockID != "" {
+		touched[blockID] = true
+	}
+	if len(matchStewards(rules, touched, paths)) > 0 {
+		return ErrStewardedBlock
+	}
+	return nil
+}
+
+// syncStewardReviews requests review from every steward of the blocks the
+// proposal touches. Authors are never asked to review their own proposal.
+func syncStewardReviews(proposal *Proposal, ctx context.Context) ([]*Steward, error) {
+	changes, err := GetChangesByProposal(proposal.ID, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching block changes: %w", err)
+	}
+	stewards, err := stewardsForProposal(proposal, changes, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error matching stewards: %w", err)
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, s := range stewards {
+		if s.StewardUserID != nil && *s.StewardUserID == proposal.AuthorID {
+			continue
+		}
+		if err := EnsureStewardReviewRequest(proposal.ID, s.StewardUserID, s.StewardTeamID, now, ctx); err != nil {
+			return nil, fmt.Errorf("error requesting steward review: %w", err)
+		}
+	}
+	return stewards, nil
+}
+
+// requestStewardReviews runs syncStewardReviews after a proposal changes.
+// Failures are logged rather than returned because the proposal itself was
+// saved; acceptance syncs again before it checks sign-off.
+func requestStewardReviews(proposalID string, ctx context.Context) {
+	proposal, err := GetProposalByID(proposalID, ctx)
+	if err == nil {
+		_, err = syncStewardReviews(proposal, ctx)
+	}
+	if err != nil {
+		config.Logger.Printf("error requesting steward reviews for proposal %s: %v", proposalID, err)
+	}
+}
+
+// requireStewardSignOff blocks acceptance until every steward of a touched
+// block has approved, when the workspace asks for it.
+func requireStewardSignOff(proposal *Proposal, ctx context.Context) error {
+	workspaceID, err := GetDocumentWorkspaceID(proposal.DocumentID, ctx)
+	if err != nil {
+		return fmt.Errorf("error fetching document workspace: %w", err)
+	}
+	if workspaceID == "" {
+		return nil
+	}
+	required, err := workspaces.RequiresStewardApproval(workspaceID, ctx)
+	if err != nil || !required {
+		return err
+	}
+
+	stewards, err := syncStewardReviews(proposal, ctx)
+	if err != nil {
+		return err
+	}
+	requests, err := GetReviewRequestsByProposal(proposal.ID, ctx)
+	if err != nil {
+		return fmt.Errorf("error fetching review requests: %w", err)
+	}
+	approved := make(map[string]bool, len(requests))
+	for _, rr := range requests {
+		if rr.ApprovedAt == nil {
+			continue
+		}
+		if rr.ReviewerUserID != nil {
+			approved["user:"+*rr.ReviewerUserID] = true
+		} else if rr.ReviewerTeamID != nil {
+			approved["team:"+*rr.ReviewerTeamID] = true
+		}
+	}
+
+	var waiting []string
+	seen := make(map[string]bool, len(stewards))
+	for _, s := range stewards {
+		key, name := "", ""
+		if s.StewardUserID != nil {
+			if *s.StewardUserID == proposal.AuthorID {
+				continue
+			}
+			key = "user:" + *s.StewardUserID
+			if s.StewardUsername != nil {
+				name = *s.StewardUsername
+			}
+		} else {
+			key = "team:" + *s.StewardTeamID
+			if s.StewardTeamName != nil {
+				name = *s.StewardTeamName
+			}
+		}
+		if approved[key] || seen[key] {
+			continue
+		}
+		seen[key] = true
+		waiting = append(waiting, name)
+	}
+	if len(waiting) > 0 {
+		return fmt.Errorf("%w: waiting on %s", ErrStewardSignOffRequired, strings.Join(waiting, ", "))
+	}
+	return nil
+}
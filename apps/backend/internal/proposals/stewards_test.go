@@ -0,0 +1,108 @@
+package proposals
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+
+	"granth/internal/blocks"
+	"granth/internal/config"
+	"granth/internal/testdb"
+)
+
+func TestMatchStewards(t *testing.T) {
+	blockID := "b1"
+	byBlock := &Steward{ID: "block", BlockID: &blockID}
+	byPrefix := &Steward{ID: "prefix", PathPrefix: pq.Int64Array{2}}
+	whole := &Steward{ID: "whole"}
+	rules := []*Steward{byBlock, byPrefix}
+
+	tests := []struct {
+		name    string
+		rules   []*Steward
+		touched map[string]bool
+		paths   [][]int64
+		want    []string
+	}{
+		{"touched block", rules, map[string]bool{"b1": true}, nil, []string{"block"}},
+		{"path under prefix", rules, nil, [][]int64{{2, 4}}, []string{"prefix"}},
+		{"path outside prefix", rules, nil, [][]int64{{3}}, nil},
+		{"unrelated block", rules, map[string]bool{"b2": true}, [][]int64{{1}}, nil},
+		{"whole document", []*Steward{whole}, nil, nil, []string{"whole"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, s := range matchStewards(tt.rules, tt.touched, tt.paths) {
+				got = append(got, s.ID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestStewardReviewIsRequestedAndRequiredForAcceptance(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	author := testdb.CreateUser(t)
+	steward := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	testdb.AddMember(t, workspaceID, author, "contributor")
+	testdb.AddMember(t, workspaceID, steward, "reviewer")
+	documentID := testdb.CreateDocument(t, workspaceID, owner)
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	block := &blocks.Block{DocumentID: documentID, OrderPath: []int64{1, 2}, BlockType: "paragraph", Content: "x",
+		CreatedBy: owner, CreatedAt: now, UpdatedAt: now, UpdatedBy: owner}
+	if err := blocks.CreateBlock(block, testdb.As(owner)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.PostgresDB.Exec(
+		`INSERT INTO block_stewards (document_id, path_prefix, steward_user_id, created_by) VALUES ($1, '{1}', $2, $3)`,
+		documentID, steward, owner); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.PostgresDB.Exec(`UPDATE workspaces SET require_steward_approval = TRUE WHERE id = $1`, workspaceID); err != nil {
+		t.Fatal(err)
+	}
+	proposalID := testdb.CreateProposal(t, documentID, author)
+	if _, err := config.PostgresDB.Exec(`UPDATE proposals SET affected_block_ids = ARRAY[$2::uuid] WHERE id = $1`, proposalID, block.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := testdb.As(owner)
+	proposal, err := GetProposalByID(proposalID, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := requireStewardSignOff(proposal, ctx); !errors.Is(err, ErrStewardSignOffRequired) {
+		t.Fatalf("before sign-off: got %v, want ErrStewardSignOffRequired", err)
+	}
+
+	requests, err := GetReviewRequestsByProposal(proposalID, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 1 || !requests[0].Steward || requests[0].ReviewerUserID == nil || *requests[0].ReviewerUserID != steward {
+		t.Fatalf("review requests = %+v, want one steward request for the steward", requests)
+	}
+	if err := cancelReviewRequest(proposalID, requests[0].ID, testdb.As(author)); err == nil || err.Error() != "steward review requests cannot be cancelled" {
+		t.Fatalf("cancelling a steward request: got %v", err)
+	}
+
+	if _, err := answerReviewRequest(proposalID, requests[0].ID, true, "", testdb.As(steward)); err != nil {
+		t.Fatalf("steward approving: %v", err)
+	}
+	if err := requireStewardSignOff(proposal, ctx); err != nil {
+		t.Fatalf("after sign-off: %v", err)
+	}
+}
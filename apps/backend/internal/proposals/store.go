@@ -2,32 +2,102 @@ package proposals
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"granth/internal/config"
+	"granth/internal/encryption"
 
 	"github.com/lib/pq"
 )
 
-func CreateProposal(proposal *Proposal, ctx context.Context) error {
-	err := config.PostgresDB.QueryRowContext(ctx,
-		"INSERT INTO proposals (document_id, affected_block_ids, title, author_id, intent, scope, state, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id",
-		proposal.DocumentID, pq.Array(proposal.AffectedBlockIDs), proposal.Title, proposal.AuthorID, proposal.Intent, proposal.Scope, proposal.State, proposal.CreatedAt, proposal.UpdatedAt).Scan(&proposal.ID)
-	return err
+const proposalColumns = "id, document_id, affected_block_ids, title, author_id, intent, scope, state, rejection_reason, created_at, updated_at, author_type, agent_account_id, agent_reasoning, deleted_at, deleted_by"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
 }
 
-func GetProposalByID(id string, ctx context.Context) (*Proposal, error) {
+func scanProposal(row rowScanner) (*Proposal, error) {
 	proposal := &Proposal{}
 	var affectedBlockIDs pq.StringArray
-	err := config.PostgresDB.QueryRowContext(ctx, "SELECT id, document_id, affected_block_ids, title, author_id, intent, scope, state, rejection_reason, created_at, updated_at FROM proposals WHERE id = $1", id).Scan(
-		&proposal.ID, &proposal.DocumentID, &affectedBlockIDs, &proposal.Title, &proposal.AuthorID, &proposal.Intent, &proposal.Scope, &proposal.State, &proposal.RejectionReason, &proposal.CreatedAt, &proposal.UpdatedAt)
+	var agentReasoning []byte
+	err := row.Scan(&proposal.ID, &proposal.DocumentID, &affectedBlockIDs, &proposal.Title, &proposal.AuthorID, &proposal.Intent, &proposal.Scope, &proposal.State, &proposal.RejectionReason, &proposal.CreatedAt, &proposal.UpdatedAt, &proposal.AuthorType, &proposal.AgentAccountID, &agentReasoning, &proposal.DeletedAt, &proposal.DeletedBy)
 	if err != nil {
 		return nil, err
 	}
 	proposal.AffectedBlockIDs = []string(affectedBlockIDs)
+	proposal.AgentReasoning = agentReasoning
 	return proposal, nil
 }
 
+func CreateProposal(proposal *Proposal, ctx context.Context) error {
+	if proposal.AuthorType == "" {
+		proposal.AuthorType = string(AuthorTypeUser)
+	}
+	err := config.PostgresDB.QueryRowContext(ctx,
+		"INSERT INTO proposals (document_id, affected_block_ids, title, author_id, intent, scope, state, created_at, updated_at, author_type) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id",
+		proposal.DocumentID, pq.Array(proposal.AffectedBlockIDs), proposal.Title, proposal.AuthorID, proposal.Intent, proposal.Scope, proposal.State, proposal.CreatedAt, proposal.UpdatedAt, proposal.AuthorType).Scan(&proposal.ID)
+	return err
+}
+
+// CreateProposalWithChanges inserts a proposal and its block changes in a
+// single transaction, so a submitted change set is never half-stored.
+func CreateProposalWithChanges(proposal *Proposal, changes []*ProposalBlockChange, ctx context.Context) error {
+	tx, err := config.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var agentReasoning interface{}
+	if len(proposal.AgentReasoning) > 0 {
+		agentReasoning = []byte(proposal.AgentReasoning)
+	}
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO proposals (document_id, affected_block_ids, title, author_id, intent, scope, state, created_at, updated_at, author_type, agent_account_id, agent_reasoning) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id",
+		proposal.DocumentID, pq.Array(proposal.AffectedBlockIDs), proposal.Title, proposal.AuthorID, proposal.Intent, proposal.Scope, proposal.State, proposal.CreatedAt, proposal.UpdatedAt, proposal.AuthorType, proposal.AgentAccountID, agentReasoning).Scan(&proposal.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		change.ProposalID = proposal.ID
+		content, err := encryption.SealForDocument(proposal.DocumentID, change.Content, ctx)
+		if err != nil {
+			return err
+		}
+		err = tx.QueryRowContext(ctx,
+			"INSERT INTO proposal_block_changes (proposal_id, block_id, action, block_type, order_path, content, created_by, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id",
+			change.ProposalID, change.BlockID, change.Action, change.BlockType, pq.Array(change.OrderPath), content, change.CreatedBy, change.CreatedAt).Scan(&change.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func GetProposalByID(id string, ctx context.Context) (*Proposal, error) {
+	return scanProposal(config.PostgresDB.QueryRowContext(ctx, "SELECT "+proposalColumns+" FROM proposals WHERE id = $1 AND deleted_at IS NULL", id))
+}
+
+// GetProposalIncludingTrashed is GetProposalByID for proposals that may be
+// in the trash.
+func GetProposalIncludingTrashed(id string, ctx context.Context) (*Proposal, error) {
+	return scanProposal(config.PostgresDB.QueryRowContext(ctx, "SELECT "+proposalColumns+" FROM proposals WHERE id = $1", id))
+}
+
 func GetProposalsByDocument(documentID string, ctx context.Context) ([]*Proposal, error) {
-	rows, err := config.PostgresDB.QueryContext(ctx, "SELECT id, document_id, affected_block_ids, title, author_id, intent, scope, state, rejection_reason, created_at, updated_at FROM proposals WHERE document_id = $1 ORDER BY created_at DESC", documentID)
+	return queryProposalsByDocument(documentID, "document_id = $1 AND deleted_at IS NULL", ctx)
+}
+
+// GetProposalsByDocumentIncludingTrashed lists every proposal of a
+// document, trashed or not.
+func GetProposalsByDocumentIncludingTrashed(documentID string, ctx context.Context) ([]*Proposal, error) {
+	return queryProposalsByDocument(documentID, "document_id = $1", ctx)
+}
+
+func queryProposalsByDocument(documentID, where string, ctx context.Context) ([]*Proposal, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx, "SELECT "+proposalColumns+" FROM proposals WHERE "+where+" ORDER BY created_at DESC", documentID)
 	if err != nil {
 		return nil, err
 	}
@@ -35,12 +105,10 @@ func GetProposalsByDocument(documentID string, ctx context.Context) ([]*Proposal
 
 	proposals := make([]*Proposal, 0)
 	for rows.Next() {
-		proposal := &Proposal{}
-		var affectedBlockIDs pq.StringArray
-		if err := rows.Scan(&proposal.ID, &proposal.DocumentID, &affectedBlockIDs, &proposal.Title, &proposal.AuthorID, &proposal.Intent, &proposal.Scope, &proposal.State, &proposal.RejectionReason, &proposal.CreatedAt, &proposal.UpdatedAt); err != nil {
+		proposal, err := scanProposal(rows)
+		if err != nil {
 			return nil, err
 		}
-		proposal.AffectedBlockIDs = []string(affectedBlockIDs)
 		proposals = append(proposals, proposal)
 	}
 	if err := rows.Err(); err != nil {
@@ -50,25 +118,51 @@ func GetProposalsByDocument(documentID string, ctx context.Context) ([]*Proposal
 }
 
 func UpdateProposal(proposal *Proposal, ctx context.Context) error {
-	_, err := config.PostgresDB.ExecContext(ctx, "UPDATE proposals SET affected_block_ids = $1, title = $2, intent = $3, scope = $4, state = $5, updated_at = $6, rejection_reason = $7 WHERE id = $8",
+	_, err := config.PostgresDB.ExecContext(ctx, "UPDATE proposals SET affected_block_ids = $1, title = $2, intent = $3, scope = $4, state = $5, updated_at = $6, rejection_reason = $7 WHERE id = $8 AND deleted_at IS NULL",
 		pq.Array(proposal.AffectedBlockIDs), proposal.Title, proposal.Intent, proposal.Scope, proposal.State, proposal.UpdatedAt, proposal.RejectionReason, proposal.ID)
 	return err
 }
 
-func DeleteProposal(id string, ctx context.Context) error {
-	_, err := config.PostgresDB.ExecContext(ctx, "DELETE FROM proposals WHERE id = $1", id)
+func DeleteProposalInTx(tx *sql.Tx, id string, ctx context.Context) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM proposals WHERE id = $1", id)
 	return err
 }
 
+// TrashProposal moves a proposal to the trash. It reports false when the
+// proposal was already trashed.
+func TrashProposal(id, userID string, ctx context.Context) (bool, error) {
+	res, err := config.PostgresDB.ExecContext(ctx, "UPDATE proposals SET deleted_at = now(), deleted_by = $2 WHERE id = $1 AND deleted_at IS NULL", id, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// RestoreTrashedProposal takes a proposal out of the trash. It reports false
+// when the proposal was not in the trash.
+func RestoreTrashedProposal(id string, ctx context.Context) (bool, error) {
+	res, err := config.PostgresDB.ExecContext(ctx, "UPDATE proposals SET deleted_at = NULL, deleted_by = NULL WHERE id = $1 AND deleted_at IS NOT NULL", id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
 func CreateProposalBlockChange(change *ProposalBlockChange, ctx context.Context) error {
-	err := config.PostgresDB.QueryRowContext(ctx,
+	content, err := encryption.SealForProposal(change.ProposalID, change.Content, ctx)
+	if err != nil {
+		return err
+	}
+	err = config.PostgresDB.QueryRowContext(ctx,
 		"INSERT INTO proposal_block_changes (proposal_id, block_id, action, block_type, order_path, content, created_by, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id",
-		change.ProposalID, change.BlockID, change.Action, change.BlockType, pq.Array(change.OrderPath), change.Content, change.CreatedBy, change.CreatedAt).Scan(&change.ID)
+		change.ProposalID, change.BlockID, change.Action, change.BlockType, pq.Array(change.OrderPath), content, change.CreatedBy, change.CreatedAt).Scan(&change.ID)
 	return err
 }
 
 func GetChangesByProposal(proposalID string, ctx context.Context) ([]*ProposalBlockChange, error) {
-	rows, err := config.PostgresDB.QueryContext(ctx, "SELECT id, proposal_id, block_id, action, block_type, order_path, content, created_by, created_at FROM proposal_block_changes WHERE proposal_id = $1 ORDER BY created_at", proposalID)
+	rows, err := config.PostgresDB.QueryContext(ctx, "SELECT id, proposal_id, block_id, action, block_type, order_path, content, content_hash, redaction_id, created_by, created_at FROM proposal_block_changes WHERE proposal_id = $1 ORDER BY created_at", proposalID)
 	if err != nil {
 		return nil, err
 	}
@@ -77,7 +171,11 @@ func GetChangesByProposal(proposalID string, ctx context.Context) ([]*ProposalBl
 	changes := make([]*ProposalBlockChange, 0)
 	for rows.Next() {
 		change := &ProposalBlockChange{}
-		if err := rows.Scan(&change.ID, &change.ProposalID, &change.BlockID, &change.Action, &change.BlockType, &change.OrderPath, &change.Content, &change.CreatedBy, &change.CreatedAt); err != nil {
+		if err := rows.Scan(&change.ID, &change.ProposalID, &change.BlockID, &change.Action, &change.BlockType, &change.OrderPath, &change.Content, &change.ContentHash, &change.RedactionID, &change.CreatedBy, &change.CreatedAt); err != nil {
+			return nil, err
+		}
+		var err error
+		if change.Content, err = encryption.Open(change.Content, ctx); err != nil {
 			return nil, err
 		}
 		changes = append(changes, change)
@@ -92,3 +190,432 @@ func DeleteChangesByProposal(proposalID string, ctx context.Context) error {
 	_, err := config.PostgresDB.ExecContext(ctx, "DELETE FROM proposal_block_changes WHERE proposal_id = $1", proposalID)
 	return err
 }
+
+func CreateDecisionInTx(tx *sql.Tx, decision *Decision, ctx context.Context) error {
+	err := tx.QueryRowContext(ctx,
+		"INSERT INTO proposal_decisions (proposal_id, outcome, decided_by, reason, synthesis_artifact_id, decided_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id",
+		decision.ProposalID, decision.Outcome, decision.DecidedBy, decision.Reason, decision.SynthesisArtifactID, decision.DecidedAt).Scan(&decision.ID)
+	return err
+}
+
+func GetDecisionByProposal(proposalID string, ctx context.Context) (*Decision, error) {
+	decision := &Decision{}
+	err := config.PostgresDB.QueryRowContext(ctx, "SELECT id, proposal_id, outcome, decided_by, reason, synthesis_artifact_id, decided_at FROM proposal_decisions WHERE proposal_id = $1", proposalID).Scan(
+		&decision.ID, &decision.ProposalID, &decision.Outcome, &decision.DecidedBy, &decision.Reason, &decision.SynthesisArtifactID, &decision.DecidedAt)
+	if err != nil {
+		return nil, err
+	}
+	return decision, nil
+}
+
+func CreateDecisionSignatureInTx(tx *sql.Tx, signature *DecisionSignature, ctx context.Context) error {
+	err := tx.QueryRowContext(ctx,
+		"INSERT INTO decision_signatures (decision_id, proposal_id, signer_id, signer_name, meaning, method, manifest_hash, manifest_version, signed_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id",
+		signature.DecisionID, signature.ProposalID, signature.SignerID, signature.SignerName, signature.Meaning, signature.Method, signature.ManifestHash, signature.ManifestVersion, signature.SignedAt).Scan(&signature.ID)
+	return err
+}
+
+// GetDecisionSignature returns the signature recorded with a decision, or
+// nil when the decision was not signed.
+func GetDecisionSignature(decisionID string, ctx context.Context) (*DecisionSignature, error) {
+	signature := &DecisionSignature{}
+	err := config.PostgresDB.QueryRowContext(ctx, "SELECT id, decision_id, proposal_id, signer_id, signer_name, meaning, method, manifest_hash, manifest_version, signed_at FROM decision_signatures WHERE decision_id = $1", decisionID).Scan(
+		&signature.ID, &signature.DecisionID, &signature.ProposalID, &signature.SignerID, &signature.SignerName, &signature.Meaning, &signature.Method, &signature.ManifestHash, &signature.ManifestVersion, &signature.SignedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return signature, nil
+}
+
+// HasDecisionSignature reports whether a proposal's decision was signed.
+func HasDecisionSignature(proposalID string, ctx context.Context) (bool, error) {
+	var signed bool
+	err := config.PostgresDB.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM decision_signatures WHERE proposal_id = $1)", proposalID).Scan(&signed)
+	return signed, err
+}
+
+// GetDocumentWorkspaceID returns the workspace a document belongs to, or ""
+// for legacy documents without one.
+func GetDocumentWorkspaceID(documentID string, ctx context.Context) (string, error) {
+	var workspaceID sql.NullString
+	err := config.PostgresDB.QueryRowContext(ctx, "SELECT workspace_id FROM documents WHERE id = $1 AND deleted_at IS NULL", documentID).Scan(&workspaceID)
+	if err != nil {
+		return "", err
+	}
+	return workspaceID.String, nil
+}
+
+const reviewRequestColumns = `rr.id, rr.proposal_id, rr.reviewer_user_id, u.username, rr.reviewer_team_id, t.name, rr.requested_by,
+	rr.steward, rr.approved_by, rr.approved_at, rr.declined_by, rr.declined_at, rr.decline_reason, rr.created_at`
+
+const reviewRequestJoins = `FROM proposal_review_requests rr
+	LEFT JOIN users u ON u.id = rr.reviewer_user_id
+	LEFT JOIN teams t ON t.id = rr.reviewer_team_id`
+
+func scanReviewRequest(row rowScanner) (*ReviewRequest, error) {
+	rr := &ReviewRequest{}
+	err := row.Scan(&rr.ID, &rr.ProposalID, &rr.ReviewerUserID, &rr.ReviewerUsername, &rr.ReviewerTeamID, &rr.ReviewerTeamName, &rr.RequestedBy,
+		&rr.Steward, &rr.ApprovedBy, &rr.ApprovedAt, &rr.DeclinedBy, &rr.DeclinedAt, &rr.DeclineReason, &rr.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return rr, nil
+}
+
+// CreateReviewRequest stores rr unless the same reviewer was already
+// requested, in which case it reports false.
+func CreateReviewRequest(rr *ReviewRequest, ctx context.Context) (bool, error) {
+	err := config.PostgresDB.QueryRowContext(ctx,
+		"INSERT INTO proposal_review_requests (proposal_id, reviewer_user_id, reviewer_team_id, requested_by, created_at) VALUES ($1, $2, $3, $4, $5) ON CONFLICT DO NOTHING RETURNING id",
+		rr.ProposalID, rr.ReviewerUserID, rr.ReviewerTeamID, rr.RequestedBy, rr.CreatedAt).Scan(&rr.ID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func GetReviewRequestsByProposal(proposalID string, ctx context.Context) ([]*ReviewRequest, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		"SELECT "+reviewRequestColumns+" "+reviewRequestJoins+" WHERE rr.proposal_id = $1 ORDER BY rr.created_at", proposalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []*ReviewRequest{}
+	for rows.Next() {
+		rr, err := scanReviewRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, rr)
+	}
+	return requests, rows.Err()
+}
+
+// GetOpenReviewRequestsForUser returns the requests on open proposals that
+// name the user directly or through one of their teams.
+func GetOpenReviewRequestsForUser(userID string, ctx context.Context) ([]*ReviewRequest, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		"SELECT "+reviewRequestColumns+" "+reviewRequestJoins+`
+		 JOIN proposals p ON p.id = rr.proposal_id
+		 WHERE p.state = 'open' AND p.deleted_at IS NULL
+		   AND (rr.reviewer_user_id = $1
+		        OR rr.reviewer_team_id IN (SELECT team_id FROM team_members WHERE user_id = $1))
+		 ORDER BY rr.created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []*ReviewRequest{}
+	for rows.Next() {
+		rr, err := scanReviewRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, rr)
+	}
+	return requests, rows.Err()
+}
+
+// EnsureStewardReviewRequest requests review from a steward, or marks an
+// existing request for the same reviewer as a steward request.
+func EnsureStewardReviewRequest(proposalID string, reviewerUserID, reviewerTeamID *string, createdAt string, ctx context.Context) error {
+	conflict := "(proposal_id, reviewer_user_id) WHERE reviewer_user_id IS NOT NULL"
+	if reviewerTeamID != nil {
+		conflict = "(proposal_id, reviewer_team_id) WHERE reviewer_team_id IS NOT NULL"
+	}
+	_, err := config.PostgresDB.ExecContext(ctx,
+		`INSERT INTO proposal_review_requests (proposal_id, reviewer_user_id, reviewer_team_id, steward, created_at)
+		 VALUES ($1, $2, $3, TRUE, $4)
+		 ON CONFLICT `+conflict+` DO UPDATE SET steward = TRUE`,
+		proposalID, reviewerUserID, reviewerTeamID, createdAt)
+	return err
+}
+
+// GetReviewRequest returns the request, or nil if the proposal has no
+// request with that ID.
+func GetReviewRequest(proposalID, requestID string, ctx context.Context) (*ReviewRequest, error) {
+	rr, err := scanReviewRequest(config.PostgresDB.QueryRowContext(ctx,
+		"SELECT "+reviewRequestColumns+" "+reviewRequestJoins+" WHERE rr.id = $1 AND rr.proposal_id = $2", requestID, proposalID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return rr, err
+}
+
+// ApproveReviewRequest records a sign-off unless the request was already
+// answered, in which case it reports false.
+func ApproveReviewRequest(requestID, userID, approvedAt string, ctx context.Context) (bool, error) {
+	res, err := config.PostgresDB.ExecContext(ctx,
+		"UPDATE proposal_review_requests SET approved_by = $1, approved_at = $2 WHERE id = $3 AND approved_at IS NULL AND declined_at IS NULL",
+		userID, approvedAt, requestID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// DeclineReviewRequest records that the reviewer turned the proposal down
+// unless the request was already answered, in which case it reports false.
+func DeclineReviewRequest(requestID, userID, reason, declinedAt string, ctx context.Context) (bool, error) {
+	res, err := config.PostgresDB.ExecContext(ctx,
+		"UPDATE proposal_review_requests SET declined_by = $1, declined_at = $2, decline_reason = NULLIF($3, '') WHERE id = $4 AND approved_at IS NULL AND declined_at IS NULL",
+		userID, declinedAt, reason, requestID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func DeleteReviewRequest(proposalID, requestID string, ctx context.Context) (bool, error) {
+	res, err := config.PostgresDB.ExecContext(ctx, "DELETE FROM proposal_review_requests WHERE id = $1 AND proposal_id = $2", requestID, proposalID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+const stewardColumns = `s.id, s.document_id, s.block_id, s.path_prefix, s.steward_user_id, u.username, s.steward_team_id, t.name, s.created_by, s.created_at`
+
+const stewardJoins = `FROM block_stewards s
+	LEFT JOIN users u ON u.id = s.steward_user_id
+	LEFT JOIN teams t ON t.id = s.steward_team_id`
+
+func scanSteward(row rowScanner) (*Steward, error) {
+	s := &Steward{}
+	err := row.Scan(&s.ID, &s.DocumentID, &s.BlockID, &s.PathPrefix, &s.StewardUserID, &s.StewardUsername,
+		&s.StewardTeamID, &s.StewardTeamName, &s.CreatedBy, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func GetStewardsByDocument(documentID string, ctx context.Context) ([]*Steward, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		"SELECT "+stewardColumns+" "+stewardJoins+" WHERE s.document_id = $1 ORDER BY s.created_at", documentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stewards := []*Steward{}
+	for rows.Next() {
+		s, err := scanSteward(rows)
+		if err != nil {
+			return nil, err
+		}
+		stewards = append(stewards, s)
+	}
+	return stewards, rows.Err()
+}
+
+func CreateSteward(s *Steward, ctx context.Context) error {
+	return config.PostgresDB.QueryRowContext(ctx,
+		`INSERT INTO block_stewards (document_id, block_id, path_prefix, steward_user_id, steward_team_id, created_by, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		s.DocumentID, s.BlockID, s.PathPrefix, s.StewardUserID, s.StewardTeamID, s.CreatedBy, s.CreatedAt).Scan(&s.ID)
+}
+
+func DeleteSteward(documentID, stewardID string, ctx context.Context) (bool, error) {
+	res, err := config.PostgresDB.ExecContext(ctx, "DELETE FROM block_stewards WHERE id = $1 AND document_id = $2", stewardID, documentID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetBlockPaths returns the order paths of the given blocks in a document,
+// keyed by block ID. Blocks that no longer exist are left out.
+func GetBlockPaths(documentID string, blockIDs []string, ctx context.Context) (map[string][]int64, error) {
+	paths := make(map[string][]int64, len(blockIDs))
+	if len(blockIDs) == 0 {
+		return paths, nil
+	}
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		"SELECT id, order_path FROM blocks WHERE document_id = $1 AND id = ANY($2)", documentID, pq.Array(blockIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var path pq.Int64Array
+		if err := rows.Scan(&id, &path); err != nil {
+			return nil, err
+		}
+		paths[id] = path
+	}
+	return paths, rows.Err()
+}
+
+// LockChainInTx serialises appends to one document's chain until tx ends.
+func LockChainInTx(tx *sql.Tx, documentID string, ctx context.Context) error {
+	_, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('document_chain:' || $1))", documentID)
+	return err
+}
+
+// GetChainHeadInTx returns the last seq and entry hash of a document's
+// chain, or 0 and "" when it is empty.
+func GetChainHeadInTx(tx *sql.Tx, documentID string, ctx context.Context) (int64, string, error) {
+	var seq int64
+	var hash string
+	err := tx.QueryRowContext(ctx,
+		"SELECT seq, entry_hash FROM document_chain WHERE document_id = $1 ORDER BY seq DESC LIMIT 1", documentID).Scan(&seq, &hash)
+	if err == sql.ErrNoRows {
+		return 0, "", nil
+	}
+	return seq, hash, err
+}
+
+func CreateChainEntryInTx(tx *sql.Tx, e *ChainEntry, ctx context.Context) error {
+	return tx.QueryRowContext(ctx,
+		`INSERT INTO document_chain (document_id, seq, kind, proposal_id, decision_id, manifest, content_hash, prev_hash, entry_hash)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at`,
+		e.DocumentID, e.Seq, e.Kind, e.ProposalID, e.DecisionID, string(e.Manifest), e.ContentHash, e.PrevHash, e.EntryHash,
+	).Scan(&e.ID, &e.CreatedAt)
+}
+
+func IsDecisionChainedInTx(tx *sql.Tx, decisionID string, ctx context.Context) (bool, error) {
+	var chained bool
+	err := tx.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM document_chain WHERE decision_id = $1 AND kind = 'decision')", decisionID).Scan(&chained)
+	return chained, err
+}
+
+// GetDecisionIDInTx returns sql.ErrNoRows for an undecided proposal.
+func GetDecisionIDInTx(tx *sql.Tx, proposalID string, ctx context.Context) (string, error) {
+	var id string
+	err := tx.QueryRowContext(ctx, "SELECT id FROM proposal_decisions WHERE proposal_id = $1", proposalID).Scan(&id)
+	return id, err
+}
+
+func SetChangeContentHashInTx(tx *sql.Tx, changeID, hash string, ctx context.Context) error {
+	_, err := tx.ExecContext(ctx, "UPDATE proposal_block_changes SET content_hash = $1 WHERE id = $2", hash, changeID)
+	return err
+}
+
+func GetChainEntries(documentID string, ctx context.Context) ([]*ChainEntry, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT id, document_id, seq, kind, proposal_id, decision_id, manifest, content_hash, prev_hash, entry_hash, created_at
+		 FROM document_chain WHERE document_id = $1 ORDER BY seq`, documentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]*ChainEntry, 0)
+	for rows.Next() {
+		e := &ChainEntry{}
+		var manifest string
+		if err := rows.Scan(&e.ID, &e.DocumentID, &e.Seq, &e.Kind, &e.ProposalID, &e.DecisionID, &manifest,
+			&e.ContentHash, &e.PrevHash, &e.EntryHash, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Manifest = json.RawMessage(manifest)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// GetUnchainedDecisions returns decisions that are not in their document's
+// chain yet, oldest first. documentID limits the search to one document
+// when not "". Trashed proposals count; they stay in the chain until purged.
+func GetUnchainedDecisions(documentID string, ctx context.Context) ([]*Decision, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT d.id, d.proposal_id, d.outcome, d.decided_by, d.reason, d.synthesis_artifact_id, d.decided_at
+		 FROM proposal_decisions d
+		 JOIN proposals p ON p.id = d.proposal_id
+		 WHERE ($1 = '' OR p.document_id::text = $1)
+		   AND NOT EXISTS (SELECT 1 FROM document_chain c WHERE c.decision_id = d.id AND c.kind = 'decision')
+		 ORDER BY d.decided_at, d.id`, documentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	decisions := make([]*Decision, 0)
+	for rows.Next() {
+		d := &Decision{}
+		if err := rows.Scan(&d.ID, &d.ProposalID, &d.Outcome, &d.DecidedBy, &d.Reason, &d.SynthesisArtifactID, &d.DecidedAt); err != nil {
+			return nil, err
+		}
+		decisions = append(decisions, d)
+	}
+	return decisions, rows.Err()
+}
+
+// GetChainedDocumentIDs returns the existing documents, trashed or not,
+// that have a chain.
+func GetChainedDocumentIDs(ctx context.Context) ([]string, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT id FROM documents d WHERE EXISTS (SELECT 1 FROM document_chain c WHERE c.document_id = d.id) ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func CreateChainCheckpoint(c *ChainCheckpoint, ctx context.Context) error {
+	return config.PostgresDB.QueryRowContext(ctx,
+		`INSERT INTO document_chain_checkpoints (document_id, seq, head_hash, token, public_key, created_by)
+		 VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`,
+		c.DocumentID, c.Seq, c.HeadHash, c.Token, []byte(c.PublicKey), c.CreatedBy,
+	).Scan(&c.ID, &c.CreatedAt)
+}
+
+const chainCheckpointColumns = "id, document_id, seq, head_hash, token, public_key, created_by, created_at"
+
+func scanChainCheckpoint(row rowScanner) (*ChainCheckpoint, error) {
+	c := &ChainCheckpoint{}
+	var publicKey []byte
+	if err := row.Scan(&c.ID, &c.DocumentID, &c.Seq, &c.HeadHash, &c.Token, &publicKey, &c.CreatedBy, &c.CreatedAt); err != nil {
+		return nil, err
+	}
+	c.PublicKey = publicKey
+	return c, nil
+}
+
+func GetChainCheckpoints(documentID string, ctx context.Context) ([]*ChainCheckpoint, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		"SELECT "+chainCheckpointColumns+" FROM document_chain_checkpoints WHERE document_id = $1 ORDER BY created_at DESC", documentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checkpoints := make([]*ChainCheckpoint, 0)
+	for rows.Next() {
+		c, err := scanChainCheckpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, c)
+	}
+	return checkpoints, rows.Err()
+}
+
+func GetChainCheckpoint(documentID, checkpointID string, ctx context.Context) (*ChainCheckpoint, error) {
+	return scanChainCheckpoint(config.PostgresDB.QueryRowContext(ctx,
+		"SELECT "+chainCheckpointColumns+" FROM document_chain_checkpoints WHERE id = $1 AND document_id = $2", checkpointID, documentID))
+}
@@ -1,6 +1,10 @@
 package proposals
 
 import (
+	"encoding/json"
+
+	"granth/internal/auth"
+
 	"github.com/lib/pq"
 )
 
@@ -12,6 +16,13 @@ const (
 	ProposalStatusRejected ProposalStatus = "rejected"
 )
 
+type AuthorType string
+
+const (
+	AuthorTypeUser  AuthorType = "user"
+	AuthorTypeAgent AuthorType = "agent"
+)
+
 type Proposal struct {
 	ID               string   `json:"id"`
 	DocumentID       string   `json:"document_id"`
@@ -24,6 +35,16 @@ type Proposal struct {
 	RejectionReason  *string  `json:"rejection_reason"`
 	CreatedAt        string   `json:"created_at"`
 	UpdatedAt        string   `json:"updated_at"`
+
+	// Provenance. Agent proposals carry the submitting service account and
+	// the structured reasoning payload it supplied.
+	AuthorType     string          `json:"author_type"`
+	AgentAccountID *string         `json:"agent_account_id,omitempty"`
+	AgentReasoning json.RawMessage `json:"agent_reasoning,omitempty"`
+
+	// Set while the proposal is in the trash.
+	DeletedAt *string `json:"deleted_at,omitempty"`
+	DeletedBy *string `json:"deleted_by,omitempty"`
 }
 
 type ProposalBlockChange struct {
@@ -34,6 +55,157 @@ type ProposalBlockChange struct {
 	BlockType  string        `json:"block_type"`
 	OrderPath  pq.Int64Array `json:"order_path"`
 	Content    string        `json:"content"`
-	CreatedBy  string        `json:"created_by"`
-	CreatedAt  string        `json:"created_at"`
+	// ContentHash is fixed when the proposal is decided, and moved on by
+	// redactions, each of which the chain records
+	ContentHash *string `json:"content_hash,omitempty"`
+	// RedactionID is the last redaction that rewrote Content
+	RedactionID *string `json:"redaction_id,omitempty"`
+	CreatedBy   string  `json:"created_by"`
+	CreatedAt   string  `json:"created_at"`
+}
+
+// RedactedChange is a decided block change whose content a redaction
+// rewrote, with its content hash before and after.
+type RedactedChange struct {
+	ID             string `json:"id"`
+	ProposalID     string `json:"-"`
+	OldContentHash string `json:"old_content_hash"`
+	NewContentHash string `json:"new_content_hash"`
+}
+
+// changeEvent is a block change as recorded in audit payloads, with a hash
+// of its content instead of the content.
+type changeEvent struct {
+	ID          string        `json:"id"`
+	ProposalID  string        `json:"proposal_id"`
+	BlockID     *string       `json:"block_id"`
+	Action      string        `json:"action"`
+	BlockType   string        `json:"block_type"`
+	OrderPath   pq.Int64Array `json:"order_path"`
+	ContentHash string        `json:"content_hash"`
+	CreatedBy   string        `json:"created_by"`
+}
+
+// commentEvent is a comment as recorded in audit payloads.
+type commentEvent struct {
+	ID         string  `json:"id"`
+	ProposalID string  `json:"proposal_id"`
+	ParentID   *string `json:"parent_id"`
+	AuthorID   string  `json:"author_id"`
+	BodyHash   string  `json:"body_hash"`
+}
+
+// Decision is the record written when a proposal is accepted or rejected. It
+// links the outcome to the reasoning that informed it.
+type Decision struct {
+	ID                  string  `json:"id"`
+	ProposalID          string  `json:"proposal_id"`
+	Outcome             string  `json:"outcome"`
+	DecidedBy           string  `json:"decided_by"`
+	Reason              *string `json:"reason"`
+	SynthesisArtifactID *string `json:"synthesis_artifact_id"`
+	DecidedAt           string  `json:"decided_at"`
+	// Signature is set when the decision was electronically signed
+	Signature *DecisionSignature `json:"signature,omitempty"`
+}
+
+// DecisionSignature is the immutable electronic signature recorded with a
+// decision. ManifestHash covers the proposal, decision, signer, meaning
+// and the content hash of each change as they were when signed, laid out
+// as ManifestVersion.
+type DecisionSignature struct {
+	ID              string `json:"id"`
+	DecisionID      string `json:"decision_id"`
+	ProposalID      string `json:"proposal_id"`
+	SignerID        string `json:"signer_id"`
+	SignerName      string `json:"signer_name"`
+	Meaning         string `json:"meaning"`
+	Method          string `json:"method"`
+	ManifestHash    string `json:"manifest_hash"`
+	ManifestVersion int    `json:"manifest_version"`
+	SignedAt        string `json:"signed_at"`
+}
+
+// SignatureRequest is sent with accept/reject in electronic signature mode:
+// the meaning of the signature plus freshly entered credentials.
+type SignatureRequest struct {
+	Meaning string `json:"meaning"`
+	auth.StepUpCredentials
+}
+
+// ReviewRequest asks one user, or any member of a team, to review a
+// proposal. Exactly one of ReviewerUserID and ReviewerTeamID is set.
+type ReviewRequest struct {
+	ID               string  `json:"id"`
+	ProposalID       string  `json:"proposal_id"`
+	ReviewerUserID   *string `json:"reviewer_user_id,omitempty"`
+	ReviewerUsername *string `json:"reviewer_username,omitempty"`
+	ReviewerTeamID   *string `json:"reviewer_team_id,omitempty"`
+	ReviewerTeamName *string `json:"reviewer_team_name,omitempty"`
+	RequestedBy      *string `json:"requested_by"`
+	// Steward marks requests made automatically for block stewards
+	Steward       bool    `json:"steward"`
+	ApprovedBy    *string `json:"approved_by"`
+	ApprovedAt    *string `json:"approved_at"`
+	DeclinedBy    *string `json:"declined_by"`
+	DeclinedAt    *string `json:"declined_at"`
+	DeclineReason *string `json:"decline_reason,omitempty"`
+	CreatedAt     string  `json:"created_at"`
+}
+
+// Steward assigns a user or team to watch over part of a document, like a
+// CODEOWNERS entry. A rule covers one block, every block whose order path
+// starts with PathPrefix, or the whole document when neither is set.
+type Steward struct {
+	ID              string        `json:"id"`
+	DocumentID      string        `json:"document_id"`
+	BlockID         *string       `json:"block_id,omitempty"`
+	PathPrefix      pq.Int64Array `json:"path_prefix,omitempty"`
+	StewardUserID   *string       `json:"steward_user_id,omitempty"`
+	StewardUsername *string       `json:"steward_username,omitempty"`
+	StewardTeamID   *string       `json:"steward_team_id,omitempty"`
+	StewardTeamName *string       `json:"steward_team_name,omitempty"`
+	CreatedBy       *string       `json:"created_by"`
+	CreatedAt       string        `json:"created_at"`
+}
+
+// ChainEntry is one link in a document's history chain. Manifest is the
+// exact JSON that ContentHash covers.
+type ChainEntry struct {
+	ID          string          `json:"id"`
+	DocumentID  string          `json:"document_id"`
+	Seq         int64           `json:"seq"`
+	Kind        string          `json:"kind"`
+	ProposalID  string          `json:"proposal_id"`
+	DecisionID  string          `json:"decision_id"`
+	Manifest    json.RawMessage `json:"manifest"`
+	ContentHash string          `json:"content_hash"`
+	PrevHash    string          `json:"prev_hash"`
+	EntryHash   string          `json:"entry_hash"`
+	CreatedAt   string          `json:"created_at"`
+}
+
+// ChainVerification is the result of re-walking a document's chain.
+// BrokenAt is the seq of the first entry that failed, when there is one.
+type ChainVerification struct {
+	DocumentID string `json:"document_id"`
+	Valid      bool   `json:"valid"`
+	Entries    int    `json:"entries"`
+	HeadHash   string `json:"head_hash"`
+	BrokenAt   *int64 `json:"broken_at,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	CheckedAt  string `json:"checked_at"`
+}
+
+// ChainCheckpoint pins the head of a document's chain with a signed token
+// (a JWT) that can be checked against PublicKey outside Granth.
+type ChainCheckpoint struct {
+	ID         string          `json:"id"`
+	DocumentID string          `json:"document_id"`
+	Seq        int64           `json:"seq"`
+	HeadHash   string          `json:"head_hash"`
+	Token      string          `json:"token"`
+	PublicKey  json.RawMessage `json:"public_key"`
+	CreatedBy  *string         `json:"created_by"`
+	CreatedAt  string          `json:"created_at"`
 }
@@ -0,0 +1,320 @@
+package reasoning
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"granth/internal/ai"
+	"granth/internal/utils"
+)
+
+// SummaryPromptVersion identifies the prompt below. Bump it whenever the
+// prompt text changes so stored summaries stay attributable.
+const SummaryPromptVersion = "proposal-summary/v1"
+
+const summarySystemPrompt = `You summarize proposed changes to a shared document for reviewers.
+You are not an author: do not suggest edits or add opinions.
+Describe, in at most five short bullet points, what the proposal changes and whether the changes match the stated intent and scope.
+Call out any change that appears to fall outside the stated scope.`
+
+// SummarizeProposal generates and stores a summary for the given proposal
+// state. If the latest stored summary was produced from identical input with
+// the current prompt, it is returned unchanged.
+func SummarizeProposal(input SummaryInput, ctx context.Context) (*Artifact, error) {
+	inputHash, err := hashSummaryInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := fetchLatestArtifact(input.ProposalID, ArtifactKindSummary, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && latest.InputHash == inputHash && latest.PromptVersion == SummaryPromptVersion {
+		return latest, nil
+	}
+
+	completion, err := ai.Complete(ai.CompletionRequest{
+		System:      summarySystemPrompt,
+		Messages:    []ai.Message{{Role: "user", Content: renderSummaryPrompt(input)}},
+		MaxTokens:   400,
+		Temperature: 0,
+	}, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error generating summary: %w", err)
+	}
+
+	a := &Artifact{
+		ProposalID:    input.ProposalID,
+		Kind:          string(ArtifactKindSummary),
+		Content:       completion.Text,
+		Model:         completion.Model,
+		PromptVersion: SummaryPromptVersion,
+		InputHash:     inputHash,
+		CreatedAt:     time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := insertArtifact(a, ctx); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// GetLatestSummary returns the newest summary for a proposal, or nil if none
+// has been generated yet.
+func GetLatestSummary(proposalID string, ctx context.Context) (*Artifact, error) {
+	return fetchLatestArtifact(proposalID, ArtifactKindSummary, ctx)
+}
+
+// FlagArtifact records that the requesting user considers an artifact
+// misleading. Flagging again replaces the user's earlier reason.
+// FlagArtifact flags an artifact of proposalID. Callers check the user may
+// review the proposal; an artifact of another proposal is not found.
+func FlagArtifact(proposalID, artifactID, reason string, ctx context.Context) (*ArtifactFlag, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("reason is required")
+	}
+
+	a, err := fetchArtifactByID(artifactID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if a.ProposalID != proposalID {
+		return nil, fmt.Errorf("artifact not found")
+	}
+
+	f := &ArtifactFlag{
+		ArtifactID: artifactID,
+		UserID:     userID,
+		Reason:     reason,
+		CreatedAt:  time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := insertFlag(f, ctx); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func GetArtifactFlags(artifactID string, ctx context.Context) ([]*ArtifactFlag, error) {
+	return fetchFlagsForArtifact(artifactID, ctx)
+}
+
+func hashSummaryInput(input SummaryInput) (string, error) {
+	b, err := json.Marshal(input)
+	if err != nil {
+		return "", fmt.Errorf("error hashing summary input: %w", err)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func renderSummaryPrompt(input SummaryInput) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Title: %s\nIntent: %s\nScope: %s\n\nChanges:\n", input.Title, input.Intent, input.Scope)
+	if len(input.Changes) == 0 {
+		b.WriteString("(no block changes yet)\n")
+	}
+	for i, c := range input.Changes {
+		target := "new block"
+		if c.BlockID != nil {
+			target = "block " + *c.BlockID
+		}
+		fmt.Fprintf(&b, "%d. %s %s (%s):\n%s\n", i+1, c.Action, target, c.BlockType, c.Content)
+	}
+	return b.String()
+}
+
+// ── Discussion ────────────────────────────────────────────────────────────────
+
+func CreateComment(proposalID string, parentID *string, body string, ctx context.Context) (*Comment, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+	if strings.TrimSpace(body) == "" {
+		return nil, fmt.Errorf("comment body is required")
+	}
+
+	if parentID != nil {
+		parent, err := fetchComment(*parentID, ctx)
+		if err != nil {
+			return nil, err
+		}
+		if parent.ProposalID != proposalID {
+			return nil, fmt.Errorf("parent comment belongs to a different proposal")
+		}
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	c := &Comment{
+		ProposalID: proposalID,
+		ParentID:   parentID,
+		AuthorID:   userID,
+		Body:       body,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := insertComment(c, ctx); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func ListComments(proposalID string, ctx context.Context) ([]*Comment, error) {
+	return fetchCommentsForProposal(proposalID, ctx)
+}
+
+// ── Synthesis ─────────────────────────────────────────────────────────────────
+
+// SynthesisPromptVersion identifies the synthesis prompt below.
+const SynthesisPromptVersion = "discussion-synthesis/v1"
+
+const synthesisSystemPrompt = `You synthesize a discussion about a proposed change to a shared document.
+You are not a participant: do not take sides or add new arguments.
+Respond with JSON only, in the form:
+{"positions":[{"text":"...","comment_ids":["..."]}],"open_questions":[{"text":"...","comment_ids":["..."]}],"agreements":[{"text":"...","comment_ids":["..."]}]}
+Every item must cite the IDs of the comments it is drawn from. Only cite IDs that appear in the discussion.`
+
+// SynthesizeDiscussion asks the AI provider to condense a proposal's
+// discussion thread and stores the result as a new synthesis version.
+// Citations to comments that are not part of the thread are dropped.
+func SynthesizeDiscussion(proposalID, title, intent string, ctx context.Context) (*Artifact, error) {
+	comments, err := fetchCommentsForProposal(proposalID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(comments) == 0 {
+		return nil, fmt.Errorf("no discussion to synthesize")
+	}
+
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Proposal: %s\nIntent: %s\n\nDiscussion:\n", title, intent)
+	known := make(map[string]bool, len(comments))
+	for _, c := range comments {
+		known[c.ID] = true
+		replyTo := ""
+		if c.ParentID != nil {
+			replyTo = " (reply to " + *c.ParentID + ")"
+		}
+		fmt.Fprintf(&prompt, "[%s] author %s%s:\n%s\n\n", c.ID, c.AuthorID, replyTo, c.Body)
+	}
+
+	inputSum := sha256.Sum256([]byte(prompt.String()))
+	inputHash := hex.EncodeToString(inputSum[:])
+
+	latest, err := fetchLatestArtifact(proposalID, ArtifactKindSynthesis, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && latest.InputHash == inputHash && latest.PromptVersion == SynthesisPromptVersion {
+		return latest, nil
+	}
+
+	completion, err := ai.Complete(ai.CompletionRequest{
+		System:      synthesisSystemPrompt,
+		Messages:    []ai.Message{{Role: "user", Content: prompt.String()}},
+		MaxTokens:   1200,
+		Temperature: 0,
+	}, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error generating synthesis: %w", err)
+	}
+
+	var synthesis Synthesis
+	if err := json.Unmarshal([]byte(extractJSONObject(completion.Text)), &synthesis); err != nil {
+		return nil, fmt.Errorf("AI provider returned malformed synthesis: %w", err)
+	}
+	synthesis.Positions = keepCitedPoints(synthesis.Positions, known)
+	synthesis.OpenQuestions = keepCitedPoints(synthesis.OpenQuestions, known)
+	synthesis.Agreements = keepCitedPoints(synthesis.Agreements, known)
+
+	content, err := json.Marshal(synthesis)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding synthesis: %w", err)
+	}
+
+	a := &Artifact{
+		ProposalID:    proposalID,
+		Kind:          string(ArtifactKindSynthesis),
+		Content:       string(content),
+		Model:         completion.Model,
+		PromptVersion: SynthesisPromptVersion,
+		InputHash:     inputHash,
+		CreatedAt:     time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := insertArtifact(a, ctx); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func GetLatestSynthesis(proposalID string, ctx context.Context) (*Artifact, error) {
+	return fetchLatestArtifact(proposalID, ArtifactKindSynthesis, ctx)
+}
+
+func ListSyntheses(proposalID string, ctx context.Context) ([]*Artifact, error) {
+	return fetchArtifactVersions(proposalID, ArtifactKindSynthesis, ctx)
+}
+
+// ResolveDecisionSynthesis picks the synthesis to attach to a proposal's
+// decision record: the requested version if one was named, otherwise the
+// latest. It returns nil when the proposal has never been synthesized.
+func ResolveDecisionSynthesis(proposalID string, artifactID *string, ctx context.Context) (*string, error) {
+	if artifactID != nil && *artifactID != "" {
+		a, err := fetchArtifactByID(*artifactID, ctx)
+		if err != nil {
+			return nil, err
+		}
+		if a.ProposalID != proposalID || a.Kind != string(ArtifactKindSynthesis) {
+			return nil, fmt.Errorf("synthesis does not belong to this proposal")
+		}
+		return &a.ID, nil
+	}
+
+	latest, err := fetchLatestArtifact(proposalID, ArtifactKindSynthesis, ctx)
+	if err != nil || latest == nil {
+		return nil, err
+	}
+	return &latest.ID, nil
+}
+
+func GetArtifact(id string, ctx context.Context) (*Artifact, error) {
+	return fetchArtifactByID(id, ctx)
+}
+
+// extractJSONObject trims any prose or code fences a model wraps around the
+// JSON object it was asked for.
+func extractJSONObject(text string) string {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return text
+	}
+	return text[start : end+1]
+}
+
+func keepCitedPoints(points []SynthesisPoint, known map[string]bool) []SynthesisPoint {
+	kept := make([]SynthesisPoint, 0, len(points))
+	for _, p := range points {
+		ids := make([]string, 0, len(p.CommentIDs))
+		for _, id := range p.CommentIDs {
+			if known[id] {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) == 0 || strings.TrimSpace(p.Text) == "" {
+			continue
+		}
+		p.CommentIDs = ids
+		kept = append(kept, p)
+	}
+	return kept
+}
@@ -0,0 +1,203 @@
+package reasoning
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"granth/internal/config"
+	"granth/internal/encryption"
+)
+
+func insertArtifact(a *Artifact, ctx context.Context) error {
+	content, err := encryption.SealForProposal(a.ProposalID, a.Content, ctx)
+	if err != nil {
+		return fmt.Errorf("error encrypting reasoning artifact: %w", err)
+	}
+	err = config.PostgresDB.QueryRowContext(ctx,
+		`INSERT INTO reasoning_artifacts (proposal_id, kind, version, content, model, prompt_version, input_hash, created_at)
+		 VALUES ($1, $2,
+		         (SELECT COALESCE(MAX(version), 0) + 1 FROM reasoning_artifacts WHERE proposal_id = $1 AND kind = $2),
+		         $3, $4, $5, $6, $7)
+		 RETURNING id, version`,
+		a.ProposalID, a.Kind, content, a.Model, a.PromptVersion, a.InputHash, a.CreatedAt,
+	).Scan(&a.ID, &a.Version)
+	if err != nil {
+		return fmt.Errorf("error inserting reasoning artifact: %w", err)
+	}
+	return nil
+}
+
+func fetchLatestArtifact(proposalID string, kind ArtifactKind, ctx context.Context) (*Artifact, error) {
+	a := &Artifact{}
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT a.id, a.proposal_id, a.kind, a.version, a.content, a.model, a.prompt_version, a.input_hash,
+		        (SELECT COUNT(*) FROM reasoning_artifact_flags f WHERE f.artifact_id = a.id), a.created_at
+		 FROM reasoning_artifacts a
+		 WHERE a.proposal_id = $1 AND a.kind = $2
+		 ORDER BY a.version DESC LIMIT 1`,
+		proposalID, string(kind),
+	).Scan(&a.ID, &a.ProposalID, &a.Kind, &a.Version, &a.Content, &a.Model, &a.PromptVersion, &a.InputHash, &a.FlagCount, &a.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching reasoning artifact: %w", err)
+	}
+	if err := openArtifact(a, ctx); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func fetchArtifactByID(id string, ctx context.Context) (*Artifact, error) {
+	a := &Artifact{}
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT a.id, a.proposal_id, a.kind, a.version, a.content, a.model, a.prompt_version, a.input_hash,
+		        (SELECT COUNT(*) FROM reasoning_artifact_flags f WHERE f.artifact_id = a.id), a.created_at
+		 FROM reasoning_artifacts a WHERE a.id = $1`, id,
+	).Scan(&a.ID, &a.ProposalID, &a.Kind, &a.Version, &a.Content, &a.Model, &a.PromptVersion, &a.InputHash, &a.FlagCount, &a.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("artifact not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching reasoning artifact: %w", err)
+	}
+	if err := openArtifact(a, ctx); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func openArtifact(a *Artifact, ctx context.Context) error {
+	var err error
+	if a.Content, err = encryption.Open(a.Content, ctx); err != nil {
+		return fmt.Errorf("error decrypting reasoning artifact: %w", err)
+	}
+	return nil
+}
+
+func insertFlag(f *ArtifactFlag, ctx context.Context) error {
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`INSERT INTO reasoning_artifact_flags (artifact_id, user_id, reason, created_at)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (artifact_id, user_id) DO UPDATE SET reason = EXCLUDED.reason, created_at = EXCLUDED.created_at
+		 RETURNING id`,
+		f.ArtifactID, f.UserID, f.Reason, f.CreatedAt,
+	).Scan(&f.ID)
+	if err != nil {
+		return fmt.Errorf("error inserting artifact flag: %w", err)
+	}
+	return nil
+}
+
+func fetchFlagsForArtifact(artifactID string, ctx context.Context) ([]*ArtifactFlag, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT id, artifact_id, COALESCE(user_id::text, ''), reason, created_at
+		 FROM reasoning_artifact_flags WHERE artifact_id = $1 ORDER BY created_at ASC`,
+		artifactID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error querying artifact flags: %w", err)
+	}
+	defer rows.Close()
+
+	flags := make([]*ArtifactFlag, 0)
+	for rows.Next() {
+		f := &ArtifactFlag{}
+		if err := rows.Scan(&f.ID, &f.ArtifactID, &f.UserID, &f.Reason, &f.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning artifact flag: %w", err)
+		}
+		flags = append(flags, f)
+	}
+	return flags, rows.Err()
+}
+
+// ── Comments ──────────────────────────────────────────────────────────────────
+
+func insertComment(c *Comment, ctx context.Context) error {
+	body, err := encryption.SealForProposal(c.ProposalID, c.Body, ctx)
+	if err != nil {
+		return fmt.Errorf("error encrypting comment: %w", err)
+	}
+	err = config.PostgresDB.QueryRowContext(ctx,
+		`INSERT INTO proposal_comments (proposal_id, parent_id, author_id, body, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		c.ProposalID, c.ParentID, c.AuthorID, body, c.CreatedAt, c.UpdatedAt,
+	).Scan(&c.ID)
+	if err != nil {
+		return fmt.Errorf("error inserting comment: %w", err)
+	}
+	return nil
+}
+
+func fetchComment(id string, ctx context.Context) (*Comment, error) {
+	c := &Comment{}
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT id, proposal_id, parent_id, author_id, body, created_at, updated_at
+		 FROM proposal_comments WHERE id = $1`, id,
+	).Scan(&c.ID, &c.ProposalID, &c.ParentID, &c.AuthorID, &c.Body, &c.CreatedAt, &c.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("comment not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching comment: %w", err)
+	}
+	if c.Body, err = encryption.Open(c.Body, ctx); err != nil {
+		return nil, fmt.Errorf("error decrypting comment: %w", err)
+	}
+	return c, nil
+}
+
+func fetchCommentsForProposal(proposalID string, ctx context.Context) ([]*Comment, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT id, proposal_id, parent_id, author_id, body, created_at, updated_at
+		 FROM proposal_comments WHERE proposal_id = $1 ORDER BY created_at ASC`,
+		proposalID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error querying comments: %w", err)
+	}
+	defer rows.Close()
+
+	comments := make([]*Comment, 0)
+	for rows.Next() {
+		c := &Comment{}
+		if err := rows.Scan(&c.ID, &c.ProposalID, &c.ParentID, &c.AuthorID, &c.Body, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning comment: %w", err)
+		}
+		var err error
+		if c.Body, err = encryption.Open(c.Body, ctx); err != nil {
+			return nil, fmt.Errorf("error decrypting comment: %w", err)
+		}
+		comments = append(comments, c)
+	}
+	return comments, rows.Err()
+}
+
+func fetchArtifactVersions(proposalID string, kind ArtifactKind, ctx context.Context) ([]*Artifact, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT a.id, a.proposal_id, a.kind, a.version, a.content, a.model, a.prompt_version, a.input_hash,
+		        (SELECT COUNT(*) FROM reasoning_artifact_flags f WHERE f.artifact_id = a.id), a.created_at
+		 FROM reasoning_artifacts a
+		 WHERE a.proposal_id = $1 AND a.kind = $2
+		 ORDER BY a.version DESC`,
+		proposalID, string(kind),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error querying reasoning artifacts: %w", err)
+	}
+	defer rows.Close()
+
+	artifacts := make([]*Artifact, 0)
+	for rows.Next() {
+		a := &Artifact{}
+		if err := rows.Scan(&a.ID, &a.ProposalID, &a.Kind, &a.Version, &a.Content, &a.Model, &a.PromptVersion, &a.InputHash, &a.FlagCount, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning reasoning artifact: %w", err)
+		}
+		if err := openArtifact(a, ctx); err != nil {
+			return nil, err
+		}
+		artifacts = append(artifacts, a)
+	}
+	return artifacts, rows.Err()
+}
@@ -0,0 +1,74 @@
+package reasoning
+
+type ArtifactKind string
+
+const (
+	ArtifactKindSummary   ArtifactKind = "summary"
+	ArtifactKindSynthesis ArtifactKind = "synthesis"
+)
+
+// Artifact is a machine-generated contribution to the reasoning layer. It is
+// always attributed to the model and prompt that produced it and never
+// written into canonical blocks.
+type Artifact struct {
+	ID            string `json:"id"`
+	ProposalID    string `json:"proposal_id"`
+	Kind          string `json:"kind"`
+	Version       int    `json:"version"`
+	Content       string `json:"content"`
+	Model         string `json:"model"`
+	PromptVersion string `json:"prompt_version"`
+	InputHash     string `json:"input_hash"`
+	FlagCount     int    `json:"flag_count"`
+	CreatedAt     string `json:"created_at"`
+}
+
+type ArtifactFlag struct {
+	ID         string `json:"id"`
+	ArtifactID string `json:"artifact_id"`
+	UserID     string `json:"user_id"`
+	Reason     string `json:"reason"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// SummaryInput is the proposal state a summary is generated from. It is
+// passed in by the proposals package so reasoning does not depend on it.
+type SummaryInput struct {
+	ProposalID string
+	Title      string
+	Intent     string
+	Scope      string
+	Changes    []ChangeInput
+}
+
+type ChangeInput struct {
+	Action    string
+	BlockType string
+	BlockID   *string
+	Content   string
+}
+
+// Comment is a single message in a proposal's discussion thread. Replies
+// reference their parent; top-level comments have no parent.
+type Comment struct {
+	ID         string  `json:"id"`
+	ProposalID string  `json:"proposal_id"`
+	ParentID   *string `json:"parent_id"`
+	AuthorID   string  `json:"author_id"`
+	Body       string  `json:"body"`
+	CreatedAt  string  `json:"created_at"`
+	UpdatedAt  string  `json:"updated_at"`
+}
+
+// Synthesis is the structured content of a synthesis artifact. Every point
+// cites the comments it was derived from.
+type Synthesis struct {
+	Positions     []SynthesisPoint `json:"positions"`
+	OpenQuestions []SynthesisPoint `json:"open_questions"`
+	Agreements    []SynthesisPoint `json:"agreements"`
+}
+
+type SynthesisPoint struct {
+	Text       string   `json:"text"`
+	CommentIDs []string `json:"comment_ids"`
+}
@@ -0,0 +1,88 @@
+package retention
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"granth/internal/utils"
+	"granth/internal/workspaces"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TrashRouter() http.Handler {
+	r := chi.NewRouter()
+
+	read := utils.RequireScope(utils.ScopeRead)
+	admin := utils.RequireScope(utils.ScopeAdmin)
+
+	r.With(read).Get("/workspace/{workspaceID}", handleListTrash)
+	r.With(admin).Post("/documents/{id}/restore", handleRestoreDocument)
+	r.With(admin).Delete("/documents/{id}", handlePurgeDocument)
+	r.With(admin).Post("/proposals/{id}/restore", handleRestoreProposal)
+	r.With(admin).Delete("/proposals/{id}", handlePurgeProposal)
+
+	return r
+}
+
+func handleListTrash(w http.ResponseWriter, r *http.Request) {
+	items, err := listTrash(chi.URLParam(r, "workspaceID"), r.Context())
+	if err != nil {
+		writeTrashError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(items); err != nil {
+		http.Error(w, "error encoding JSON: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func handleRestoreDocument(w http.ResponseWriter, r *http.Request) {
+	if err := restoreDocument(chi.URLParam(r, "id"), r.Context()); err != nil {
+		writeTrashError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func handlePurgeDocument(w http.ResponseWriter, r *http.Request) {
+	if err := purgeTrashedDocument(chi.URLParam(r, "id"), r.Context()); err != nil {
+		writeTrashError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func handleRestoreProposal(w http.ResponseWriter, r *http.Request) {
+	if err := restoreProposal(chi.URLParam(r, "id"), r.Context()); err != nil {
+		writeTrashError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func handlePurgeProposal(w http.ResponseWriter, r *http.Request) {
+	if err := purgeTrashedProposal(chi.URLParam(r, "id"), r.Context()); err != nil {
+		writeTrashError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeTrashError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	switch {
+	case errors.Is(err, workspaces.ErrPermissionDenied) || errors.Is(err, workspaces.ErrTwoFactorRequired):
+		http.Error(w, msg, http.StatusForbidden)
+	case msg == "document not found" || msg == "proposal not found":
+		http.Error(w, msg, http.StatusNotFound)
+	case errors.Is(err, workspaces.ErrLegalHold) || errors.Is(err, workspaces.ErrRetained) ||
+		msg == "signed proposals cannot be deleted" || msg == "document is not in the trash" ||
+		msg == "proposal is not in the trash" || msg == "restore the document first" ||
+		msg == "the restore window has passed":
+		http.Error(w, msg, http.StatusConflict)
+	default:
+		http.Error(w, msg, http.StatusInternalServerError)
+	}
+}
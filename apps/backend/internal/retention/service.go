@@ -0,0 +1,85 @@
+package retention
+
+import (
+	"context"
+	"time"
+
+	"granth/internal/config"
+	"granth/internal/documents"
+	"granth/internal/proposals"
+)
+
+// purgeBatchSize bounds how many records one pass deletes.
+const purgeBatchSize = 500
+
+// TrashWindow is how long deleted documents and proposals can be restored.
+// After it they are purged.
+var TrashWindow = 30 * 24 * time.Hour
+
+// StartPurger runs PurgeExpired every interval until ctx is done.
+func StartPurger(interval time.Duration, ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				runCtx, cancel := context.WithTimeout(ctx, interval)
+				n, err := PurgeExpired(runCtx)
+				if err != nil {
+					config.Logger.Printf("retention purge: %v", err)
+				} else if n > 0 {
+					config.Logger.Printf("retention purge: deleted %d record(s)", n)
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// PurgeExpired deletes records whose retention period is over and whose
+// policy disposes of them, and trash older than TrashWindow. It reports how
+// many records it deleted. Deletion goes through the same checks as
+// deleting by hand, so a hold placed during the pass still wins; such
+// records are logged and skipped.
+func PurgeExpired(ctx context.Context) (int, error) {
+	records, err := fetchPurgeableRecords(purgeBatchSize, time.Now().Add(-TrashWindow), ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, r := range records {
+		if r.ProposalID != nil {
+			err = proposals.PurgeProposal(*r.ProposalID, ctx)
+		} else {
+			err = purgeDocument(r.DocumentID, ctx)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return purged, ctx.Err()
+			}
+			config.Logger.Printf("retention purge: skipping document %s: %v", r.DocumentID, err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// purgeDocument removes the document's proposals, trashed or not, one by
+// one first, so decided ones leave removal entries in the document's chain.
+func purgeDocument(documentID string, ctx context.Context) error {
+	docProposals, err := proposals.GetProposalsByDocumentIncludingTrashed(documentID, ctx)
+	if err != nil {
+		return err
+	}
+	for _, p := range docProposals {
+		if err := proposals.PurgeProposal(p.ID, ctx); err != nil {
+			return err
+		}
+	}
+	return documents.PurgeDocument(documentID, ctx)
+}
@@ -0,0 +1,95 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"granth/internal/config"
+	"time"
+)
+
+// purgeableRecord is a document (ProposalID nil) or proposal the purge job
+// may delete.
+type purgeableRecord struct {
+	DocumentID string
+	ProposalID *string
+}
+
+// fetchPurgeableRecords returns up to limit records whose retention period
+// is over and whose policy disposes of them, or that were trashed before
+// trashedBefore, proposals first. Records under legal hold, documents with
+// a proposal that is still retained and anything signed are left alone.
+func fetchPurgeableRecords(limit int, trashedBefore time.Time, ctx context.Context) ([]*purgeableRecord, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`WITH candidates AS (
+		     SELECT s.workspace_id, s.document_id, s.proposal_id
+		     FROM retention_schedule s WHERE s.dispose AND s.keep_until <= now()
+		     UNION
+		     SELECT d.workspace_id, d.id, NULL::uuid FROM documents d WHERE d.deleted_at < $2
+		     UNION
+		     SELECT d.workspace_id, p.document_id, p.id
+		     FROM proposals p JOIN documents d ON d.id = p.document_id
+		     WHERE p.deleted_at < $2 AND d.deleted_at IS NULL
+		 )
+		 SELECT c.document_id, c.proposal_id
+		 FROM candidates c
+		 WHERE NOT EXISTS (
+		       SELECT 1 FROM legal_holds h
+		       WHERE h.workspace_id = c.workspace_id AND h.released_at IS NULL
+		         AND (h.document_id IS NULL OR h.document_id = c.document_id))
+		   AND NOT EXISTS (
+		       SELECT 1 FROM retention_schedule r
+		       WHERE r.document_id = c.document_id AND r.keep_until > now()
+		         AND (c.proposal_id IS NULL OR r.proposal_id = c.proposal_id))
+		   AND NOT EXISTS (
+		       SELECT 1 FROM decision_signatures ds JOIN proposals p ON p.id = ds.proposal_id
+		       WHERE p.document_id = c.document_id AND (c.proposal_id IS NULL OR p.id = c.proposal_id))
+		 ORDER BY c.proposal_id IS NULL
+		 LIMIT $1`, limit, trashedBefore)
+	if err != nil {
+		return nil, fmt.Errorf("error querying purgeable records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*purgeableRecord
+	for rows.Next() {
+		r := &purgeableRecord{}
+		if err := rows.Scan(&r.DocumentID, &r.ProposalID); err != nil {
+			return nil, fmt.Errorf("error scanning purgeable record: %w", err)
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// fetchTrash lists a workspace's trashed documents, and trashed proposals
+// of documents that are not themselves in the trash, newest first.
+func fetchTrash(workspaceID string, ctx context.Context) ([]*TrashedItem, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT 'document', d.id, d.id, d.title, d.deleted_at, d.deleted_by, u.username
+		 FROM documents d LEFT JOIN users u ON u.id = d.deleted_by
+		 WHERE d.workspace_id = $1 AND d.deleted_at IS NOT NULL
+		 UNION ALL
+		 SELECT 'proposal', p.id, p.document_id, COALESCE(p.title, ''), p.deleted_at, p.deleted_by, u.username
+		 FROM proposals p
+		 JOIN documents d ON d.id = p.document_id
+		 LEFT JOIN users u ON u.id = p.deleted_by
+		 WHERE d.workspace_id = $1 AND d.deleted_at IS NULL AND p.deleted_at IS NOT NULL
+		 ORDER BY 5 DESC`, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying trash: %w", err)
+	}
+	defer rows.Close()
+
+	items := []*TrashedItem{}
+	for rows.Next() {
+		item := &TrashedItem{}
+		var deletedAt time.Time
+		if err := rows.Scan(&item.Kind, &item.ID, &item.DocumentID, &item.Title, &deletedAt, &item.DeletedBy, &item.DeletedByName); err != nil {
+			return nil, fmt.Errorf("error scanning trash: %w", err)
+		}
+		item.DeletedAt = deletedAt.UTC().Format(time.RFC3339)
+		item.RestorableUntil = deletedAt.Add(TrashWindow).UTC().Format(time.RFC3339)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,84 @@
+package retention
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"granth/internal/config"
+	"granth/internal/testdb"
+)
+
+func trash(t *testing.T, documentID string) {
+	t.Helper()
+	if _, err := config.PostgresDB.Exec(`UPDATE documents SET deleted_at = now() - interval '1 day' WHERE id = $1`, documentID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func placeHold(t *testing.T, workspaceID string, documentID *string, released bool) {
+	t.Helper()
+	if _, err := config.PostgresDB.Exec(
+		`INSERT INTO legal_holds (workspace_id, document_id, reason, released_at)
+		 VALUES ($1, $2, 'litigation', CASE WHEN $3 THEN now() END)`, workspaceID, documentID, released); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFetchPurgeableRecordsHonoursHoldsAndSignatures(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	heldWorkspaceID := testdb.CreateWorkspace(t, owner)
+
+	plain := testdb.CreateDocument(t, workspaceID, owner)
+	held := testdb.CreateDocument(t, workspaceID, owner)
+	released := testdb.CreateDocument(t, workspaceID, owner)
+	signed := testdb.CreateDocument(t, workspaceID, owner)
+	inHeldWorkspace := testdb.CreateDocument(t, heldWorkspaceID, owner)
+	for _, id := range []string{plain, held, released, signed, inHeldWorkspace} {
+		trash(t, id)
+	}
+	placeHold(t, workspaceID, &held, false)
+	placeHold(t, workspaceID, &released, true)
+	placeHold(t, heldWorkspaceID, nil, false)
+
+	proposalID := testdb.CreateProposal(t, signed, owner)
+	var decisionID string
+	if err := config.PostgresDB.QueryRow(
+		`INSERT INTO proposal_decisions (proposal_id, outcome, decided_by) VALUES ($1, 'accepted', $2) RETURNING id`,
+		proposalID, owner,
+	).Scan(&decisionID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.PostgresDB.Exec(
+		`INSERT INTO decision_signatures (decision_id, proposal_id, signer_id, signer_name, meaning, method, manifest_hash)
+		 VALUES ($1, $2, $3, 'owner', 'approved', 'password', 'x')`, decisionID, proposalID, owner); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := fetchPurgeableRecords(10000, time.Now(), context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	purgeable := map[string]bool{}
+	for _, r := range records {
+		if r.ProposalID == nil {
+			purgeable[r.DocumentID] = true
+		}
+	}
+	for name, tt := range map[string]struct {
+		documentID string
+		want       bool
+	}{
+		"trashed":         {plain, true},
+		"released hold":   {released, true},
+		"document hold":   {held, false},
+		"workspace hold":  {inHeldWorkspace, false},
+		"signed decision": {signed, false},
+	} {
+		if purgeable[tt.documentID] != tt.want {
+			t.Errorf("%s: purgeable = %v, want %v", name, purgeable[tt.documentID], tt.want)
+		}
+	}
+}
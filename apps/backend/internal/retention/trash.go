@@ -0,0 +1,141 @@
+package retention
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"granth/internal/documents"
+	"granth/internal/proposals"
+	"granth/internal/utils"
+	"granth/internal/workspaces"
+)
+
+func listTrash(workspaceID string, ctx context.Context) ([]*TrashedItem, error) {
+	if err := workspaces.RequirePermission(workspaceID, workspaces.PermRead, ctx); err != nil {
+		return nil, err
+	}
+	return fetchTrash(workspaceID, ctx)
+}
+
+// fetchTrashedDocument returns a document of any state, or "document not
+// found".
+func fetchTrashedDocument(documentID string, ctx context.Context) (*documents.Document, error) {
+	doc, err := documents.FetchDocumentIncludingTrashed(documentID, ctx)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("document not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching document: %w", err)
+	}
+	return doc, nil
+}
+
+// requireDocumentPermission checks perm in the document's workspace. Only
+// the creator may act on legacy documents without one.
+func requireDocumentPermission(doc *documents.Document, perm workspaces.Permission, ctx context.Context) error {
+	if doc.WorkspaceID != nil {
+		return workspaces.RequirePermission(*doc.WorkspaceID, perm, ctx)
+	}
+	userID, _ := utils.GetUserIDFromContext(ctx)
+	if userID != doc.CreatedBy {
+		return workspaces.ErrPermissionDenied
+	}
+	return nil
+}
+
+// checkRestorable returns an error once the trash window has passed.
+func checkRestorable(deletedAt *string) error {
+	t, err := time.Parse(time.RFC3339Nano, *deletedAt)
+	if err != nil {
+		return fmt.Errorf("error reading deletion time: %w", err)
+	}
+	if time.Since(t) > TrashWindow {
+		return fmt.Errorf("the restore window has passed")
+	}
+	return nil
+}
+
+func restoreDocument(documentID string, ctx context.Context) error {
+	doc, err := fetchTrashedDocument(documentID, ctx)
+	if err != nil {
+		return err
+	}
+	if err := requireDocumentPermission(doc, workspaces.PermPropose, ctx); err != nil {
+		return err
+	}
+	if doc.DeletedAt == nil {
+		return fmt.Errorf("document is not in the trash")
+	}
+	if err := checkRestorable(doc.DeletedAt); err != nil {
+		return err
+	}
+	return documents.RestoreDocument(documentID, ctx)
+}
+
+// purgeTrashedDocument deletes a trashed document for good.
+func purgeTrashedDocument(documentID string, ctx context.Context) error {
+	doc, err := fetchTrashedDocument(documentID, ctx)
+	if err != nil {
+		return err
+	}
+	if err := requireDocumentPermission(doc, workspaces.PermManagePolicy, ctx); err != nil {
+		return err
+	}
+	if doc.DeletedAt == nil {
+		return fmt.Errorf("document is not in the trash")
+	}
+	return purgeDocument(documentID, ctx)
+}
+
+// fetchTrashedProposal returns a proposal of any state with its document.
+func fetchTrashedProposal(proposalID string, ctx context.Context) (*proposals.Proposal, *documents.Document, error) {
+	p, err := proposals.GetProposalIncludingTrashed(proposalID, ctx)
+	if err == sql.ErrNoRows {
+		return nil, nil, fmt.Errorf("proposal not found")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("error fetching proposal: %w", err)
+	}
+	doc, err := fetchTrashedDocument(p.DocumentID, ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, doc, nil
+}
+
+func restoreProposal(proposalID string, ctx context.Context) error {
+	p, doc, err := fetchTrashedProposal(proposalID, ctx)
+	if err != nil {
+		return err
+	}
+	if err := requireDocumentPermission(doc, workspaces.PermPropose, ctx); err != nil {
+		return err
+	}
+	if p.DeletedAt == nil {
+		return fmt.Errorf("proposal is not in the trash")
+	}
+	if doc.DeletedAt != nil {
+		return fmt.Errorf("restore the document first")
+	}
+	if err := checkRestorable(p.DeletedAt); err != nil {
+		return err
+	}
+	return proposals.RestoreProposal(proposalID, ctx)
+}
+
+// purgeTrashedProposal deletes a trashed proposal for good.
+func purgeTrashedProposal(proposalID string, ctx context.Context) error {
+	p, doc, err := fetchTrashedProposal(proposalID, ctx)
+	if err != nil {
+		return err
+	}
+	if err := requireDocumentPermission(doc, workspaces.PermManagePolicy, ctx); err != nil {
+		return err
+	}
+	if p.DeletedAt == nil {
+		return fmt.Errorf("proposal is not in the trash")
+	}
+	return proposals.PurgeProposal(proposalID, ctx)
+}
@@ -0,0 +1,14 @@
+package retention
+
+// TrashedItem is a document or proposal in a workspace's trash. It can be
+// restored until RestorableUntil.
+type TrashedItem struct {
+	Kind            string  `json:"kind"` // "document" or "proposal"
+	ID              string  `json:"id"`
+	DocumentID      string  `json:"document_id"`
+	Title           string  `json:"title"`
+	DeletedAt       string  `json:"deleted_at"`
+	DeletedBy       *string `json:"deleted_by"`
+	DeletedByName   *string `json:"deleted_by_name,omitempty"`
+	RestorableUntil string  `json:"restorable_until"`
+}
@@ -0,0 +1,192 @@
+// Package testdb gives tests a migrated PostgreSQL database. Tests that use
+// it are skipped unless TEST_DATABASE_URL points at a database they may
+// write to.
+package testdb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+
+	"granth/internal/config"
+	"granth/internal/utils"
+
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+)
+
+var (
+	once    sync.Once
+	openErr error
+
+	redisOnce sync.Once
+	redisErr  error
+)
+
+// Open connects config.PostgresDB to TEST_DATABASE_URL and migrates it,
+// or skips the test when the variable is unset.
+func Open(t *testing.T) {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	once.Do(func() {
+		config.InitLogger()
+		if _, err := config.InitPostgresDB(url); err != nil {
+			openErr = err
+			return
+		}
+		openErr = migrateUp()
+	})
+	if openErr != nil {
+		t.Fatalf("opening test database: %v", openErr)
+	}
+}
+
+// OpenRedis connects config.RedisClient to TEST_REDIS_ADDR, or skips the
+// test when the variable is unset.
+func OpenRedis(t *testing.T) {
+	t.Helper()
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TEST_REDIS_ADDR not set")
+	}
+	redisOnce.Do(func() {
+		_, redisErr = config.InitRedisClient(addr, os.Getenv("TEST_REDIS_PASSWORD"), 0)
+	})
+	if redisErr != nil {
+		t.Fatalf("opening test redis: %v", redisErr)
+	}
+}
+
+func migrateUp() error {
+	dir, err := migrationsDir()
+	if err != nil {
+		return err
+	}
+	driver, err := postgres.WithInstance(config.PostgresDB, &postgres.Config{})
+	if err != nil {
+		return err
+	}
+	m, err := migrate.NewWithDatabaseInstance("file://"+dir, "postgres", driver)
+	if err != nil {
+		return err
+	}
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		return err
+	}
+	return nil
+}
+
+// migrationsDir finds apps/backend/migrations from a package directory.
+func migrationsDir() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return filepath.Join(dir, "migrations"), nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("go.mod not found")
+		}
+		dir = parent
+	}
+}
+
+// CreateUser inserts a user with a unique, verified email address and
+// returns its ID.
+func CreateUser(t *testing.T) string {
+	t.Helper()
+	return createUser(t, true)
+}
+
+// CreateUnverifiedUser is CreateUser for an account that has not confirmed
+// its email address.
+func CreateUnverifiedUser(t *testing.T) string {
+	t.Helper()
+	return createUser(t, false)
+}
+
+func createUser(t *testing.T, verified bool) string {
+	t.Helper()
+	name := fmt.Sprintf("u%d", time.Now().UnixNano())
+	var id string
+	err := config.PostgresDB.QueryRow(
+		`INSERT INTO users (username, email, password_hash, email_verified_at)
+		 VALUES ($1, $2, 'x', CASE WHEN $3 THEN now() END) RETURNING id`,
+		name, name+"@example.com", verified,
+	).Scan(&id)
+	if err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+	return id
+}
+
+// CreateWorkspace inserts a workspace owned by ownerID, who joins as admin.
+func CreateWorkspace(t *testing.T, ownerID string) string {
+	t.Helper()
+	var id string
+	err := config.PostgresDB.QueryRow(
+		`INSERT INTO workspaces (name, owner_id) VALUES ('test', $1) RETURNING id`, ownerID,
+	).Scan(&id)
+	if err != nil {
+		t.Fatalf("creating workspace: %v", err)
+	}
+	AddMember(t, id, ownerID, "admin")
+	return id
+}
+
+// AddMember adds userID to the workspace with role.
+func AddMember(t *testing.T, workspaceID, userID, role string) {
+	t.Helper()
+	_, err := config.PostgresDB.Exec(
+		`INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, $3)`,
+		workspaceID, userID, role)
+	if err != nil {
+		t.Fatalf("adding member: %v", err)
+	}
+}
+
+// CreateDocument inserts a document in the workspace.
+func CreateDocument(t *testing.T, workspaceID, createdBy string) string {
+	t.Helper()
+	var id string
+	err := config.PostgresDB.QueryRow(
+		`INSERT INTO documents (title, workspace_id, created_by, updated_by) VALUES ('doc', $1, $2, $2) RETURNING id`,
+		workspaceID, createdBy,
+	).Scan(&id)
+	if err != nil {
+		t.Fatalf("creating document: %v", err)
+	}
+	return id
+}
+
+// CreateProposal inserts an open proposal on the document.
+func CreateProposal(t *testing.T, documentID, authorID string) string {
+	t.Helper()
+	var id string
+	err := config.PostgresDB.QueryRow(
+		`INSERT INTO proposals (document_id, title, author_id, state, created_at, updated_at)
+		 VALUES ($1, 'proposal', $2, 'open', now(), now()) RETURNING id`,
+		documentID, authorID,
+	).Scan(&id)
+	if err != nil {
+		t.Fatalf("creating proposal: %v", err)
+	}
+	return id
+}
+
+// As returns a context authenticated as userID with a session token.
+func As(userID string) context.Context {
+	return utils.WithClaims(context.Background(), &utils.Claims{UserID: userID, Authorized: true, TokenType: "access"})
+}
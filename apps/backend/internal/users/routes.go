@@ -0,0 +1,58 @@
+package users
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"granth/internal/utils"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// searchLimit keeps type-ahead usable while making bulk enumeration slow.
+var searchLimit = utils.RateLimit{Name: "user_search", Limit: 120, Window: time.Minute}
+
+// UsersRouter serves the user directory. It is mounted behind AuthMiddleware.
+func UsersRouter() http.Handler {
+	r := chi.NewRouter()
+	r.Use(utils.RequireScope(utils.ScopeRead))
+
+	r.With(utils.RateLimitMiddleware(searchLimit, utils.KeyByUser)).Get("/search", handleSearchUsers)
+
+	return r
+}
+
+// handleSearchUsers serves GET /search?q=&workspace_id=&limit=
+func handleSearchUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := 0
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			http.Error(w, "limit must be a number", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	profiles, err := searchUsers(query.Get("q"), query.Get("workspace_id"), limit, r.Context())
+	if err != nil {
+		switch {
+		case err.Error() == "user ID not found in context":
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		case err.Error() == "not a member of this workspace":
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case strings.HasPrefix(err.Error(), "query must be"):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Error searching users: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profiles)
+}
@@ -0,0 +1,51 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"granth/internal/utils"
+	"granth/internal/workspaces"
+)
+
+const (
+	minQueryLength     = 2
+	defaultSearchLimit = 10
+	maxSearchLimit     = 25
+)
+
+// searchUsers matches q against username and email prefixes. Results are
+// limited to people the caller already shares a workspace with, or to the
+// members of workspaceID when given, so the search cannot enumerate the
+// whole user table.
+func searchUsers(q, workspaceID string, limit int, ctx context.Context) ([]*Profile, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+
+	q = strings.TrimSpace(q)
+	if utf8.RuneCountInString(q) < minQueryLength {
+		return nil, fmt.Errorf("query must be at least %d characters", minQueryLength)
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	if workspaceID == "" {
+		return searchCoMembers(userID, q, limit, ctx)
+	}
+	isMember, err := workspaces.IsMember(workspaceID, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error checking membership: %w", err)
+	}
+	if !isMember {
+		return nil, fmt.Errorf("not a member of this workspace")
+	}
+	return searchWorkspaceMembers(workspaceID, q, limit, ctx)
+}
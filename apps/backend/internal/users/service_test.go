@@ -0,0 +1,71 @@
+package users
+
+import (
+	"testing"
+
+	"granth/internal/config"
+	"granth/internal/testdb"
+)
+
+func username(t *testing.T, userID string) string {
+	t.Helper()
+	var name string
+	if err := config.PostgresDB.QueryRow(`SELECT username FROM users WHERE id = $1`, userID).Scan(&name); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestSearchUsersRejectsShortQueries(t *testing.T) {
+	if _, err := searchUsers(" a ", "", 0, testdb.As("caller")); err == nil || err.Error() != "query must be at least 2 characters" {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestSearchUsersReturnsOnlyCoMembers(t *testing.T) {
+	testdb.Open(t)
+	caller := testdb.CreateUser(t)
+	coMember := testdb.CreateUser(t)
+	otherMember := testdb.CreateUser(t)
+	stranger := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, caller)
+	sharedWorkspaceID := testdb.CreateWorkspace(t, caller)
+	strangersWorkspaceID := testdb.CreateWorkspace(t, stranger)
+	testdb.AddMember(t, workspaceID, coMember, "contributor")
+	testdb.AddMember(t, sharedWorkspaceID, otherMember, "contributor")
+	ctx := testdb.As(caller)
+
+	tests := []struct {
+		name        string
+		userID      string
+		workspaceID string
+		found       bool
+	}{
+		{"co-member", coMember, "", true},
+		{"member of another shared workspace", otherMember, "", true},
+		{"stranger", stranger, "", false},
+		{"member of the given workspace", coMember, workspaceID, true},
+		{"co-member outside the given workspace", otherMember, workspaceID, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profiles, err := searchUsers(username(t, tt.userID), tt.workspaceID, 0, ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			found := false
+			for _, p := range profiles {
+				if p.ID == tt.userID {
+					found = true
+				}
+			}
+			if found != tt.found {
+				t.Fatalf("found = %v, want %v", found, tt.found)
+			}
+		})
+	}
+
+	if _, err := searchUsers(username(t, stranger), strangersWorkspaceID, 0, ctx); err == nil || err.Error() != "not a member of this workspace" {
+		t.Fatalf("searching a workspace the caller is not in: got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"granth/internal/config"
+)
+
+// likePrefix escapes LIKE wildcards so q only ever matches as a prefix.
+func likePrefix(q string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(strings.ToLower(q)) + "%"
+}
+
+// searchCoMembers matches users who share at least one workspace with
+// userID. The caller is included so they can mention themselves.
+func searchCoMembers(userID, q string, limit int, ctx context.Context) ([]*Profile, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT u.id, u.username
+		 FROM users u
+		 WHERE (LOWER(u.username) LIKE $2 OR LOWER(u.email) LIKE $2)
+		   AND EXISTS (
+		       SELECT 1 FROM workspace_members theirs
+		       JOIN workspace_members mine ON mine.workspace_id = theirs.workspace_id
+		       WHERE theirs.user_id = u.id AND mine.user_id = $1)
+		 ORDER BY LOWER(u.username)
+		 LIMIT $3`,
+		userID, likePrefix(q), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error searching users: %w", err)
+	}
+	defer rows.Close()
+
+	profiles := []*Profile{}
+	for rows.Next() {
+		p := &Profile{}
+		if err := rows.Scan(&p.ID, &p.Username); err != nil {
+			return nil, fmt.Errorf("error scanning user: %w", err)
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles, rows.Err()
+}
+
+// searchWorkspaceMembers matches the members of one workspace, with their
+// role, for reviewer assignment and mentions inside that workspace.
+func searchWorkspaceMembers(workspaceID, q string, limit int, ctx context.Context) ([]*Profile, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT u.id, u.username, wm.role
+		 FROM workspace_members wm
+		 JOIN users u ON u.id = wm.user_id
+		 WHERE wm.workspace_id = $1
+		   AND (LOWER(u.username) LIKE $2 OR LOWER(u.email) LIKE $2)
+		 ORDER BY LOWER(u.username)
+		 LIMIT $3`,
+		workspaceID, likePrefix(q), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error searching workspace members: %w", err)
+	}
+	defer rows.Close()
+
+	profiles := []*Profile{}
+	for rows.Next() {
+		p := &Profile{}
+		var role string
+		if err := rows.Scan(&p.ID, &p.Username, &role); err != nil {
+			return nil, fmt.Errorf("error scanning member: %w", err)
+		}
+		p.Role = &role
+		profiles = append(profiles, p)
+	}
+	return profiles, rows.Err()
+}
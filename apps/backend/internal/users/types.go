@@ -0,0 +1,9 @@
+package users
+
+// Profile is the public face of a user as seen by people who share a
+// workspace with them. It deliberately leaves out the email address.
+type Profile struct {
+	ID       string  `json:"id"`
+	Username string  `json:"username"`
+	Role     *string `json:"role,omitempty"`
+}
@@ -0,0 +1,449 @@
+package utils
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"granth/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// MaxTokenLifetime is the longest lifetime of any token the keyring signs.
+// Retired keys keep verifying, and are kept, for this long.
+const MaxTokenLifetime = max(RefreshTokenLifetime, MaxActionTokenLifetime)
+
+const (
+	// keyringRefreshInterval is how often instances reload the keyring and
+	// check whether the signing key is due for rotation.
+	keyringRefreshInterval = 5 * time.Minute
+	// keyringMissReloadInterval throttles reloads triggered by tokens
+	// carrying a kid this instance has not seen yet.
+	keyringMissReloadInterval = 10 * time.Second
+)
+
+// KeyringConfig configures token signing.
+type KeyringConfig struct {
+	// Algorithm for new keys: "EdDSA" (default) or "RS256"
+	Algorithm string
+	// RotationInterval is how long a key signs before it is replaced;
+	// defaults to 30 days
+	RotationInterval time.Duration
+	// LegacySecret is the old JWT_SECRET. Tokens without a kid are
+	// verified with it so sessions survive the switch to the keyring, for
+	// MaxTokenLifetime after the first key was created.
+	LegacySecret string
+}
+
+type signingKey struct {
+	id        string
+	algorithm string
+	method    jwt.SigningMethod
+	private   interface{}
+	public    interface{}
+	retired   bool
+}
+
+type keyring struct {
+	mu     sync.RWMutex
+	keys   map[string]*signingKey
+	active *signingKey
+	legacy []byte
+	// legacyIssuedBy is when the keyring started; legacy tokens must have
+	// been issued by then and expire within MaxTokenLifetime of it
+	legacyIssuedBy time.Time
+	algorithm      string
+	rotation       time.Duration
+	missAt         time.Time
+}
+
+var tokenKeys = &keyring{keys: map[string]*signingKey{}}
+
+// InitKeyring loads the signing keys from the database, creating the
+// first key or rotating an overdue one.
+func InitKeyring(cfg KeyringConfig, ctx context.Context) error {
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = "EdDSA"
+	}
+	if cfg.Algorithm != "EdDSA" && cfg.Algorithm != "RS256" {
+		return fmt.Errorf("unsupported JWT algorithm %q", cfg.Algorithm)
+	}
+	if cfg.RotationInterval <= 0 {
+		cfg.RotationInterval = 30 * 24 * time.Hour
+	}
+
+	tokenKeys.mu.Lock()
+	tokenKeys.algorithm = cfg.Algorithm
+	tokenKeys.rotation = cfg.RotationInterval
+	tokenKeys.mu.Unlock()
+
+	if _, err := rotateSigningKey(false, false, ctx); err != nil {
+		return err
+	}
+	if cfg.LegacySecret == "" {
+		return nil
+	}
+	var startedAt time.Time
+	if err := config.PostgresDB.QueryRowContext(ctx, `SELECT started_at FROM jwt_keyring_state`).Scan(&startedAt); err != nil {
+		return fmt.Errorf("error fetching keyring start: %w", err)
+	}
+	if time.Since(startedAt) >= MaxTokenLifetime {
+		config.Logger.Println("JWT_SECRET is ignored: every token signed with it has expired")
+		return nil
+	}
+	tokenKeys.mu.Lock()
+	tokenKeys.legacy = []byte(cfg.LegacySecret)
+	tokenKeys.legacyIssuedBy = startedAt
+	tokenKeys.mu.Unlock()
+	return nil
+}
+
+// StartKeyRotation keeps the keyring current until ctx is done: keys
+// rotated by other instances are picked up and overdue keys are replaced.
+func StartKeyRotation(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(keyringRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				runCtx, cancel := context.WithTimeout(ctx, time.Minute)
+				if _, err := rotateSigningKey(false, false, runCtx); err != nil {
+					config.Logger.Printf("key rotation: %v", err)
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// RotateSigningKey replaces the signing key now and returns the new kid.
+// With revoke, tokens signed by the previous key stop verifying at once,
+// which signs everyone out; use it when a key has leaked.
+func RotateSigningKey(revoke bool, ctx context.Context) (string, error) {
+	return rotateSigningKey(true, revoke, ctx)
+}
+
+func rotateSigningKey(force, revoke bool, ctx context.Context) (string, error) {
+	tokenKeys.mu.RLock()
+	algorithm, rotation := tokenKeys.algorithm, tokenKeys.rotation
+	tokenKeys.mu.RUnlock()
+
+	tx, err := config.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("rotateSigningKey begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	// serialise instances so only one of them generates the next key
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('jwt_signing_keys'))`); err != nil {
+		return "", fmt.Errorf("rotateSigningKey lock: %w", err)
+	}
+
+	var activeID string
+	var createdAt time.Time
+	err = tx.QueryRowContext(ctx,
+		`SELECT id, created_at FROM jwt_signing_keys
+		 WHERE retired_at IS NULL AND revoked_at IS NULL
+		 ORDER BY created_at DESC LIMIT 1`,
+	).Scan(&activeID, &createdAt)
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("rotateSigningKey query: %w", err)
+	}
+
+	if force || activeID == "" || time.Since(createdAt) >= rotation {
+		newID, privatePEM, publicPEM, err := generateSigningKey(algorithm)
+		if err != nil {
+			return "", err
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO jwt_signing_keys (id, algorithm, private_key, public_key) VALUES ($1, $2, $3, $4)`,
+			newID, algorithm, privatePEM, publicPEM); err != nil {
+			return "", fmt.Errorf("rotateSigningKey exec: %w", err)
+		}
+		retire := `UPDATE jwt_signing_keys SET retired_at = now() WHERE retired_at IS NULL AND id <> $1`
+		if revoke {
+			retire = `UPDATE jwt_signing_keys SET retired_at = COALESCE(retired_at, now()), revoked_at = now()
+			          WHERE revoked_at IS NULL AND id <> $1`
+		}
+		if _, err := tx.ExecContext(ctx, retire, newID); err != nil {
+			return "", fmt.Errorf("rotateSigningKey exec: %w", err)
+		}
+		config.Logger.Printf("JWT signing key rotated to %s (%s)", newID, algorithm)
+		activeID = newID
+	}
+
+	// keys past the verification window are no longer useful
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM jwt_signing_keys WHERE retired_at < now() - make_interval(secs => $1)`,
+		MaxTokenLifetime.Seconds()); err != nil {
+		return "", fmt.Errorf("rotateSigningKey exec: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("rotateSigningKey commit: %w", err)
+	}
+
+	return activeID, loadKeyring(ctx)
+}
+
+// loadKeyring replaces the in-memory keyring with the keys that can still
+// verify tokens.
+func loadKeyring(ctx context.Context) error {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT id, algorithm, private_key, public_key, retired_at IS NOT NULL
+		 FROM jwt_signing_keys
+		 WHERE revoked_at IS NULL AND (retired_at IS NULL OR retired_at > now() - make_interval(secs => $1))
+		 ORDER BY created_at`, MaxTokenLifetime.Seconds())
+	if err != nil {
+		return fmt.Errorf("loadKeyring query: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make(map[string]*signingKey)
+	var active *signingKey
+	for rows.Next() {
+		var privatePEM, publicPEM string
+		key := &signingKey{}
+		if err := rows.Scan(&key.id, &key.algorithm, &privatePEM, &publicPEM, &key.retired); err != nil {
+			return fmt.Errorf("loadKeyring scan: %w", err)
+		}
+		if err := key.parse(privatePEM, publicPEM); err != nil {
+			return fmt.Errorf("signing key %s: %w", key.id, err)
+		}
+		keys[key.id] = key
+		if !key.retired {
+			active = key
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("loadKeyring rows: %w", err)
+	}
+
+	tokenKeys.mu.Lock()
+	tokenKeys.keys = keys
+	tokenKeys.active = active
+	tokenKeys.mu.Unlock()
+	return nil
+}
+
+func generateSigningKey(algorithm string) (id, privatePEM, publicPEM string, err error) {
+	var private, public interface{}
+	switch algorithm {
+	case "EdDSA":
+		public, private, err = ed25519.GenerateKey(rand.Reader)
+	case "RS256":
+		var rsaKey *rsa.PrivateKey
+		rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		if err == nil {
+			private, public = rsaKey, &rsaKey.PublicKey
+		}
+	default:
+		err = fmt.Errorf("unsupported JWT algorithm %q", algorithm)
+	}
+	if err != nil {
+		return "", "", "", fmt.Errorf("error generating signing key: %w", err)
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return "", "", "", fmt.Errorf("error encoding signing key: %w", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return "", "", "", fmt.Errorf("error encoding signing key: %w", err)
+	}
+
+	kid := make([]byte, 12)
+	if _, err := rand.Read(kid); err != nil {
+		return "", "", "", fmt.Errorf("error generating key ID: %w", err)
+	}
+	return hex.EncodeToString(kid),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})),
+		nil
+}
+
+func (k *signingKey) parse(privatePEM, publicPEM string) error {
+	privateBlock, _ := pem.Decode([]byte(privatePEM))
+	publicBlock, _ := pem.Decode([]byte(publicPEM))
+	if privateBlock == nil || publicBlock == nil {
+		return fmt.Errorf("invalid PEM")
+	}
+	private, err := x509.ParsePKCS8PrivateKey(privateBlock.Bytes)
+	if err != nil {
+		return err
+	}
+	public, err := x509.ParsePKIXPublicKey(publicBlock.Bytes)
+	if err != nil {
+		return err
+	}
+
+	switch k.algorithm {
+	case "EdDSA":
+		k.method = jwt.SigningMethodEdDSA
+	case "RS256":
+		k.method = jwt.SigningMethodRS256
+	default:
+		return fmt.Errorf("unsupported algorithm %q", k.algorithm)
+	}
+	k.private, k.public = private, public
+	return nil
+}
+
+// signToken signs claims with the active key and sets its kid header.
+func signToken(claims Claims) (string, error) {
+	tokenKeys.mu.RLock()
+	key := tokenKeys.active
+	tokenKeys.mu.RUnlock()
+	if key == nil {
+		return "", fmt.Errorf("no token signing key configured")
+	}
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+	return token.SignedString(key.private)
+}
+
+// verificationKey is the jwt.Keyfunc for tokens issued by Granth.
+func verificationKey(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		return legacyKey(t)
+	}
+
+	key := lookupKey(kid)
+	if key == nil {
+		return nil, jwt.ErrTokenUnverifiable
+	}
+	if t.Method.Alg() != key.algorithm {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+	return key.public, nil
+}
+
+// legacyKey verifies a token signed with JWT_SECRET before the keyring
+// existed. Such a token expires within MaxTokenLifetime of the keyring
+// start, so anything claiming otherwise is refused.
+func legacyKey(t *jwt.Token) (interface{}, error) {
+	tokenKeys.mu.RLock()
+	legacy, issuedBy := tokenKeys.legacy, tokenKeys.legacyIssuedBy
+	tokenKeys.mu.RUnlock()
+	if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok || legacy == nil {
+		return nil, jwt.ErrTokenUnverifiable
+	}
+
+	deadline := issuedBy.Add(MaxTokenLifetime)
+	if !time.Now().Before(deadline) {
+		return nil, jwt.ErrTokenUnverifiable
+	}
+	exp, err := t.Claims.GetExpirationTime()
+	if err != nil || exp == nil || exp.After(deadline) {
+		return nil, jwt.ErrTokenUnverifiable
+	}
+	if iat, err := t.Claims.GetIssuedAt(); err != nil || (iat != nil && iat.After(issuedBy)) {
+		return nil, jwt.ErrTokenUnverifiable
+	}
+	return legacy, nil
+}
+
+// lookupKey finds a key by kid, reloading once in a while on a miss so
+// keys created by another instance are picked up before the next refresh.
+func lookupKey(kid string) *signingKey {
+	tokenKeys.mu.Lock()
+	key := tokenKeys.keys[kid]
+	reload := key == nil && time.Since(tokenKeys.missAt) > keyringMissReloadInterval
+	if reload {
+		tokenKeys.missAt = time.Now()
+	}
+	tokenKeys.mu.Unlock()
+	if !reload {
+		return key
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := loadKeyring(ctx); err != nil {
+		config.Logger.Printf("error reloading keyring: %v", err)
+		return nil
+	}
+	tokenKeys.mu.RLock()
+	defer tokenKeys.mu.RUnlock()
+	return tokenKeys.keys[kid]
+}
+
+// JWK is a public key in JSON Web Key form.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKSHandler publishes the public keys that verify Granth-issued tokens,
+// so integrations can check tokens without sharing a secret.
+func JWKSHandler(w http.ResponseWriter, r *http.Request) {
+	tokenKeys.mu.RLock()
+	keys := make([]JWK, 0, len(tokenKeys.keys))
+	for _, key := range tokenKeys.keys {
+		if k := key.jwk(); k != nil {
+			keys = append(keys, *k)
+		}
+	}
+	tokenKeys.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(map[string][]JWK{"keys": keys})
+}
+
+func (k *signingKey) jwk() *JWK {
+	switch public := k.public.(type) {
+	case ed25519.PublicKey:
+		return &JWK{Kty: "OKP", Kid: k.id, Alg: k.algorithm, Use: "sig", Crv: "Ed25519",
+			X: base64.RawURLEncoding.EncodeToString(public)}
+	case *rsa.PublicKey:
+		return &JWK{Kty: "RSA", Kid: k.id, Alg: k.algorithm, Use: "sig",
+			N: base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())}
+	}
+	return nil
+}
+
+// SignStatement signs claims that are not a login token, such as history
+// checkpoints, with the active key. It returns the public key as well so
+// the statement can be checked after the key has been rotated away.
+func SignStatement(claims jwt.Claims) (string, *JWK, error) {
+	tokenKeys.mu.RLock()
+	key := tokenKeys.active
+	tokenKeys.mu.RUnlock()
+	if key == nil {
+		return "", nil, fmt.Errorf("no token signing key configured")
+	}
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+	signed, err := token.SignedString(key.private)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, key.jwk(), nil
+}
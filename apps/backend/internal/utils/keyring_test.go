@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestLegacyTokens(t *testing.T) {
+	secret := []byte("legacy-secret")
+	now := time.Now()
+	started := now.Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		legacy   []byte
+		started  time.Time
+		iat, exp *time.Time
+		wantOK   bool
+	}{
+		{"issued before the keyring", secret, started, at(started.Add(-time.Minute)), at(now.Add(time.Hour)), true},
+		{"without iat", secret, started, nil, at(now.Add(time.Hour)), true},
+		{"no legacy secret", nil, started, at(started.Add(-time.Minute)), at(now.Add(time.Hour)), false},
+		{"issued after the keyring", secret, started, at(now), at(now.Add(time.Hour)), false},
+		{"expiring past the window", secret, started, at(started.Add(-time.Minute)), at(started.Add(MaxTokenLifetime + time.Minute)), false},
+		{"without exp", secret, started, at(started.Add(-time.Minute)), nil, false},
+		{"window over", secret, now.Add(-MaxTokenLifetime - time.Minute), nil, at(now.Add(time.Minute)), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenKeys.mu.Lock()
+			tokenKeys.legacy, tokenKeys.legacyIssuedBy = tt.legacy, tt.started
+			tokenKeys.mu.Unlock()
+			t.Cleanup(func() {
+				tokenKeys.mu.Lock()
+				tokenKeys.legacy, tokenKeys.legacyIssuedBy = nil, time.Time{}
+				tokenKeys.mu.Unlock()
+			})
+
+			claims := Claims{UserID: "u1", Authorized: true, TokenType: "access",
+				RegisteredClaims: jwt.RegisteredClaims{Issuer: "granth"}}
+			if tt.iat != nil {
+				claims.IssuedAt = jwt.NewNumericDate(*tt.iat)
+			}
+			if tt.exp != nil {
+				claims.ExpiresAt = jwt.NewNumericDate(*tt.exp)
+			}
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = ValidateToken(token)
+			if tt.wantOK && err != nil {
+				t.Fatalf("rejected: %v", err)
+			}
+			if !tt.wantOK && err == nil {
+				t.Fatal("accepted")
+			}
+		})
+	}
+}
+
+func at(t time.Time) *time.Time { return &t }
+
+func TestActionTokenLifetimeIsCapped(t *testing.T) {
+	_, err := CreateActionToken("u1", "password_reset", "t1", MaxActionTokenLifetime+time.Minute)
+	if err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Fatalf("got %v, want the lifetime to be refused", err)
+	}
+	if MaxTokenLifetime < RefreshTokenLifetime || MaxTokenLifetime < MaxActionTokenLifetime {
+		t.Fatal("retired keys are dropped before tokens signed with them expire")
+	}
+}
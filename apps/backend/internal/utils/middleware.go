@@ -12,6 +12,10 @@ type Claims struct {
 	UserID     string `json:"user_id"`
 	Authorized bool   `json:"authorized"`
 	TokenType  string `json:"token_type"`
+	// Scopes is only set for personal access tokens; sessions are unscoped.
+	Scopes []string `json:"scopes,omitempty"`
+	// SessionID ties access and refresh tokens to a login session.
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -32,6 +36,17 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		// Extract token
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
+		// Personal access tokens are opaque and checked against the database
+		if strings.HasPrefix(tokenString, PersonalAccessTokenKind+"_") {
+			claims, err := ValidatePersonalAccessToken(tokenString, r.Context())
+			if err != nil {
+				http.Error(w, "Invalid token: "+err.Error(), http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), claims)))
+			return
+		}
+
 		// Validate token
 		claims, err := ValidateToken(tokenString)
 		if err != nil {
@@ -46,7 +61,7 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		}
 
 		// Add claims to context
-		ctx := context.WithValue(r.Context(), "claims", claims)
+		ctx := WithClaims(r.Context(), claims)
 		r = r.WithContext(ctx)
 
 		// Call next handler
@@ -68,3 +83,30 @@ func GetClaimsFromContext(ctx context.Context) (*Claims, bool) {
 	claims, ok := ctx.Value("claims").(*Claims)
 	return claims, ok
 }
+
+// WithClaims returns a copy of ctx carrying claims, the same way
+// AuthMiddleware does for validated tokens.
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, "claims", claims)
+}
+
+// IsAgentContext reports whether the request was authenticated with a
+// service account key rather than a human user's token.
+func IsAgentContext(ctx context.Context) bool {
+	claims, ok := GetClaimsFromContext(ctx)
+	return ok && claims.TokenType == "agent"
+}
+
+// ClientIPMiddleware stores the client address in the request context so
+// service code can record it.
+func ClientIPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "client_ip", ClientIP(r))))
+	})
+}
+
+// GetClientIPFromContext returns the address stored by ClientIPMiddleware.
+func GetClientIPFromContext(ctx context.Context) (string, bool) {
+	ip, ok := ctx.Value("client_ip").(string)
+	return ip, ok
+}
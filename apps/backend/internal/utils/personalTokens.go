@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"granth/internal/config"
+
+	"github.com/lib/pq"
+)
+
+// PersonalAccessTokenKind prefixes personal access tokens so AuthMiddleware
+// can tell them apart from JWTs.
+const PersonalAccessTokenKind = "gpat"
+
+const (
+	ScopeRead    = "read"
+	ScopePropose = "propose"
+	ScopeReview  = "review"
+	ScopeAdmin   = "admin"
+)
+
+var AllScopes = []string{ScopeRead, ScopePropose, ScopeReview, ScopeAdmin}
+
+func IsValidScope(scope string) bool {
+	for _, s := range AllScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidatePersonalAccessToken resolves a presented token to claims for its
+// owner, recording the use. Revoked, expired and unknown tokens are rejected
+// with the same error.
+func ValidatePersonalAccessToken(token string, ctx context.Context) (*Claims, error) {
+	lookup, ok := ParseOpaqueToken(PersonalAccessTokenKind, token)
+	if !ok {
+		return nil, fmt.Errorf("token is invalid")
+	}
+
+	var id, userID, tokenHash string
+	var scopes pq.StringArray
+	var expiresAt sql.NullTime
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT id, user_id, token_hash, scopes, expires_at
+		 FROM personal_access_tokens
+		 WHERE prefix = $1 AND revoked_at IS NULL`,
+		lookup,
+	).Scan(&id, &userID, &tokenHash, &scopes, &expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("token is invalid")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching personal access token: %w", err)
+	}
+	if !TokenMatchesHash(token, tokenHash) {
+		return nil, fmt.Errorf("token is invalid")
+	}
+	if expiresAt.Valid && time.Now().After(expiresAt.Time) {
+		return nil, fmt.Errorf("token is invalid")
+	}
+
+	_, err = config.PostgresDB.ExecContext(ctx,
+		`UPDATE personal_access_tokens SET last_used_at = $1 WHERE id = $2`,
+		time.Now().UTC().Format(time.RFC3339), id)
+	if err != nil {
+		return nil, fmt.Errorf("error updating personal access token usage: %w", err)
+	}
+
+	return &Claims{
+		UserID:     userID,
+		Authorized: true,
+		TokenType:  "pat",
+		Scopes:     []string(scopes),
+	}, nil
+}
+
+// IsPersonalAccessTokenContext reports whether the request was authenticated
+// with a personal access token rather than an interactive session.
+func IsPersonalAccessTokenContext(ctx context.Context) bool {
+	claims, ok := GetClaimsFromContext(ctx)
+	return ok && claims.TokenType == "pat"
+}
+
+// HasScope reports whether the caller may act within scope. Interactive
+// sessions are unscoped and may do anything their role allows.
+func HasScope(ctx context.Context, scope string) bool {
+	claims, ok := GetClaimsFromContext(ctx)
+	if !ok {
+		return false
+	}
+	if claims.TokenType != "pat" {
+		return true
+	}
+	for _, s := range claims.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireScope rejects personal access tokens that were not granted scope.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !HasScope(r.Context(), scope) {
+				http.Error(w, "Token is missing required scope: "+scope, http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
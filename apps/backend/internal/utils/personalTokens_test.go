@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHasScope(t *testing.T) {
+	pat := WithClaims(context.Background(), &Claims{UserID: "u", TokenType: "pat", Scopes: []string{ScopeRead, ScopePropose}})
+	session := WithClaims(context.Background(), &Claims{UserID: "u", TokenType: "access"})
+
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		scope string
+		want  bool
+	}{
+		{"unauthenticated", context.Background(), ScopeRead, false},
+		{"session", session, ScopeAdmin, true},
+		{"granted scope", pat, ScopePropose, true},
+		{"missing scope", pat, ScopeReview, false},
+		{"admin is not implied", pat, ScopeAdmin, false},
+	}
+	for _, tt := range tests {
+		if got := HasScope(tt.ctx, tt.scope); got != tt.want {
+			t.Errorf("%s: HasScope(%s) = %v, want %v", tt.name, tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	handler := RequireScope(ScopeReview)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	for _, tt := range []struct {
+		scopes []string
+		want   int
+	}{
+		{[]string{ScopeRead}, http.StatusForbidden},
+		{[]string{ScopeRead, ScopeReview}, http.StatusNoContent},
+	} {
+		ctx := WithClaims(context.Background(), &Claims{UserID: "u", TokenType: "pat", Scopes: tt.scopes})
+		req := httptest.NewRequest(http.MethodPost, "/", nil).WithContext(ctx)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("scopes %v: got %d, want %d", tt.scopes, rec.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"granth/internal/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimit allows Limit events per sliding Window. Name namespaces the
+// Redis keys so different limits on the same subject do not share a budget.
+type RateLimit struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+}
+
+// slidingWindowScript keeps one sorted-set member per event scored by its
+// time in milliseconds. Events older than the window are trimmed, and the
+// new event is only recorded when it fits. Returns 0 when allowed, or the
+// milliseconds until the oldest event leaves the window.
+var slidingWindowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+if redis.call('ZCARD', KEYS[1]) >= limit then
+	local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+	return math.max(1, tonumber(oldest[2]) + window - now)
+end
+redis.call('ZADD', KEYS[1], now, ARGV[4])
+redis.call('PEXPIRE', KEYS[1], window)
+return 0
+`)
+
+// AllowRequest records an event for subject under limit. When the limit is
+// exhausted it returns false and how long until the next event would fit.
+func AllowRequest(subject string, limit RateLimit, ctx context.Context) (bool, time.Duration, error) {
+	member := make([]byte, 8)
+	if _, err := rand.Read(member); err != nil {
+		return false, 0, fmt.Errorf("error generating rate limit member: %w", err)
+	}
+
+	wait, err := slidingWindowScript.Run(ctx, config.RedisClient,
+		[]string{"ratelimit:" + limit.Name + ":" + subject},
+		time.Now().UnixMilli(), limit.Window.Milliseconds(), limit.Limit, hex.EncodeToString(member),
+	).Int64()
+	if err != nil {
+		return false, 0, fmt.Errorf("error checking rate limit: %w", err)
+	}
+	if wait > 0 {
+		return false, time.Duration(wait) * time.Millisecond, nil
+	}
+	return true, 0, nil
+}
+
+// RateLimitKeyFunc picks the subject a request is counted against.
+type RateLimitKeyFunc func(r *http.Request) string
+
+// KeyByIP counts requests per client address.
+func KeyByIP(r *http.Request) string {
+	return "ip:" + ClientIP(r)
+}
+
+// KeyByUser counts requests per authenticated user, service account or
+// token owner, falling back to the client address. It must run after the
+// authentication middleware.
+func KeyByUser(r *http.Request) string {
+	if userID, ok := GetUserIDFromContext(r.Context()); ok {
+		return "user:" + userID
+	}
+	return KeyByIP(r)
+}
+
+// RateLimitMiddleware rejects requests over limit with 429 and a
+// Retry-After header. Redis failures are logged and the request is let
+// through, so an outage does not take the API down with it.
+func RateLimitMiddleware(limit RateLimit, key RateLimitKeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			allowed, retryAfter, err := AllowRequest(key(r), limit, r.Context())
+			if err != nil {
+				config.Logger.Printf("rate limit %s: %v", limit.Name, err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !allowed {
+				WriteTooManyRequests(w, "Too many requests", retryAfter)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// WriteTooManyRequests sends a 429 with Retry-After rounded up to seconds.
+func WriteTooManyRequests(w http.ResponseWriter, msg string, retryAfter time.Duration) {
+	seconds := int((retryAfter + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	http.Error(w, msg, http.StatusTooManyRequests)
+}
+
+// trustedProxies are the networks whose X-Forwarded-For and X-Real-IP
+// headers are believed. Empty means none are, and the client address is
+// always the connection's.
+var trustedProxies []*net.IPNet
+
+// SetTrustedProxies sets the proxies from a comma-separated list of
+// addresses and CIDR ranges.
+func SetTrustedProxies(list string) error {
+	var nets []*net.IPNet
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return fmt.Errorf("invalid proxy address %q", entry)
+			}
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(entry)
+		if err != nil {
+			return fmt.Errorf("invalid proxy range %q", entry)
+		}
+		nets = append(nets, n)
+	}
+	trustedProxies = nets
+	return nil
+}
+
+func isTrustedProxy(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range trustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the client address without its port. Forwarded headers
+// are only used when the connection comes from a trusted proxy; then the
+// client is the last X-Forwarded-For hop that is not itself a trusted
+// proxy, since earlier hops are whatever the client chose to send.
+func ClientIP(r *http.Request) string {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	if !isTrustedProxy(remote) {
+		return remote
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				break
+			}
+			remote = hop
+			if !isTrustedProxy(hop) {
+				break
+			}
+		}
+		return remote
+	}
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+	return remote
+}
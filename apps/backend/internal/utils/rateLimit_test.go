@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	if err := SetTrustedProxies("10.0.0.0/8, 192.0.2.7"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { trustedProxies = nil })
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded string
+		realIP    string
+		want      string
+	}{
+		{"direct", "203.0.113.5:4000", "", "", "203.0.113.5"},
+		{"untrusted peer cannot forward", "203.0.113.5:4000", "198.51.100.1", "198.51.100.2", "203.0.113.5"},
+		{"trusted proxy", "10.1.2.3:4000", "198.51.100.1", "", "198.51.100.1"},
+		{"trusted single address", "192.0.2.7:4000", "198.51.100.1", "", "198.51.100.1"},
+		{"spoofed leading hops", "10.1.2.3:4000", "1.1.1.1, 198.51.100.1", "", "198.51.100.1"},
+		{"chained proxies", "10.1.2.3:4000", "198.51.100.1, 10.9.9.9", "", "198.51.100.1"},
+		{"only proxies", "10.1.2.3:4000", "10.9.9.9", "", "10.9.9.9"},
+		{"garbage hop", "10.1.2.3:4000", "not-an-ip", "", "10.1.2.3"},
+		{"real ip from trusted proxy", "10.1.2.3:4000", "", "198.51.100.1", "198.51.100.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remote
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := ClientIP(r); got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetTrustedProxiesRejectsGarbage(t *testing.T) {
+	t.Cleanup(func() { trustedProxies = nil })
+	for _, list := range []string{"10.0.0.0/33", "proxy.internal"} {
+		if err := SetTrustedProxies(list); err == nil {
+			t.Errorf("%q accepted", list)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// GenerateOpaqueToken mints a random bearer secret of the form
+// <kind>_<lookup>_<secret>. The lookup part is safe to store and index; only
+// the SHA-256 of the whole token is persisted.
+func GenerateOpaqueToken(kind string) (token, lookup, hash string, err error) {
+	lookupBytes := make([]byte, 6)
+	if _, err := rand.Read(lookupBytes); err != nil {
+		return "", "", "", fmt.Errorf("error generating token: %w", err)
+	}
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", "", "", fmt.Errorf("error generating token: %w", err)
+	}
+
+	lookup = hex.EncodeToString(lookupBytes)
+	token = kind + "_" + lookup + "_" + base64.RawURLEncoding.EncodeToString(secretBytes)
+	return token, lookup, HashToken(token), nil
+}
+
+// ParseOpaqueToken splits a token minted by GenerateOpaqueToken and returns
+// its lookup part.
+func ParseOpaqueToken(kind, token string) (string, bool) {
+	parts := strings.SplitN(token, "_", 3)
+	if len(parts) != 3 || parts[0] != kind || parts[1] == "" || parts[2] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// TokenMatchesHash compares a presented token against a stored hash in
+// constant time.
+func TokenMatchesHash(token, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashToken(token)), []byte(hash)) == 1
+}
@@ -1,51 +1,57 @@
 package utils
 
 import (
-	"os"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var secretKey, _ = os.LookupEnv("JWT_SECRET")
+// RefreshTokenLifetime is how long a refresh token, and so an idle session,
+// stays valid.
+const RefreshTokenLifetime = 24 * time.Hour
 
-func CreateUserToken(userID string) (string, error) {
+// MaxActionTokenLifetime caps the ttl of CreateActionToken.
+const MaxActionTokenLifetime = 72 * time.Hour
+
+func CreateUserToken(userID, sessionID string) (string, error) {
 	claims := Claims{
 		UserID:     userID,
 		Authorized: true,
 		TokenType:  "access",
+		SessionID:  sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(1 * time.Minute)),
 			Issuer:    "granth",
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secretKey))
+	return signToken(claims)
 }
 
-func CreateRefreshToken(userID string) (string, error) {
+// CreateRefreshToken signs a refresh token for a session. tokenID becomes
+// the jti and identifies this link in the session's rotation chain.
+func CreateRefreshToken(userID, sessionID, tokenID string) (string, error) {
 	claims := Claims{
 		UserID:     userID,
 		Authorized: true,
 		TokenType:  "refresh",
+		SessionID:  sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(1 * 24 * time.Hour)),
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(RefreshTokenLifetime)),
 			Issuer:    "granth",
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secretKey))
+	return signToken(claims)
 }
 
 func ValidateToken(token string) (*Claims, error) {
-	parsedToken, err := jwt.ParseWithClaims(token, &Claims{}, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrTokenMalformed
-		}
-		return []byte(secretKey), nil
-	})
+	parsedToken, err := jwt.ParseWithClaims(token, &Claims{}, verificationKey,
+		jwt.WithValidMethods([]string{"EdDSA", "RS256", "HS256"}),
+		jwt.WithIssuer("granth"),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -58,28 +64,25 @@ func ValidateToken(token string) (*Claims, error) {
 	return claims, nil
 }
 
-func RefreshToken(token string) (string, string, string, error) {
-	claims, err := ValidateToken(token)
-	if err != nil {
-		return "", "", "", err
-	}
-
-	// Ensure this is a refresh token
-	if claims.TokenType != "refresh" {
-		return "", "", "", jwt.ErrTokenInvalidClaims
-	}
-
-	userID := claims.UserID
-
-	accessToken, err := CreateUserToken(userID)
-	if err != nil {
-		return "", "", "", err
+// CreateActionToken signs a short-lived token for a single action such as a
+// password reset. The purpose is carried as the token type so action tokens
+// are never accepted as access tokens; tokenID is recorded server-side to
+// make the token single use.
+func CreateActionToken(userID, purpose, tokenID string, ttl time.Duration) (string, error) {
+	// retired keys are only kept for MaxTokenLifetime
+	if ttl > MaxActionTokenLifetime {
+		return "", fmt.Errorf("action token lifetime %s exceeds %s", ttl, MaxActionTokenLifetime)
 	}
-
-	refreshToken, err := CreateRefreshToken(userID)
-	if err != nil {
-		return "", "", "", err
+	claims := Claims{
+		UserID:     userID,
+		Authorized: false,
+		TokenType:  purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			Issuer:    "granth",
+		},
 	}
 
-	return accessToken, refreshToken, userID, nil
+	return signToken(claims)
 }
@@ -0,0 +1,227 @@
+package workspaces
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"granth/internal/audit"
+	"granth/internal/config"
+	granthmail "granth/internal/mail"
+	"granth/internal/utils"
+)
+
+const (
+	defaultGuestGrantDays = 14
+	maxGuestGrantDays     = 90
+)
+
+// guestPermissions are all a guest can ever be granted.
+var guestPermissions = []Permission{PermRead, PermComment, PermReview}
+
+// normalizeGuestPermissions validates a guest permission list. Read is
+// always included.
+func normalizeGuestPermissions(permissions []Permission) ([]Permission, error) {
+	out := []Permission{PermRead}
+	for _, p := range permissions {
+		if !hasPermission(guestPermissions, p) {
+			return nil, fmt.Errorf("guests can only be granted read, comment and review")
+		}
+		if !hasPermission(out, p) {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func listGuestGrants(workspaceID string, ctx context.Context) ([]*GuestGrant, error) {
+	if _, err := requirePermission(workspaceID, PermManageMembers, ctx); err != nil {
+		return nil, err
+	}
+	return fetchGuestGrantsForWorkspace(workspaceID, ctx)
+}
+
+// createGuestGrant gives the account using email access to one document or
+// one proposal until the grant expires. A proposal grant that includes
+// review also asks the guest to review it.
+func createGuestGrant(workspaceID, email string, documentID, proposalID *string, permissions []Permission, expiresInDays int, note string, ctx context.Context) (*GuestGrant, error) {
+	admin, err := requirePermission(workspaceID, PermManageMembers, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if (documentID == nil) == (proposalID == nil) {
+		return nil, fmt.Errorf("exactly one of document_id and proposal_id is required")
+	}
+	addr, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil {
+		return nil, fmt.Errorf("invalid email address")
+	}
+	permissions, err = normalizeGuestPermissions(permissions)
+	if err != nil {
+		return nil, err
+	}
+	if expiresInDays == 0 {
+		expiresInDays = defaultGuestGrantDays
+	}
+	if expiresInDays < 1 || expiresInDays > maxGuestGrantDays {
+		return nil, fmt.Errorf("expires_in_days must be between 1 and %d", maxGuestGrantDays)
+	}
+	note = strings.TrimSpace(note)
+	if len(note) > 500 {
+		return nil, fmt.Errorf("note must be at most 500 characters")
+	}
+
+	targetWorkspace, title, err := fetchGuestTarget(documentID, proposalID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if targetWorkspace != workspaceID {
+		if documentID != nil {
+			return nil, fmt.Errorf("document not found")
+		}
+		return nil, fmt.Errorf("proposal not found")
+	}
+
+	guestID, username, err := fetchUserByEmail(addr.Address, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if guestID == "" {
+		return nil, fmt.Errorf("no account uses this email address; ask them to sign up first")
+	}
+	member, err := fetchMember(workspaceID, guestID, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error checking membership: %w", err)
+	}
+	if member != nil {
+		return nil, fmt.Errorf("user is already a member of this workspace")
+	}
+
+	g := &GuestGrant{
+		WorkspaceID: workspaceID,
+		UserID:      guestID,
+		Username:    username,
+		Email:       strings.ToLower(addr.Address),
+		DocumentID:  documentID,
+		ProposalID:  proposalID,
+		TargetTitle: title,
+		Permissions: permissions,
+		Note:        note,
+		Status:      "active",
+		GrantedBy:   &admin.UserID,
+		ExpiresAt:   time.Now().UTC().Add(time.Duration(expiresInDays) * 24 * time.Hour).Format(time.RFC3339),
+	}
+	requestReview := proposalID != nil && hasPermission(permissions, PermReview)
+	if err := insertGuestGrant(g, requestReview, ctx); err != nil {
+		return nil, err
+	}
+
+	w, err := fetchWorkspaceByID(workspaceID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	g.WorkspaceName = w.Name
+	audit.Record(audit.Entry{WorkspaceID: workspaceID, Action: "guest_grant.created", TargetType: "guest_grant", TargetID: g.ID, After: g}, ctx)
+	sendGuestGrantEmailAsync(g, admin.Username)
+	return g, nil
+}
+
+// sendGuestGrantEmailAsync tells the guest what was shared with them.
+func sendGuestGrantEmailAsync(g *GuestGrant, grantedBy string) {
+	var link string
+	if g.DocumentID != nil {
+		link = config.AppURL + "/truth/" + *g.DocumentID
+	} else {
+		link = config.AppURL + "/proposals/" + *g.ProposalID
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		err := granthmail.Send(granthmail.Message{
+			To:      g.Email,
+			Subject: fmt.Sprintf("%s shared %q with you on Granth", grantedBy, g.TargetTitle),
+			Body: fmt.Sprintf("%s gave you guest access to %q in the workspace %q until %s.\n\n%s",
+				grantedBy, g.TargetTitle, g.WorkspaceName, g.ExpiresAt, link),
+		}, ctx)
+		if err != nil {
+			config.Logger.Printf("error sending guest grant %s: %v", g.ID, err)
+		}
+	}()
+}
+
+func revokeGuestGrant(workspaceID, grantID string, ctx context.Context) error {
+	admin, err := requirePermission(workspaceID, PermManageMembers, ctx)
+	if err != nil {
+		return err
+	}
+	g, err := fetchGuestGrant(workspaceID, grantID, ctx)
+	if err != nil {
+		return err
+	}
+	if g == nil {
+		return fmt.Errorf("guest grant not found")
+	}
+	revoked, err := revokeGuestGrantRow(grantID, admin.UserID, ctx)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return fmt.Errorf("guest grant is already revoked")
+	}
+	audit.Record(audit.Entry{WorkspaceID: workspaceID, Action: "guest_grant.revoked", TargetType: "guest_grant", TargetID: grantID, Before: g}, ctx)
+	return nil
+}
+
+func listGuestGrantEvents(workspaceID, grantID string, ctx context.Context) ([]*GuestGrantEvent, error) {
+	if _, err := requirePermission(workspaceID, PermManageMembers, ctx); err != nil {
+		return nil, err
+	}
+	g, err := fetchGuestGrant(workspaceID, grantID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if g == nil {
+		return nil, fmt.Errorf("guest grant not found")
+	}
+	return fetchGuestGrantEvents(grantID, ctx)
+}
+
+// listMyGuestGrants returns the live grants held by the requesting user.
+func listMyGuestGrants(ctx context.Context) ([]*GuestGrant, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+	return fetchActiveGuestGrantsForUser(userID, ctx)
+}
+
+// RequireGuestPermission checks that the requesting user holds a live guest
+// grant for perm on the document or the proposal, and records the use.
+// proposalID may be "" when acting on the document itself.
+func RequireGuestPermission(workspaceID, documentID, proposalID string, perm Permission, ctx context.Context) error {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("user ID not found in context")
+	}
+	grantID, err := findGuestGrant(workspaceID, userID, documentID, proposalID, perm, ctx)
+	if err != nil {
+		return err
+	}
+	if grantID == "" {
+		return fmt.Errorf("%w: requires the %s permission", ErrPermissionDenied, perm)
+	}
+	target := proposalID
+	if target == "" {
+		target = documentID
+	}
+	return insertGuestGrantUse(grantID, userID, perm, target, ctx)
+}
+
+// GuestHasPermission reports whether userID holds a live guest grant for
+// perm on the document or the proposal. It does not record a use.
+func GuestHasPermission(workspaceID, userID, documentID, proposalID string, perm Permission, ctx context.Context) (bool, error) {
+	grantID, err := findGuestGrant(workspaceID, userID, documentID, proposalID, perm, ctx)
+	return grantID != "", err
+}
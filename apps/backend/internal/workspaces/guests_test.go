@@ -0,0 +1,115 @@
+package workspaces
+
+import (
+	"errors"
+	"testing"
+
+	"granth/internal/testdb"
+)
+
+func TestCreateGuestGrantValidation(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	member := testdb.CreateUser(t)
+	guest := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	otherWorkspaceID := testdb.CreateWorkspace(t, owner)
+	testdb.AddMember(t, workspaceID, member, string(RoleContributor))
+	documentID := testdb.CreateDocument(t, workspaceID, owner)
+	foreignDocumentID := testdb.CreateDocument(t, otherWorkspaceID, owner)
+	guestEmail, _, err := fetchUserEmail(guest, testdb.As(owner))
+	if err != nil {
+		t.Fatal(err)
+	}
+	memberEmail, _, err := fetchUserEmail(member, testdb.As(owner))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		userID      string
+		email       string
+		documentID  *string
+		permissions []Permission
+		want        string
+	}{
+		{"caller without manage_members", member, guestEmail, &documentID, nil, "permission denied"},
+		{"no target", owner, guestEmail, nil, nil, "exactly one of document_id and proposal_id is required"},
+		{"permission guests cannot hold", owner, guestEmail, &documentID, []Permission{PermAccept}, "guests can only be granted read, comment and review"},
+		{"document of another workspace", owner, guestEmail, &foreignDocumentID, nil, "document not found"},
+		{"existing member", owner, memberEmail, &documentID, nil, "user is already a member of this workspace"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := createGuestGrant(workspaceID, tt.email, tt.documentID, nil, tt.permissions, 0, "", testdb.As(tt.userID))
+			if tt.want == "permission denied" {
+				if !errors.Is(err, ErrPermissionDenied) {
+					t.Fatalf("got %v, want permission denied", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("got %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuestGrantScopeAndRevocation(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	guest := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	documentID := testdb.CreateDocument(t, workspaceID, owner)
+	otherDocumentID := testdb.CreateDocument(t, workspaceID, owner)
+	email, _, err := fetchUserEmail(guest, testdb.As(owner))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := createGuestGrant(workspaceID, email, &documentID, nil, []Permission{PermComment}, 1, "", testdb.As(owner))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasPermission(g.Permissions, PermRead) {
+		t.Fatalf("permissions = %v, want read to be included", g.Permissions)
+	}
+
+	ctx := testdb.As(guest)
+	if err := RequireGuestPermission(workspaceID, documentID, "", PermComment, ctx); err != nil {
+		t.Fatalf("granted permission on the granted document: %v", err)
+	}
+	if err := RequireGuestPermission(workspaceID, documentID, "", PermReview, ctx); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("permission outside the grant: got %v, want permission denied", err)
+	}
+	if err := RequireGuestPermission(workspaceID, otherDocumentID, "", PermRead, ctx); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("another document: got %v, want permission denied", err)
+	}
+	if err := RequirePermission(workspaceID, PermRead, ctx); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("a guest grant must not make the guest a member: got %v", err)
+	}
+
+	events, err := listGuestGrantEvents(workspaceID, g.ID, testdb.As(owner))
+	if err != nil {
+		t.Fatal(err)
+	}
+	uses := 0
+	for _, e := range events {
+		if e.Action == "used" {
+			uses++
+		}
+	}
+	if uses != 1 {
+		t.Fatalf("recorded %d uses, want 1 for the one granted check", uses)
+	}
+
+	if err := revokeGuestGrant(workspaceID, g.ID, testdb.As(owner)); err != nil {
+		t.Fatal(err)
+	}
+	if err := RequireGuestPermission(workspaceID, documentID, "", PermRead, ctx); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("after revocation: got %v, want permission denied", err)
+	}
+	if err := revokeGuestGrant(workspaceID, g.ID, testdb.As(owner)); err == nil || err.Error() != "guest grant is already revoked" {
+		t.Fatalf("revoking twice: got %v", err)
+	}
+}
@@ -0,0 +1,292 @@
+package workspaces
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"granth/internal/audit"
+	"granth/internal/config"
+	granthmail "granth/internal/mail"
+	"granth/internal/utils"
+)
+
+const (
+	invitationTokenKind = "ginv"
+
+	defaultInvitationDays = 7
+	maxInvitationDays     = 30
+)
+
+func invitationURL(token string) string {
+	return config.AppURL + "/invitations/accept?token=" + url.QueryEscape(token)
+}
+
+func invitationExpiry(expiresInDays int) (string, error) {
+	if expiresInDays == 0 {
+		expiresInDays = defaultInvitationDays
+	}
+	if expiresInDays < 1 || expiresInDays > maxInvitationDays {
+		return "", fmt.Errorf("expires_in_days must be between 1 and %d", maxInvitationDays)
+	}
+	return time.Now().UTC().Add(time.Duration(expiresInDays) * 24 * time.Hour).Format(time.RFC3339), nil
+}
+
+func listInvitations(workspaceID string, ctx context.Context) ([]*Invitation, error) {
+	if _, err := requirePermission(workspaceID, PermManageMembers, ctx); err != nil {
+		return nil, err
+	}
+	return fetchPendingInvitations(workspaceID, ctx)
+}
+
+// inviteByEmail invites an address, registered or not, and mails it a link.
+// Inviting the same address again replaces the earlier invitation.
+func inviteByEmail(workspaceID, email, role string, expiresInDays int, ctx context.Context) (*Invitation, error) {
+	admin, err := requirePermission(workspaceID, PermManageMembers, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := requireAssignableRole(workspaceID, role, admin, ctx); err != nil {
+		return nil, err
+	}
+	addr, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil {
+		return nil, fmt.Errorf("invalid email address")
+	}
+	email = strings.ToLower(addr.Address)
+	expiresAt, err := invitationExpiry(expiresInDays)
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := memberExistsByEmail(workspaceID, email, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if member {
+		return nil, fmt.Errorf("user is already a member of this workspace")
+	}
+	if err := revokeEmailInvitations(workspaceID, email, ctx); err != nil {
+		return nil, err
+	}
+
+	token, prefix, hash, err := utils.GenerateOpaqueToken(invitationTokenKind)
+	if err != nil {
+		return nil, err
+	}
+	inv := &Invitation{
+		WorkspaceID: workspaceID,
+		Kind:        "email",
+		Email:       &email,
+		Role:        role,
+		Prefix:      prefix,
+		InvitedBy:   &admin.UserID,
+		InviterName: &admin.Username,
+		ExpiresAt:   expiresAt,
+	}
+	if err := insertInvitation(inv, hash, ctx); err != nil {
+		return nil, err
+	}
+
+	w, err := fetchWorkspaceByID(workspaceID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	inv.WorkspaceName = w.Name
+	audit.Record(audit.Entry{WorkspaceID: workspaceID, Action: "invitation.created", TargetType: "invitation", TargetID: inv.ID, After: inv}, ctx)
+	sendInvitationEmailAsync(inv, token)
+	return inv, nil
+}
+
+// sendInvitationEmailAsync mails the invitation link in the background; on
+// failure the admin can invite the address again.
+func sendInvitationEmailAsync(inv *Invitation, token string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		err := granthmail.Send(granthmail.Message{
+			To:      *inv.Email,
+			Subject: fmt.Sprintf("%s invited you to %s on Granth", *inv.InviterName, inv.WorkspaceName),
+			Body: fmt.Sprintf("%s invited you to join the workspace %q as %s. Open the link below to accept or decline; "+
+				"you can create an account with this email address if you don't have one yet. The invitation expires on %s.\n\n%s",
+				*inv.InviterName, inv.WorkspaceName, inv.Role, inv.ExpiresAt, invitationURL(token)),
+		}, ctx)
+		if err != nil {
+			config.Logger.Printf("error sending invitation %s: %v", inv.ID, err)
+		}
+	}()
+}
+
+// createInviteLink creates a shareable link. Links never grant management
+// permissions, since anyone who gets hold of one can use it.
+func createInviteLink(workspaceID, role string, expiresInDays int, maxUses *int, ctx context.Context) (*CreatedInvitation, error) {
+	admin, err := requirePermission(workspaceID, PermManageMembers, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		role = string(RoleContributor)
+	}
+	r, err := requireAssignableRole(workspaceID, role, admin, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if grantsManagement(r) {
+		return nil, fmt.Errorf("invalid role: invite links cannot grant manage_members or manage_policy")
+	}
+	if maxUses != nil && *maxUses < 1 {
+		return nil, fmt.Errorf("max_uses must be at least 1")
+	}
+	expiresAt, err := invitationExpiry(expiresInDays)
+	if err != nil {
+		return nil, err
+	}
+
+	token, prefix, hash, err := utils.GenerateOpaqueToken(invitationTokenKind)
+	if err != nil {
+		return nil, err
+	}
+	inv := &Invitation{
+		WorkspaceID: workspaceID,
+		Kind:        "link",
+		Role:        role,
+		Prefix:      prefix,
+		MaxUses:     maxUses,
+		InvitedBy:   &admin.UserID,
+		InviterName: &admin.Username,
+		ExpiresAt:   expiresAt,
+	}
+	if err := insertInvitation(inv, hash, ctx); err != nil {
+		return nil, err
+	}
+	audit.Record(audit.Entry{WorkspaceID: workspaceID, Action: "invitation.created", TargetType: "invitation", TargetID: inv.ID, After: inv}, ctx)
+	return &CreatedInvitation{Invitation: *inv, URL: invitationURL(token)}, nil
+}
+
+func revokeInvitation(workspaceID, invitationID string, ctx context.Context) error {
+	if _, err := requirePermission(workspaceID, PermManageMembers, ctx); err != nil {
+		return err
+	}
+	revoked, err := revokePendingInvitation(workspaceID, invitationID, ctx)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return fmt.Errorf("invitation not found")
+	}
+	audit.Record(audit.Entry{WorkspaceID: workspaceID, Action: "invitation.revoked", TargetType: "invitation", TargetID: invitationID}, ctx)
+	return nil
+}
+
+// listMyInvitations returns the pending email invitations for the caller.
+func listMyInvitations(ctx context.Context) ([]*Invitation, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+	return fetchPendingInvitationsForUser(userID, ctx)
+}
+
+// resolveInvitation finds the live invitation the caller refers to, either
+// by token (from an email or a shared link) or, for email invitations
+// shown in the app, by ID. Email invitations only work for the account
+// with that address; by ID the address must also be verified.
+func resolveInvitation(token, invitationID string, ctx context.Context) (*Invitation, string, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return nil, "", fmt.Errorf("user ID not found in context")
+	}
+
+	var inv *Invitation
+	if token != "" {
+		prefix, ok := utils.ParseOpaqueToken(invitationTokenKind, token)
+		if !ok {
+			return nil, "", fmt.Errorf("invitation not found")
+		}
+		found, hash, err := fetchInvitationByPrefix(prefix, ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		if found == nil || !utils.TokenMatchesHash(token, hash) {
+			return nil, "", fmt.Errorf("invitation not found")
+		}
+		inv = found
+	} else {
+		found, err := fetchInvitationByID(invitationID, ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		if found == nil || found.Kind != "email" {
+			return nil, "", fmt.Errorf("invitation not found")
+		}
+		inv = found
+	}
+
+	if inv.Kind == "email" {
+		email, verified, err := fetchUserEmail(userID, ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		if !strings.EqualFold(email, *inv.Email) {
+			if token == "" {
+				return nil, "", fmt.Errorf("invitation not found")
+			}
+			return nil, "", fmt.Errorf("this invitation was sent to a different email address")
+		}
+		if token == "" && !verified {
+			return nil, "", fmt.Errorf("verify your email address to accept this invitation")
+		}
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, inv.ExpiresAt)
+	if inv.Status != "pending" || err != nil || !expiresAt.After(time.Now()) ||
+		(inv.MaxUses != nil && inv.UseCount >= *inv.MaxUses) {
+		return nil, "", fmt.Errorf("invitation has expired or is no longer valid")
+	}
+	return inv, userID, nil
+}
+
+// previewInvitation shows who invited the caller to which workspace before
+// they accept.
+func previewInvitation(token string, ctx context.Context) (*Invitation, error) {
+	inv, _, err := resolveInvitation(token, "", ctx)
+	return inv, err
+}
+
+func acceptInvitation(token, invitationID string, ctx context.Context) (*WorkspaceMember, error) {
+	inv, userID, err := resolveInvitation(token, invitationID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	redeemed, member, err := redeemInvitation(inv, userID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !redeemed {
+		return nil, fmt.Errorf("invitation has expired or is no longer valid")
+	}
+	audit.Record(audit.Entry{WorkspaceID: inv.WorkspaceID, Action: "invitation.accepted", TargetType: "invitation", TargetID: inv.ID, After: member}, ctx)
+	return member, nil
+}
+
+func declineInvitation(token, invitationID string, ctx context.Context) error {
+	inv, userID, err := resolveInvitation(token, invitationID, ctx)
+	if err != nil {
+		return err
+	}
+	if inv.Kind != "email" {
+		return fmt.Errorf("invite links cannot be declined")
+	}
+	declined, err := declineEmailInvitation(inv.ID, userID, ctx)
+	if err != nil {
+		return err
+	}
+	if !declined {
+		return fmt.Errorf("invitation has expired or is no longer valid")
+	}
+	audit.Record(audit.Entry{WorkspaceID: inv.WorkspaceID, Action: "invitation.declined", TargetType: "invitation", TargetID: inv.ID}, ctx)
+	return nil
+}
@@ -0,0 +1,143 @@
+package workspaces
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"granth/internal/testdb"
+	"granth/internal/utils"
+)
+
+// emailInvitation stores an email invitation for userID's address and
+// returns its token.
+func emailInvitation(t *testing.T, workspaceID, inviterID, userID string, expiresAt time.Time) string {
+	t.Helper()
+	email, _, err := fetchUserEmail(userID, testdb.As(inviterID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, prefix, hash, err := utils.GenerateOpaqueToken(invitationTokenKind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inv := &Invitation{
+		WorkspaceID: workspaceID,
+		Kind:        "email",
+		Email:       &email,
+		Role:        string(RoleReviewer),
+		Prefix:      prefix,
+		InvitedBy:   &inviterID,
+		ExpiresAt:   expiresAt.UTC().Format(time.RFC3339),
+	}
+	if err := insertInvitation(inv, hash, testdb.As(inviterID)); err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func linkToken(t *testing.T, created *CreatedInvitation) string {
+	t.Helper()
+	u, err := url.Parse(created.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Query().Get("token")
+}
+
+func TestEmailInvitationAccept(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	invitee := testdb.CreateUser(t)
+	stranger := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	token := emailInvitation(t, workspaceID, owner, invitee, time.Now().Add(time.Hour))
+
+	if _, err := acceptInvitation(token, "", testdb.As(stranger)); err == nil || err.Error() != "this invitation was sent to a different email address" {
+		t.Fatalf("stranger accepting: got %v", err)
+	}
+	member, err := acceptInvitation(token, "", testdb.As(invitee))
+	if err != nil {
+		t.Fatalf("accepting: %v", err)
+	}
+	if member.Role != string(RoleReviewer) {
+		t.Fatalf("role = %q, want reviewer", member.Role)
+	}
+	if _, err := acceptInvitation(token, "", testdb.As(invitee)); err == nil || err.Error() != "invitation has expired or is no longer valid" {
+		t.Fatalf("accepting twice: got %v", err)
+	}
+}
+
+func TestEmailInvitationDecline(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	invitee := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	token := emailInvitation(t, workspaceID, owner, invitee, time.Now().Add(time.Hour))
+
+	if err := declineInvitation(token, "", testdb.As(invitee)); err != nil {
+		t.Fatalf("declining: %v", err)
+	}
+	if _, err := acceptInvitation(token, "", testdb.As(invitee)); err == nil {
+		t.Fatal("a declined invitation was accepted")
+	}
+	member, err := fetchMember(workspaceID, invitee, testdb.As(owner))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if member != nil {
+		t.Fatal("declining made the invitee a member")
+	}
+}
+
+func TestExpiredInvitationIsRefused(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	invitee := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	token := emailInvitation(t, workspaceID, owner, invitee, time.Now().Add(-time.Minute))
+
+	if _, err := acceptInvitation(token, "", testdb.As(invitee)); err == nil || err.Error() != "invitation has expired or is no longer valid" {
+		t.Fatalf("got %v, want the invitation to have expired", err)
+	}
+}
+
+func TestInviteLinkMaxUses(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	first := testdb.CreateUser(t)
+	second := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	maxUses := 1
+	created, err := createInviteLink(workspaceID, "", 1, &maxUses, testdb.As(owner))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := linkToken(t, created)
+
+	if err := declineInvitation(token, "", testdb.As(first)); err == nil || err.Error() != "invite links cannot be declined" {
+		t.Fatalf("declining a link: got %v", err)
+	}
+	if _, err := acceptInvitation(token, "", testdb.As(first)); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	if _, err := acceptInvitation(token, "", testdb.As(second)); err == nil || err.Error() != "invitation has expired or is no longer valid" {
+		t.Fatalf("use beyond max_uses: got %v", err)
+	}
+}
+
+func TestInviteLinkRequiresManageMembersAndCannotGrantIt(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	contributor := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	testdb.AddMember(t, workspaceID, contributor, "contributor")
+
+	if _, err := createInviteLink(workspaceID, "", 1, nil, testdb.As(contributor)); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("contributor creating a link: got %v, want permission denied", err)
+	}
+	if _, err := createInviteLink(workspaceID, string(RoleAdmin), 1, nil, testdb.As(owner)); err == nil {
+		t.Fatal("created an invite link that grants admin")
+	}
+}
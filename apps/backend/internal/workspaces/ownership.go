@@ -0,0 +1,242 @@
+package workspaces
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"granth/internal/audit"
+	"granth/internal/config"
+	granthmail "granth/internal/mail"
+	"granth/internal/utils"
+)
+
+// requireOwner returns the workspace when the requesting user owns it.
+func requireOwner(workspaceID, msg string, ctx context.Context) (*Workspace, string, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return nil, "", fmt.Errorf("user ID not found in context")
+	}
+	w, err := fetchWorkspaceByID(workspaceID, ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	if w.OwnerID != userID {
+		return nil, "", fmt.Errorf("%s", msg)
+	}
+	return w, userID, nil
+}
+
+// transferOwnership offers the workspace to another member. The new owner
+// has to be able to manage members, and nothing changes until they accept.
+func transferOwnership(workspaceID, toUserID string, ctx context.Context) (*OwnershipTransfer, error) {
+	w, userID, err := requireOwner(workspaceID, "only the workspace owner can transfer ownership", ctx)
+	if err != nil {
+		return nil, err
+	}
+	if toUserID == userID {
+		return nil, fmt.Errorf("you already own this workspace")
+	}
+	owner, err := fetchMember(workspaceID, userID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	target, err := fetchMember(workspaceID, toUserID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, fmt.Errorf("user is not a member of this workspace")
+	}
+	permissions, err := memberPermissions(workspaceID, target, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !hasPermission(permissions, PermManageMembers) {
+		return nil, fmt.Errorf("the new owner must be able to manage members")
+	}
+
+	t := &OwnershipTransfer{
+		WorkspaceID:   workspaceID,
+		WorkspaceName: w.Name,
+		FromUserID:    &userID,
+		ToUserID:      toUserID,
+		ToUsername:    target.Username,
+	}
+	if owner != nil {
+		t.FromUsername = &owner.Username
+	}
+	if err := insertOwnershipTransfer(t, ctx); err != nil {
+		return nil, err
+	}
+	audit.Record(audit.Entry{WorkspaceID: workspaceID, Action: "ownership.offered", TargetType: "ownership_transfer", TargetID: t.ID, After: t}, ctx)
+	sendOwnershipTransferEmailAsync(t)
+	return t, nil
+}
+
+// sendOwnershipTransferEmailAsync asks the new owner to confirm.
+func sendOwnershipTransferEmailAsync(t *OwnershipTransfer) {
+	from := "The workspace owner"
+	if t.FromUsername != nil {
+		from = *t.FromUsername
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		email, _, err := fetchUserEmail(t.ToUserID, ctx)
+		if err == nil {
+			err = granthmail.Send(granthmail.Message{
+				To:      email,
+				Subject: fmt.Sprintf("%s wants you to own %q on Granth", from, t.WorkspaceName),
+				Body: fmt.Sprintf("%s offered you ownership of the workspace %q. Accept or decline it from your workspaces:\n\n%s",
+					from, t.WorkspaceName, config.AppURL+"/group"),
+			}, ctx)
+		}
+		if err != nil {
+			config.Logger.Printf("error sending ownership transfer %s: %v", t.ID, err)
+		}
+	}()
+}
+
+// getOwnershipTransfer returns the workspace's pending offer, or nil.
+func getOwnershipTransfer(workspaceID string, ctx context.Context) (*OwnershipTransfer, error) {
+	if _, err := requirePermission(workspaceID, PermRead, ctx); err != nil {
+		return nil, err
+	}
+	return fetchPendingOwnershipTransfer(workspaceID, ctx)
+}
+
+// listMyOwnershipTransfers returns the offers waiting on the requesting user.
+func listMyOwnershipTransfers(ctx context.Context) ([]*OwnershipTransfer, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+	return fetchPendingOwnershipTransfersForUser(userID, ctx)
+}
+
+func cancelOwnershipTransfer(workspaceID string, ctx context.Context) error {
+	if _, _, err := requireOwner(workspaceID, "only the workspace owner can cancel a transfer", ctx); err != nil {
+		return err
+	}
+	t, err := fetchPendingOwnershipTransfer(workspaceID, ctx)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return fmt.Errorf("no ownership transfer is pending")
+	}
+	closed, err := closeOwnershipTransfer(t.ID, "cancelled", ctx)
+	if err != nil {
+		return err
+	}
+	if !closed {
+		return fmt.Errorf("no ownership transfer is pending")
+	}
+	audit.Record(audit.Entry{WorkspaceID: workspaceID, Action: "ownership.cancelled", TargetType: "ownership_transfer", TargetID: t.ID}, ctx)
+	return nil
+}
+
+// respondOwnershipTransfer lets the member the workspace was offered to
+// accept or decline it. Accepting needs the manage_members permission, so
+// a member demoted since the offer cannot take over.
+func respondOwnershipTransfer(workspaceID string, accept bool, ctx context.Context) (*Workspace, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+	t, err := fetchPendingOwnershipTransfer(workspaceID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil || t.ToUserID != userID {
+		return nil, fmt.Errorf("no ownership transfer is pending")
+	}
+
+	if !accept {
+		if _, err := closeOwnershipTransfer(t.ID, "declined", ctx); err != nil {
+			return nil, err
+		}
+		audit.Record(audit.Entry{WorkspaceID: workspaceID, Action: "ownership.declined", TargetType: "ownership_transfer", TargetID: t.ID}, ctx)
+		return fetchWorkspaceByID(workspaceID, ctx)
+	}
+
+	if _, err := requirePermission(workspaceID, PermManageMembers, ctx); err != nil {
+		return nil, err
+	}
+	done, err := completeOwnershipTransfer(t, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !done {
+		return nil, fmt.Errorf("no ownership transfer is pending")
+	}
+	audit.Record(audit.Entry{
+		WorkspaceID: workspaceID, Action: "ownership.accepted", TargetType: "workspace", TargetID: workspaceID,
+		Before: map[string]*string{"owner_id": t.FromUserID}, After: map[string]string{"owner_id": t.ToUserID},
+	}, ctx)
+	return fetchWorkspaceByID(workspaceID, ctx)
+}
+
+// leaveWorkspace removes the requesting user from the workspace. Their open
+// proposals and steward rules go to successorID, or to the owner when it is
+// empty. The owner has to transfer ownership first, and the last member
+// able to manage members cannot leave.
+func leaveWorkspace(workspaceID, successorID string, ctx context.Context) (*LeaveResult, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+	member, err := fetchMember(workspaceID, userID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, fmt.Errorf("user is not a member of this workspace")
+	}
+	w, err := fetchWorkspaceByID(workspaceID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if w.OwnerID == userID {
+		return nil, fmt.Errorf("the workspace owner must transfer ownership before leaving")
+	}
+	if err := ensureMemberManagerRemains(workspaceID, member, "the last admin cannot leave", ctx); err != nil {
+		return nil, err
+	}
+
+	if successorID == "" {
+		successorID = w.OwnerID
+	}
+	if successorID == userID {
+		return nil, fmt.Errorf("choose another member to take over your work")
+	}
+	successor, err := fetchMember(workspaceID, successorID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if successor == nil {
+		return nil, fmt.Errorf("successor is not a member of this workspace")
+	}
+	proposals, stewards, err := countLeaverDuties(workspaceID, userID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	permissions, err := memberPermissions(workspaceID, successor, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if proposals > 0 && !hasPermission(permissions, PermPropose) {
+		return nil, fmt.Errorf("successor cannot propose in this workspace")
+	}
+	if stewards > 0 && !hasPermission(permissions, PermReview) {
+		return nil, fmt.Errorf("successor cannot review in this workspace")
+	}
+
+	result, err := leaveWorkspaceRows(workspaceID, userID, successorID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	audit.Record(audit.Entry{WorkspaceID: workspaceID, Action: "member.left", TargetType: "user", TargetID: userID, Before: member, After: result}, ctx)
+	return result, nil
+}
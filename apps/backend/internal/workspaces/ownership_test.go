@@ -0,0 +1,112 @@
+package workspaces
+
+import (
+	"testing"
+
+	"granth/internal/config"
+	"granth/internal/testdb"
+)
+
+func TestOwnershipTransferAccept(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	admin := testdb.CreateUser(t)
+	contributor := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	testdb.AddMember(t, workspaceID, admin, string(RoleAdmin))
+	testdb.AddMember(t, workspaceID, contributor, string(RoleContributor))
+
+	if _, err := transferOwnership(workspaceID, owner, testdb.As(admin)); err == nil || err.Error() != "only the workspace owner can transfer ownership" {
+		t.Fatalf("non-owner offering: got %v", err)
+	}
+	if _, err := transferOwnership(workspaceID, contributor, testdb.As(owner)); err == nil || err.Error() != "the new owner must be able to manage members" {
+		t.Fatalf("offering to a contributor: got %v", err)
+	}
+	if _, err := transferOwnership(workspaceID, admin, testdb.As(owner)); err != nil {
+		t.Fatalf("offering to an admin: %v", err)
+	}
+	if _, err := respondOwnershipTransfer(workspaceID, true, testdb.As(contributor)); err == nil || err.Error() != "no ownership transfer is pending" {
+		t.Fatalf("someone else accepting: got %v", err)
+	}
+
+	w, err := respondOwnershipTransfer(workspaceID, true, testdb.As(admin))
+	if err != nil {
+		t.Fatalf("accepting: %v", err)
+	}
+	if w.OwnerID != admin {
+		t.Fatalf("owner = %s, want %s", w.OwnerID, admin)
+	}
+	if _, err := leaveWorkspace(workspaceID, "", testdb.As(owner)); err != nil {
+		t.Fatalf("former owner leaving: %v", err)
+	}
+}
+
+func TestOwnershipTransferCancelAndDecline(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	admin := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	testdb.AddMember(t, workspaceID, admin, string(RoleAdmin))
+
+	if _, err := transferOwnership(workspaceID, admin, testdb.As(owner)); err != nil {
+		t.Fatal(err)
+	}
+	if err := cancelOwnershipTransfer(workspaceID, testdb.As(admin)); err == nil || err.Error() != "only the workspace owner can cancel a transfer" {
+		t.Fatalf("recipient cancelling: got %v", err)
+	}
+	if err := cancelOwnershipTransfer(workspaceID, testdb.As(owner)); err != nil {
+		t.Fatalf("cancelling: %v", err)
+	}
+	if _, err := respondOwnershipTransfer(workspaceID, true, testdb.As(admin)); err == nil || err.Error() != "no ownership transfer is pending" {
+		t.Fatalf("accepting a cancelled offer: got %v", err)
+	}
+
+	if _, err := transferOwnership(workspaceID, admin, testdb.As(owner)); err != nil {
+		t.Fatal(err)
+	}
+	w, err := respondOwnershipTransfer(workspaceID, false, testdb.As(admin))
+	if err != nil {
+		t.Fatalf("declining: %v", err)
+	}
+	if w.OwnerID != owner {
+		t.Fatalf("declining changed the owner to %s", w.OwnerID)
+	}
+}
+
+func TestOwnershipTransferNeedsManageMembersOnAccept(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	admin := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	testdb.AddMember(t, workspaceID, admin, string(RoleAdmin))
+
+	if _, err := transferOwnership(workspaceID, admin, testdb.As(owner)); err != nil {
+		t.Fatal(err)
+	}
+	if err := updateMember(workspaceID, admin, string(RoleReviewer), testdb.As(owner)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := respondOwnershipTransfer(workspaceID, true, testdb.As(admin)); err == nil {
+		t.Fatal("a member demoted after the offer took over the workspace")
+	}
+}
+
+func TestLeaveWorkspaceGuards(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	admin := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	testdb.AddMember(t, workspaceID, admin, string(RoleAdmin))
+
+	if _, err := leaveWorkspace(workspaceID, "", testdb.As(owner)); err == nil || err.Error() != "the workspace owner must transfer ownership before leaving" {
+		t.Fatalf("owner leaving: got %v", err)
+	}
+	if _, err := config.PostgresDB.Exec(
+		`UPDATE workspace_members SET role = 'contributor' WHERE workspace_id = $1 AND user_id = $2`, workspaceID, owner,
+	); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := leaveWorkspace(workspaceID, "", testdb.As(admin)); err == nil || err.Error() != "the last admin cannot leave" {
+		t.Fatalf("last member manager leaving: got %v", err)
+	}
+}
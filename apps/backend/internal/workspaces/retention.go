@@ -0,0 +1,178 @@
+package workspaces
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"granth/internal/audit"
+)
+
+// ErrLegalHold is returned when deleting something a legal hold covers.
+var ErrLegalHold = errors.New("under legal hold")
+
+// ErrRetained is returned when deleting something a retention policy still
+// keeps.
+var ErrRetained = errors.New("kept by a retention policy")
+
+const maxRetentionDays = 36500
+
+// retentionTargets are the kinds of record a policy can apply to.
+var retentionTargets = []string{"documents", "open_proposals", "accepted_proposals", "rejected_proposals"}
+
+func validRetentionTarget(target string) bool {
+	for _, t := range retentionTargets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+func getRetentionSettings(workspaceID string, ctx context.Context) (*RetentionSettings, error) {
+	if _, err := requirePermission(workspaceID, PermManagePolicy, ctx); err != nil {
+		return nil, err
+	}
+	policies, err := fetchRetentionPolicies(workspaceID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	holds, err := fetchLegalHolds(workspaceID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &RetentionSettings{Policies: policies, Holds: holds}, nil
+}
+
+// setRetentionPolicy creates or replaces the workspace's policy for target.
+func setRetentionPolicy(workspaceID, target string, keepDays int, dispose bool, ctx context.Context) (*RetentionPolicy, error) {
+	admin, err := requirePermission(workspaceID, PermManagePolicy, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !validRetentionTarget(target) {
+		return nil, fmt.Errorf("unknown retention target %q", target)
+	}
+	if keepDays < 1 || keepDays > maxRetentionDays {
+		return nil, fmt.Errorf("keep_days must be between 1 and %d", maxRetentionDays)
+	}
+
+	before, err := fetchRetentionPolicy(workspaceID, target, ctx)
+	if err != nil {
+		return nil, err
+	}
+	p := &RetentionPolicy{WorkspaceID: workspaceID, Target: target, KeepDays: keepDays, Dispose: dispose, UpdatedBy: &admin.UserID}
+	if err := upsertRetentionPolicy(p, ctx); err != nil {
+		return nil, err
+	}
+	entry := audit.Entry{WorkspaceID: workspaceID, Action: "retention_policy.set", TargetType: "retention_policy", TargetID: p.ID, After: p}
+	if before != nil {
+		entry.Before = before
+	}
+	audit.Record(entry, ctx)
+	return p, nil
+}
+
+func removeRetentionPolicy(workspaceID, target string, ctx context.Context) error {
+	if _, err := requirePermission(workspaceID, PermManagePolicy, ctx); err != nil {
+		return err
+	}
+	p, err := fetchRetentionPolicy(workspaceID, target, ctx)
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return fmt.Errorf("retention policy not found")
+	}
+	if err := deleteRetentionPolicy(workspaceID, target, ctx); err != nil {
+		return err
+	}
+	audit.Record(audit.Entry{WorkspaceID: workspaceID, Action: "retention_policy.deleted", TargetType: "retention_policy", TargetID: p.ID, Before: p}, ctx)
+	return nil
+}
+
+// placeLegalHold holds one document of the workspace, or the whole
+// workspace when documentID is nil.
+func placeLegalHold(workspaceID string, documentID *string, reason string, ctx context.Context) (*LegalHold, error) {
+	admin, err := requirePermission(workspaceID, PermManagePolicy, ctx)
+	if err != nil {
+		return nil, err
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required")
+	}
+
+	h := &LegalHold{WorkspaceID: workspaceID, DocumentID: documentID, Reason: reason, PlacedBy: &admin.UserID}
+	if documentID != nil {
+		docWorkspace, title, err := fetchGuestTarget(documentID, nil, ctx)
+		if err != nil {
+			return nil, err
+		}
+		if docWorkspace != workspaceID {
+			return nil, fmt.Errorf("document not found")
+		}
+		h.DocumentTitle = &title
+	}
+	if err := insertLegalHold(h, ctx); err != nil {
+		return nil, err
+	}
+	audit.Record(audit.Entry{WorkspaceID: workspaceID, Action: "legal_hold.placed", TargetType: "legal_hold", TargetID: h.ID, After: h}, ctx)
+	return h, nil
+}
+
+func releaseLegalHold(workspaceID, holdID string, ctx context.Context) error {
+	admin, err := requirePermission(workspaceID, PermManagePolicy, ctx)
+	if err != nil {
+		return err
+	}
+	h, err := fetchLegalHold(workspaceID, holdID, ctx)
+	if err != nil {
+		return err
+	}
+	if h == nil {
+		return fmt.Errorf("legal hold not found")
+	}
+	released, err := releaseLegalHoldByID(holdID, admin.UserID, ctx)
+	if err != nil {
+		return err
+	}
+	if !released {
+		return fmt.Errorf("legal hold is already released")
+	}
+	audit.Record(audit.Entry{WorkspaceID: workspaceID, Action: "legal_hold.released", TargetType: "legal_hold", TargetID: holdID, Before: h}, ctx)
+	return nil
+}
+
+// checkDeletable returns an error wrapping ErrLegalHold or ErrRetained when
+// what may not be deleted yet.
+func checkDeletable(what, holdQuery, keepQuery, id string, ctx context.Context) error {
+	held, until, err := fetchDeletionBlock(holdQuery, keepQuery, id, ctx)
+	if err != nil {
+		return err
+	}
+	if held {
+		return fmt.Errorf("%s is %w", what, ErrLegalHold)
+	}
+	if until != nil {
+		return fmt.Errorf("%s is %w until %s", what, ErrRetained, until.UTC().Format(time.DateOnly))
+	}
+	return nil
+}
+
+// CheckDocumentDeletable reports whether the document, with its proposals,
+// may be deleted. It checks no permission, so the purge job can use it too.
+func CheckDocumentDeletable(documentID string, ctx context.Context) error {
+	return checkDeletable("this document", documentHoldQuery, documentKeepQuery, documentID, ctx)
+}
+
+// CheckProposalDeletable reports whether the proposal may be deleted.
+func CheckProposalDeletable(proposalID string, ctx context.Context) error {
+	return checkDeletable("this proposal", proposalHoldQuery, proposalKeepQuery, proposalID, ctx)
+}
+
+func checkWorkspaceDeletable(workspaceID string, ctx context.Context) error {
+	return checkDeletable("this workspace", workspaceHoldQuery, workspaceKeepQuery, workspaceID, ctx)
+}
@@ -0,0 +1,373 @@
+package workspaces
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"granth/internal/audit"
+	"granth/internal/utils"
+)
+
+// ErrPermissionDenied is returned when the caller's role lacks the
+// permission an action needs.
+var ErrPermissionDenied = errors.New("permission denied")
+
+// AllPermissions lists every permission a role can grant.
+var AllPermissions = []Permission{
+	PermRead, PermPropose, PermComment, PermReview, PermAccept,
+	PermManageMembers, PermManagePolicy, PermExport,
+}
+
+// builtInRoles keep the behaviour the fixed roles always had.
+var builtInRoles = []*Role{
+	{
+		Name:        string(RoleAdmin),
+		Description: "Full control of the workspace",
+		Permissions: AllPermissions,
+		BuiltIn:     true,
+	},
+	{
+		Name:        string(RoleReviewer),
+		Description: "Reviews and decides on proposals",
+		Permissions: []Permission{PermRead, PermPropose, PermComment, PermReview, PermAccept, PermExport},
+		BuiltIn:     true,
+	},
+	{
+		Name:        string(RoleContributor),
+		Description: "Proposes changes and joins discussions",
+		Permissions: []Permission{PermRead, PermPropose, PermComment, PermExport},
+		BuiltIn:     true,
+	},
+}
+
+// privilegedPermissions are subject to the workspace 2FA policy.
+var privilegedPermissions = []Permission{PermReview, PermAccept, PermManageMembers, PermManagePolicy}
+
+func builtInRole(name string) *Role {
+	for _, r := range builtInRoles {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+func hasPermission(permissions []Permission, perm Permission) bool {
+	for _, p := range permissions {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveRole returns the built-in or custom role called name, or nil.
+func resolveRole(workspaceID, name string, ctx context.Context) (*Role, error) {
+	if r := builtInRole(name); r != nil {
+		return r, nil
+	}
+	return fetchCustomRole(workspaceID, name, ctx)
+}
+
+// memberPermissions returns what member may do. Members whose custom role
+// has been removed from under them get no permissions.
+func memberPermissions(workspaceID string, member *WorkspaceMember, ctx context.Context) ([]Permission, error) {
+	if member == nil {
+		return nil, nil
+	}
+	role, err := resolveRole(workspaceID, member.Role, ctx)
+	if err != nil || role == nil {
+		return nil, err
+	}
+	return role.Permissions, nil
+}
+
+// requirePermission returns the caller's membership if their role grants
+// perm, applying the 2FA policy to privileged permissions.
+func requirePermission(workspaceID string, perm Permission, ctx context.Context) (*WorkspaceMember, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+	member, err := fetchMember(workspaceID, userID, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error checking membership: %w", err)
+	}
+	permissions, err := memberPermissions(workspaceID, member, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !hasPermission(permissions, perm) {
+		return nil, fmt.Errorf("%w: requires the %s permission", ErrPermissionDenied, perm)
+	}
+	if err := checkTwoFactorPolicy(workspaceID, member, ctx); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// HasPermission reports whether the requesting user's role in the
+// workspace grants perm. It does not apply the 2FA policy.
+func HasPermission(workspaceID string, perm Permission, ctx context.Context) (bool, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return false, fmt.Errorf("user ID not found in context")
+	}
+	member, err := fetchMember(workspaceID, userID, ctx)
+	if err != nil {
+		return false, err
+	}
+	permissions, err := memberPermissions(workspaceID, member, ctx)
+	if err != nil {
+		return false, err
+	}
+	return hasPermission(permissions, perm), nil
+}
+
+// RequirePermission checks that the requesting user may act with perm in
+// the workspace, including the 2FA policy. Failures wrap
+// ErrPermissionDenied or are ErrTwoFactorRequired.
+func RequirePermission(workspaceID string, perm Permission, ctx context.Context) error {
+	_, err := requirePermission(workspaceID, perm, ctx)
+	return err
+}
+
+// requireAssignableRole resolves role and checks that caller could hold
+// every permission it grants, so managing members never escalates.
+func requireAssignableRole(workspaceID, role string, caller *WorkspaceMember, ctx context.Context) (*Role, error) {
+	r, err := resolveRole(workspaceID, role, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, fmt.Errorf("role not found")
+	}
+	if err := requireGrantable(workspaceID, r.Permissions, caller, ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// requireGrantable refuses permissions the caller does not hold themselves.
+func requireGrantable(workspaceID string, permissions []Permission, caller *WorkspaceMember, ctx context.Context) error {
+	callerPermissions, err := memberPermissions(workspaceID, caller, ctx)
+	if err != nil {
+		return err
+	}
+	for _, p := range permissions {
+		if !hasPermission(callerPermissions, p) {
+			return fmt.Errorf("%w: cannot grant the %s permission you do not have", ErrPermissionDenied, p)
+		}
+	}
+	return nil
+}
+
+// grantsManagement reports whether a role can manage members or policy.
+// Self-service joins (links, domain rules) never hand these out.
+func grantsManagement(r *Role) bool {
+	return hasPermission(r.Permissions, PermManageMembers) || hasPermission(r.Permissions, PermManagePolicy)
+}
+
+// rolesWithPermission names every role in the workspace granting perm.
+func rolesWithPermission(workspaceID string, perm Permission, ctx context.Context) ([]string, error) {
+	custom, err := fetchCustomRoles(workspaceID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, r := range append(append([]*Role{}, builtInRoles...), custom...) {
+		if hasPermission(r.Permissions, perm) {
+			names = append(names, r.Name)
+		}
+	}
+	return names, nil
+}
+
+// ensureMemberManagerRemains refuses a change that would leave the
+// workspace with no one able to manage members. target is the member
+// losing the permission.
+func ensureMemberManagerRemains(workspaceID string, target *WorkspaceMember, msg string, ctx context.Context) error {
+	targetPermissions, err := memberPermissions(workspaceID, target, ctx)
+	if err != nil {
+		return err
+	}
+	if !hasPermission(targetPermissions, PermManageMembers) {
+		return nil
+	}
+	managers, err := rolesWithPermission(workspaceID, PermManageMembers, ctx)
+	if err != nil {
+		return err
+	}
+	count, err := countMembersWithRoles(workspaceID, managers, ctx)
+	if err != nil {
+		return err
+	}
+	if count <= 1 {
+		return fmt.Errorf("%s", msg)
+	}
+	return nil
+}
+
+// ── Role management ───────────────────────────────────────────────────────────
+
+// roleNamePattern allows names such as "counsel" or "client observer".
+var roleNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9 _-]{0,48}[a-z0-9]$`)
+
+// normalizePermissions validates and de-duplicates a permission list.
+// Every role can read; nothing else in a workspace works without it.
+func normalizePermissions(permissions []Permission) ([]Permission, error) {
+	seen := map[Permission]bool{PermRead: true}
+	out := []Permission{PermRead}
+	for _, p := range permissions {
+		if !hasPermission(AllPermissions, p) {
+			return nil, fmt.Errorf("unknown permission: %s", p)
+		}
+		if !seen[p] {
+			seen[p] = true
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func listRoles(workspaceID string, ctx context.Context) ([]*Role, error) {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("user ID not found in context")
+	}
+	member, err := fetchMember(workspaceID, userID, ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error checking membership: %w", err)
+	}
+	if member == nil {
+		return nil, fmt.Errorf("access denied")
+	}
+
+	custom, err := fetchCustomRoles(workspaceID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]*Role{}, builtInRoles...), custom...), nil
+}
+
+func createRole(workspaceID, name, description string, permissions []Permission, ctx context.Context) (*Role, error) {
+	member, err := requirePermission(workspaceID, PermManagePolicy, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !roleNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("role names must be 2 to 50 lowercase letters, digits, spaces, hyphens or underscores")
+	}
+	if builtInRole(name) != nil {
+		return nil, fmt.Errorf("a role with this name already exists")
+	}
+	permissions, err = normalizePermissions(permissions)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireGrantable(workspaceID, permissions, member, ctx); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	r := &Role{
+		WorkspaceID: workspaceID,
+		Name:        name,
+		Description: strings.TrimSpace(description),
+		Permissions: permissions,
+		CreatedBy:   &member.UserID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := insertRole(r, ctx); err != nil {
+		return nil, err
+	}
+	audit.Record(audit.Entry{WorkspaceID: workspaceID, Action: "role.created", TargetType: "role", TargetID: name, After: r}, ctx)
+	return r, nil
+}
+
+func updateRole(workspaceID, name, description string, permissions []Permission, ctx context.Context) (*Role, error) {
+	member, err := requirePermission(workspaceID, PermManagePolicy, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if builtInRole(name) != nil {
+		return nil, fmt.Errorf("built-in roles cannot be changed")
+	}
+	r, err := fetchCustomRole(workspaceID, name, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, fmt.Errorf("role not found")
+	}
+	permissions, err = normalizePermissions(permissions)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireGrantable(workspaceID, permissions, member, ctx); err != nil {
+		return nil, err
+	}
+
+	// Taking manage_members away must not strand the workspace
+	if hasPermission(r.Permissions, PermManageMembers) && !hasPermission(permissions, PermManageMembers) {
+		managers, err := rolesWithPermission(workspaceID, PermManageMembers, ctx)
+		if err != nil {
+			return nil, err
+		}
+		remaining := make([]string, 0, len(managers))
+		for _, m := range managers {
+			if m != name {
+				remaining = append(remaining, m)
+			}
+		}
+		count, err := countMembersWithRoles(workspaceID, remaining, ctx)
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, fmt.Errorf("the workspace must keep at least one member who can manage members")
+		}
+	}
+
+	before := *r
+	r.Description = strings.TrimSpace(description)
+	r.Permissions = permissions
+	r.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	if err := updateRoleRow(r, ctx); err != nil {
+		return nil, err
+	}
+	audit.Record(audit.Entry{WorkspaceID: workspaceID, Action: "role.updated", TargetType: "role", TargetID: name, Before: before, After: r}, ctx)
+	return r, nil
+}
+
+func deleteRole(workspaceID, name string, ctx context.Context) error {
+	if _, err := requirePermission(workspaceID, PermManagePolicy, ctx); err != nil {
+		return err
+	}
+	if builtInRole(name) != nil {
+		return fmt.Errorf("built-in roles cannot be changed")
+	}
+	r, err := fetchCustomRole(workspaceID, name, ctx)
+	if err != nil {
+		return err
+	}
+	if r == nil {
+		return fmt.Errorf("role not found")
+	}
+	deleted, err := deleteUnusedRole(workspaceID, name, ctx)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("role is still assigned to members, invitations or domain rules")
+	}
+	audit.Record(audit.Entry{WorkspaceID: workspaceID, Action: "role.deleted", TargetType: "role", TargetID: name, Before: r}, ctx)
+	return nil
+}
@@ -0,0 +1,79 @@
+package workspaces
+
+import (
+	"errors"
+	"testing"
+
+	"granth/internal/testdb"
+)
+
+func TestRequireGrantableRefusesEscalation(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	manager := testdb.CreateUser(t)
+	policy := testdb.CreateUser(t)
+	newcomer := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	if _, err := createRole(workspaceID, "membership", "", []Permission{PermManageMembers}, testdb.As(owner)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := createRole(workspaceID, "policy", "", []Permission{PermManagePolicy}, testdb.As(owner)); err != nil {
+		t.Fatal(err)
+	}
+	testdb.AddMember(t, workspaceID, manager, "membership")
+	testdb.AddMember(t, workspaceID, policy, "policy")
+
+	for _, role := range []string{string(RoleAdmin), string(RoleContributor)} {
+		if _, err := addMember(workspaceID, newcomer, role, testdb.As(manager)); !errors.Is(err, ErrPermissionDenied) {
+			t.Fatalf("granting %s without its permissions: got %v, want permission denied", role, err)
+		}
+	}
+	if _, err := addMember(workspaceID, newcomer, "membership", testdb.As(manager)); err != nil {
+		t.Fatalf("granting the caller's own role: %v", err)
+	}
+	if err := updateMember(workspaceID, manager, string(RoleAdmin), testdb.As(manager)); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("promoting oneself to admin: got %v, want permission denied", err)
+	}
+
+	if _, err := createRole(workspaceID, "deciders", "", []Permission{PermAccept}, testdb.As(policy)); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("creating a role with a permission the caller lacks: got %v, want permission denied", err)
+	}
+	if _, err := updateRole(workspaceID, "policy", "", []Permission{PermManagePolicy, PermManageMembers}, testdb.As(policy)); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("widening one's own role: got %v, want permission denied", err)
+	}
+	if _, err := createRole(workspaceID, "deciders", "", []Permission{PermAccept}, testdb.As(manager)); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("creating a role without manage_policy: got %v, want permission denied", err)
+	}
+}
+
+func TestLastMemberManagerRemains(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	admin := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+
+	if err := updateMember(workspaceID, owner, string(RoleContributor), testdb.As(owner)); err == nil || err.Error() != "cannot demote the last admin" {
+		t.Fatalf("demoting the only admin: got %v", err)
+	}
+
+	testdb.AddMember(t, workspaceID, admin, string(RoleAdmin))
+	if err := updateMember(workspaceID, admin, string(RoleContributor), testdb.As(owner)); err != nil {
+		t.Fatalf("demoting one of two admins: %v", err)
+	}
+	if err := updateMember(workspaceID, owner, string(RoleReviewer), testdb.As(owner)); err == nil || err.Error() != "cannot demote the last admin" {
+		t.Fatalf("demoting the remaining admin: got %v", err)
+	}
+
+	if _, err := createRole(workspaceID, "lead", "", []Permission{PermManageMembers}, testdb.As(owner)); err != nil {
+		t.Fatal(err)
+	}
+	if err := updateMember(workspaceID, admin, "lead", testdb.As(owner)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := updateRole(workspaceID, "lead", "", []Permission{PermRead}, testdb.As(owner)); err != nil {
+		t.Fatalf("an admin still manages members, so lead may lose it: %v", err)
+	}
+	if err := updateMember(workspaceID, owner, string(RoleReviewer), testdb.As(owner)); err == nil || err.Error() != "cannot demote the last admin" {
+		t.Fatalf("demoting the admin after lead lost manage_members: got %v", err)
+	}
+}
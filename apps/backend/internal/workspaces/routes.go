@@ -3,9 +3,13 @@ package workspaces
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"granth/internal/audit"
 	"granth/internal/config"
+	"granth/internal/utils"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -13,18 +17,72 @@ import (
 func WorkspacesRouter() http.Handler {
 	r := chi.NewRouter()
 
-	r.Get("/", handleListWorkspaces)
-	r.Post("/", handleCreateWorkspace)
-	r.Get("/{id}", handleGetWorkspace)
-	r.Put("/{id}", handleUpdateWorkspace)
-	r.Delete("/{id}", handleDeleteWorkspace)
+	read := utils.RequireScope(utils.ScopeRead)
+	admin := utils.RequireScope(utils.ScopeAdmin)
 
-	r.Get("/{id}/members", handleListMembers)
-	r.Post("/{id}/members", handleAddMember)
-	r.Put("/{id}/members/{uid}", handleUpdateMemberRole)
-	r.Delete("/{id}/members/{uid}", handleRemoveMember)
+	// invitations addressed to the caller; static paths win over /{id}
+	r.With(read).Get("/invitations", handleListMyInvitations)
+	r.With(read).Post("/invitations/preview", handlePreviewInvitation)
+	r.With(admin).Post("/invitations/accept", handleAcceptInvitation)
+	r.With(admin).Post("/invitations/decline", handleDeclineInvitation)
+	r.With(read).Get("/guest-grants", handleListMyGuestGrants)
+	r.With(read).Get("/ownership-transfers", handleListMyOwnershipTransfers)
 
-	r.Get("/{id}/documents", handleListWorkspaceDocuments)
+	r.With(read).Get("/", handleListWorkspaces)
+	r.With(admin).Post("/", handleCreateWorkspace)
+	r.With(read).Get("/{id}", handleGetWorkspace)
+	r.With(admin).Put("/{id}", handleUpdateWorkspace)
+	r.With(admin).Delete("/{id}", handleDeleteWorkspace)
+	r.With(admin).Put("/{id}/policy", handleUpdatePolicy)
+
+	r.With(read).Get("/{id}/ownership-transfer", handleGetOwnershipTransfer)
+	r.With(admin).Post("/{id}/ownership-transfer", handleTransferOwnership)
+	r.With(admin).Delete("/{id}/ownership-transfer", handleCancelOwnershipTransfer)
+	r.With(admin).Post("/{id}/ownership-transfer/accept", handleAcceptOwnershipTransfer)
+	r.With(admin).Post("/{id}/ownership-transfer/decline", handleDeclineOwnershipTransfer)
+	r.With(admin).Post("/{id}/leave", handleLeaveWorkspace)
+
+	r.With(read).Get("/{id}/members", handleListMembers)
+	r.With(admin).Post("/{id}/members", handleAddMember)
+	r.With(admin).Put("/{id}/members/{uid}", handleUpdateMemberRole)
+	r.With(admin).Delete("/{id}/members/{uid}", handleRemoveMember)
+
+	r.With(admin).Get("/{id}/invitations", handleListInvitations)
+	r.With(admin).Post("/{id}/invitations", handleCreateInvitation)
+	r.With(admin).Post("/{id}/invite-links", handleCreateInviteLink)
+	r.With(admin).Delete("/{id}/invitations/{invitationID}", handleRevokeInvitation)
+
+	r.With(read).Get("/{id}/roles", handleListRoles)
+	r.With(admin).Post("/{id}/roles", handleCreateRole)
+	r.With(admin).Put("/{id}/roles/{name}", handleUpdateRole)
+	r.With(admin).Delete("/{id}/roles/{name}", handleDeleteRole)
+
+	r.With(read).Get("/{id}/teams", handleListTeams)
+	r.With(admin).Post("/{id}/teams", handleCreateTeam)
+	r.With(read).Get("/{id}/teams/{teamID}", handleGetTeam)
+	r.With(admin).Put("/{id}/teams/{teamID}", handleUpdateTeam)
+	r.With(admin).Delete("/{id}/teams/{teamID}", handleDeleteTeam)
+	r.With(admin).Put("/{id}/teams/{teamID}/members/{uid}", handleSetTeamMember)
+	r.With(admin).Delete("/{id}/teams/{teamID}/members/{uid}", handleRemoveTeamMember)
+
+	r.With(admin).Get("/{id}/guests", handleListGuestGrants)
+	r.With(admin).Post("/{id}/guests", handleCreateGuestGrant)
+	r.With(admin).Delete("/{id}/guests/{grantID}", handleRevokeGuestGrant)
+	r.With(admin).Get("/{id}/guests/{grantID}/events", handleListGuestGrantEvents)
+
+	r.With(admin).Get("/{id}/retention", handleGetRetention)
+	r.With(admin).Put("/{id}/retention-policies/{target}", handleSetRetentionPolicy)
+	r.With(admin).Delete("/{id}/retention-policies/{target}", handleDeleteRetentionPolicy)
+	r.With(admin).Post("/{id}/legal-holds", handlePlaceLegalHold)
+	r.With(admin).Delete("/{id}/legal-holds/{holdID}", handleReleaseLegalHold)
+
+	r.With(read).Get("/{id}/documents", handleListWorkspaceDocuments)
+
+	r.With(admin).Get("/{id}/audit", handleListAuditEvents)
+
+	r.With(admin).Get("/{id}/domains", handleListDomainRules)
+	r.With(admin).Post("/{id}/domains", handleAddDomainRule)
+	r.With(admin).Delete("/{id}/domains/{ruleID}", handleRemoveDomainRule)
 
 	return r
 }
@@ -44,7 +102,7 @@ type workspaceDocument struct {
 func fetchDocumentsByWorkspaceID(workspaceID string, ctx context.Context) ([]*workspaceDocument, error) {
 	rows, err := config.PostgresDB.QueryContext(ctx,
 		`SELECT id, title, workspace_id, created_by, created_at, updated_at
-		 FROM documents WHERE workspace_id = $1 ORDER BY created_at DESC`,
+		 FROM documents WHERE workspace_id = $1 AND deleted_at IS NULL ORDER BY created_at DESC`,
 		workspaceID,
 	)
 	if err != nil {
@@ -129,7 +187,7 @@ func handleUpdateWorkspace(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := updateWorkspaceDetails(id, req.Name, req.Description, r.Context()); err != nil {
-		if err.Error() == "only admins can update workspace details" {
+		if errors.Is(err, ErrPermissionDenied) || errors.Is(err, ErrTwoFactorRequired) {
 			http.Error(w, err.Error(), http.StatusForbidden)
 			return
 		}
@@ -146,12 +204,63 @@ func handleDeleteWorkspace(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, err.Error(), http.StatusForbidden)
 			return
 		}
+		if errors.Is(err, ErrLegalHold) || errors.Is(err, ErrRetained) ||
+			err.Error() == "workspaces with signed proposals cannot be deleted" {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
 }
 
+func handleUpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var req struct {
+		RequireTwoFactor  *bool     `json:"require_two_factor"`
+		RequireSignature  *bool     `json:"require_signature"`
+		SignatureMeanings *[]string `json:"signature_meanings"`
+		// RequireStewardApproval needs steward sign-off before acceptance
+		RequireStewardApproval *bool `json:"require_steward_approval"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.RequireTwoFactor == nil && req.RequireSignature == nil && req.RequireStewardApproval == nil {
+		http.Error(w, "require_two_factor, require_signature or require_steward_approval is required", http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	if req.RequireTwoFactor != nil {
+		err = setTwoFactorPolicy(id, *req.RequireTwoFactor, r.Context())
+	}
+	if err == nil && req.RequireSignature != nil {
+		meanings := []string{}
+		if req.SignatureMeanings != nil {
+			meanings = *req.SignatureMeanings
+		}
+		err = setSignaturePolicy(id, *req.RequireSignature, meanings, r.Context())
+	}
+	if err == nil && req.RequireStewardApproval != nil {
+		err = setStewardPolicy(id, *req.RequireStewardApproval, r.Context())
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrPermissionDenied) || errors.Is(err, ErrTwoFactorRequired):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case strings.HasPrefix(err.Error(), "signature meanings") || strings.HasPrefix(err.Error(), "at most"):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
 func handleListMembers(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	members, err := getWorkspaceMembers(id, r.Context())
@@ -186,7 +295,7 @@ func handleAddMember(w http.ResponseWriter, r *http.Request) {
 
 	member, err := addMember(workspaceID, req.UserID, req.Role, r.Context())
 	if err != nil {
-		if err.Error() == "only admins can add members" {
+		if errors.Is(err, ErrPermissionDenied) || errors.Is(err, ErrTwoFactorRequired) {
 			http.Error(w, err.Error(), http.StatusForbidden)
 			return
 		}
@@ -212,7 +321,7 @@ func handleUpdateMemberRole(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := updateMember(workspaceID, targetUID, req.Role, r.Context()); err != nil {
-		if err.Error() == "only admins can change member roles" || err.Error() == "cannot demote the last admin" {
+		if errors.Is(err, ErrPermissionDenied) || err.Error() == "cannot demote the last admin" || errors.Is(err, ErrTwoFactorRequired) {
 			http.Error(w, err.Error(), http.StatusForbidden)
 			return
 		}
@@ -227,7 +336,8 @@ func handleRemoveMember(w http.ResponseWriter, r *http.Request) {
 	targetUID := chi.URLParam(r, "uid")
 
 	if err := removeMemberFromWorkspace(workspaceID, targetUID, r.Context()); err != nil {
-		if err.Error() == "only admins can remove members" || err.Error() == "cannot remove the last admin" {
+		if errors.Is(err, ErrPermissionDenied) || err.Error() == "cannot remove the last admin" ||
+			err.Error() == "cannot remove the workspace owner" || errors.Is(err, ErrTwoFactorRequired) {
 			http.Error(w, err.Error(), http.StatusForbidden)
 			return
 		}
@@ -261,6 +371,386 @@ func handleListWorkspaceDocuments(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, docs)
 }
 
+func handleListDomainRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := getDomainRules(chi.URLParam(r, "id"), r.Context())
+	if err != nil {
+		writeDomainRuleError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rules)
+}
+
+func handleAddDomainRule(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Domain string `json:"domain"`
+		Role   string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Domain == "" {
+		http.Error(w, "domain is required", http.StatusBadRequest)
+		return
+	}
+
+	rule, err := addDomainRule(chi.URLParam(r, "id"), req.Domain, req.Role, r.Context())
+	if err != nil {
+		writeDomainRuleError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, rule)
+}
+
+func handleRemoveDomainRule(w http.ResponseWriter, r *http.Request) {
+	if err := removeDomainRule(chi.URLParam(r, "id"), chi.URLParam(r, "ruleID"), r.Context()); err != nil {
+		writeDomainRuleError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeDomainRuleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrPermissionDenied) || errors.Is(err, ErrTwoFactorRequired):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case err.Error() == "domain rule not found":
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err.Error() == "a rule for this domain already exists":
+		http.Error(w, err.Error(), http.StatusConflict)
+	case err.Error() == "invalid domain" || err.Error() == "role not found" || strings.HasPrefix(err.Error(), "invalid role"):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func handleListInvitations(w http.ResponseWriter, r *http.Request) {
+	invitations, err := listInvitations(chi.URLParam(r, "id"), r.Context())
+	if err != nil {
+		writeInvitationError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, invitations)
+}
+
+func handleCreateInvitation(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email         string `json:"email"`
+		Role          string `json:"role"`
+		ExpiresInDays int    `json:"expires_in_days"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Email == "" || req.Role == "" {
+		http.Error(w, "email and role are required", http.StatusBadRequest)
+		return
+	}
+
+	inv, err := inviteByEmail(chi.URLParam(r, "id"), req.Email, req.Role, req.ExpiresInDays, r.Context())
+	if err != nil {
+		writeInvitationError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, inv)
+}
+
+func handleCreateInviteLink(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Role          string `json:"role"`
+		ExpiresInDays int    `json:"expires_in_days"`
+		MaxUses       *int   `json:"max_uses"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	inv, err := createInviteLink(chi.URLParam(r, "id"), req.Role, req.ExpiresInDays, req.MaxUses, r.Context())
+	if err != nil {
+		writeInvitationError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, inv)
+}
+
+func handleRevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	if err := revokeInvitation(chi.URLParam(r, "id"), chi.URLParam(r, "invitationID"), r.Context()); err != nil {
+		writeInvitationError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func handleListMyInvitations(w http.ResponseWriter, r *http.Request) {
+	invitations, err := listMyInvitations(r.Context())
+	if err != nil {
+		writeInvitationError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, invitations)
+}
+
+// invitationRequest names an invitation by token, or by ID for email
+// invitations listed in the app.
+type invitationRequest struct {
+	Token        string `json:"token"`
+	InvitationID string `json:"invitation_id"`
+}
+
+func decodeInvitationRequest(w http.ResponseWriter, r *http.Request) (*invitationRequest, bool) {
+	var req invitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	if req.Token == "" && req.InvitationID == "" {
+		http.Error(w, "token or invitation_id is required", http.StatusBadRequest)
+		return nil, false
+	}
+	return &req, true
+}
+
+func handlePreviewInvitation(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeInvitationRequest(w, r)
+	if !ok {
+		return
+	}
+	if req.Token == "" {
+		http.Error(w, "token is required", http.StatusBadRequest)
+		return
+	}
+	inv, err := previewInvitation(req.Token, r.Context())
+	if err != nil {
+		writeInvitationError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, inv)
+}
+
+func handleAcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeInvitationRequest(w, r)
+	if !ok {
+		return
+	}
+	member, err := acceptInvitation(req.Token, req.InvitationID, r.Context())
+	if err != nil {
+		writeInvitationError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, member)
+}
+
+func handleDeclineInvitation(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeInvitationRequest(w, r)
+	if !ok {
+		return
+	}
+	if err := declineInvitation(req.Token, req.InvitationID, r.Context()); err != nil {
+		writeInvitationError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeInvitationError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	switch {
+	case errors.Is(err, ErrPermissionDenied) || errors.Is(err, ErrTwoFactorRequired) ||
+		msg == "this invitation was sent to a different email address" ||
+		msg == "verify your email address to accept this invitation":
+		http.Error(w, msg, http.StatusForbidden)
+	case msg == "invitation not found":
+		http.Error(w, msg, http.StatusNotFound)
+	case msg == "invitation has expired or is no longer valid":
+		http.Error(w, msg, http.StatusGone)
+	case msg == "user is already a member of this workspace" || msg == "you are already a member of this workspace":
+		http.Error(w, msg, http.StatusConflict)
+	case msg == "invalid email address" || msg == "invite links cannot be declined" || msg == "role not found" ||
+		strings.HasPrefix(msg, "invalid role") || strings.HasPrefix(msg, "expires_in_days") || strings.HasPrefix(msg, "max_uses"):
+		http.Error(w, msg, http.StatusBadRequest)
+	default:
+		http.Error(w, msg, http.StatusInternalServerError)
+	}
+}
+
+// roleRequest is the body for creating or updating a custom role.
+type roleRequest struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions"`
+}
+
+func handleListRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := listRoles(chi.URLParam(r, "id"), r.Context())
+	if err != nil {
+		writeRoleError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, roles)
+}
+
+func handleCreateRole(w http.ResponseWriter, r *http.Request) {
+	var req roleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+
+	role, err := createRole(chi.URLParam(r, "id"), req.Name, req.Description, req.Permissions, r.Context())
+	if err != nil {
+		writeRoleError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, role)
+}
+
+func handleUpdateRole(w http.ResponseWriter, r *http.Request) {
+	var req roleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	role, err := updateRole(chi.URLParam(r, "id"), chi.URLParam(r, "name"), req.Description, req.Permissions, r.Context())
+	if err != nil {
+		writeRoleError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, role)
+}
+
+func handleDeleteRole(w http.ResponseWriter, r *http.Request) {
+	if err := deleteRole(chi.URLParam(r, "id"), chi.URLParam(r, "name"), r.Context()); err != nil {
+		writeRoleError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeRoleError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	switch {
+	case msg == "access denied" || errors.Is(err, ErrPermissionDenied) || errors.Is(err, ErrTwoFactorRequired) ||
+		msg == "built-in roles cannot be changed":
+		http.Error(w, msg, http.StatusForbidden)
+	case msg == "role not found":
+		http.Error(w, msg, http.StatusNotFound)
+	case msg == "a role with this name already exists" || strings.HasPrefix(msg, "role is still assigned") ||
+		strings.HasPrefix(msg, "the workspace must keep"):
+		http.Error(w, msg, http.StatusConflict)
+	case strings.HasPrefix(msg, "role names must") || strings.HasPrefix(msg, "unknown permission"):
+		http.Error(w, msg, http.StatusBadRequest)
+	default:
+		http.Error(w, msg, http.StatusInternalServerError)
+	}
+}
+
+// teamRequest is the body for creating or updating a team.
+type teamRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func handleListTeams(w http.ResponseWriter, r *http.Request) {
+	teams, err := listTeams(chi.URLParam(r, "id"), r.Context())
+	if err != nil {
+		writeTeamError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, teams)
+}
+
+func handleCreateTeam(w http.ResponseWriter, r *http.Request) {
+	var req teamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	team, err := createTeam(chi.URLParam(r, "id"), req.Name, req.Description, r.Context())
+	if err != nil {
+		writeTeamError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, team)
+}
+
+func handleGetTeam(w http.ResponseWriter, r *http.Request) {
+	team, err := getTeam(chi.URLParam(r, "id"), chi.URLParam(r, "teamID"), r.Context())
+	if err != nil {
+		writeTeamError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, team)
+}
+
+func handleUpdateTeam(w http.ResponseWriter, r *http.Request) {
+	var req teamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	team, err := updateTeam(chi.URLParam(r, "id"), chi.URLParam(r, "teamID"), req.Name, req.Description, r.Context())
+	if err != nil {
+		writeTeamError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, team)
+}
+
+func handleDeleteTeam(w http.ResponseWriter, r *http.Request) {
+	if err := deleteTeam(chi.URLParam(r, "id"), chi.URLParam(r, "teamID"), r.Context()); err != nil {
+		writeTeamError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func handleSetTeamMember(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IsLead bool `json:"is_lead"`
+	}
+	// Body is optional; without it the user joins as a regular member
+	json.NewDecoder(r.Body).Decode(&req)
+
+	team, err := setTeamMember(chi.URLParam(r, "id"), chi.URLParam(r, "teamID"), chi.URLParam(r, "uid"), req.IsLead, r.Context())
+	if err != nil {
+		writeTeamError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, team)
+}
+
+func handleRemoveTeamMember(w http.ResponseWriter, r *http.Request) {
+	if err := removeTeamMember(chi.URLParam(r, "id"), chi.URLParam(r, "teamID"), chi.URLParam(r, "uid"), r.Context()); err != nil {
+		writeTeamError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeTeamError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	switch {
+	case errors.Is(err, ErrPermissionDenied) || errors.Is(err, ErrTwoFactorRequired):
+		http.Error(w, msg, http.StatusForbidden)
+	case msg == "team not found" || msg == "user is not on this team":
+		http.Error(w, msg, http.StatusNotFound)
+	case msg == "a team with this name already exists":
+		http.Error(w, msg, http.StatusConflict)
+	case strings.HasPrefix(msg, "team name must") || msg == "user is not a member of this workspace":
+		http.Error(w, msg, http.StatusBadRequest)
+	default:
+		http.Error(w, msg, http.StatusInternalServerError)
+	}
+}
+
 func writeJSON(w http.ResponseWriter, status int, v interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -268,3 +758,286 @@ func writeJSON(w http.ResponseWriter, status int, v interface{}) {
 		http.Error(w, "error encoding JSON: "+err.Error(), http.StatusInternalServerError)
 	}
 }
+
+func handleListGuestGrants(w http.ResponseWriter, r *http.Request) {
+	grants, err := listGuestGrants(chi.URLParam(r, "id"), r.Context())
+	if err != nil {
+		writeGuestGrantError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, grants)
+}
+
+func handleCreateGuestGrant(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email         string       `json:"email"`
+		DocumentID    *string      `json:"document_id"`
+		ProposalID    *string      `json:"proposal_id"`
+		Permissions   []Permission `json:"permissions"`
+		ExpiresInDays int          `json:"expires_in_days"`
+		Note          string       `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	grant, err := createGuestGrant(chi.URLParam(r, "id"), req.Email, req.DocumentID, req.ProposalID,
+		req.Permissions, req.ExpiresInDays, req.Note, r.Context())
+	if err != nil {
+		writeGuestGrantError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, grant)
+}
+
+func handleRevokeGuestGrant(w http.ResponseWriter, r *http.Request) {
+	if err := revokeGuestGrant(chi.URLParam(r, "id"), chi.URLParam(r, "grantID"), r.Context()); err != nil {
+		writeGuestGrantError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func handleListGuestGrantEvents(w http.ResponseWriter, r *http.Request) {
+	events, err := listGuestGrantEvents(chi.URLParam(r, "id"), chi.URLParam(r, "grantID"), r.Context())
+	if err != nil {
+		writeGuestGrantError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, events)
+}
+
+func handleListMyGuestGrants(w http.ResponseWriter, r *http.Request) {
+	grants, err := listMyGuestGrants(r.Context())
+	if err != nil {
+		writeGuestGrantError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, grants)
+}
+
+func writeGuestGrantError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	switch {
+	case errors.Is(err, ErrPermissionDenied) || errors.Is(err, ErrTwoFactorRequired):
+		http.Error(w, msg, http.StatusForbidden)
+	case msg == "guest grant not found" || msg == "document not found" || msg == "proposal not found":
+		http.Error(w, msg, http.StatusNotFound)
+	case msg == "user is already a member of this workspace" || msg == "guest grant is already revoked":
+		http.Error(w, msg, http.StatusConflict)
+	case msg == "invalid email address" || strings.HasPrefix(msg, "exactly one of") || strings.HasPrefix(msg, "guests can only") ||
+		strings.HasPrefix(msg, "expires_in_days") || strings.HasPrefix(msg, "note must") || strings.HasPrefix(msg, "no account uses"):
+		http.Error(w, msg, http.StatusBadRequest)
+	default:
+		http.Error(w, msg, http.StatusInternalServerError)
+	}
+}
+
+func handleGetOwnershipTransfer(w http.ResponseWriter, r *http.Request) {
+	t, err := getOwnershipTransfer(chi.URLParam(r, "id"), r.Context())
+	if err != nil {
+		writeOwnershipError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func handleListMyOwnershipTransfers(w http.ResponseWriter, r *http.Request) {
+	transfers, err := listMyOwnershipTransfers(r.Context())
+	if err != nil {
+		writeOwnershipError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, transfers)
+}
+
+func handleTransferOwnership(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID string `json:"user_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.UserID == "" {
+		http.Error(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	t, err := transferOwnership(chi.URLParam(r, "id"), req.UserID, r.Context())
+	if err != nil {
+		writeOwnershipError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, t)
+}
+
+func handleCancelOwnershipTransfer(w http.ResponseWriter, r *http.Request) {
+	if err := cancelOwnershipTransfer(chi.URLParam(r, "id"), r.Context()); err != nil {
+		writeOwnershipError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func handleAcceptOwnershipTransfer(w http.ResponseWriter, r *http.Request) {
+	ws, err := respondOwnershipTransfer(chi.URLParam(r, "id"), true, r.Context())
+	if err != nil {
+		writeOwnershipError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ws)
+}
+
+func handleDeclineOwnershipTransfer(w http.ResponseWriter, r *http.Request) {
+	if _, err := respondOwnershipTransfer(chi.URLParam(r, "id"), false, r.Context()); err != nil {
+		writeOwnershipError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func handleLeaveWorkspace(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		// SuccessorID takes over open proposals and steward rules; the
+		// owner does when it is empty
+		SuccessorID string `json:"successor_id"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	result, err := leaveWorkspace(chi.URLParam(r, "id"), req.SuccessorID, r.Context())
+	if err != nil {
+		writeOwnershipError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func writeOwnershipError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	switch {
+	case errors.Is(err, ErrPermissionDenied) || errors.Is(err, ErrTwoFactorRequired) ||
+		strings.HasPrefix(msg, "only the workspace owner") || msg == "the last admin cannot leave":
+		http.Error(w, msg, http.StatusForbidden)
+	case msg == "workspace not found" || msg == "no ownership transfer is pending":
+		http.Error(w, msg, http.StatusNotFound)
+	case msg == "the workspace owner must transfer ownership before leaving":
+		http.Error(w, msg, http.StatusConflict)
+	case msg == "user is not a member of this workspace" || msg == "you already own this workspace" ||
+		strings.HasPrefix(msg, "the new owner") || strings.HasPrefix(msg, "successor") ||
+		strings.HasPrefix(msg, "choose another member"):
+		http.Error(w, msg, http.StatusBadRequest)
+	default:
+		http.Error(w, msg, http.StatusInternalServerError)
+	}
+}
+
+func handleListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	workspaceID := chi.URLParam(r, "id")
+	filter, err := audit.FilterFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	events, err := listAuditEvents(workspaceID, filter, r.Context())
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case errors.Is(err, ErrPermissionDenied) || errors.Is(err, ErrTwoFactorRequired):
+			http.Error(w, msg, http.StatusForbidden)
+		case strings.HasPrefix(msg, "limit") || strings.HasPrefix(msg, "since and until"):
+			http.Error(w, msg, http.StatusBadRequest)
+		default:
+			http.Error(w, msg, http.StatusInternalServerError)
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, events)
+}
+
+func handleGetRetention(w http.ResponseWriter, r *http.Request) {
+	settings, err := getRetentionSettings(chi.URLParam(r, "id"), r.Context())
+	if err != nil {
+		writeRetentionError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, settings)
+}
+
+func handleSetRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		KeepDays int  `json:"keep_days"`
+		Dispose  bool `json:"dispose"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	p, err := setRetentionPolicy(chi.URLParam(r, "id"), chi.URLParam(r, "target"), req.KeepDays, req.Dispose, r.Context())
+	if err != nil {
+		writeRetentionError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+func handleDeleteRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	if err := removeRetentionPolicy(chi.URLParam(r, "id"), chi.URLParam(r, "target"), r.Context()); err != nil {
+		writeRetentionError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func handlePlaceLegalHold(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		// DocumentID is empty for a hold on the whole workspace
+		DocumentID *string `json:"document_id"`
+		Reason     string  `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.DocumentID != nil && *req.DocumentID == "" {
+		req.DocumentID = nil
+	}
+
+	h, err := placeLegalHold(chi.URLParam(r, "id"), req.DocumentID, req.Reason, r.Context())
+	if err != nil {
+		writeRetentionError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, h)
+}
+
+func handleReleaseLegalHold(w http.ResponseWriter, r *http.Request) {
+	if err := releaseLegalHold(chi.URLParam(r, "id"), chi.URLParam(r, "holdID"), r.Context()); err != nil {
+		writeRetentionError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeRetentionError(w http.ResponseWriter, err error) {
+	msg := err.Error()
+	switch {
+	case errors.Is(err, ErrPermissionDenied) || errors.Is(err, ErrTwoFactorRequired):
+		http.Error(w, msg, http.StatusForbidden)
+	case msg == "retention policy not found" || msg == "legal hold not found" || msg == "document not found":
+		http.Error(w, msg, http.StatusNotFound)
+	case msg == "legal hold is already released":
+		http.Error(w, msg, http.StatusConflict)
+	case msg == "a reason is required" || strings.HasPrefix(msg, "unknown retention target") || strings.HasPrefix(msg, "keep_days"):
+		http.Error(w, msg, http.StatusBadRequest)
+	default:
+		http.Error(w, msg, http.StatusInternalServerError)
+	}
+}
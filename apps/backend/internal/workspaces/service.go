@@ -2,11 +2,19 @@ package workspaces
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"granth/internal/audit"
 	"granth/internal/utils"
+	"regexp"
+	"strings"
 	"time"
 )
 
+// ErrTwoFactorRequired is returned when a workspace requires 2FA and a
+// member with review, accept or management permissions acts without it.
+var ErrTwoFactorRequired = errors.New("two-factor authentication is required for reviewers and admins in this workspace")
+
 func createWorkspace(name, description string, ctx context.Context) (*Workspace, error) {
 	userID, ok := utils.GetUserIDFromContext(ctx)
 	if !ok {
@@ -18,13 +26,16 @@ func createWorkspace(name, description string, ctx context.Context) (*Workspace,
 		Name:        name,
 		Description: description,
 		OwnerID:     userID,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+		// matches the column default
+		SignatureMeanings: []string{},
+		CreatedAt:         now,
+		UpdatedAt:         now,
 	}
 
 	if err := createWorkspaceInTx(w, userID, ctx); err != nil {
 		return nil, fmt.Errorf("error creating workspace: %w", err)
 	}
+	audit.Record(audit.Entry{WorkspaceID: w.ID, Action: "workspace.created", TargetType: "workspace", TargetID: w.ID, After: w}, ctx)
 	return w, nil
 }
 
@@ -59,17 +70,12 @@ func getUserWorkspaces(ctx context.Context) ([]*Workspace, error) {
 }
 
 func updateWorkspaceDetails(id, name, description string, ctx context.Context) error {
-	userID, ok := utils.GetUserIDFromContext(ctx)
-	if !ok {
-		return fmt.Errorf("user ID not found in context")
+	if _, err := requirePermission(id, PermManagePolicy, ctx); err != nil {
+		return err
 	}
-
-	member, err := fetchMember(id, userID, ctx)
+	before, err := fetchWorkspaceByID(id, ctx)
 	if err != nil {
-		return fmt.Errorf("error checking membership: %w", err)
-	}
-	if member == nil || member.Role != string(RoleAdmin) {
-		return fmt.Errorf("only admins can update workspace details")
+		return err
 	}
 
 	w := &Workspace{
@@ -78,7 +84,15 @@ func updateWorkspaceDetails(id, name, description string, ctx context.Context) e
 		Description: description,
 		UpdatedAt:   time.Now().UTC().Format(time.RFC3339),
 	}
-	return updateWorkspace(w, ctx)
+	if err := updateWorkspace(w, ctx); err != nil {
+		return err
+	}
+	audit.Record(audit.Entry{
+		WorkspaceID: id, Action: "workspace.updated", TargetType: "workspace", TargetID: id,
+		Before: map[string]string{"name": before.Name, "description": before.Description},
+		After:  map[string]string{"name": name, "description": description},
+	}, ctx)
+	return nil
 }
 
 func deleteWorkspaceByID(id string, ctx context.Context) error {
@@ -94,8 +108,204 @@ func deleteWorkspaceByID(id string, ctx context.Context) error {
 	if w.OwnerID != userID {
 		return fmt.Errorf("only the workspace owner can delete it")
 	}
+	if err := checkWorkspaceDeletable(id, ctx); err != nil {
+		return err
+	}
+	signed, err := hasSignedDecisions(id, ctx)
+	if err != nil {
+		return err
+	}
+	if signed {
+		return fmt.Errorf("workspaces with signed proposals cannot be deleted")
+	}
+
+	if err := deleteWorkspace(id, ctx); err != nil {
+		return err
+	}
+	audit.Record(audit.Entry{WorkspaceID: id, Action: "workspace.deleted", TargetType: "workspace", TargetID: id, Before: w}, ctx)
+	return nil
+}
+
+// setTwoFactorPolicy turns the workspace 2FA requirement on or off. An admin
+// can only require 2FA once they have it, so they cannot lock themselves out.
+func setTwoFactorPolicy(id string, required bool, ctx context.Context) error {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("user ID not found in context")
+	}
+
+	// The 2FA check below stands in for checkTwoFactorPolicy
+	allowed, err := HasPermission(id, PermManagePolicy, ctx)
+	if err != nil {
+		return fmt.Errorf("error checking membership: %w", err)
+	}
+	if !allowed {
+		return fmt.Errorf("%w: requires the %s permission", ErrPermissionDenied, PermManagePolicy)
+	}
 
-	return deleteWorkspace(id, ctx)
+	hasTwoFactor, err := userHasTwoFactor(userID, ctx)
+	if err != nil {
+		return err
+	}
+	if !hasTwoFactor {
+		// Covers both directions: enabling would lock the caller out, and
+		// disabling must not be possible for an admin the policy excludes
+		return ErrTwoFactorRequired
+	}
+
+	before, err := fetchTwoFactorPolicy(id, ctx)
+	if err != nil {
+		return err
+	}
+	if err := updateTwoFactorPolicy(id, required, time.Now().UTC().Format(time.RFC3339), ctx); err != nil {
+		return err
+	}
+	recordPolicyChange(id, "require_two_factor", before, required, ctx)
+	return nil
+}
+
+// recordPolicyChange audits a change to one workspace policy setting.
+func recordPolicyChange(workspaceID, setting string, before, after interface{}, ctx context.Context) {
+	audit.Record(audit.Entry{
+		WorkspaceID: workspaceID, Action: "policy.updated", TargetType: "workspace", TargetID: workspaceID,
+		Before: map[string]interface{}{setting: before},
+		After:  map[string]interface{}{setting: after},
+	}, ctx)
+}
+
+// maxSignatureMeanings keeps the list short enough to pick from.
+const maxSignatureMeanings = 20
+
+// setSignaturePolicy turns electronic signature mode on or off and sets the
+// meanings reviewers may sign with.
+func setSignaturePolicy(id string, required bool, meanings []string, ctx context.Context) error {
+	if _, err := requirePermission(id, PermManagePolicy, ctx); err != nil {
+		return err
+	}
+
+	if len(meanings) > maxSignatureMeanings {
+		return fmt.Errorf("at most %d signature meanings are allowed", maxSignatureMeanings)
+	}
+	cleaned := make([]string, 0, len(meanings))
+	seen := make(map[string]bool, len(meanings))
+	for _, meaning := range meanings {
+		meaning = strings.TrimSpace(meaning)
+		if meaning == "" || len(meaning) > 100 {
+			return fmt.Errorf("signature meanings must be 1 to 100 characters")
+		}
+		if !seen[meaning] {
+			seen[meaning] = true
+			cleaned = append(cleaned, meaning)
+		}
+	}
+
+	before, err := fetchSignaturePolicy(id, ctx)
+	if err != nil {
+		return err
+	}
+	policy := SignaturePolicy{Required: required, Meanings: cleaned}
+	if err := updateSignaturePolicy(id, policy, time.Now().UTC().Format(time.RFC3339), ctx); err != nil {
+		return err
+	}
+	recordPolicyChange(id, "signature", before, policy, ctx)
+	return nil
+}
+
+// GetSignaturePolicy returns the electronic signature setting of a
+// workspace, or a disabled policy if the workspace does not exist.
+func GetSignaturePolicy(workspaceID string, ctx context.Context) (*SignaturePolicy, error) {
+	return fetchSignaturePolicy(workspaceID, ctx)
+}
+
+// setStewardPolicy turns the steward sign-off requirement on or off.
+func setStewardPolicy(id string, required bool, ctx context.Context) error {
+	if _, err := requirePermission(id, PermManagePolicy, ctx); err != nil {
+		return err
+	}
+	before, err := fetchStewardPolicy(id, ctx)
+	if err != nil {
+		return err
+	}
+	if err := updateStewardPolicy(id, required, time.Now().UTC().Format(time.RFC3339), ctx); err != nil {
+		return err
+	}
+	recordPolicyChange(id, "require_steward_approval", before, required, ctx)
+	return nil
+}
+
+// RequiresStewardApproval reports whether proposals in the workspace need
+// every steward's sign-off before they can be accepted.
+func RequiresStewardApproval(workspaceID string, ctx context.Context) (bool, error) {
+	return fetchStewardPolicy(workspaceID, ctx)
+}
+
+// domainPattern accepts plain hostnames such as example.com.
+var domainPattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]*[a-z0-9])?\.)+[a-z]{2,}$`)
+
+func getDomainRules(workspaceID string, ctx context.Context) ([]*DomainRule, error) {
+	if _, err := requirePermission(workspaceID, PermManageMembers, ctx); err != nil {
+		return nil, err
+	}
+	return fetchDomainRules(workspaceID, ctx)
+}
+
+func addDomainRule(workspaceID, domain, role string, ctx context.Context) (*DomainRule, error) {
+	member, err := requirePermission(workspaceID, PermManageMembers, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	domain = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(domain, "@")))
+	if !domainPattern.MatchString(domain) {
+		return nil, fmt.Errorf("invalid domain")
+	}
+	if role == "" {
+		role = string(RoleContributor)
+	}
+	r, err := requireAssignableRole(workspaceID, role, member, ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Auto-join never grants management
+	if grantsManagement(r) {
+		return nil, fmt.Errorf("invalid role: domain rules cannot grant manage_members or manage_policy")
+	}
+
+	exists, err := domainRuleExists(workspaceID, domain, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, fmt.Errorf("a rule for this domain already exists")
+	}
+
+	d := &DomainRule{
+		WorkspaceID: workspaceID,
+		Domain:      domain,
+		Role:        role,
+		CreatedBy:   &member.UserID,
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := insertDomainRule(d, ctx); err != nil {
+		return nil, err
+	}
+	audit.Record(audit.Entry{WorkspaceID: workspaceID, Action: "domain_rule.added", TargetType: "domain_rule", TargetID: d.ID, After: d}, ctx)
+	return d, nil
+}
+
+func removeDomainRule(workspaceID, ruleID string, ctx context.Context) error {
+	if _, err := requirePermission(workspaceID, PermManageMembers, ctx); err != nil {
+		return err
+	}
+	deleted, err := deleteDomainRule(workspaceID, ruleID, ctx)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("domain rule not found")
+	}
+	audit.Record(audit.Entry{WorkspaceID: workspaceID, Action: "domain_rule.removed", TargetType: "domain_rule", TargetID: ruleID}, ctx)
+	return nil
 }
 
 func getWorkspaceMembers(workspaceID string, ctx context.Context) ([]*WorkspaceMember, error) {
@@ -116,21 +326,12 @@ func getWorkspaceMembers(workspaceID string, ctx context.Context) ([]*WorkspaceM
 }
 
 func addMember(workspaceID, targetUserID, role string, ctx context.Context) (*WorkspaceMember, error) {
-	userID, ok := utils.GetUserIDFromContext(ctx)
-	if !ok {
-		return nil, fmt.Errorf("user ID not found in context")
-	}
-
-	if role != string(RoleAdmin) && role != string(RoleReviewer) && role != string(RoleContributor) {
-		return nil, fmt.Errorf("invalid role: must be admin, reviewer, or contributor")
-	}
-
-	caller, err := fetchMember(workspaceID, userID, ctx)
+	caller, err := requirePermission(workspaceID, PermManageMembers, ctx)
 	if err != nil {
-		return nil, fmt.Errorf("error checking caller membership: %w", err)
+		return nil, err
 	}
-	if caller == nil || caller.Role != string(RoleAdmin) {
-		return nil, fmt.Errorf("only admins can add members")
+	if _, err := requireAssignableRole(workspaceID, role, caller, ctx); err != nil {
+		return nil, err
 	}
 
 	existing, err := fetchMember(workspaceID, targetUserID, ctx)
@@ -145,65 +346,53 @@ func addMember(workspaceID, targetUserID, role string, ctx context.Context) (*Wo
 		WorkspaceID: workspaceID,
 		UserID:      targetUserID,
 		Role:        role,
-		InvitedBy:   &userID,
+		InvitedBy:   &caller.UserID,
 		JoinedAt:    time.Now().UTC().Format(time.RFC3339),
 	}
 	if err := insertMember(m, ctx); err != nil {
 		return nil, err
 	}
+	audit.Record(audit.Entry{WorkspaceID: workspaceID, Action: "member.added", TargetType: "user", TargetID: targetUserID, After: m}, ctx)
 	return m, nil
 }
 
 func updateMember(workspaceID, targetUserID, role string, ctx context.Context) error {
-	userID, ok := utils.GetUserIDFromContext(ctx)
-	if !ok {
-		return fmt.Errorf("user ID not found in context")
+	caller, err := requirePermission(workspaceID, PermManageMembers, ctx)
+	if err != nil {
+		return err
 	}
-
-	if role != string(RoleAdmin) && role != string(RoleReviewer) && role != string(RoleContributor) {
-		return fmt.Errorf("invalid role: must be admin, reviewer, or contributor")
+	newRole, err := requireAssignableRole(workspaceID, role, caller, ctx)
+	if err != nil {
+		return err
 	}
 
-	caller, err := fetchMember(workspaceID, userID, ctx)
+	target, err := fetchMember(workspaceID, targetUserID, ctx)
 	if err != nil {
-		return fmt.Errorf("error checking caller membership: %w", err)
+		return fmt.Errorf("error fetching target member: %w", err)
 	}
-	if caller == nil || caller.Role != string(RoleAdmin) {
-		return fmt.Errorf("only admins can change member roles")
+	if target == nil {
+		return fmt.Errorf("user is not a member of this workspace")
 	}
-
-	// Prevent demoting the last admin
-	if role != string(RoleAdmin) {
-		target, err := fetchMember(workspaceID, targetUserID, ctx)
-		if err != nil {
-			return fmt.Errorf("error fetching target member: %w", err)
-		}
-		if target != nil && target.Role == string(RoleAdmin) {
-			adminCount, err := countAdmins(workspaceID, ctx)
-			if err != nil {
-				return err
-			}
-			if adminCount <= 1 {
-				return fmt.Errorf("cannot demote the last admin")
-			}
+	// Prevent demoting the last member who can manage members
+	if !hasPermission(newRole.Permissions, PermManageMembers) {
+		if err := ensureMemberManagerRemains(workspaceID, target, "cannot demote the last admin", ctx); err != nil {
+			return err
 		}
 	}
 
-	return updateMemberRole(workspaceID, targetUserID, role, ctx)
+	if err := updateMemberRole(workspaceID, targetUserID, role, ctx); err != nil {
+		return err
+	}
+	audit.Record(audit.Entry{
+		WorkspaceID: workspaceID, Action: "member.role_changed", TargetType: "user", TargetID: targetUserID,
+		Before: map[string]string{"role": target.Role}, After: map[string]string{"role": role},
+	}, ctx)
+	return nil
 }
 
 func removeMemberFromWorkspace(workspaceID, targetUserID string, ctx context.Context) error {
-	userID, ok := utils.GetUserIDFromContext(ctx)
-	if !ok {
-		return fmt.Errorf("user ID not found in context")
-	}
-
-	caller, err := fetchMember(workspaceID, userID, ctx)
-	if err != nil {
-		return fmt.Errorf("error checking caller membership: %w", err)
-	}
-	if caller == nil || caller.Role != string(RoleAdmin) {
-		return fmt.Errorf("only admins can remove members")
+	if _, err := requirePermission(workspaceID, PermManageMembers, ctx); err != nil {
+		return err
 	}
 
 	target, err := fetchMember(workspaceID, targetUserID, ctx)
@@ -214,17 +403,32 @@ func removeMemberFromWorkspace(workspaceID, targetUserID string, ctx context.Con
 		return fmt.Errorf("user is not a member of this workspace")
 	}
 
-	if target.Role == string(RoleAdmin) {
-		adminCount, err := countAdmins(workspaceID, ctx)
-		if err != nil {
-			return err
-		}
-		if adminCount <= 1 {
-			return fmt.Errorf("cannot remove the last admin")
-		}
+	w, err := fetchWorkspaceByID(workspaceID, ctx)
+	if err != nil {
+		return err
+	}
+	if w.OwnerID == targetUserID {
+		return fmt.Errorf("cannot remove the workspace owner")
+	}
+
+	if err := ensureMemberManagerRemains(workspaceID, target, "cannot remove the last admin", ctx); err != nil {
+		return err
 	}
 
-	return removeMember(workspaceID, targetUserID, ctx)
+	if err := removeMember(workspaceID, targetUserID, ctx); err != nil {
+		return err
+	}
+	audit.Record(audit.Entry{WorkspaceID: workspaceID, Action: "member.removed", TargetType: "user", TargetID: targetUserID, Before: target}, ctx)
+	return nil
+}
+
+// listAuditEvents returns the workspace's audit log to members who can
+// manage its policy.
+func listAuditEvents(workspaceID string, filter audit.Filter, ctx context.Context) ([]*audit.Event, error) {
+	if _, err := requirePermission(workspaceID, PermManagePolicy, ctx); err != nil {
+		return nil, err
+	}
+	return audit.ListForWorkspace(workspaceID, filter, ctx)
 }
 
 // IsMember returns whether the requesting user is a member of the given workspace.
@@ -240,3 +444,46 @@ func IsMember(workspaceID string, ctx context.Context) (bool, error) {
 	}
 	return m != nil, nil
 }
+
+// checkTwoFactorPolicy enforces the workspace 2FA requirement for a member
+// whose role grants review, accept or management permissions. Members who
+// can only read, propose and comment are never affected.
+func checkTwoFactorPolicy(workspaceID string, member *WorkspaceMember, ctx context.Context) error {
+	permissions, err := memberPermissions(workspaceID, member, ctx)
+	if err != nil {
+		return err
+	}
+	privileged := false
+	for _, p := range privilegedPermissions {
+		privileged = privileged || hasPermission(permissions, p)
+	}
+	if !privileged {
+		return nil
+	}
+	required, err := fetchTwoFactorPolicy(workspaceID, ctx)
+	if err != nil || !required {
+		return err
+	}
+	enabled, err := userHasTwoFactor(member.UserID, ctx)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return ErrTwoFactorRequired
+	}
+	return nil
+}
+
+// RequireTwoFactorCompliance checks the requesting user against the
+// workspace 2FA policy.
+func RequireTwoFactorCompliance(workspaceID string, ctx context.Context) error {
+	userID, ok := utils.GetUserIDFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("user ID not found in context")
+	}
+	m, err := fetchMember(workspaceID, userID, ctx)
+	if err != nil {
+		return err
+	}
+	return checkTwoFactorPolicy(workspaceID, m, ctx)
+}
@@ -0,0 +1,36 @@
+package workspaces
+
+import (
+	"strings"
+	"testing"
+
+	"granth/internal/config"
+	"granth/internal/testdb"
+)
+
+func TestSignedDecisionsBlockDeletion(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	documentID := testdb.CreateDocument(t, workspaceID, owner)
+	proposalID := testdb.CreateProposal(t, documentID, owner)
+	var decisionID string
+	if err := config.PostgresDB.QueryRow(
+		`INSERT INTO proposal_decisions (proposal_id, outcome, decided_by) VALUES ($1, 'accepted', $2) RETURNING id`,
+		proposalID, owner,
+	).Scan(&decisionID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.PostgresDB.Exec(
+		`INSERT INTO decision_signatures (decision_id, proposal_id, signer_id, signer_name, meaning, method, manifest_hash)
+		 VALUES ($1, $2, $3, 'owner', 'approved', 'password', 'x')`, decisionID, proposalID, owner); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := deleteWorkspaceByID(workspaceID, testdb.As(owner)); err == nil || err.Error() != "workspaces with signed proposals cannot be deleted" {
+		t.Fatalf("deleting the workspace: got %v", err)
+	}
+	if _, err := config.PostgresDB.Exec(`DELETE FROM proposals WHERE id = $1`, proposalID); err == nil || !strings.Contains(err.Error(), "signed decisions cannot be deleted") {
+		t.Fatalf("cascading from the proposal: got %v", err)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"granth/internal/config"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 // ── Workspaces ────────────────────────────────────────────────────────────────
@@ -41,9 +43,9 @@ func createWorkspaceInTx(w *Workspace, memberID string, ctx context.Context) err
 func fetchWorkspaceByID(id string, ctx context.Context) (*Workspace, error) {
 	w := &Workspace{}
 	err := config.PostgresDB.QueryRowContext(ctx,
-		`SELECT id, name, COALESCE(description, ''), owner_id, created_at, updated_at
+		`SELECT id, name, COALESCE(description, ''), owner_id, require_two_factor, require_signature, signature_meanings, require_steward_approval, created_at, updated_at
 		 FROM workspaces WHERE id = $1`, id,
-	).Scan(&w.ID, &w.Name, &w.Description, &w.OwnerID, &w.CreatedAt, &w.UpdatedAt)
+	).Scan(&w.ID, &w.Name, &w.Description, &w.OwnerID, &w.RequireTwoFactor, &w.RequireSignature, pq.Array(&w.SignatureMeanings), &w.RequireStewardApproval, &w.CreatedAt, &w.UpdatedAt)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("workspace not found")
 	}
@@ -55,7 +57,7 @@ func fetchWorkspaceByID(id string, ctx context.Context) (*Workspace, error) {
 
 func fetchWorkspacesForUser(userID string, ctx context.Context) ([]*Workspace, error) {
 	rows, err := config.PostgresDB.QueryContext(ctx,
-		`SELECT w.id, w.name, COALESCE(w.description, ''), w.owner_id, w.created_at, w.updated_at
+		`SELECT w.id, w.name, COALESCE(w.description, ''), w.owner_id, w.require_two_factor, w.require_signature, w.signature_meanings, w.require_steward_approval, w.created_at, w.updated_at
 		 FROM workspaces w
 		 INNER JOIN workspace_members wm ON wm.workspace_id = w.id
 		 WHERE wm.user_id = $1
@@ -69,7 +71,7 @@ func fetchWorkspacesForUser(userID string, ctx context.Context) ([]*Workspace, e
 	var workspaces []*Workspace
 	for rows.Next() {
 		w := &Workspace{}
-		if err := rows.Scan(&w.ID, &w.Name, &w.Description, &w.OwnerID, &w.CreatedAt, &w.UpdatedAt); err != nil {
+		if err := rows.Scan(&w.ID, &w.Name, &w.Description, &w.OwnerID, &w.RequireTwoFactor, &w.RequireSignature, pq.Array(&w.SignatureMeanings), &w.RequireStewardApproval, &w.CreatedAt, &w.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("error scanning workspace: %w", err)
 		}
 		workspaces = append(workspaces, w)
@@ -88,6 +90,56 @@ func updateWorkspace(w *Workspace, ctx context.Context) error {
 	return nil
 }
 
+func updateTwoFactorPolicy(id string, required bool, updatedAt string, ctx context.Context) error {
+	_, err := config.PostgresDB.ExecContext(ctx,
+		`UPDATE workspaces SET require_two_factor = $1, updated_at = $2 WHERE id = $3`,
+		required, updatedAt, id,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating two-factor policy: %w", err)
+	}
+	return nil
+}
+
+func updateSignaturePolicy(id string, policy SignaturePolicy, updatedAt string, ctx context.Context) error {
+	_, err := config.PostgresDB.ExecContext(ctx,
+		`UPDATE workspaces SET require_signature = $1, signature_meanings = $2, updated_at = $3 WHERE id = $4`,
+		policy.Required, pq.Array(policy.Meanings), updatedAt, id,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating signature policy: %w", err)
+	}
+	return nil
+}
+
+func updateStewardPolicy(id string, required bool, updatedAt string, ctx context.Context) error {
+	_, err := config.PostgresDB.ExecContext(ctx,
+		`UPDATE workspaces SET require_steward_approval = $1, updated_at = $2 WHERE id = $3`,
+		required, updatedAt, id,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating steward policy: %w", err)
+	}
+	return nil
+}
+
+// hasSignedDecisions reports whether any proposal in the workspace, trashed
+// or not, has a signed decision.
+func hasSignedDecisions(id string, ctx context.Context) (bool, error) {
+	var signed bool
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT EXISTS(
+		     SELECT 1 FROM decision_signatures ds
+		     JOIN proposals p ON p.id = ds.proposal_id
+		     JOIN documents d ON d.id = p.document_id
+		     WHERE d.workspace_id = $1)`, id,
+	).Scan(&signed)
+	if err != nil {
+		return false, fmt.Errorf("error checking signatures: %w", err)
+	}
+	return signed, nil
+}
+
 func deleteWorkspace(id string, ctx context.Context) error {
 	_, err := config.PostgresDB.ExecContext(ctx, `DELETE FROM workspaces WHERE id = $1`, id)
 	if err != nil {
@@ -164,25 +216,1273 @@ func updateMemberRole(workspaceID, userID, role string, ctx context.Context) err
 	return nil
 }
 
+// removeMember drops a member together with their places in the
+// workspace's teams.
 func removeMember(workspaceID, userID string, ctx context.Context) error {
-	_, err := config.PostgresDB.ExecContext(ctx,
+	tx, err := config.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := deleteMemberRows(tx, workspaceID, userID, ctx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// deleteMemberRows removes a member and their team memberships.
+func deleteMemberRows(tx *sql.Tx, workspaceID, userID string, ctx context.Context) error {
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM team_members tm USING teams t
+		 WHERE t.id = tm.team_id AND t.workspace_id = $1 AND tm.user_id = $2`,
+		workspaceID, userID,
+	); err != nil {
+		return fmt.Errorf("error removing team memberships: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
 		`DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2`,
 		workspaceID, userID,
-	)
-	if err != nil {
+	); err != nil {
 		return fmt.Errorf("error removing member: %w", err)
 	}
 	return nil
 }
 
-func countAdmins(workspaceID string, ctx context.Context) (int, error) {
+// countMembersWithRoles counts the members holding any of roles.
+func countMembersWithRoles(workspaceID string, roles []string, ctx context.Context) (int, error) {
 	var count int
 	err := config.PostgresDB.QueryRowContext(ctx,
-		`SELECT COUNT(*) FROM workspace_members WHERE workspace_id = $1 AND role = 'admin'`,
-		workspaceID,
+		`SELECT COUNT(*) FROM workspace_members WHERE workspace_id = $1 AND role = ANY($2)`,
+		workspaceID, pq.Array(roles),
 	).Scan(&count)
 	if err != nil {
-		return 0, fmt.Errorf("error counting admins: %w", err)
+		return 0, fmt.Errorf("error counting members: %w", err)
 	}
 	return count, nil
 }
+
+// ── Roles ─────────────────────────────────────────────────────────────────────
+
+func scanRole(row interface{ Scan(...any) error }) (*Role, error) {
+	r := &Role{}
+	var permissions []string
+	if err := row.Scan(&r.ID, &r.WorkspaceID, &r.Name, &r.Description, pq.Array(&permissions),
+		&r.CreatedBy, &r.CreatedAt, &r.UpdatedAt); err != nil {
+		return nil, err
+	}
+	r.Permissions = make([]Permission, len(permissions))
+	for i, p := range permissions {
+		r.Permissions[i] = Permission(p)
+	}
+	return r, nil
+}
+
+func permissionStrings(permissions []Permission) []string {
+	out := make([]string, len(permissions))
+	for i, p := range permissions {
+		out[i] = string(p)
+	}
+	return out
+}
+
+func fetchCustomRoles(workspaceID string, ctx context.Context) ([]*Role, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT id, workspace_id, name, description, permissions, created_by, created_at, updated_at
+		 FROM workspace_roles WHERE workspace_id = $1 ORDER BY name`,
+		workspaceID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error querying roles: %w", err)
+	}
+	defer rows.Close()
+
+	var roles []*Role
+	for rows.Next() {
+		r, err := scanRole(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning role: %w", err)
+		}
+		roles = append(roles, r)
+	}
+	return roles, rows.Err()
+}
+
+// fetchCustomRole returns nil if the workspace has no role called name.
+func fetchCustomRole(workspaceID, name string, ctx context.Context) (*Role, error) {
+	r, err := scanRole(config.PostgresDB.QueryRowContext(ctx,
+		`SELECT id, workspace_id, name, description, permissions, created_by, created_at, updated_at
+		 FROM workspace_roles WHERE workspace_id = $1 AND name = $2`,
+		workspaceID, name,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching role: %w", err)
+	}
+	return r, nil
+}
+
+func insertRole(r *Role, ctx context.Context) error {
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`INSERT INTO workspace_roles (workspace_id, name, description, permissions, created_by, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $6)
+		 ON CONFLICT (workspace_id, name) DO NOTHING
+		 RETURNING id`,
+		r.WorkspaceID, r.Name, r.Description, pq.Array(permissionStrings(r.Permissions)), r.CreatedBy, r.CreatedAt,
+	).Scan(&r.ID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("a role with this name already exists")
+	}
+	if err != nil {
+		return fmt.Errorf("error inserting role: %w", err)
+	}
+	return nil
+}
+
+func updateRoleRow(r *Role, ctx context.Context) error {
+	_, err := config.PostgresDB.ExecContext(ctx,
+		`UPDATE workspace_roles SET description = $1, permissions = $2, updated_at = $3
+		 WHERE workspace_id = $4 AND name = $5`,
+		r.Description, pq.Array(permissionStrings(r.Permissions)), r.UpdatedAt, r.WorkspaceID, r.Name,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating role: %w", err)
+	}
+	return nil
+}
+
+// deleteUnusedRole removes a custom role unless a member, pending
+// invitation or domain rule still refers to it.
+func deleteUnusedRole(workspaceID, name string, ctx context.Context) (bool, error) {
+	res, err := config.PostgresDB.ExecContext(ctx,
+		`DELETE FROM workspace_roles r
+		 WHERE r.workspace_id = $1 AND r.name = $2
+		   AND NOT EXISTS (SELECT 1 FROM workspace_members WHERE workspace_id = $1 AND role = $2)
+		   AND NOT EXISTS (SELECT 1 FROM workspace_invitations WHERE workspace_id = $1 AND role = $2 AND status = 'pending')
+		   AND NOT EXISTS (SELECT 1 FROM workspace_domain_rules WHERE workspace_id = $1 AND role = $2)`,
+		workspaceID, name,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error deleting role: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error deleting role: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ── Security policy ───────────────────────────────────────────────────────────
+
+func fetchTwoFactorPolicy(workspaceID string, ctx context.Context) (bool, error) {
+	var required bool
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT require_two_factor FROM workspaces WHERE id = $1`, workspaceID,
+	).Scan(&required)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error fetching two-factor policy: %w", err)
+	}
+	return required, nil
+}
+
+func fetchStewardPolicy(workspaceID string, ctx context.Context) (bool, error) {
+	var required bool
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT require_steward_approval FROM workspaces WHERE id = $1`, workspaceID,
+	).Scan(&required)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error fetching steward policy: %w", err)
+	}
+	return required, nil
+}
+
+func fetchSignaturePolicy(workspaceID string, ctx context.Context) (*SignaturePolicy, error) {
+	p := &SignaturePolicy{}
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT require_signature, signature_meanings FROM workspaces WHERE id = $1`, workspaceID,
+	).Scan(&p.Required, pq.Array(&p.Meanings))
+	if err == sql.ErrNoRows {
+		return &SignaturePolicy{Meanings: []string{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching signature policy: %w", err)
+	}
+	return p, nil
+}
+
+// userHasTwoFactor reads user_totp directly; the auth package owns the
+// table but importing it here would create a cycle.
+func userHasTwoFactor(userID string, ctx context.Context) (bool, error) {
+	var enabled bool
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM user_totp WHERE user_id = $1 AND enabled_at IS NOT NULL)`, userID,
+	).Scan(&enabled)
+	if err != nil {
+		return false, fmt.Errorf("error checking two-factor status: %w", err)
+	}
+	return enabled, nil
+}
+
+// ── Domain rules ──────────────────────────────────────────────────────────────
+
+func insertDomainRule(d *DomainRule, ctx context.Context) error {
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`INSERT INTO workspace_domain_rules (workspace_id, domain, role, created_by, created_at)
+		 VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		d.WorkspaceID, d.Domain, d.Role, d.CreatedBy, d.CreatedAt,
+	).Scan(&d.ID)
+	if err != nil {
+		return fmt.Errorf("error inserting domain rule: %w", err)
+	}
+	return nil
+}
+
+func fetchDomainRules(workspaceID string, ctx context.Context) ([]*DomainRule, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT id, workspace_id, domain, role, created_by, created_at
+		 FROM workspace_domain_rules WHERE workspace_id = $1 ORDER BY domain ASC`,
+		workspaceID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error querying domain rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := make([]*DomainRule, 0)
+	for rows.Next() {
+		d := &DomainRule{}
+		if err := rows.Scan(&d.ID, &d.WorkspaceID, &d.Domain, &d.Role, &d.CreatedBy, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning domain rule: %w", err)
+		}
+		rules = append(rules, d)
+	}
+	return rules, rows.Err()
+}
+
+func domainRuleExists(workspaceID, domain string, ctx context.Context) (bool, error) {
+	var exists bool
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM workspace_domain_rules WHERE workspace_id = $1 AND domain = $2)`,
+		workspaceID, domain,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("error checking domain rule: %w", err)
+	}
+	return exists, nil
+}
+
+func deleteDomainRule(workspaceID, ruleID string, ctx context.Context) (bool, error) {
+	res, err := config.PostgresDB.ExecContext(ctx,
+		`DELETE FROM workspace_domain_rules WHERE id = $1 AND workspace_id = $2`, ruleID, workspaceID)
+	if err != nil {
+		return false, fmt.Errorf("error deleting domain rule: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error deleting domain rule: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ── Invitations ───────────────────────────────────────────────────────────────
+
+const invitationColumns = `i.id, i.workspace_id, w.name, i.kind, i.email, i.role, i.prefix, i.max_uses, i.use_count,
+	i.status, i.invited_by, u.username, i.expires_at, i.created_at`
+
+const invitationJoins = `FROM workspace_invitations i
+	INNER JOIN workspaces w ON w.id = i.workspace_id
+	LEFT JOIN users u ON u.id = i.invited_by`
+
+func scanInvitation(row interface{ Scan(...interface{}) error }) (*Invitation, error) {
+	inv := &Invitation{}
+	err := row.Scan(&inv.ID, &inv.WorkspaceID, &inv.WorkspaceName, &inv.Kind, &inv.Email, &inv.Role, &inv.Prefix,
+		&inv.MaxUses, &inv.UseCount, &inv.Status, &inv.InvitedBy, &inv.InviterName, &inv.ExpiresAt, &inv.CreatedAt)
+	return inv, err
+}
+
+func queryInvitations(ctx context.Context, where string, args ...interface{}) ([]*Invitation, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT `+invitationColumns+` `+invitationJoins+` WHERE `+where+` ORDER BY i.created_at DESC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying invitations: %w", err)
+	}
+	defer rows.Close()
+
+	invitations := make([]*Invitation, 0)
+	for rows.Next() {
+		inv, err := scanInvitation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning invitation: %w", err)
+		}
+		invitations = append(invitations, inv)
+	}
+	return invitations, nil
+}
+
+func insertInvitation(inv *Invitation, tokenHash string, ctx context.Context) error {
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`INSERT INTO workspace_invitations (workspace_id, kind, email, role, prefix, token_hash, max_uses, invited_by, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, status, created_at`,
+		inv.WorkspaceID, inv.Kind, inv.Email, inv.Role, inv.Prefix, tokenHash, inv.MaxUses, inv.InvitedBy, inv.ExpiresAt,
+	).Scan(&inv.ID, &inv.Status, &inv.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error inserting invitation: %w", err)
+	}
+	return nil
+}
+
+// fetchPendingInvitations lists the invitations of a workspace that can
+// still be used.
+func fetchPendingInvitations(workspaceID string, ctx context.Context) ([]*Invitation, error) {
+	return queryInvitations(ctx,
+		`i.workspace_id = $1 AND i.status = 'pending' AND i.expires_at > now()
+		 AND (i.max_uses IS NULL OR i.use_count < i.max_uses)`, workspaceID)
+}
+
+// fetchPendingInvitationsForUser lists live email invitations addressed to
+// the user's email address.
+func fetchPendingInvitationsForUser(userID string, ctx context.Context) ([]*Invitation, error) {
+	return queryInvitations(ctx,
+		`i.kind = 'email' AND i.status = 'pending' AND i.expires_at > now()
+		 AND lower(i.email) = (SELECT lower(email) FROM users WHERE id = $1)`, userID)
+}
+
+// fetchInvitationByPrefix returns an invitation and its token hash.
+func fetchInvitationByPrefix(prefix string, ctx context.Context) (*Invitation, string, error) {
+	var tokenHash string
+	row := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT `+invitationColumns+`, i.token_hash `+invitationJoins+` WHERE i.prefix = $1`, prefix)
+	inv := &Invitation{}
+	err := row.Scan(&inv.ID, &inv.WorkspaceID, &inv.WorkspaceName, &inv.Kind, &inv.Email, &inv.Role, &inv.Prefix,
+		&inv.MaxUses, &inv.UseCount, &inv.Status, &inv.InvitedBy, &inv.InviterName, &inv.ExpiresAt, &inv.CreatedAt, &tokenHash)
+	if err == sql.ErrNoRows {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("error fetching invitation: %w", err)
+	}
+	return inv, tokenHash, nil
+}
+
+func fetchInvitationByID(id string, ctx context.Context) (*Invitation, error) {
+	inv, err := scanInvitation(config.PostgresDB.QueryRowContext(ctx,
+		`SELECT `+invitationColumns+` `+invitationJoins+` WHERE i.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching invitation: %w", err)
+	}
+	return inv, nil
+}
+
+// revokePendingInvitation revokes a workspace invitation that is still
+// pending. It reports false when there was none.
+func revokePendingInvitation(workspaceID, id string, ctx context.Context) (bool, error) {
+	res, err := config.PostgresDB.ExecContext(ctx,
+		`UPDATE workspace_invitations SET status = 'revoked'
+		 WHERE workspace_id = $1 AND id = $2 AND status = 'pending'`, workspaceID, id)
+	if err != nil {
+		return false, fmt.Errorf("error revoking invitation: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error revoking invitation: %w", err)
+	}
+	return n > 0, nil
+}
+
+// revokeEmailInvitations revokes earlier pending invitations for the same
+// address so only the newest link works.
+func revokeEmailInvitations(workspaceID, email string, ctx context.Context) error {
+	_, err := config.PostgresDB.ExecContext(ctx,
+		`UPDATE workspace_invitations SET status = 'revoked'
+		 WHERE workspace_id = $1 AND kind = 'email' AND lower(email) = lower($2) AND status = 'pending'`,
+		workspaceID, email)
+	if err != nil {
+		return fmt.Errorf("error revoking invitations: %w", err)
+	}
+	return nil
+}
+
+// memberExistsByEmail reports whether a user with email already belongs to
+// the workspace.
+func memberExistsByEmail(workspaceID, email string, ctx context.Context) (bool, error) {
+	var exists bool
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM workspace_members wm INNER JOIN users u ON u.id = wm.user_id
+		 WHERE wm.workspace_id = $1 AND lower(u.email) = lower($2))`, workspaceID, email,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("error checking membership: %w", err)
+	}
+	return exists, nil
+}
+
+// fetchUserEmail returns a user's email and whether it is verified.
+func fetchUserEmail(userID string, ctx context.Context) (string, bool, error) {
+	var email string
+	var verified bool
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT email, email_verified_at IS NOT NULL FROM users WHERE id = $1`, userID,
+	).Scan(&email, &verified)
+	if err != nil {
+		return "", false, fmt.Errorf("error fetching user: %w", err)
+	}
+	return email, verified, nil
+}
+
+// redeemInvitation uses an invitation for userID and adds the membership in
+// one transaction. It reports false when the invitation can no longer be
+// used.
+func redeemInvitation(inv *Invitation, userID string, ctx context.Context) (bool, *WorkspaceMember, error) {
+	tx, err := config.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE workspace_invitations
+		 SET use_count = use_count + 1,
+		     status = CASE WHEN kind = 'email' THEN 'accepted' ELSE status END,
+		     responded_by = CASE WHEN kind = 'email' THEN $2::uuid ELSE responded_by END,
+		     responded_at = CASE WHEN kind = 'email' THEN now() ELSE responded_at END
+		 WHERE id = $1 AND status = 'pending' AND expires_at > now()
+		   AND (max_uses IS NULL OR use_count < max_uses)`, inv.ID, userID)
+	if err != nil {
+		return false, nil, fmt.Errorf("error redeeming invitation: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, nil, err
+	}
+
+	m := &WorkspaceMember{
+		WorkspaceID: inv.WorkspaceID,
+		UserID:      userID,
+		Role:        inv.Role,
+		InvitedBy:   inv.InvitedBy,
+		JoinedAt:    time.Now().UTC().Format(time.RFC3339),
+	}
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO workspace_members (workspace_id, user_id, role, invited_by, joined_at)
+		 VALUES ($1, $2, $3, $4, $5) ON CONFLICT (workspace_id, user_id) DO NOTHING RETURNING id`,
+		m.WorkspaceID, m.UserID, m.Role, m.InvitedBy, m.JoinedAt,
+	).Scan(&m.ID)
+	if err == sql.ErrNoRows {
+		return false, nil, fmt.Errorf("you are already a member of this workspace")
+	}
+	if err != nil {
+		return false, nil, fmt.Errorf("error inserting member: %w", err)
+	}
+	return true, m, tx.Commit()
+}
+
+// declineEmailInvitation records that the invitee turned the invitation
+// down. It reports false when it was no longer pending.
+func declineEmailInvitation(id, userID string, ctx context.Context) (bool, error) {
+	res, err := config.PostgresDB.ExecContext(ctx,
+		`UPDATE workspace_invitations SET status = 'declined', responded_by = $2, responded_at = now()
+		 WHERE id = $1 AND kind = 'email' AND status = 'pending'`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("error declining invitation: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error declining invitation: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ── Teams ─────────────────────────────────────────────────────────────────────
+
+// fetchTeam returns nil if there is no such team.
+func fetchTeam(teamID string, ctx context.Context) (*Team, error) {
+	t := &Team{}
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT id, workspace_id, name, description, created_by, created_at, updated_at
+		 FROM teams WHERE id = $1`,
+		teamID,
+	).Scan(&t.ID, &t.WorkspaceID, &t.Name, &t.Description, &t.CreatedBy, &t.CreatedAt, &t.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching team: %w", err)
+	}
+	members, err := fetchTeamMembers([]string{teamID}, ctx)
+	if err != nil {
+		return nil, err
+	}
+	t.Members = members[teamID]
+	if t.Members == nil {
+		t.Members = []*TeamMember{}
+	}
+	return t, nil
+}
+
+func fetchTeamsForWorkspace(workspaceID string, ctx context.Context) ([]*Team, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT id, workspace_id, name, description, created_by, created_at, updated_at
+		 FROM teams WHERE workspace_id = $1 ORDER BY name`,
+		workspaceID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error querying teams: %w", err)
+	}
+	defer rows.Close()
+
+	teams := []*Team{}
+	var ids []string
+	for rows.Next() {
+		t := &Team{Members: []*TeamMember{}}
+		if err := rows.Scan(&t.ID, &t.WorkspaceID, &t.Name, &t.Description, &t.CreatedBy, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning team: %w", err)
+		}
+		teams = append(teams, t)
+		ids = append(ids, t.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	members, err := fetchTeamMembers(ids, ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range teams {
+		if m := members[t.ID]; m != nil {
+			t.Members = m
+		}
+	}
+	return teams, nil
+}
+
+// fetchTeamMembers returns the members of each team, leads first.
+func fetchTeamMembers(teamIDs []string, ctx context.Context) (map[string][]*TeamMember, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT tm.team_id, tm.user_id, u.username, tm.is_lead, tm.added_at
+		 FROM team_members tm
+		 JOIN users u ON u.id = tm.user_id
+		 WHERE tm.team_id = ANY($1)
+		 ORDER BY tm.is_lead DESC, u.username`,
+		pq.Array(teamIDs),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error querying team members: %w", err)
+	}
+	defer rows.Close()
+
+	members := make(map[string][]*TeamMember)
+	for rows.Next() {
+		var teamID string
+		m := &TeamMember{}
+		if err := rows.Scan(&teamID, &m.UserID, &m.Username, &m.IsLead, &m.AddedAt); err != nil {
+			return nil, fmt.Errorf("error scanning team member: %w", err)
+		}
+		members[teamID] = append(members[teamID], m)
+	}
+	return members, rows.Err()
+}
+
+func insertTeam(t *Team, ctx context.Context) error {
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`INSERT INTO teams (workspace_id, name, description, created_by, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $5)
+		 ON CONFLICT (workspace_id, name) DO NOTHING
+		 RETURNING id`,
+		t.WorkspaceID, t.Name, t.Description, t.CreatedBy, t.CreatedAt,
+	).Scan(&t.ID)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("a team with this name already exists")
+	}
+	if err != nil {
+		return fmt.Errorf("error inserting team: %w", err)
+	}
+	return nil
+}
+
+func updateTeamRow(t *Team, ctx context.Context) error {
+	_, err := config.PostgresDB.ExecContext(ctx,
+		`UPDATE teams SET name = $1, description = $2, updated_at = $3 WHERE id = $4`,
+		t.Name, t.Description, t.UpdatedAt, t.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("error updating team: %w", err)
+	}
+	return nil
+}
+
+// teamNameTaken reports whether another team in the workspace uses name.
+func teamNameTaken(workspaceID, name, exceptTeamID string, ctx context.Context) (bool, error) {
+	var taken bool
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM teams WHERE workspace_id = $1 AND name = $2 AND id <> $3)`,
+		workspaceID, name, exceptTeamID,
+	).Scan(&taken)
+	if err != nil {
+		return false, fmt.Errorf("error checking team name: %w", err)
+	}
+	return taken, nil
+}
+
+func deleteTeamRow(teamID string, ctx context.Context) error {
+	if _, err := config.PostgresDB.ExecContext(ctx, `DELETE FROM teams WHERE id = $1`, teamID); err != nil {
+		return fmt.Errorf("error deleting team: %w", err)
+	}
+	return nil
+}
+
+// upsertTeamMember adds userID to the team or updates their lead flag.
+func upsertTeamMember(teamID, userID string, isLead bool, addedBy, addedAt string, ctx context.Context) error {
+	_, err := config.PostgresDB.ExecContext(ctx,
+		`INSERT INTO team_members (team_id, user_id, is_lead, added_by, added_at)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (team_id, user_id) DO UPDATE SET is_lead = EXCLUDED.is_lead`,
+		teamID, userID, isLead, addedBy, addedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("error saving team member: %w", err)
+	}
+	return nil
+}
+
+func deleteTeamMember(teamID, userID string, ctx context.Context) (bool, error) {
+	res, err := config.PostgresDB.ExecContext(ctx,
+		`DELETE FROM team_members WHERE team_id = $1 AND user_id = $2`, teamID, userID)
+	if err != nil {
+		return false, fmt.Errorf("error removing team member: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error removing team member: %w", err)
+	}
+	return n > 0, nil
+}
+
+func isTeamLead(teamID, userID string, ctx context.Context) (bool, error) {
+	var lead bool
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM team_members WHERE team_id = $1 AND user_id = $2 AND is_lead)`,
+		teamID, userID,
+	).Scan(&lead)
+	if err != nil {
+		return false, fmt.Errorf("error checking team lead: %w", err)
+	}
+	return lead, nil
+}
+
+// ── Guest grants ──────────────────────────────────────────────────────────────
+
+const guestGrantColumns = `g.id, g.workspace_id, w.name, g.user_id, u.username, u.email, g.document_id, g.proposal_id,
+	COALESCE(d.title, p.title, ''), g.permissions, g.note,
+	CASE WHEN g.revoked_at IS NOT NULL THEN 'revoked' WHEN g.expires_at <= now() THEN 'expired' ELSE 'active' END,
+	g.granted_by, g.expires_at, g.revoked_at, g.revoked_by, g.created_at`
+
+const guestGrantJoins = `FROM guest_grants g
+	JOIN workspaces w ON w.id = g.workspace_id
+	JOIN users u ON u.id = g.user_id
+	LEFT JOIN documents d ON d.id = g.document_id
+	LEFT JOIN proposals p ON p.id = g.proposal_id`
+
+func scanGuestGrant(row interface{ Scan(...interface{}) error }) (*GuestGrant, error) {
+	g := &GuestGrant{}
+	var permissions []string
+	err := row.Scan(&g.ID, &g.WorkspaceID, &g.WorkspaceName, &g.UserID, &g.Username, &g.Email, &g.DocumentID, &g.ProposalID,
+		&g.TargetTitle, pq.Array(&permissions), &g.Note, &g.Status,
+		&g.GrantedBy, &g.ExpiresAt, &g.RevokedAt, &g.RevokedBy, &g.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	g.Permissions = make([]Permission, len(permissions))
+	for i, p := range permissions {
+		g.Permissions[i] = Permission(p)
+	}
+	return g, nil
+}
+
+func queryGuestGrants(ctx context.Context, where string, args ...interface{}) ([]*GuestGrant, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT `+guestGrantColumns+` `+guestGrantJoins+` WHERE `+where+` ORDER BY g.created_at DESC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying guest grants: %w", err)
+	}
+	defer rows.Close()
+
+	grants := []*GuestGrant{}
+	for rows.Next() {
+		g, err := scanGuestGrant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning guest grant: %w", err)
+		}
+		grants = append(grants, g)
+	}
+	return grants, rows.Err()
+}
+
+func fetchGuestGrantsForWorkspace(workspaceID string, ctx context.Context) ([]*GuestGrant, error) {
+	return queryGuestGrants(ctx, `g.workspace_id = $1`, workspaceID)
+}
+
+func fetchActiveGuestGrantsForUser(userID string, ctx context.Context) ([]*GuestGrant, error) {
+	return queryGuestGrants(ctx, `g.user_id = $1 AND g.revoked_at IS NULL AND g.expires_at > now()`, userID)
+}
+
+// fetchGuestGrant returns a grant of the workspace, or nil if there is none.
+func fetchGuestGrant(workspaceID, grantID string, ctx context.Context) (*GuestGrant, error) {
+	g, err := scanGuestGrant(config.PostgresDB.QueryRowContext(ctx,
+		`SELECT `+guestGrantColumns+` `+guestGrantJoins+` WHERE g.workspace_id = $1 AND g.id = $2`, workspaceID, grantID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching guest grant: %w", err)
+	}
+	return g, nil
+}
+
+// insertGuestGrant stores a grant and its "granted" event. When
+// requestReview is set it also asks the guest to review the proposal; the
+// review request table belongs to the proposals package but is written
+// here so the grant and the request are created together.
+func insertGuestGrant(g *GuestGrant, requestReview bool, ctx context.Context) error {
+	tx, err := config.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO guest_grants (workspace_id, user_id, document_id, proposal_id, permissions, note, granted_by, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at`,
+		g.WorkspaceID, g.UserID, g.DocumentID, g.ProposalID, pq.Array(permissionStrings(g.Permissions)), g.Note, g.GrantedBy, g.ExpiresAt,
+	).Scan(&g.ID, &g.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error inserting guest grant: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO guest_grant_events (grant_id, actor_id, action) VALUES ($1, $2, 'granted')`, g.ID, g.GrantedBy,
+	); err != nil {
+		return fmt.Errorf("error recording guest grant event: %w", err)
+	}
+	if requestReview {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO proposal_review_requests (proposal_id, reviewer_user_id, requested_by)
+			 VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`,
+			g.ProposalID, g.UserID, g.GrantedBy,
+		); err != nil {
+			return fmt.Errorf("error requesting guest review: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// revokeGuestGrantRow revokes a grant that is not yet revoked and records
+// the event. It reports false when there was nothing to revoke.
+func revokeGuestGrantRow(grantID, revokedBy string, ctx context.Context) (bool, error) {
+	tx, err := config.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE guest_grants SET revoked_at = now(), revoked_by = $1 WHERE id = $2 AND revoked_at IS NULL`,
+		revokedBy, grantID)
+	if err != nil {
+		return false, fmt.Errorf("error revoking guest grant: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil || n == 0 {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO guest_grant_events (grant_id, actor_id, action) VALUES ($1, $2, 'revoked')`, grantID, revokedBy,
+	); err != nil {
+		return false, fmt.Errorf("error recording guest grant event: %w", err)
+	}
+	return true, tx.Commit()
+}
+
+func insertGuestGrantUse(grantID, userID string, perm Permission, targetID string, ctx context.Context) error {
+	_, err := config.PostgresDB.ExecContext(ctx,
+		`INSERT INTO guest_grant_events (grant_id, actor_id, action, permission, target_id)
+		 VALUES ($1, $2, 'used', $3, NULLIF($4, '')::uuid)`,
+		grantID, userID, string(perm), targetID)
+	if err != nil {
+		return fmt.Errorf("error recording guest grant event: %w", err)
+	}
+	return nil
+}
+
+func fetchGuestGrantEvents(grantID string, ctx context.Context) ([]*GuestGrantEvent, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT e.id, e.grant_id, e.actor_id, u.username, e.action, e.permission, e.target_id, e.created_at
+		 FROM guest_grant_events e
+		 LEFT JOIN users u ON u.id = e.actor_id
+		 WHERE e.grant_id = $1
+		 ORDER BY e.created_at DESC`, grantID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying guest grant events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*GuestGrantEvent{}
+	for rows.Next() {
+		e := &GuestGrantEvent{}
+		if err := rows.Scan(&e.ID, &e.GrantID, &e.ActorID, &e.ActorName, &e.Action, &e.Permission, &e.TargetID, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning guest grant event: %w", err)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// findGuestGrant returns the ID of a live grant giving userID perm on the
+// document or the proposal, or "" if there is none. Either ID may be "".
+func findGuestGrant(workspaceID, userID, documentID, proposalID string, perm Permission, ctx context.Context) (string, error) {
+	var grantID string
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT id FROM guest_grants
+		 WHERE workspace_id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > now()
+		   AND $5 = ANY(permissions)
+		   AND (document_id = NULLIF($3, '')::uuid OR proposal_id = NULLIF($4, '')::uuid)
+		 ORDER BY expires_at DESC LIMIT 1`,
+		workspaceID, userID, documentID, proposalID, string(perm),
+	).Scan(&grantID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error checking guest access: %w", err)
+	}
+	return grantID, nil
+}
+
+// fetchUserByEmail returns the ID and username of the account using email.
+// The ID is "" when there is none.
+func fetchUserByEmail(email string, ctx context.Context) (string, string, error) {
+	var id, username string
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT id, username FROM users WHERE lower(email) = lower($1)`, email,
+	).Scan(&id, &username)
+	if err == sql.ErrNoRows {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("error fetching user: %w", err)
+	}
+	return id, username, nil
+}
+
+// fetchGuestTarget returns the workspace of the document, or of the
+// proposal's document, and the target's title. The workspace is "" when
+// the target does not exist.
+func fetchGuestTarget(documentID, proposalID *string, ctx context.Context) (string, string, error) {
+	var workspaceID sql.NullString
+	var title string
+	var err error
+	if documentID != nil {
+		err = config.PostgresDB.QueryRowContext(ctx,
+			`SELECT workspace_id, title FROM documents WHERE id = $1`, *documentID,
+		).Scan(&workspaceID, &title)
+	} else {
+		err = config.PostgresDB.QueryRowContext(ctx,
+			`SELECT d.workspace_id, p.title FROM proposals p JOIN documents d ON d.id = p.document_id WHERE p.id = $1`, *proposalID,
+		).Scan(&workspaceID, &title)
+	}
+	if err == sql.ErrNoRows {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("error fetching guest target: %w", err)
+	}
+	return workspaceID.String, title, nil
+}
+
+// ── Ownership transfer and leaving ────────────────────────────────────────────
+
+const ownershipTransferColumns = `t.id, t.workspace_id, w.name, t.from_user_id, fu.username, t.to_user_id, tu.username,
+	t.status, t.created_at, t.responded_at`
+
+const ownershipTransferJoins = `FROM workspace_ownership_transfers t
+	JOIN workspaces w ON w.id = t.workspace_id
+	JOIN users tu ON tu.id = t.to_user_id
+	LEFT JOIN users fu ON fu.id = t.from_user_id`
+
+func scanOwnershipTransfer(row interface{ Scan(...interface{}) error }) (*OwnershipTransfer, error) {
+	t := &OwnershipTransfer{}
+	err := row.Scan(&t.ID, &t.WorkspaceID, &t.WorkspaceName, &t.FromUserID, &t.FromUsername, &t.ToUserID, &t.ToUsername,
+		&t.Status, &t.CreatedAt, &t.RespondedAt)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// fetchPendingOwnershipTransfer returns the workspace's open offer, or nil.
+func fetchPendingOwnershipTransfer(workspaceID string, ctx context.Context) (*OwnershipTransfer, error) {
+	t, err := scanOwnershipTransfer(config.PostgresDB.QueryRowContext(ctx,
+		`SELECT `+ownershipTransferColumns+` `+ownershipTransferJoins+` WHERE t.workspace_id = $1 AND t.status = 'pending'`,
+		workspaceID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching ownership transfer: %w", err)
+	}
+	return t, nil
+}
+
+func fetchPendingOwnershipTransfersForUser(userID string, ctx context.Context) ([]*OwnershipTransfer, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT `+ownershipTransferColumns+` `+ownershipTransferJoins+`
+		 WHERE t.to_user_id = $1 AND t.status = 'pending' ORDER BY t.created_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying ownership transfers: %w", err)
+	}
+	defer rows.Close()
+
+	transfers := []*OwnershipTransfer{}
+	for rows.Next() {
+		t, err := scanOwnershipTransfer(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning ownership transfer: %w", err)
+		}
+		transfers = append(transfers, t)
+	}
+	return transfers, rows.Err()
+}
+
+// insertOwnershipTransfer replaces any open offer for the workspace.
+func insertOwnershipTransfer(t *OwnershipTransfer, ctx context.Context) error {
+	tx, err := config.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE workspace_ownership_transfers SET status = 'cancelled', responded_at = now()
+		 WHERE workspace_id = $1 AND status = 'pending'`, t.WorkspaceID,
+	); err != nil {
+		return fmt.Errorf("error cancelling ownership transfer: %w", err)
+	}
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO workspace_ownership_transfers (workspace_id, from_user_id, to_user_id)
+		 VALUES ($1, $2, $3) RETURNING id, status, created_at`,
+		t.WorkspaceID, t.FromUserID, t.ToUserID,
+	).Scan(&t.ID, &t.Status, &t.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error inserting ownership transfer: %w", err)
+	}
+	return tx.Commit()
+}
+
+// closeOwnershipTransfer marks a pending offer declined or cancelled. It
+// reports false when the offer was no longer pending.
+func closeOwnershipTransfer(transferID, status string, ctx context.Context) (bool, error) {
+	res, err := config.PostgresDB.ExecContext(ctx,
+		`UPDATE workspace_ownership_transfers SET status = $2, responded_at = now()
+		 WHERE id = $1 AND status = 'pending'`, transferID, status)
+	if err != nil {
+		return false, fmt.Errorf("error updating ownership transfer: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// completeOwnershipTransfer accepts the offer and moves owner_id to the new
+// owner. It reports false when the offer was no longer pending or the
+// workspace changed owner in the meantime.
+func completeOwnershipTransfer(t *OwnershipTransfer, ctx context.Context) (bool, error) {
+	tx, err := config.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE workspace_ownership_transfers SET status = 'accepted', responded_at = now()
+		 WHERE id = $1 AND status = 'pending'`, t.ID)
+	if err != nil {
+		return false, fmt.Errorf("error updating ownership transfer: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	res, err = tx.ExecContext(ctx,
+		`UPDATE workspaces SET owner_id = $1, updated_at = now() WHERE id = $2 AND owner_id = $3`,
+		t.ToUserID, t.WorkspaceID, t.FromUserID)
+	if err != nil {
+		return false, fmt.Errorf("error updating workspace owner: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// countLeaverDuties counts the open proposals a member wrote and the
+// steward rules naming them in the workspace's documents.
+func countLeaverDuties(workspaceID, userID string, ctx context.Context) (int, int, error) {
+	var proposals, stewards int
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`SELECT
+		   (SELECT COUNT(*) FROM proposals p JOIN documents d ON d.id = p.document_id
+		    WHERE d.workspace_id = $1 AND p.author_id = $2 AND p.author_type = 'user' AND p.state = 'open'),
+		   (SELECT COUNT(*) FROM block_stewards s JOIN documents d ON d.id = s.document_id
+		    WHERE d.workspace_id = $1 AND s.steward_user_id = $2)`,
+		workspaceID, userID,
+	).Scan(&proposals, &stewards)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error counting open work: %w", err)
+	}
+	return proposals, stewards, nil
+}
+
+// leaveWorkspaceRows hands the leaver's open proposals and steward rules to
+// successorID, drops review requests still waiting on the leaver and any
+// ownership offer made to them, then removes the membership. The proposal
+// and steward tables belong to the proposals package but are written here
+// so the handover and the removal happen together.
+func leaveWorkspaceRows(workspaceID, userID, successorID string, ctx context.Context) (*LeaveResult, error) {
+	tx, err := config.PostgresDB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &LeaveResult{SuccessorID: successorID}
+	res, err := tx.ExecContext(ctx,
+		`UPDATE proposals p SET author_id = $3, updated_at = now()
+		 FROM documents d
+		 WHERE d.id = p.document_id AND d.workspace_id = $1
+		   AND p.author_id = $2 AND p.author_type = 'user' AND p.state = 'open'`,
+		workspaceID, userID, successorID)
+	if err != nil {
+		return nil, fmt.Errorf("error reassigning proposals: %w", err)
+	}
+	if result.ReassignedProposals, err = res.RowsAffected(); err != nil {
+		return nil, err
+	}
+	res, err = tx.ExecContext(ctx,
+		`UPDATE block_stewards s SET steward_user_id = $3
+		 FROM documents d
+		 WHERE d.id = s.document_id AND d.workspace_id = $1 AND s.steward_user_id = $2`,
+		workspaceID, userID, successorID)
+	if err != nil {
+		return nil, fmt.Errorf("error reassigning stewards: %w", err)
+	}
+	if result.ReassignedStewards, err = res.RowsAffected(); err != nil {
+		return nil, err
+	}
+	// Steward requests are raised again for the successor the next time
+	// the proposal changes or someone tries to accept it.
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM proposal_review_requests rr
+		 USING proposals p, documents d
+		 WHERE p.id = rr.proposal_id AND d.id = p.document_id AND d.workspace_id = $1
+		   AND rr.reviewer_user_id = $2 AND rr.approved_at IS NULL AND rr.declined_at IS NULL`,
+		workspaceID, userID,
+	); err != nil {
+		return nil, fmt.Errorf("error removing review requests: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE workspace_ownership_transfers SET status = 'cancelled', responded_at = now()
+		 WHERE workspace_id = $1 AND to_user_id = $2 AND status = 'pending'`,
+		workspaceID, userID,
+	); err != nil {
+		return nil, fmt.Errorf("error cancelling ownership transfer: %w", err)
+	}
+	if err := deleteMemberRows(tx, workspaceID, userID, ctx); err != nil {
+		return nil, err
+	}
+	return result, tx.Commit()
+}
+
+// ── Retention and legal holds ─────────────────────────────────────────────────
+
+const retentionPolicyColumns = `id, workspace_id, target, keep_days, dispose, updated_by, updated_at`
+
+func scanRetentionPolicy(row interface{ Scan(...interface{}) error }) (*RetentionPolicy, error) {
+	p := &RetentionPolicy{}
+	if err := row.Scan(&p.ID, &p.WorkspaceID, &p.Target, &p.KeepDays, &p.Dispose, &p.UpdatedBy, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func fetchRetentionPolicies(workspaceID string, ctx context.Context) ([]*RetentionPolicy, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT `+retentionPolicyColumns+` FROM retention_policies WHERE workspace_id = $1 ORDER BY target`, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying retention policies: %w", err)
+	}
+	defer rows.Close()
+
+	policies := []*RetentionPolicy{}
+	for rows.Next() {
+		p, err := scanRetentionPolicy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning retention policy: %w", err)
+		}
+		policies = append(policies, p)
+	}
+	return policies, rows.Err()
+}
+
+// fetchRetentionPolicy returns the workspace's policy for target, or nil.
+func fetchRetentionPolicy(workspaceID, target string, ctx context.Context) (*RetentionPolicy, error) {
+	p, err := scanRetentionPolicy(config.PostgresDB.QueryRowContext(ctx,
+		`SELECT `+retentionPolicyColumns+` FROM retention_policies WHERE workspace_id = $1 AND target = $2`,
+		workspaceID, target))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching retention policy: %w", err)
+	}
+	return p, nil
+}
+
+func upsertRetentionPolicy(p *RetentionPolicy, ctx context.Context) error {
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`INSERT INTO retention_policies (workspace_id, target, keep_days, dispose, updated_by)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (workspace_id, target) DO UPDATE
+		 SET keep_days = EXCLUDED.keep_days, dispose = EXCLUDED.dispose,
+		     updated_by = EXCLUDED.updated_by, updated_at = now()
+		 RETURNING id, updated_at`,
+		p.WorkspaceID, p.Target, p.KeepDays, p.Dispose, p.UpdatedBy,
+	).Scan(&p.ID, &p.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("error saving retention policy: %w", err)
+	}
+	return nil
+}
+
+func deleteRetentionPolicy(workspaceID, target string, ctx context.Context) error {
+	if _, err := config.PostgresDB.ExecContext(ctx,
+		`DELETE FROM retention_policies WHERE workspace_id = $1 AND target = $2`, workspaceID, target,
+	); err != nil {
+		return fmt.Errorf("error deleting retention policy: %w", err)
+	}
+	return nil
+}
+
+const legalHoldColumns = `h.id, h.workspace_id, h.document_id, d.title, h.reason,
+	h.placed_by, h.placed_at, h.released_by, h.released_at`
+
+func scanLegalHold(row interface{ Scan(...interface{}) error }) (*LegalHold, error) {
+	h := &LegalHold{}
+	err := row.Scan(&h.ID, &h.WorkspaceID, &h.DocumentID, &h.DocumentTitle, &h.Reason,
+		&h.PlacedBy, &h.PlacedAt, &h.ReleasedBy, &h.ReleasedAt)
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// fetchLegalHolds lists the workspace's holds, active ones first.
+func fetchLegalHolds(workspaceID string, ctx context.Context) ([]*LegalHold, error) {
+	rows, err := config.PostgresDB.QueryContext(ctx,
+		`SELECT `+legalHoldColumns+` FROM legal_holds h LEFT JOIN documents d ON d.id = h.document_id
+		 WHERE h.workspace_id = $1 ORDER BY h.released_at IS NOT NULL, h.placed_at DESC`, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying legal holds: %w", err)
+	}
+	defer rows.Close()
+
+	holds := []*LegalHold{}
+	for rows.Next() {
+		h, err := scanLegalHold(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning legal hold: %w", err)
+		}
+		holds = append(holds, h)
+	}
+	return holds, rows.Err()
+}
+
+// fetchLegalHold returns a hold of the workspace, or nil if there is none.
+func fetchLegalHold(workspaceID, holdID string, ctx context.Context) (*LegalHold, error) {
+	h, err := scanLegalHold(config.PostgresDB.QueryRowContext(ctx,
+		`SELECT `+legalHoldColumns+` FROM legal_holds h LEFT JOIN documents d ON d.id = h.document_id
+		 WHERE h.workspace_id = $1 AND h.id = $2`, workspaceID, holdID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching legal hold: %w", err)
+	}
+	return h, nil
+}
+
+func insertLegalHold(h *LegalHold, ctx context.Context) error {
+	err := config.PostgresDB.QueryRowContext(ctx,
+		`INSERT INTO legal_holds (workspace_id, document_id, reason, placed_by)
+		 VALUES ($1, $2, $3, $4) RETURNING id, placed_at`,
+		h.WorkspaceID, h.DocumentID, h.Reason, h.PlacedBy,
+	).Scan(&h.ID, &h.PlacedAt)
+	if err != nil {
+		return fmt.Errorf("error inserting legal hold: %w", err)
+	}
+	return nil
+}
+
+// releaseLegalHoldByID reports false when the hold was already released.
+func releaseLegalHoldByID(holdID, userID string, ctx context.Context) (bool, error) {
+	res, err := config.PostgresDB.ExecContext(ctx,
+		`UPDATE legal_holds SET released_by = $2, released_at = now()
+		 WHERE id = $1 AND released_at IS NULL`, holdID, userID)
+	if err != nil {
+		return false, fmt.Errorf("error releasing legal hold: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// Each deletion check has a query for active holds covering the record and
+// one for the latest time a retention policy keeps it, or anything in it,
+// until. The $1 parameter is the workspace, document or proposal ID.
+const (
+	workspaceHoldQuery = `SELECT EXISTS (SELECT 1 FROM legal_holds WHERE workspace_id = $1 AND released_at IS NULL)`
+	documentHoldQuery  = `SELECT EXISTS (SELECT 1 FROM legal_holds h JOIN documents d ON d.workspace_id = h.workspace_id
+		WHERE d.id = $1 AND h.released_at IS NULL AND (h.document_id IS NULL OR h.document_id = d.id))`
+	proposalHoldQuery = `SELECT EXISTS (SELECT 1 FROM legal_holds h JOIN documents d ON d.workspace_id = h.workspace_id
+		JOIN proposals p ON p.document_id = d.id
+		WHERE p.id = $1 AND h.released_at IS NULL AND (h.document_id IS NULL OR h.document_id = d.id))`
+
+	workspaceKeepQuery = `SELECT MAX(keep_until) FROM retention_schedule WHERE workspace_id = $1 AND keep_until > now()`
+	documentKeepQuery  = `SELECT MAX(keep_until) FROM retention_schedule WHERE document_id = $1 AND keep_until > now()`
+	proposalKeepQuery  = `SELECT MAX(keep_until) FROM retention_schedule WHERE proposal_id = $1 AND keep_until > now()`
+)
+
+// fetchDeletionBlock reports whether a hold covers the record and, if a
+// policy still keeps it, until when.
+func fetchDeletionBlock(holdQuery, keepQuery, id string, ctx context.Context) (bool, *time.Time, error) {
+	var held bool
+	if err := config.PostgresDB.QueryRowContext(ctx, holdQuery, id).Scan(&held); err != nil {
+		return false, nil, fmt.Errorf("error checking legal holds: %w", err)
+	}
+	if held {
+		return true, nil, nil
+	}
+	var until sql.NullTime
+	if err := config.PostgresDB.QueryRowContext(ctx, keepQuery, id).Scan(&until); err != nil {
+		return false, nil, fmt.Errorf("error checking retention: %w", err)
+	}
+	if !until.Valid {
+		return false, nil, nil
+	}
+	return false, &until.Time, nil
+}
@@ -0,0 +1,213 @@
+package workspaces
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"granth/internal/audit"
+	"granth/internal/utils"
+)
+
+const maxTeamNameLength = 100
+
+func normalizeTeamName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxTeamNameLength {
+		return "", fmt.Errorf("team name must be 1 to %d characters", maxTeamNameLength)
+	}
+	return name, nil
+}
+
+// requireTeamInWorkspace returns the team if it belongs to workspaceID.
+func requireTeamInWorkspace(workspaceID, teamID string, ctx context.Context) (*Team, error) {
+	t, err := fetchTeam(teamID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil || t.WorkspaceID != workspaceID {
+		return nil, fmt.Errorf("team not found")
+	}
+	return t, nil
+}
+
+// requireTeamManager allows members with manage_members, and the team's
+// own leads, to change who is on a team.
+func requireTeamManager(workspaceID, teamID string, ctx context.Context) (*WorkspaceMember, error) {
+	member, err := requirePermission(workspaceID, PermManageMembers, ctx)
+	if err == nil || !errors.Is(err, ErrPermissionDenied) {
+		return member, err
+	}
+	userID, _ := utils.GetUserIDFromContext(ctx)
+	lead, leadErr := isTeamLead(teamID, userID, ctx)
+	if leadErr != nil {
+		return nil, leadErr
+	}
+	if !lead {
+		return nil, err
+	}
+	return fetchMember(workspaceID, userID, ctx)
+}
+
+func listTeams(workspaceID string, ctx context.Context) ([]*Team, error) {
+	if _, err := requirePermission(workspaceID, PermRead, ctx); err != nil {
+		return nil, err
+	}
+	return fetchTeamsForWorkspace(workspaceID, ctx)
+}
+
+func getTeam(workspaceID, teamID string, ctx context.Context) (*Team, error) {
+	if _, err := requirePermission(workspaceID, PermRead, ctx); err != nil {
+		return nil, err
+	}
+	return requireTeamInWorkspace(workspaceID, teamID, ctx)
+}
+
+func createTeam(workspaceID, name, description string, ctx context.Context) (*Team, error) {
+	member, err := requirePermission(workspaceID, PermManageMembers, ctx)
+	if err != nil {
+		return nil, err
+	}
+	name, err = normalizeTeamName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	t := &Team{
+		WorkspaceID: workspaceID,
+		Name:        name,
+		Description: strings.TrimSpace(description),
+		Members:     []*TeamMember{},
+		CreatedBy:   &member.UserID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := insertTeam(t, ctx); err != nil {
+		return nil, err
+	}
+	audit.Record(audit.Entry{WorkspaceID: workspaceID, Action: "team.created", TargetType: "team", TargetID: t.ID, After: t}, ctx)
+	return t, nil
+}
+
+func updateTeam(workspaceID, teamID, name, description string, ctx context.Context) (*Team, error) {
+	if _, err := requireTeamManager(workspaceID, teamID, ctx); err != nil {
+		return nil, err
+	}
+	t, err := requireTeamInWorkspace(workspaceID, teamID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	name, err = normalizeTeamName(name)
+	if err != nil {
+		return nil, err
+	}
+	taken, err := teamNameTaken(workspaceID, name, teamID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, fmt.Errorf("a team with this name already exists")
+	}
+
+	before := map[string]string{"name": t.Name, "description": t.Description}
+	t.Name = name
+	t.Description = strings.TrimSpace(description)
+	t.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	if err := updateTeamRow(t, ctx); err != nil {
+		return nil, err
+	}
+	audit.Record(audit.Entry{
+		WorkspaceID: workspaceID, Action: "team.updated", TargetType: "team", TargetID: teamID,
+		Before: before, After: map[string]string{"name": t.Name, "description": t.Description},
+	}, ctx)
+	return t, nil
+}
+
+func deleteTeam(workspaceID, teamID string, ctx context.Context) error {
+	if _, err := requirePermission(workspaceID, PermManageMembers, ctx); err != nil {
+		return err
+	}
+	t, err := requireTeamInWorkspace(workspaceID, teamID, ctx)
+	if err != nil {
+		return err
+	}
+	if err := deleteTeamRow(teamID, ctx); err != nil {
+		return err
+	}
+	audit.Record(audit.Entry{WorkspaceID: workspaceID, Action: "team.deleted", TargetType: "team", TargetID: teamID, Before: t}, ctx)
+	return nil
+}
+
+// setTeamMember adds a workspace member to the team, or changes whether
+// they lead it.
+func setTeamMember(workspaceID, teamID, userID string, isLead bool, ctx context.Context) (*Team, error) {
+	caller, err := requireTeamManager(workspaceID, teamID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := requireTeamInWorkspace(workspaceID, teamID, ctx); err != nil {
+		return nil, err
+	}
+	target, err := fetchMember(workspaceID, userID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, fmt.Errorf("user is not a member of this workspace")
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	if err := upsertTeamMember(teamID, userID, isLead, caller.UserID, now, ctx); err != nil {
+		return nil, err
+	}
+	audit.Record(audit.Entry{
+		WorkspaceID: workspaceID, Action: "team.member_set", TargetType: "team", TargetID: teamID,
+		After: map[string]interface{}{"user_id": userID, "is_lead": isLead},
+	}, ctx)
+	return fetchTeam(teamID, ctx)
+}
+
+func removeTeamMember(workspaceID, teamID, userID string, ctx context.Context) error {
+	if _, err := requireTeamManager(workspaceID, teamID, ctx); err != nil {
+		return err
+	}
+	if _, err := requireTeamInWorkspace(workspaceID, teamID, ctx); err != nil {
+		return err
+	}
+	removed, err := deleteTeamMember(teamID, userID, ctx)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return fmt.Errorf("user is not on this team")
+	}
+	audit.Record(audit.Entry{
+		WorkspaceID: workspaceID, Action: "team.member_removed", TargetType: "team", TargetID: teamID,
+		Before: map[string]string{"user_id": userID},
+	}, ctx)
+	return nil
+}
+
+// GetTeam returns a team with its members, or nil if it does not exist.
+// Callers check WorkspaceID against the workspace they are acting in.
+func GetTeam(teamID string, ctx context.Context) (*Team, error) {
+	return fetchTeam(teamID, ctx)
+}
+
+// MemberHasPermission reports whether userID's role in the workspace
+// grants perm. Use it to vet people other than the requesting user, such
+// as a requested reviewer.
+func MemberHasPermission(workspaceID, userID string, perm Permission, ctx context.Context) (bool, error) {
+	member, err := fetchMember(workspaceID, userID, ctx)
+	if err != nil {
+		return false, err
+	}
+	permissions, err := memberPermissions(workspaceID, member, ctx)
+	if err != nil {
+		return false, err
+	}
+	return hasPermission(permissions, perm), nil
+}
@@ -0,0 +1,60 @@
+package workspaces
+
+import (
+	"errors"
+	"testing"
+
+	"granth/internal/testdb"
+)
+
+func TestTeamLeadsManageOnlyTheirTeam(t *testing.T) {
+	testdb.Open(t)
+	owner := testdb.CreateUser(t)
+	lead := testdb.CreateUser(t)
+	member := testdb.CreateUser(t)
+	outsider := testdb.CreateUser(t)
+	workspaceID := testdb.CreateWorkspace(t, owner)
+	otherWorkspaceID := testdb.CreateWorkspace(t, owner)
+	testdb.AddMember(t, workspaceID, lead, string(RoleContributor))
+	testdb.AddMember(t, workspaceID, member, string(RoleContributor))
+
+	led, err := createTeam(workspaceID, "legal", "", testdb.As(owner))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := createTeam(workspaceID, "finance", "", testdb.As(owner))
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreign, err := createTeam(otherWorkspaceID, "legal", "", testdb.As(owner))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := setTeamMember(workspaceID, led.ID, member, false, testdb.As(lead)); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("contributor before leading: got %v, want permission denied", err)
+	}
+	if _, err := setTeamMember(workspaceID, led.ID, lead, true, testdb.As(owner)); err != nil {
+		t.Fatal(err)
+	}
+
+	team, err := setTeamMember(workspaceID, led.ID, member, false, testdb.As(lead))
+	if err != nil {
+		t.Fatalf("lead adding to own team: %v", err)
+	}
+	if len(team.Members) != 2 {
+		t.Fatalf("team has %d members, want 2", len(team.Members))
+	}
+	if _, err := setTeamMember(workspaceID, other.ID, member, false, testdb.As(lead)); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("lead adding to another team: got %v, want permission denied", err)
+	}
+	if _, err := setTeamMember(workspaceID, led.ID, outsider, false, testdb.As(lead)); err == nil || err.Error() != "user is not a member of this workspace" {
+		t.Fatalf("adding a non-member: got %v", err)
+	}
+	if _, err := setTeamMember(workspaceID, foreign.ID, member, false, testdb.As(owner)); err == nil || err.Error() != "team not found" {
+		t.Fatalf("using another workspace's team: got %v", err)
+	}
+	if err := deleteTeam(workspaceID, led.ID, testdb.As(lead)); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("lead deleting the team: got %v, want permission denied", err)
+	}
+}
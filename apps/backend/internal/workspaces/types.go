@@ -8,13 +8,57 @@ const (
 	RoleContributor WorkspaceRole = "contributor"
 )
 
+// Permission is one capability a role can grant inside a workspace.
+type Permission string
+
+const (
+	PermRead          Permission = "read"
+	PermPropose       Permission = "propose"
+	PermComment       Permission = "comment"
+	PermReview        Permission = "review"
+	PermAccept        Permission = "accept"
+	PermManageMembers Permission = "manage_members"
+	PermManagePolicy  Permission = "manage_policy"
+	PermExport        Permission = "export"
+)
+
+// Role is a named permission set. The built-in roles exist in every
+// workspace and cannot be changed; custom roles belong to one workspace.
+type Role struct {
+	ID          string       `json:"id,omitempty"`
+	WorkspaceID string       `json:"workspace_id,omitempty"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions"`
+	BuiltIn     bool         `json:"built_in"`
+	CreatedBy   *string      `json:"created_by,omitempty"`
+	CreatedAt   string       `json:"created_at,omitempty"`
+	UpdatedAt   string       `json:"updated_at,omitempty"`
+}
+
 type Workspace struct {
 	ID          string `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	OwnerID     string `json:"owner_id"`
-	CreatedAt   string `json:"created_at"`
-	UpdatedAt   string `json:"updated_at"`
+	// RequireTwoFactor blocks members with review, accept or management
+	// permissions from acting until they set up 2FA
+	RequireTwoFactor bool `json:"require_two_factor"`
+	// RequireSignature turns accept/reject into electronic signatures
+	RequireSignature  bool     `json:"require_signature"`
+	SignatureMeanings []string `json:"signature_meanings"`
+	// RequireStewardApproval keeps proposals touching stewarded blocks
+	// from being accepted until every steward has signed off
+	RequireStewardApproval bool   `json:"require_steward_approval"`
+	CreatedAt              string `json:"created_at"`
+	UpdatedAt              string `json:"updated_at"`
+}
+
+// SignaturePolicy is the electronic signature setting of a workspace. An
+// empty Meanings list allows any meaning.
+type SignaturePolicy struct {
+	Required bool     `json:"required"`
+	Meanings []string `json:"meanings"`
 }
 
 type WorkspaceMember struct {
@@ -26,3 +70,149 @@ type WorkspaceMember struct {
 	InvitedBy   *string `json:"invited_by,omitempty"`
 	JoinedAt    string  `json:"joined_at"`
 }
+
+// DomainRule auto-joins users who sign in through SSO with a verified email
+// at Domain.
+type DomainRule struct {
+	ID          string  `json:"id"`
+	WorkspaceID string  `json:"workspace_id"`
+	Domain      string  `json:"domain"`
+	Role        string  `json:"role"`
+	CreatedBy   *string `json:"created_by"`
+	CreatedAt   string  `json:"created_at"`
+}
+
+// Invitation invites one email address (Kind "email") or anyone holding a
+// shareable link (Kind "link") to join a workspace with Role.
+type Invitation struct {
+	ID            string  `json:"id"`
+	WorkspaceID   string  `json:"workspace_id"`
+	WorkspaceName string  `json:"workspace_name,omitempty"`
+	Kind          string  `json:"kind"`
+	Email         *string `json:"email,omitempty"`
+	Role          string  `json:"role"`
+	Prefix        string  `json:"prefix"`
+	MaxUses       *int    `json:"max_uses,omitempty"`
+	UseCount      int     `json:"use_count"`
+	Status        string  `json:"status"`
+	InvitedBy     *string `json:"invited_by"`
+	InviterName   *string `json:"inviter_name,omitempty"`
+	ExpiresAt     string  `json:"expires_at"`
+	CreatedAt     string  `json:"created_at"`
+}
+
+// CreatedInvitation carries the shareable URL of a new invite link. It is
+// only returned when the link is created.
+type CreatedInvitation struct {
+	Invitation
+	URL string `json:"url,omitempty"`
+}
+
+// Team is a named group of workspace members. It can stand in for a single
+// reviewer, so "the security team" can be asked to review rather than a
+// person. Leads may manage the team's membership.
+type Team struct {
+	ID          string        `json:"id"`
+	WorkspaceID string        `json:"workspace_id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Members     []*TeamMember `json:"members"`
+	CreatedBy   *string       `json:"created_by,omitempty"`
+	CreatedAt   string        `json:"created_at"`
+	UpdatedAt   string        `json:"updated_at"`
+}
+
+type TeamMember struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	IsLead   bool   `json:"is_lead"`
+	AddedAt  string `json:"added_at"`
+}
+
+// GuestGrant gives someone outside the workspace time-limited access to
+// one document, including its proposals, or to one proposal.
+type GuestGrant struct {
+	ID            string       `json:"id"`
+	WorkspaceID   string       `json:"workspace_id"`
+	WorkspaceName string       `json:"workspace_name,omitempty"`
+	UserID        string       `json:"user_id"`
+	Username      string       `json:"username"`
+	Email         string       `json:"email"`
+	DocumentID    *string      `json:"document_id,omitempty"`
+	ProposalID    *string      `json:"proposal_id,omitempty"`
+	TargetTitle   string       `json:"target_title"`
+	Permissions   []Permission `json:"permissions"`
+	Note          string       `json:"note"`
+	Status        string       `json:"status"`
+	GrantedBy     *string      `json:"granted_by"`
+	ExpiresAt     string       `json:"expires_at"`
+	RevokedAt     *string      `json:"revoked_at,omitempty"`
+	RevokedBy     *string      `json:"revoked_by,omitempty"`
+	CreatedAt     string       `json:"created_at"`
+}
+
+// GuestGrantEvent is one entry in a grant's audit trail.
+type GuestGrantEvent struct {
+	ID         string  `json:"id"`
+	GrantID    string  `json:"grant_id"`
+	ActorID    *string `json:"actor_id"`
+	ActorName  *string `json:"actor_name,omitempty"`
+	Action     string  `json:"action"`
+	Permission *string `json:"permission,omitempty"`
+	TargetID   *string `json:"target_id,omitempty"`
+	CreatedAt  string  `json:"created_at"`
+}
+
+// OwnershipTransfer offers ownership of a workspace to another member.
+// Ownership only changes once that member accepts.
+type OwnershipTransfer struct {
+	ID            string  `json:"id"`
+	WorkspaceID   string  `json:"workspace_id"`
+	WorkspaceName string  `json:"workspace_name,omitempty"`
+	FromUserID    *string `json:"from_user_id"`
+	FromUsername  *string `json:"from_username,omitempty"`
+	ToUserID      string  `json:"to_user_id"`
+	ToUsername    string  `json:"to_username"`
+	Status        string  `json:"status"`
+	CreatedAt     string  `json:"created_at"`
+	RespondedAt   *string `json:"responded_at,omitempty"`
+}
+
+// LeaveResult reports what was handed over when a member left.
+type LeaveResult struct {
+	SuccessorID         string `json:"successor_id"`
+	ReassignedProposals int64  `json:"reassigned_proposals"`
+	ReassignedStewards  int64  `json:"reassigned_stewards"`
+}
+
+// RetentionPolicy keeps one kind of record (Target) for KeepDays. With
+// Dispose set, the purge job deletes the record once the period is over.
+type RetentionPolicy struct {
+	ID          string  `json:"id"`
+	WorkspaceID string  `json:"workspace_id"`
+	Target      string  `json:"target"`
+	KeepDays    int     `json:"keep_days"`
+	Dispose     bool    `json:"dispose"`
+	UpdatedBy   *string `json:"updated_by"`
+	UpdatedAt   string  `json:"updated_at"`
+}
+
+// LegalHold stops anything in a document, or in the whole workspace when
+// DocumentID is nil, from being deleted until it is released.
+type LegalHold struct {
+	ID            string  `json:"id"`
+	WorkspaceID   string  `json:"workspace_id"`
+	DocumentID    *string `json:"document_id"`
+	DocumentTitle *string `json:"document_title,omitempty"`
+	Reason        string  `json:"reason"`
+	PlacedBy      *string `json:"placed_by"`
+	PlacedAt      string  `json:"placed_at"`
+	ReleasedBy    *string `json:"released_by,omitempty"`
+	ReleasedAt    *string `json:"released_at,omitempty"`
+}
+
+// RetentionSettings is everything the retention settings page shows.
+type RetentionSettings struct {
+	Policies []*RetentionPolicy `json:"policies"`
+	Holds    []*LegalHold       `json:"holds"`
+}
@@ -1,10 +1,24 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
 	"net/http"
+	"os"
+	"strings"
+	"time"
 
 	"granth/internal"
+	"granth/internal/ai"
+	"granth/internal/auth"
 	"granth/internal/config"
+	"granth/internal/conflicts"
+	"granth/internal/encryption"
+	"granth/internal/mail"
+	"granth/internal/proposals"
+	"granth/internal/retention"
+	"granth/internal/utils"
 
 	"github.com/joho/godotenv"
 )
@@ -25,13 +39,89 @@ func main() {
 	}
 	defer psqlDB.Close()
 
-	// perform any pending migrations
-	config.Logger.Println("Running database migrations...")
-	err = config.RunMigrations(psqlDB)
-	if err != nil {
-		config.Logger.Fatalf("Error running database migrations: %v", err)
+	// subcommands run before startup writes anything, so that read-only
+	// ones such as verify-chain leave the database as they found it
+	command := ""
+	if len(os.Args) > 1 {
+		command = os.Args[1]
 	}
-	config.Logger.Println("Successfully connected to the database")
+	switch command {
+	// `verify-chain [document-id...]` re-walks document history chains
+	// (all of them by default), rebuilding decision and signature
+	// manifests, and exits non-zero if any link is broken
+	case "verify-chain":
+		if err := config.CheckMigrations(psqlDB); err != nil {
+			config.Logger.Fatalf("Error checking database migrations: %v", err)
+		}
+		initEncryption(env)
+		if !verifyChains(os.Args[2:], context.Background()) {
+			os.Exit(1)
+		}
+		return
+	// `backfill-chain` chains decisions made before document history
+	// chains existed
+	case "backfill-chain":
+		runMigrations(psqlDB)
+		initEncryption(env)
+		n, err := proposals.BackfillChain(context.Background())
+		if err != nil {
+			config.Logger.Fatalf("Error backfilling document chains: %v", err)
+		}
+		config.Logger.Printf("Added %d earlier decision(s) to document chains", n)
+		return
+	// `rotate-signing-key [-revoke]` rotates the signing key and exits;
+	// -revoke also invalidates every token signed by earlier keys
+	case "rotate-signing-key":
+		runMigrations(psqlDB)
+		initKeyring(env)
+		revoke := len(os.Args) > 2 && os.Args[2] == "-revoke"
+		kid, err := utils.RotateSigningKey(revoke, context.Background())
+		if err != nil {
+			config.Logger.Fatalf("Error rotating signing key: %v", err)
+		}
+		config.Logger.Printf("New signing key %s is active (previous keys revoked: %t)", kid, revoke)
+		return
+	// `rotate-content-key [workspace-id...]` replaces the data keys of the
+	// given workspaces (all by default), re-encrypts their content and exits
+	case "rotate-content-key":
+		runMigrations(psqlDB)
+		initEncryption(env)
+		if _, err := encryption.RewrapDataKeys(context.Background()); err != nil {
+			config.Logger.Fatalf("Error rewrapping content keys: %v", err)
+		}
+		n, err := encryption.RotateDataKeys(os.Args[2:], context.Background())
+		if err != nil {
+			config.Logger.Fatalf("Error rotating content keys: %v", err)
+		}
+		config.Logger.Printf("Content keys rotated; re-encrypted %d value(s)", n)
+		return
+	case "":
+		// no subcommand: start the server
+	default:
+		config.Logger.Fatalf("Unknown command %q", command)
+	}
+
+	runMigrations(psqlDB)
+	initKeyring(env)
+	initEncryption(env)
+
+	// move data keys off previous master keys, and encrypt content written
+	// before encryption was turned on
+	if !encryption.Enabled() {
+		config.Logger.Println("CONTENT_MASTER_KEY not set; content is stored unencrypted")
+	} else {
+		if n, err := encryption.RewrapDataKeys(context.Background()); err != nil {
+			config.Logger.Fatalf("Error rewrapping content keys: %v", err)
+		} else if n > 0 {
+			config.Logger.Printf("Rewrapped %d data key(s) with the current master key", n)
+		}
+		if n, err := encryption.Reencrypt(context.Background()); err != nil {
+			config.Logger.Fatalf("Error encrypting existing content: %v", err)
+		} else if n > 0 {
+			config.Logger.Printf("Encrypted %d existing value(s)", n)
+		}
+	}
+	utils.StartKeyRotation(context.Background())
 
 	// initialize Redis
 	redisClient, err := config.InitRedisClient(env["REDIS_IP"], env["REDIS_PASSWORD"], 1)
@@ -41,6 +131,77 @@ func main() {
 	defer redisClient.Close()
 	config.Logger.Println("Successfully connected to Redis")
 
+	// initialize AI provider (optional)
+	if provider := ai.InitProvider(env["AI_BASE_URL"], env["AI_API_KEY"], env["AI_MODEL"]); provider != nil {
+		config.Logger.Println("AI provider configured with model " + provider.Model())
+	} else {
+		config.Logger.Println("AI provider not configured; AI features are disabled")
+	}
+
+	// initialize mailer; without SMTP_HOST mail is only logged (and written to MAIL_DIR)
+	mail.InitMailer(mail.Config{
+		Host:     env["SMTP_HOST"],
+		Port:     env["SMTP_PORT"],
+		Username: env["SMTP_USERNAME"],
+		Password: env["SMTP_PASSWORD"],
+		From:     env["MAIL_FROM"],
+		Dir:      env["MAIL_DIR"],
+	})
+	if env["SMTP_HOST"] == "" {
+		config.Logger.Println("SMTP not configured; outgoing mail will be logged")
+	}
+	// only proxies listed in TRUSTED_PROXIES may set the client address
+	// through X-Forwarded-For or X-Real-IP
+	if err := utils.SetTrustedProxies(env["TRUSTED_PROXIES"]); err != nil {
+		config.Logger.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	if v := env["APP_URL"]; v != "" {
+		config.AppURL = strings.TrimRight(v, "/")
+	}
+
+	// configure OpenID Connect providers for SSO (optional)
+	if v := env["OIDC_PROVIDERS"]; v != "" {
+		var providers []auth.OIDCProviderConfig
+		if err := json.Unmarshal([]byte(v), &providers); err != nil {
+			config.Logger.Fatalf("Invalid OIDC_PROVIDERS: %v", err)
+		}
+		apiURL := env["API_URL"]
+		if apiURL == "" {
+			apiURL = "http://localhost:" + env["SERVER_PORT"]
+		}
+		auth.InitOIDC(providers, apiURL)
+		config.Logger.Printf("SSO enabled with %d OIDC provider(s)", len(providers))
+	}
+
+	// start background conflict analysis of open proposals
+	analyzerInterval := 10 * time.Minute
+	if v := env["CONFLICT_ANALYZER_INTERVAL"]; v != "" {
+		analyzerInterval, err = time.ParseDuration(v)
+		if err != nil {
+			config.Logger.Fatalf("Invalid CONFLICT_ANALYZER_INTERVAL: %v", err)
+		}
+	}
+	conflicts.StartAnalyzer(analyzerInterval, context.Background())
+	config.Logger.Println("Conflict analyzer running every " + analyzerInterval.String())
+
+	// start the purge of records whose retention period is over, and of
+	// trash that can no longer be restored
+	if v := env["TRASH_RESTORE_WINDOW"]; v != "" {
+		retention.TrashWindow, err = time.ParseDuration(v)
+		if err != nil {
+			config.Logger.Fatalf("Invalid TRASH_RESTORE_WINDOW: %v", err)
+		}
+	}
+	purgeInterval := time.Hour
+	if v := env["RETENTION_PURGE_INTERVAL"]; v != "" {
+		purgeInterval, err = time.ParseDuration(v)
+		if err != nil {
+			config.Logger.Fatalf("Invalid RETENTION_PURGE_INTERVAL: %v", err)
+		}
+	}
+	retention.StartPurger(purgeInterval, context.Background())
+	config.Logger.Println("Retention purge running every " + purgeInterval.String())
+
 	// create router from api package
 	router := internal.BaseRouter()
 
@@ -51,3 +212,78 @@ func main() {
 	}
 	config.Logger.Println("Server stopped")
 }
+
+// runMigrations applies any pending database migrations.
+func runMigrations(db *sql.DB) {
+	config.Logger.Println("Running database migrations...")
+	if err := config.RunMigrations(db); err != nil {
+		config.Logger.Fatalf("Error running database migrations: %v", err)
+	}
+	config.Logger.Println("Successfully connected to the database")
+}
+
+// initKeyring loads the token signing keyring, creating or rotating keys
+// as needed.
+func initKeyring(env map[string]string) {
+	keyRotation := 30 * 24 * time.Hour
+	if v := env["JWT_KEY_ROTATION_INTERVAL"]; v != "" {
+		var err error
+		keyRotation, err = time.ParseDuration(v)
+		if err != nil {
+			config.Logger.Fatalf("Invalid JWT_KEY_ROTATION_INTERVAL: %v", err)
+		}
+	}
+	err := utils.InitKeyring(utils.KeyringConfig{
+		Algorithm:        env["JWT_ALGORITHM"],
+		RotationInterval: keyRotation,
+		LegacySecret:     env["JWT_SECRET"],
+	}, context.Background())
+	if err != nil {
+		config.Logger.Fatalf("Error loading JWT signing keys: %v", err)
+	}
+}
+
+// initEncryption loads the master key that wraps the per-workspace content
+// keys; without CONTENT_MASTER_KEY content is stored unencrypted.
+func initEncryption(env map[string]string) {
+	var previousMasterKeys []string
+	if v := env["CONTENT_MASTER_KEY_PREVIOUS"]; v != "" {
+		previousMasterKeys = strings.Split(v, ",")
+	}
+	err := encryption.Init(encryption.Config{
+		MasterKey:          env["CONTENT_MASTER_KEY"],
+		PreviousMasterKeys: previousMasterKeys,
+	})
+	if err != nil {
+		config.Logger.Fatalf("Error loading content encryption keys: %v", err)
+	}
+}
+
+// verifyChains verifies the chains of documentIDs, or of every document,
+// logging each result. It reports whether all of them are intact.
+func verifyChains(documentIDs []string, ctx context.Context) bool {
+	if len(documentIDs) == 0 {
+		var err error
+		if documentIDs, err = proposals.GetChainedDocumentIDs(ctx); err != nil {
+			config.Logger.Fatalf("Error listing document chains: %v", err)
+		}
+	}
+	intact := true
+	for _, id := range documentIDs {
+		result, err := proposals.VerifyChain(id, ctx)
+		if err != nil {
+			config.Logger.Fatalf("Error verifying chain of document %s: %v", id, err)
+		}
+		switch {
+		case result.Valid:
+			config.Logger.Printf("document %s: ok, %d entries, head %s", id, result.Entries, result.HeadHash)
+		case result.BrokenAt != nil:
+			intact = false
+			config.Logger.Printf("document %s: BROKEN at entry %d: %s", id, *result.BrokenAt, result.Reason)
+		default:
+			intact = false
+			config.Logger.Printf("document %s: BROKEN: %s", id, result.Reason)
+		}
+	}
+	return intact
+}
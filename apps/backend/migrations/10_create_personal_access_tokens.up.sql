@@ -0,0 +1,16 @@
+-- personal_access_tokens: user-issued scoped tokens for scripts and CI.
+-- Only the SHA-256 of the token is stored; prefix is used for lookup.
+CREATE TABLE personal_access_tokens (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id      UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name         VARCHAR(255) NOT NULL,
+    prefix       TEXT NOT NULL UNIQUE,
+    token_hash   TEXT NOT NULL,
+    scopes       TEXT[] NOT NULL CHECK (scopes <@ ARRAY['read', 'propose', 'review', 'admin']::TEXT[] AND cardinality(scopes) > 0),
+    expires_at   TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at   TIMESTAMP WITH TIME ZONE,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_personal_access_tokens_user_id ON personal_access_tokens(user_id);
@@ -0,0 +1,17 @@
+-- Email verification. Accounts that existed before verification was
+-- introduced are treated as verified.
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP WITH TIME ZONE;
+UPDATE users SET email_verified_at = COALESCE(created_at, now());
+
+-- auth_action_tokens: server-side record of signed single-use tokens
+-- (password reset, email verification). id is the token's jti.
+CREATE TABLE auth_action_tokens (
+    id         TEXT PRIMARY KEY,
+    user_id    UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose    TEXT NOT NULL CHECK (purpose IN ('password_reset', 'email_verification')),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at    TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_auth_action_tokens_user_id ON auth_action_tokens(user_id);
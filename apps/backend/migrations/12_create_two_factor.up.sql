@@ -0,0 +1,23 @@
+-- user_totp: one TOTP authenticator per user. enabled_at stays NULL until
+-- the user proves possession with a first code. last_used_step blocks
+-- replaying a code inside its validity window.
+CREATE TABLE user_totp (
+    user_id        UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret         TEXT NOT NULL,
+    enabled_at     TIMESTAMP WITH TIME ZONE,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+-- user_recovery_codes: single-use fallbacks for a lost authenticator.
+CREATE TABLE user_recovery_codes (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash  TEXT NOT NULL,
+    used_at    TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    UNIQUE(user_id, code_hash)
+);
+
+-- Workspace policy: admins and reviewers must have 2FA enabled to act
+ALTER TABLE workspaces ADD COLUMN require_two_factor BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,27 @@
+-- user_identities: links an external OIDC subject to a local user.
+CREATE TABLE user_identities (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider   TEXT NOT NULL,
+    subject    TEXT NOT NULL,
+    email      VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    last_login_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE(provider, subject)
+);
+
+CREATE INDEX idx_user_identities_user_id ON user_identities(user_id);
+
+-- workspace_domain_rules: users signing in through SSO with a verified
+-- email at domain join the workspace with the given role.
+CREATE TABLE workspace_domain_rules (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    domain       TEXT NOT NULL,
+    role         TEXT NOT NULL CHECK (role IN ('reviewer', 'contributor')),
+    created_by   UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    UNIQUE(workspace_id, domain)
+);
+
+CREATE INDEX idx_workspace_domain_rules_domain ON workspace_domain_rules(domain);
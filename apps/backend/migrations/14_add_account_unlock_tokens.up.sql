@@ -0,0 +1,4 @@
+-- Account unlock links sent after a login lockout reuse auth_action_tokens.
+ALTER TABLE auth_action_tokens DROP CONSTRAINT IF EXISTS auth_action_tokens_purpose_check;
+ALTER TABLE auth_action_tokens ADD CONSTRAINT auth_action_tokens_purpose_check
+    CHECK (purpose IN ('password_reset', 'email_verification', 'account_unlock'));
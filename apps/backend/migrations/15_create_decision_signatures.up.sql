@@ -0,0 +1,33 @@
+-- Electronic signature mode: reviewers re-authenticate and state what their
+-- signature means when accepting or rejecting. An empty meanings list
+-- accepts any non-empty meaning.
+ALTER TABLE workspaces ADD COLUMN require_signature BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE workspaces ADD COLUMN signature_meanings TEXT[] NOT NULL DEFAULT '{}';
+
+-- decision_signatures: the signature event attached to a decision.
+-- manifest_hash is the SHA-256 of what was signed (proposal, outcome,
+-- reason and every block change), so later edits to the record show.
+CREATE TABLE decision_signatures (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    decision_id   UUID NOT NULL UNIQUE REFERENCES proposal_decisions(id),
+    proposal_id   UUID NOT NULL,
+    signer_id     UUID NOT NULL,
+    signer_name   TEXT NOT NULL,
+    meaning       TEXT NOT NULL,
+    method        TEXT NOT NULL CHECK (method IN ('password', 'password+totp', 'totp')),
+    manifest_hash TEXT NOT NULL,
+    signed_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_decision_signatures_proposal_id ON decision_signatures(proposal_id);
+
+-- Signatures are append-only.
+CREATE FUNCTION decision_signatures_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'decision signatures are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER decision_signatures_no_update
+    BEFORE UPDATE OR DELETE ON decision_signatures
+    FOR EACH ROW EXECUTE FUNCTION decision_signatures_immutable();
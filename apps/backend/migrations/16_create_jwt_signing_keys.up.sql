@@ -0,0 +1,15 @@
+-- jwt_signing_keys: the token signing keyring shared by every API
+-- instance. The newest key without retired_at signs; retired keys keep
+-- verifying until tokens signed with them have expired. Revoked keys stop
+-- verifying immediately.
+CREATE TABLE jwt_signing_keys (
+    id          TEXT PRIMARY KEY,
+    algorithm   TEXT NOT NULL CHECK (algorithm IN ('EdDSA', 'RS256')),
+    private_key TEXT NOT NULL,
+    public_key  TEXT NOT NULL,
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    retired_at  TIMESTAMP WITH TIME ZONE,
+    revoked_at  TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_jwt_signing_keys_created_at ON jwt_signing_keys(created_at);
@@ -0,0 +1,24 @@
+-- workspace_invitations: email invitations addressed to one person, and
+-- shareable links anyone signed in can use until they expire, run out of
+-- uses or are revoked. Only a hash of the invitation token is stored.
+CREATE TABLE workspace_invitations (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    kind         TEXT NOT NULL CHECK (kind IN ('email', 'link')),
+    email        TEXT,
+    role         TEXT NOT NULL CHECK (role IN ('admin', 'reviewer', 'contributor')),
+    prefix       TEXT NOT NULL UNIQUE,
+    token_hash   TEXT NOT NULL,
+    max_uses     INTEGER,
+    use_count    INTEGER NOT NULL DEFAULT 0,
+    status       TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined', 'revoked')),
+    invited_by   UUID REFERENCES users(id) ON DELETE SET NULL,
+    responded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    expires_at   TIMESTAMP WITH TIME ZONE NOT NULL,
+    responded_at TIMESTAMP WITH TIME ZONE,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    CHECK ((kind = 'email') = (email IS NOT NULL))
+);
+
+CREATE INDEX idx_workspace_invitations_workspace_id ON workspace_invitations(workspace_id);
+CREATE INDEX idx_workspace_invitations_email ON workspace_invitations(lower(email)) WHERE status = 'pending';
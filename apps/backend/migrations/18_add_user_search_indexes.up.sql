@@ -0,0 +1,3 @@
+-- prefix lookups for the user directory search
+CREATE INDEX idx_users_username_lower ON users (LOWER(username) text_pattern_ops);
+CREATE INDEX idx_users_email_lower ON users (LOWER(email) text_pattern_ops);
@@ -0,0 +1,21 @@
+-- workspace_roles: roles a workspace defines on top of the built-in
+-- admin, reviewer and contributor. Members, invitations and domain rules
+-- reference a role by name, so the fixed role checks are dropped.
+CREATE TABLE workspace_roles (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    name         TEXT NOT NULL CHECK (name NOT IN ('admin', 'reviewer', 'contributor')),
+    description  TEXT NOT NULL DEFAULT '',
+    permissions  TEXT[] NOT NULL CHECK (permissions <@ ARRAY[
+        'read', 'propose', 'comment', 'review', 'accept',
+        'manage_members', 'manage_policy', 'export'
+    ]::TEXT[]),
+    created_by   UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    UNIQUE(workspace_id, name)
+);
+
+ALTER TABLE workspace_members DROP CONSTRAINT workspace_members_role_check;
+ALTER TABLE workspace_invitations DROP CONSTRAINT workspace_invitations_role_check;
+ALTER TABLE workspace_domain_rules DROP CONSTRAINT workspace_domain_rules_role_check;
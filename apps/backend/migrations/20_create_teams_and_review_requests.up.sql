@@ -0,0 +1,40 @@
+-- teams: named groups of workspace members, referenced wherever a single
+-- reviewer could be. Leads can manage their team's membership.
+CREATE TABLE teams (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    name         TEXT NOT NULL,
+    description  TEXT NOT NULL DEFAULT '',
+    created_by   UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    UNIQUE(workspace_id, name)
+);
+
+CREATE TABLE team_members (
+    team_id  UUID NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    user_id  UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    is_lead  BOOLEAN NOT NULL DEFAULT false,
+    added_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    added_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    PRIMARY KEY (team_id, user_id)
+);
+
+CREATE INDEX idx_team_members_user_id ON team_members(user_id);
+
+-- proposal_review_requests: asks a user or a whole team to review a
+-- proposal. A team request is satisfied by any of its members.
+CREATE TABLE proposal_review_requests (
+    id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    proposal_id      UUID NOT NULL REFERENCES proposals(id) ON DELETE CASCADE,
+    reviewer_user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    reviewer_team_id UUID REFERENCES teams(id) ON DELETE CASCADE,
+    requested_by     UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at       TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    CHECK ((reviewer_user_id IS NULL) <> (reviewer_team_id IS NULL))
+);
+
+CREATE UNIQUE INDEX idx_review_requests_user ON proposal_review_requests(proposal_id, reviewer_user_id)
+    WHERE reviewer_user_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_review_requests_team ON proposal_review_requests(proposal_id, reviewer_team_id)
+    WHERE reviewer_team_id IS NOT NULL;
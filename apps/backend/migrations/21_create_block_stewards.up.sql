@@ -0,0 +1,26 @@
+-- Stewards own parts of a document the way CODEOWNERS owns paths. A rule
+-- covers the whole document, one block, or every block whose order_path
+-- starts with path_prefix (e.g. {3} for everything under section 3).
+CREATE TABLE block_stewards (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    document_id     UUID NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    block_id        UUID REFERENCES blocks(id) ON DELETE CASCADE,
+    path_prefix     INT[],
+    steward_user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    steward_team_id UUID REFERENCES teams(id) ON DELETE CASCADE,
+    created_by      UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    CHECK ((steward_user_id IS NULL) <> (steward_team_id IS NULL)),
+    CHECK (block_id IS NULL OR path_prefix IS NULL),
+    CHECK (path_prefix IS NULL OR cardinality(path_prefix) > 0)
+);
+
+CREATE INDEX idx_block_stewards_document ON block_stewards(document_id);
+
+-- Steward requests are created automatically and record the sign-off
+ALTER TABLE proposal_review_requests
+    ADD COLUMN steward     BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN approved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN approved_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE workspaces ADD COLUMN require_steward_approval BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,40 @@
+-- guest_grants: time-limited access for someone outside the workspace to
+-- one document (and its proposals) or one proposal. Guests can only read,
+-- comment and answer review requests addressed to them.
+CREATE TABLE guest_grants (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    user_id      UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    document_id  UUID REFERENCES documents(id) ON DELETE CASCADE,
+    proposal_id  UUID REFERENCES proposals(id) ON DELETE CASCADE,
+    permissions  TEXT[] NOT NULL CHECK (permissions <@ ARRAY['read', 'comment', 'review']::TEXT[]),
+    note         TEXT NOT NULL DEFAULT '',
+    granted_by   UUID REFERENCES users(id) ON DELETE SET NULL,
+    expires_at   TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at   TIMESTAMP WITH TIME ZONE,
+    revoked_by   UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    CHECK ((document_id IS NULL) <> (proposal_id IS NULL))
+);
+
+CREATE INDEX idx_guest_grants_user ON guest_grants(user_id);
+CREATE INDEX idx_guest_grants_workspace ON guest_grants(workspace_id);
+
+-- guest_grant_events: every grant, revocation and use of a grant
+CREATE TABLE guest_grant_events (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    grant_id   UUID NOT NULL REFERENCES guest_grants(id) ON DELETE CASCADE,
+    actor_id   UUID REFERENCES users(id) ON DELETE SET NULL,
+    action     TEXT NOT NULL CHECK (action IN ('granted', 'revoked', 'used')),
+    permission TEXT,
+    target_id  UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_guest_grant_events_grant ON guest_grant_events(grant_id, created_at);
+
+-- Reviewers can now decline a review request as well as approve it
+ALTER TABLE proposal_review_requests
+    ADD COLUMN declined_by    UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN declined_at    TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN decline_reason TEXT;
@@ -0,0 +1,15 @@
+-- workspace_ownership_transfers: the owner offers the workspace to another
+-- member, who has to accept before workspaces.owner_id changes. A
+-- workspace has at most one pending offer.
+CREATE TABLE workspace_ownership_transfers (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    from_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    to_user_id   UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status       TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined', 'cancelled')),
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    responded_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX idx_ownership_transfers_pending ON workspace_ownership_transfers(workspace_id)
+    WHERE status = 'pending';
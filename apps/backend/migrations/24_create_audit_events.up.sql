@@ -0,0 +1,32 @@
+-- audit_events: who changed what, from where. Rows are never updated or
+-- deleted. workspace_id, document_id and actor_id have no foreign keys so
+-- events outlive what they describe.
+CREATE TABLE audit_events (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id UUID,
+    document_id  UUID,
+    actor_id     UUID,
+    actor_type   TEXT NOT NULL CHECK (actor_type IN ('user', 'agent', 'system')),
+    action       TEXT NOT NULL,
+    target_type  TEXT NOT NULL,
+    target_id    TEXT,
+    before       JSONB,
+    after        JSONB,
+    ip           TEXT,
+    request_id   TEXT,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_audit_events_workspace ON audit_events(workspace_id, created_at DESC);
+CREATE INDEX idx_audit_events_actor ON audit_events(actor_id, created_at DESC);
+
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_no_update BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+CREATE TRIGGER audit_events_no_truncate BEFORE TRUNCATE ON audit_events
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
@@ -0,0 +1,55 @@
+-- Tamper-evident history. Every decision on a proposal is appended to its
+-- document's chain: content_hash is the SHA-256 of the entry's manifest
+-- (the decision and its block changes) and entry_hash is the SHA-256 of
+-- prev_hash || content_hash. Removing a decided proposal through the app
+-- appends a 'removal' entry, so only out-of-band edits break the chain.
+CREATE TABLE document_chain (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    document_id  UUID NOT NULL,
+    seq          BIGINT NOT NULL,
+    kind         TEXT NOT NULL CHECK (kind IN ('decision', 'removal')),
+    proposal_id  UUID NOT NULL,
+    decision_id  UUID NOT NULL,
+    manifest     TEXT NOT NULL, -- text, not JSONB, so its bytes hash the same
+    content_hash TEXT NOT NULL,
+    prev_hash    TEXT NOT NULL,
+    entry_hash   TEXT NOT NULL,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    UNIQUE (document_id, seq)
+);
+
+CREATE INDEX idx_document_chain_decision ON document_chain(decision_id);
+
+-- content_hash of each change is fixed when the proposal is decided, so a
+-- change's content can later be checked on its own.
+ALTER TABLE proposal_block_changes ADD COLUMN content_hash TEXT;
+
+-- Signed checkpoints pin the head of a chain. The public key is kept with
+-- the checkpoint because signing keys are rotated away.
+CREATE TABLE document_chain_checkpoints (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    document_id UUID NOT NULL,
+    seq         BIGINT NOT NULL,
+    head_hash   TEXT NOT NULL,
+    token       TEXT NOT NULL,
+    public_key  JSONB NOT NULL,
+    created_by  UUID,
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_document_chain_checkpoints_document ON document_chain_checkpoints(document_id, created_at DESC);
+
+CREATE FUNCTION document_chain_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER document_chain_no_update BEFORE UPDATE OR DELETE ON document_chain
+    FOR EACH ROW EXECUTE FUNCTION document_chain_append_only();
+CREATE TRIGGER document_chain_no_truncate BEFORE TRUNCATE ON document_chain
+    FOR EACH STATEMENT EXECUTE FUNCTION document_chain_append_only();
+CREATE TRIGGER document_chain_checkpoints_no_update BEFORE UPDATE OR DELETE ON document_chain_checkpoints
+    FOR EACH ROW EXECUTE FUNCTION document_chain_append_only();
+CREATE TRIGGER document_chain_checkpoints_no_truncate BEFORE TRUNCATE ON document_chain_checkpoints
+    FOR EACH STATEMENT EXECUTE FUNCTION document_chain_append_only();
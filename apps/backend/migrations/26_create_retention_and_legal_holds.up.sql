@@ -0,0 +1,45 @@
+-- retention_policies: how long a workspace keeps one kind of record. A
+-- record cannot be deleted until keep_days have passed since it last
+-- changed (or was decided); with dispose, the purge job deletes it then.
+CREATE TABLE retention_policies (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    target       TEXT NOT NULL CHECK (target IN ('documents', 'open_proposals', 'accepted_proposals', 'rejected_proposals')),
+    keep_days    INT NOT NULL CHECK (keep_days > 0),
+    dispose      BOOLEAN NOT NULL DEFAULT false,
+    updated_by   UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    UNIQUE (workspace_id, target)
+);
+
+-- legal_holds: while a hold is active nothing it covers can be deleted,
+-- by anyone or by the purge job. A hold without document_id covers the
+-- whole workspace. Released holds are kept as a record.
+CREATE TABLE legal_holds (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    document_id  UUID,
+    reason       TEXT NOT NULL,
+    placed_by    UUID REFERENCES users(id) ON DELETE SET NULL,
+    placed_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    released_by  UUID REFERENCES users(id) ON DELETE SET NULL,
+    released_at  TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_legal_holds_active ON legal_holds(workspace_id, document_id) WHERE released_at IS NULL;
+
+-- retention_schedule: every record a policy applies to, with the time it
+-- must be kept until. Proposals are measured from their decision, open
+-- proposals and documents from their last update.
+CREATE VIEW retention_schedule AS
+SELECT doc.workspace_id, doc.id AS document_id, NULL::uuid AS proposal_id, rp.dispose,
+       (doc.updated_at AT TIME ZONE 'UTC') + make_interval(days => rp.keep_days) AS keep_until
+FROM documents doc
+JOIN retention_policies rp ON rp.workspace_id = doc.workspace_id AND rp.target = 'documents'
+UNION ALL
+SELECT doc.workspace_id, p.document_id, p.id AS proposal_id, rp.dispose,
+       COALESCE(d.decided_at, p.updated_at) + make_interval(days => rp.keep_days) AS keep_until
+FROM proposals p
+JOIN documents doc ON doc.id = p.document_id
+LEFT JOIN proposal_decisions d ON d.proposal_id = p.id
+JOIN retention_policies rp ON rp.workspace_id = doc.workspace_id AND rp.target = p.state || '_proposals';
@@ -0,0 +1,15 @@
+-- Soft delete. Deleting a document or proposal moves it to its workspace's
+-- trash by setting deleted_at; it can be restored until the trash window
+-- ends and is only removed for good when purged. Trashing a document also
+-- trashes its live proposals with the same deleted_at, so restoring the
+-- document brings exactly those back.
+ALTER TABLE documents
+    ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN deleted_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE proposals
+    ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN deleted_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_documents_trash ON documents(workspace_id, deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_proposals_trash ON proposals(document_id, deleted_at) WHERE deleted_at IS NOT NULL;
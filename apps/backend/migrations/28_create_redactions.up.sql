@@ -0,0 +1,103 @@
+-- redactions: each time sensitive text was scrubbed from a document's
+-- blocks, block changes, AI summaries and audit payloads. The text itself is not kept;
+-- text_hash lets someone who knows it confirm what was removed. Rows are
+-- never updated or deleted and have no foreign keys, so the record
+-- outlives the document.
+CREATE TABLE redactions (
+    id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id       UUID,
+    document_id        UUID NOT NULL,
+    reason             TEXT NOT NULL,
+    text_hash          TEXT NOT NULL,
+    blocks_redacted    INT NOT NULL,
+    changes_redacted   INT NOT NULL,
+    artifacts_redacted INT NOT NULL,
+    events_redacted    INT NOT NULL,
+    redacted_by        UUID,
+    created_at         TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_redactions_document ON redactions(document_id, created_at DESC);
+
+CREATE TRIGGER redactions_no_update BEFORE UPDATE OR DELETE ON redactions
+    FOR EACH ROW EXECUTE FUNCTION document_chain_append_only();
+CREATE TRIGGER redactions_no_truncate BEFORE TRUNCATE ON redactions
+    FOR EACH STATEMENT EXECUTE FUNCTION document_chain_append_only();
+
+-- A redacted change keeps the content_hash it was decided with, so the
+-- history chain still verifies; redaction_id marks why its content no
+-- longer matches. The check is deferred so a redaction can mark changes
+-- before its own row, with the final counts, is inserted.
+ALTER TABLE proposal_block_changes ADD COLUMN redaction_id UUID REFERENCES redactions(id) DEFERRABLE INITIALLY DEFERRED;
+
+-- Audit payloads may be rewritten only by redact_audit_events, which runs
+-- as the granth_audit_redactor role and can do nothing but replace text of
+-- at least three characters with [REDACTED] in one document's events.
+-- Nothing logs in as that role and no other role is a member of it.
+-- Creating it needs a migrating role with CREATEROLE.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = current_user AND (rolsuper OR rolcreaterole)) THEN
+        RAISE EXCEPTION 'migration 28 creates the granth_audit_redactor role: run it as a role with CREATEROLE';
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'granth_audit_redactor') THEN
+        CREATE ROLE granth_audit_redactor NOLOGIN;
+    END IF;
+END
+$$;
+GRANT SELECT, UPDATE ON audit_events TO granth_audit_redactor;
+
+-- redact_jsonb replaces text in every string of a JSON value, keys included
+CREATE FUNCTION redact_jsonb(value JSONB, secret TEXT) RETURNS JSONB AS $$
+BEGIN
+    CASE jsonb_typeof(value)
+    WHEN 'string' THEN
+        RETURN to_jsonb(replace(value #>> '{}', secret, '[REDACTED]'));
+    WHEN 'array' THEN
+        RETURN COALESCE((SELECT jsonb_agg(redact_jsonb(e, secret) ORDER BY n)
+                         FROM jsonb_array_elements(value) WITH ORDINALITY AS a(e, n)), '[]'::jsonb);
+    WHEN 'object' THEN
+        RETURN COALESCE((SELECT jsonb_object_agg(replace(k, secret, '[REDACTED]'), redact_jsonb(v, secret))
+                         FROM jsonb_each(value) AS o(k, v)), '{}'::jsonb);
+    ELSE
+        RETURN value;
+    END CASE;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+CREATE FUNCTION redact_audit_events(target_document UUID, secret TEXT) RETURNS INT
+SECURITY DEFINER SET search_path = public, pg_temp AS $$
+DECLARE
+    n INT;
+BEGIN
+    IF length(secret) < 3 THEN
+        RAISE EXCEPTION 'redacted text must be at least 3 characters';
+    END IF;
+    UPDATE audit_events
+    SET before = redact_jsonb(before, secret), after = redact_jsonb(after, secret)
+    WHERE document_id = target_document
+      AND (before IS DISTINCT FROM redact_jsonb(before, secret) OR after IS DISTINCT FROM redact_jsonb(after, secret));
+    GET DIAGNOSTICS n = ROW_COUNT;
+    RETURN n;
+END;
+$$ LANGUAGE plpgsql;
+
+-- ownership can only move to a role the migrating role may SET ROLE to, so
+-- membership is granted just long enough
+GRANT granth_audit_redactor TO CURRENT_USER;
+ALTER FUNCTION redact_audit_events(UUID, TEXT) OWNER TO granth_audit_redactor;
+REVOKE granth_audit_redactor FROM CURRENT_USER;
+
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND current_user = 'granth_audit_redactor'
+       AND (NEW.id, NEW.workspace_id, NEW.document_id, NEW.actor_id, NEW.actor_type, NEW.action,
+            NEW.target_type, NEW.target_id, NEW.ip, NEW.request_id, NEW.created_at)
+           IS NOT DISTINCT FROM
+           (OLD.id, OLD.workspace_id, OLD.document_id, OLD.actor_id, OLD.actor_type, OLD.action,
+            OLD.target_type, OLD.target_id, OLD.ip, OLD.request_id, OLD.created_at) THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
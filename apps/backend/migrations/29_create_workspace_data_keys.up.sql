@@ -0,0 +1,19 @@
+-- workspace_data_keys: the keys block content, block change content and
+-- comment bodies are encrypted with, one active key per workspace (and one
+-- for documents outside any workspace, with workspace_id NULL). Each key is
+-- stored wrapped by the master key from config, identified by
+-- master_key_id. Retired keys are kept so older values can still be read;
+-- deleting a workspace deletes its keys and with them its content.
+CREATE TABLE workspace_data_keys (
+    id            UUID PRIMARY KEY,
+    workspace_id  UUID REFERENCES workspaces(id) ON DELETE CASCADE,
+    wrapped_key   TEXT NOT NULL,
+    master_key_id TEXT NOT NULL,
+    created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    retired_at    TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX idx_workspace_data_keys_active
+    ON workspace_data_keys ((COALESCE(workspace_id, '00000000-0000-0000-0000-000000000000'::uuid)))
+    WHERE retired_at IS NULL;
+CREATE INDEX idx_workspace_data_keys_master ON workspace_data_keys(master_key_id);
@@ -0,0 +1,36 @@
+-- Block, change and comment content is encrypted at rest, so audit payloads
+-- record a SHA-256 of it instead. This rewrites the payloads recorded
+-- before: "content" becomes "content_hash" and a comment's "body" becomes
+-- "body_hash". Reasoning artifacts are encrypted by the server's
+-- re-encryption pass at startup, like the other sealed columns.
+CREATE FUNCTION pg_temp.hash_field(payload JSONB, field TEXT, hash_field TEXT) RETURNS JSONB AS $$
+    SELECT CASE WHEN jsonb_typeof(payload) = 'object' AND payload ? field
+        THEN (payload - field) || jsonb_build_object(hash_field,
+             encode(sha256(convert_to(COALESCE(payload->>field, ''), 'UTF8')), 'hex'))
+        ELSE payload END
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE FUNCTION pg_temp.hash_changes(payload JSONB) RETURNS JSONB AS $$
+    SELECT CASE WHEN jsonb_typeof(payload->'changes') = 'array'
+        THEN jsonb_set(payload, '{changes}', COALESCE(
+             (SELECT jsonb_agg(pg_temp.hash_field(c, 'content', 'content_hash') ORDER BY n)
+              FROM jsonb_array_elements(payload->'changes') WITH ORDINALITY AS t(c, n)), '[]'::jsonb))
+        ELSE payload END
+$$ LANGUAGE sql IMMUTABLE;
+
+ALTER TABLE audit_events DISABLE TRIGGER audit_events_no_update;
+
+UPDATE audit_events
+SET before = pg_temp.hash_field(before, 'content', 'content_hash'),
+    after  = pg_temp.hash_field(after, 'content', 'content_hash')
+WHERE action IN ('block.created', 'block.updated', 'block.deleted', 'proposal.change_added');
+
+UPDATE audit_events
+SET after = pg_temp.hash_changes(after)
+WHERE action IN ('proposal.created', 'proposal.accepted');
+
+UPDATE audit_events
+SET after = pg_temp.hash_field(after, 'body', 'body_hash')
+WHERE action = 'comment.created';
+
+ALTER TABLE audit_events ENABLE TRIGGER audit_events_no_update;
@@ -0,0 +1,7 @@
+-- A redaction that rewrites decided block changes moves their content_hash
+-- to the redacted content and appends a 'redaction' entry to the chain
+-- with each change's old and new hash, so every change is still checked
+-- against its content and the decision entry stays as it was.
+ALTER TABLE document_chain DROP CONSTRAINT document_chain_kind_check;
+ALTER TABLE document_chain
+ADD CONSTRAINT document_chain_kind_check CHECK (kind IN ('decision', 'removal', 'redaction'));
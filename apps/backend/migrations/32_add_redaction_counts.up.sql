@@ -0,0 +1,6 @@
+-- redactions also cover discussion comments, proposal text and conflict
+-- findings
+ALTER TABLE redactions
+    ADD COLUMN comments_redacted  INT NOT NULL DEFAULT 0,
+    ADD COLUMN proposals_redacted INT NOT NULL DEFAULT 0,
+    ADD COLUMN findings_redacted  INT NOT NULL DEFAULT 0;
@@ -0,0 +1,10 @@
+-- jwt_keyring_state: when this database first had a signing key. Tokens
+-- signed with the legacy JWT_SECRET are only accepted if they were issued
+-- before then, and only until every such token has expired.
+CREATE TABLE jwt_keyring_state (
+    id         BOOLEAN PRIMARY KEY DEFAULT true CHECK (id),
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+INSERT INTO jwt_keyring_state (started_at)
+SELECT COALESCE(MIN(created_at), now()) FROM jwt_signing_keys;
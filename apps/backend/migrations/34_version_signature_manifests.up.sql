@@ -0,0 +1,5 @@
+-- Signatures record the layout their manifest_hash was computed with.
+-- Existing ones hashed the API form of the changes and are version 0;
+-- they stay pinned by the decision chain but cannot be rebuilt.
+ALTER TABLE decision_signatures ADD COLUMN manifest_version INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE decision_signatures ALTER COLUMN manifest_version DROP DEFAULT;
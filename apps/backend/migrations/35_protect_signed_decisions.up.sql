@@ -0,0 +1,17 @@
+-- decision_signatures.decision_id has no ON DELETE action and signatures
+-- are immutable, so deleting a signed decision, directly or by cascade from
+-- its proposal, document or workspace, used to fail on the foreign key.
+-- Signed decisions are kept: the delete now fails up front with a message
+-- saying why.
+CREATE FUNCTION proposal_decisions_keep_signed() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM decision_signatures WHERE decision_id = OLD.id) THEN
+        RAISE EXCEPTION 'signed decisions cannot be deleted';
+    END IF;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER proposal_decisions_keep_signed
+    BEFORE DELETE ON proposal_decisions
+    FOR EACH ROW EXECUTE FUNCTION proposal_decisions_keep_signed();
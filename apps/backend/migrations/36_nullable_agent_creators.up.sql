@@ -0,0 +1,12 @@
+-- Deleting a user who created a service account or an API key failed on
+-- these foreign keys. The account and its keys outlive their creator, like
+-- roles and teams do.
+ALTER TABLE service_accounts ALTER COLUMN created_by DROP NOT NULL;
+ALTER TABLE service_accounts DROP CONSTRAINT service_accounts_created_by_fkey;
+ALTER TABLE service_accounts ADD CONSTRAINT service_accounts_created_by_fkey
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE service_account_keys ALTER COLUMN created_by DROP NOT NULL;
+ALTER TABLE service_account_keys DROP CONSTRAINT service_account_keys_created_by_fkey;
+ALTER TABLE service_account_keys ADD CONSTRAINT service_account_keys_created_by_fkey
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL;
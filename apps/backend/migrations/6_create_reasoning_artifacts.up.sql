@@ -0,0 +1,26 @@
+-- reasoning_artifacts: machine-generated reasoning attached to proposals.
+-- Never written into blocks; every row is attributed to a model and prompt.
+CREATE TABLE reasoning_artifacts (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    proposal_id    UUID NOT NULL REFERENCES proposals(id) ON DELETE CASCADE,
+    kind           TEXT NOT NULL CHECK (kind IN ('summary')),
+    version        INT NOT NULL,
+    content        TEXT NOT NULL,
+    model          TEXT NOT NULL,
+    prompt_version TEXT NOT NULL,
+    input_hash     TEXT NOT NULL,
+    created_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    UNIQUE(proposal_id, kind, version)
+);
+
+CREATE INDEX idx_reasoning_artifacts_proposal_id ON reasoning_artifacts(proposal_id, kind);
+
+-- reasoning_artifact_flags: reviewers marking an artifact as misleading
+CREATE TABLE reasoning_artifact_flags (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    artifact_id UUID NOT NULL REFERENCES reasoning_artifacts(id) ON DELETE CASCADE,
+    user_id     UUID REFERENCES users(id) ON DELETE SET NULL,
+    reason      TEXT NOT NULL,
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    UNIQUE(artifact_id, user_id)
+);
@@ -0,0 +1,29 @@
+-- proposal_comments: threaded discussion attached to proposals (reasoning layer)
+CREATE TABLE proposal_comments (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    proposal_id UUID NOT NULL REFERENCES proposals(id) ON DELETE CASCADE,
+    parent_id   UUID REFERENCES proposal_comments(id) ON DELETE CASCADE,
+    author_id   TEXT NOT NULL,
+    body        TEXT NOT NULL,
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_proposal_comments_proposal_id ON proposal_comments(proposal_id);
+
+-- syntheses of a discussion are stored alongside summaries as versioned artifacts
+ALTER TABLE reasoning_artifacts DROP CONSTRAINT reasoning_artifacts_kind_check;
+ALTER TABLE reasoning_artifacts
+ADD CONSTRAINT reasoning_artifacts_kind_check CHECK (kind IN ('summary', 'synthesis'));
+
+-- proposal_decisions: one record per accepted/rejected proposal, linking the
+-- outcome to the synthesis that informed it
+CREATE TABLE proposal_decisions (
+    id                    UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    proposal_id           UUID NOT NULL UNIQUE REFERENCES proposals(id) ON DELETE CASCADE,
+    outcome               TEXT NOT NULL CHECK (outcome IN ('accepted', 'rejected')),
+    decided_by            TEXT NOT NULL,
+    reason                TEXT,
+    synthesis_artifact_id UUID REFERENCES reasoning_artifacts(id) ON DELETE SET NULL,
+    decided_at            TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
@@ -0,0 +1,30 @@
+-- conflict_findings: potential semantic conflicts between open proposals,
+-- raised by rule checks or the AI provider and confirmed/dismissed by reviewers
+CREATE TABLE conflict_findings (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id  UUID REFERENCES workspaces(id) ON DELETE CASCADE,
+    proposal_a_id UUID NOT NULL REFERENCES proposals(id) ON DELETE CASCADE,
+    proposal_b_id UUID NOT NULL REFERENCES proposals(id) ON DELETE CASCADE,
+    source        TEXT NOT NULL CHECK (source IN ('rule', 'ai')),
+    kind          TEXT NOT NULL,
+    explanation   TEXT NOT NULL,
+    status        TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'confirmed', 'dismissed')),
+    fingerprint   TEXT NOT NULL UNIQUE,
+    reviewed_by   UUID REFERENCES users(id) ON DELETE SET NULL,
+    review_note   TEXT,
+    reviewed_at   TIMESTAMP WITH TIME ZONE,
+    created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_conflict_findings_proposal_a ON conflict_findings(proposal_a_id);
+CREATE INDEX idx_conflict_findings_proposal_b ON conflict_findings(proposal_b_id);
+
+-- conflict_analyses: last AI comparison per proposal pair, so unchanged
+-- pairs are not sent to the provider again
+CREATE TABLE conflict_analyses (
+    proposal_a_id UUID NOT NULL REFERENCES proposals(id) ON DELETE CASCADE,
+    proposal_b_id UUID NOT NULL REFERENCES proposals(id) ON DELETE CASCADE,
+    input_hash    TEXT NOT NULL,
+    analyzed_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    PRIMARY KEY (proposal_a_id, proposal_b_id)
+);
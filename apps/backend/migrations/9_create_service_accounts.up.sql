@@ -0,0 +1,29 @@
+-- service_accounts: workspace-scoped, non-human principals for AI agents
+CREATE TABLE service_accounts (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    name         VARCHAR(255) NOT NULL,
+    description  TEXT,
+    created_by   UUID NOT NULL REFERENCES users(id),
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    revoked_at   TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_service_accounts_workspace_id ON service_accounts(workspace_id);
+
+-- service_account_keys: API keys, stored as SHA-256 hashes and looked up by prefix
+CREATE TABLE service_account_keys (
+    id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_account_id UUID NOT NULL REFERENCES service_accounts(id) ON DELETE CASCADE,
+    prefix             TEXT NOT NULL UNIQUE,
+    key_hash           TEXT NOT NULL,
+    created_by         UUID NOT NULL REFERENCES users(id),
+    created_at         TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    last_used_at       TIMESTAMP WITH TIME ZONE,
+    revoked_at         TIMESTAMP WITH TIME ZONE
+);
+
+-- proposal provenance: who submitted it and, for agents, the reasoning payload
+ALTER TABLE proposals ADD COLUMN author_type TEXT NOT NULL DEFAULT 'user' CHECK (author_type IN ('user', 'agent'));
+ALTER TABLE proposals ADD COLUMN agent_account_id UUID REFERENCES service_accounts(id) ON DELETE SET NULL;
+ALTER TABLE proposals ADD COLUMN agent_reasoning JSONB;
@@ -3,12 +3,14 @@ import { Navigate, Route, Routes } from "react-router-dom";
 import ArchivePage from "@/features/archive/archive.page";
 import LoginPage from "@/features/auth/login.page";
 import RegisterPage from "@/features/auth/register.page";
+import SSOCallbackPage from "@/features/auth/sso-callback.page";
 import ComposerPage from "@/features/composer/composer.page";
 import DecisionRoomPage from "@/features/decision/decision-room.page";
 import InboxPage from "@/features/inbox/inbox.page";
 import MotionPage from "@/features/motion/motion.page";
 import TruthPage from "@/features/truth/truth.page";
 import ProfilePage from "@/features/user/profile.page";
+import InvitationPage from "@/features/workspaces/invitation.page";
 import WorkspaceListPage from "@/features/workspaces/workspace-list.page";
 import WorkspaceSettingsPage from "@/features/workspaces/workspace-settings.page";
 import MainLayout from "@/layouts/main.layout";
@@ -48,12 +50,17 @@ const App: React.FC = () => (
 			<Route path=":id/settings" element={<WorkspaceSettingsPage />} />
 		</Route>
 
+		<Route path="/invitations/accept" element={<MainLayout />}>
+			<Route index element={<InvitationPage />} />
+		</Route>
+
 		<Route path="/profile" element={<MainLayout />}>
 			<Route index element={<ProfilePage />} />
 		</Route>
 
 		<Route path="/login" element={<LoginPage />} />
 		<Route path="/register" element={<RegisterPage />} />
+		<Route path="/auth/callback" element={<SSOCallbackPage />} />
 
 		{/* Legacy redirects so old links still work */}
 		<Route path="/home" element={<Navigate to="/inbox" replace />} />
@@ -5,6 +5,14 @@ interface LoginResponse {
 	username: string;
 	accessToken: string;
 	refreshToken: string;
+	// Set instead of tokens when the account has two-factor authentication
+	twoFactorRequired?: boolean;
+	challengeToken?: string;
+}
+
+export interface SSOProvider {
+	id: string;
+	name: string;
 }
 
 interface RefreshResponse {
@@ -16,6 +24,13 @@ export const authApi = {
 	login: (email: string, password: string) =>
 		http.post<LoginResponse>("/auth/login", { email, password }),
 
+	loginTwoFactor: (challengeToken: string, code: string) =>
+		http.post<LoginResponse>("/auth/login/2fa", { challengeToken, code }),
+
+	ssoProviders: () => http.get<SSOProvider[]>("/auth/oidc/providers"),
+
+	ssoExchange: (code: string) => http.post<LoginResponse>("/auth/oidc/exchange", { code }),
+
 	register: (name: string, email: string, password: string) =>
 		http.post<LoginResponse>("/auth/register", { name, email, password }),
 
@@ -17,7 +17,11 @@ interface AuthContextValue {
 	accessToken: string | null;
 	isAuthenticated: boolean;
 	isLoading: boolean;
-	login: (email: string, password: string) => Promise<void>;
+	/** Resolves to a challenge token when a two-factor code is still needed. */
+	login: (email: string, password: string) => Promise<string | null>;
+	completeTwoFactorLogin: (challengeToken: string, code: string) => Promise<void>;
+	/** Resolves to a challenge token when a two-factor code is still needed. */
+	completeSSOLogin: (code: string) => Promise<string | null>;
 	register: (name: string, email: string, password: string) => Promise<void>;
 	logout: () => Promise<void>;
 	updateUsername: (newUsername: string) => Promise<void>;
@@ -107,16 +111,54 @@ export const AuthProvider: React.FC<{ children: React.ReactNode }> = ({ children
 		});
 	}, []);
 
-	const login = async (email: string, password: string) => {
+	const login = async (email: string, password: string): Promise<string | null> => {
 		setIsLoading(true);
 		try {
 			const res = await authApi.login(email, password);
+			if (res.twoFactorRequired && res.challengeToken) {
+				return res.challengeToken;
+			}
 			applyUser({
 				userId: res.userID,
 				username: res.username,
 				accessToken: res.accessToken,
 				refreshToken: res.refreshToken,
 			});
+			return null;
+		} finally {
+			setIsLoading(false);
+		}
+	};
+
+	const completeTwoFactorLogin = async (challengeToken: string, code: string) => {
+		setIsLoading(true);
+		try {
+			const res = await authApi.loginTwoFactor(challengeToken, code);
+			applyUser({
+				userId: res.userID,
+				username: res.username,
+				accessToken: res.accessToken,
+				refreshToken: res.refreshToken,
+			});
+		} finally {
+			setIsLoading(false);
+		}
+	};
+
+	const completeSSOLogin = async (code: string): Promise<string | null> => {
+		setIsLoading(true);
+		try {
+			const res = await authApi.ssoExchange(code);
+			if (res.twoFactorRequired && res.challengeToken) {
+				return res.challengeToken;
+			}
+			applyUser({
+				userId: res.userID,
+				username: res.username,
+				accessToken: res.accessToken,
+				refreshToken: res.refreshToken,
+			});
+			return null;
 		} finally {
 			setIsLoading(false);
 		}
@@ -146,6 +188,8 @@ export const AuthProvider: React.FC<{ children: React.ReactNode }> = ({ children
 				isAuthenticated: !!accessToken,
 				isLoading,
 				login,
+				completeTwoFactorLogin,
+				completeSSOLogin,
 				register,
 				logout,
 				updateUsername,
@@ -1,21 +1,43 @@
 import type React from "react";
-import { useState } from "react";
-import { useNavigate } from "react-router-dom";
-import { ApiError } from "@/lib/http";
+import { useEffect, useState } from "react";
+import { useLocation, useNavigate, useSearchParams } from "react-router-dom";
+import { ApiError, BASE_URL } from "@/lib/http";
 import Button from "@/ui/button";
 import Input from "@/ui/input";
+import { authApi, type SSOProvider } from "./auth.api";
 import { useAuth } from "./auth.context";
 import AuthLayout from "./auth.layout";
 import "./login.page.scss";
 
 const LoginPage: React.FC = () => {
 	const navigate = useNavigate();
-	const { login } = useAuth();
+	const location = useLocation();
+	const { login, completeTwoFactorLogin } = useAuth();
+	const [searchParams] = useSearchParams();
 	const [email, setEmail] = useState("");
 	const [password, setPassword] = useState("");
 	const [emailError, setEmailError] = useState("");
 	const [passwordError, setPasswordError] = useState("");
 	const [isSubmitting, setIsSubmitting] = useState(false);
+	// An SSO login of a 2FA account arrives here with its challenge
+	const [challengeToken, setChallengeToken] = useState<string | null>(
+		(location.state as { challengeToken?: string } | null)?.challengeToken ?? null,
+	);
+	const [code, setCode] = useState("");
+	const [codeError, setCodeError] = useState("");
+	const [ssoProviders, setSsoProviders] = useState<SSOProvider[]>([]);
+	const ssoError = searchParams.get("sso_error");
+	// Only same-site paths, e.g. an invitation link opened while signed out
+	const redirectParam = searchParams.get("redirect") ?? "";
+	const redirectTo =
+		redirectParam.startsWith("/") && !redirectParam.startsWith("//") ? redirectParam : "/home";
+
+	useEffect(() => {
+		authApi
+			.ssoProviders()
+			.then(setSsoProviders)
+			.catch(() => setSsoProviders([]));
+	}, []);
 
 	const validateEmail = (v: string) => {
 		if (!v) return "Email is required";
@@ -39,8 +61,12 @@ const LoginPage: React.FC = () => {
 
 		setIsSubmitting(true);
 		try {
-			await login(email, password);
-			navigate("/home");
+			const challenge = await login(email, password);
+			if (challenge) {
+				setChallengeToken(challenge);
+				return;
+			}
+			navigate(redirectTo);
 		} catch (err) {
 			if (err instanceof ApiError && err.status === 401) {
 				setEmailError("Invalid email or password");
@@ -54,6 +80,80 @@ const LoginPage: React.FC = () => {
 		}
 	};
 
+	const handleCodeSubmit = async (e: React.FormEvent) => {
+		e.preventDefault();
+		if (!challengeToken) return;
+		if (!code.trim()) {
+			setCodeError("Code is required");
+			return;
+		}
+
+		setIsSubmitting(true);
+		try {
+			await completeTwoFactorLogin(challengeToken, code.trim());
+			navigate(redirectTo);
+		} catch (err) {
+			if (err instanceof ApiError && err.status === 401) {
+				setCodeError("Invalid or expired code");
+			} else {
+				setCodeError("Verification failed. Please try again.");
+			}
+		} finally {
+			setIsSubmitting(false);
+		}
+	};
+
+	if (challengeToken) {
+		return (
+			<AuthLayout>
+				<form className="login-form" onSubmit={handleCodeSubmit}>
+					<div className="login-form__header">
+						<h1 className="login-form__title">Two-factor authentication</h1>
+						<p className="login-form__subtitle">Enter the code from your authenticator app</p>
+					</div>
+
+					<div className="login-form__fields">
+						<Input
+							label="Code"
+							type="text"
+							value={code}
+							placeholder="123456"
+							isRequired
+							hasError={!!codeError}
+							errorMessage={codeError}
+							onChange={setCode}
+						/>
+					</div>
+
+					<div className="login-form__actions">
+						<Button
+							type="submit"
+							variant="primary"
+							size="large"
+							isDisabled={isSubmitting}
+							isFullWidth
+						>
+							{isSubmitting ? "Verifying…" : "Verify"}
+						</Button>
+						<div className="login-form__switch">
+							<button
+								type="button"
+								className="login-form__switch-link"
+								onClick={() => {
+									setChallengeToken(null);
+									setCode("");
+									setCodeError("");
+								}}
+							>
+								Back to sign in
+							</button>
+						</div>
+					</div>
+				</form>
+			</AuthLayout>
+		);
+	}
+
 	return (
 		<AuthLayout>
 			<form className="login-form" onSubmit={handleSubmit}>
@@ -97,6 +197,21 @@ const LoginPage: React.FC = () => {
 					>
 						{isSubmitting ? "Signing in…" : "Sign in"}
 					</Button>
+					{ssoError && <p className="login-form__error">Single sign-on failed: {ssoError}</p>}
+					{ssoProviders.map((p) => (
+						<Button
+							key={p.id}
+							type="button"
+							variant="secondary"
+							size="large"
+							isFullWidth
+							onClick={() => {
+								window.location.href = `${BASE_URL}/auth/oidc/${encodeURIComponent(p.id)}/login?redirect=${encodeURIComponent(redirectTo)}`;
+							}}
+						>
+							Continue with {p.name}
+						</Button>
+					))}
 					<div className="login-form__switch">
 						<span className="login-form__switch-text">Don't have an account?</span>
 						<button
@@ -0,0 +1,61 @@
+import type React from "react";
+import { useEffect, useRef, useState } from "react";
+import { useNavigate } from "react-router-dom";
+import { useAuth } from "./auth.context";
+import AuthLayout from "./auth.layout";
+import "./login.page.scss";
+
+/**
+ * Landing page after an OIDC login. The backend redirects here with a
+ * one-time code in the URL fragment, which is exchanged for tokens.
+ */
+const SSOCallbackPage: React.FC = () => {
+	const navigate = useNavigate();
+	const { completeSSOLogin } = useAuth();
+	const [error, setError] = useState("");
+	const started = useRef(false);
+
+	// biome-ignore lint/correctness/useExhaustiveDependencies: the one-time code must only be exchanged once
+	useEffect(() => {
+		if (started.current) return;
+		started.current = true;
+
+		const params = new URLSearchParams(window.location.hash.slice(1));
+		const code = params.get("code");
+		const redirect = params.get("redirect") ?? "/home";
+		// Drop the code from the address bar and history
+		window.history.replaceState(null, "", window.location.pathname);
+
+		if (!code) {
+			setError("Missing login code.");
+			return;
+		}
+		const target = redirect.startsWith("/") ? redirect : "/home";
+		completeSSOLogin(code)
+			.then((challengeToken) => {
+				if (challengeToken) {
+					// The login page asks for the two-factor code
+					navigate(`/login?redirect=${encodeURIComponent(target)}`, {
+						replace: true,
+						state: { challengeToken },
+					});
+					return;
+				}
+				navigate(target, { replace: true });
+			})
+			.catch(() => setError("Single sign-on failed. Please try again."));
+	}, []);
+
+	return (
+		<AuthLayout>
+			<div className="login-form">
+				<div className="login-form__header">
+					<h1 className="login-form__title">Signing you in…</h1>
+					{error && <p className="login-form__error">{error}</p>}
+				</div>
+			</div>
+		</AuthLayout>
+	);
+};
+
+export default SSOCallbackPage;
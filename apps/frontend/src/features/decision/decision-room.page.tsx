@@ -11,13 +11,18 @@ import { useNavigate, useParams } from "react-router-dom";
 import { useAuth } from "@/features/auth/auth.context";
 import { documentsApi } from "@/features/documents/documents.api";
 import type { Document } from "@/features/documents/types";
-import type { Proposal, ProposalBlockChange } from "@/features/proposals/proposals.api";
+import type {
+	Proposal,
+	ProposalBlockChange,
+	SignatureInput,
+} from "@/features/proposals/proposals.api";
 import { proposalsApi } from "@/features/proposals/proposals.api";
-import type { WorkspaceMember } from "@/features/workspaces/types";
+import { type Role, roleAllows, type WorkspaceMember } from "@/features/workspaces/types";
 import { useWorkspace } from "@/features/workspaces/workspace.context";
 import { workspacesApi } from "@/features/workspaces/workspaces.api";
 import Button from "@/ui/button";
 import "./decision-room.page.scss";
+import ReviewersPanel from "./reviewers-panel";
 
 // ─── Helpers ─────────────────────────────────────────────────────────────────
 
@@ -80,6 +85,62 @@ const SemanticDiffEntry: React.FC<SemanticDiffEntryProps> = ({ change, showTextD
 	);
 };
 
+// ─── Electronic signature ─────────────────────────────────────────────────────
+
+interface SignatureFieldsProps {
+	meanings: string[];
+	value: SignatureInput;
+	onChange: (value: SignatureInput) => void;
+}
+
+const SignatureFields: React.FC<SignatureFieldsProps> = ({ meanings, value, onChange }) => (
+	<div className="decision-room__signature">
+		<p className="decision-room__decline-label">
+			This workspace records decisions as electronic signatures. Confirm your identity and
+			the meaning of your signature.
+		</p>
+		{meanings.length > 0 ? (
+			<select
+				className="decision-room__signature-input"
+				value={value.meaning}
+				onChange={(e) => onChange({ ...value, meaning: e.target.value })}
+			>
+				<option value="">Signature meaning…</option>
+				{meanings.map((m) => (
+					<option key={m} value={m}>
+						{m}
+					</option>
+				))}
+			</select>
+		) : (
+			<input
+				className="decision-room__signature-input"
+				value={value.meaning}
+				placeholder="Signature meaning, e.g. approved as author"
+				onChange={(e) => onChange({ ...value, meaning: e.target.value })}
+			/>
+		)}
+		<input
+			type="password"
+			className="decision-room__signature-input"
+			value={value.password ?? ""}
+			placeholder="Password"
+			autoComplete="current-password"
+			onChange={(e) => onChange({ ...value, password: e.target.value })}
+		/>
+		<input
+			className="decision-room__signature-input"
+			value={value.code ?? ""}
+			placeholder="Authenticator code (if enabled)"
+			inputMode="numeric"
+			autoComplete="one-time-code"
+			onChange={(e) => onChange({ ...value, code: e.target.value })}
+		/>
+	</div>
+);
+
+const emptySignature: SignatureInput = { meaning: "", password: "", code: "" };
+
 // ─── Decision room page ───────────────────────────────────────────────────────
 
 const DecisionRoomPage: React.FC = () => {
@@ -93,6 +154,7 @@ const DecisionRoomPage: React.FC = () => {
 	const [changes, setChanges] = useState<ProposalBlockChange[]>([]);
 	const [conflicts, setConflicts] = useState<ConflictingProposal[]>([]);
 	const [members, setMembers] = useState<WorkspaceMember[]>([]);
+	const [roles, setRoles] = useState<Role[]>([]);
 	const [loading, setLoading] = useState(true);
 	const [showTextDiff, setShowTextDiff] = useState(false);
 
@@ -100,9 +162,14 @@ const DecisionRoomPage: React.FC = () => {
 	const [acting, setActing] = useState<"accept" | "decline" | null>(null);
 	const [declineReason, setDeclineReason] = useState("");
 	const [declineStep, setDeclineStep] = useState<"confirm" | "reason">("confirm");
+	const [signature, setSignature] = useState<SignatureInput>(emptySignature);
 	const [submitting, setSubmitting] = useState(false);
 	const [error, setError] = useState<string | null>(null);
 
+	const requiresSignature = currentWorkspace?.require_signature ?? false;
+	const signatureMeanings = currentWorkspace?.signature_meanings ?? [];
+	const signatureReady = !requiresSignature || signature.meaning.trim() !== "";
+
 	useEffect(() => {
 		if (!proposalId) return;
 		setLoading(true);
@@ -115,16 +182,21 @@ const DecisionRoomPage: React.FC = () => {
 				const membersFetch = currentWorkspace
 					? workspacesApi.getMembers(currentWorkspace.id).catch(() => [] as WorkspaceMember[])
 					: Promise.resolve([] as WorkspaceMember[]);
+				const rolesFetch = currentWorkspace
+					? workspacesApi.getRoles(currentWorkspace.id).catch(() => [] as Role[])
+					: Promise.resolve([] as Role[]);
 
-				const [doc, blockChanges, allProposals, fetchedMembers] = await Promise.all([
+				const [doc, blockChanges, allProposals, fetchedMembers, fetchedRoles] = await Promise.all([
 					documentsApi.get(p.document_id),
 					proposalsApi.getBlockChanges(proposalId),
 					proposalsApi.getForDocument(p.document_id),
 					membersFetch,
+					rolesFetch,
 				]);
 				setDocument(doc);
 				setChanges(blockChanges);
 				setMembers(fetchedMembers);
+				setRoles(fetchedRoles);
 
 				// Detect conflicts with other open proposals
 				const openOthers = allProposals.filter((op) => op.id !== proposalId && op.state === "open");
@@ -149,9 +221,10 @@ const DecisionRoomPage: React.FC = () => {
 		setSubmitting(true);
 		setError(null);
 		try {
-			await proposalsApi.accept(proposalId);
+			await proposalsApi.accept(proposalId, requiresSignature ? signature : undefined);
 			setProposal((prev) => (prev ? { ...prev, state: "accepted" } : prev));
 			setActing(null);
+			setSignature(emptySignature);
 		} catch (e) {
 			setError(e instanceof Error ? e.message : "Failed to accept proposal");
 		} finally {
@@ -164,13 +237,18 @@ const DecisionRoomPage: React.FC = () => {
 		setSubmitting(true);
 		setError(null);
 		try {
-			await proposalsApi.reject(proposalId, declineReason.trim());
+			await proposalsApi.reject(
+				proposalId,
+				declineReason.trim(),
+				requiresSignature ? signature : undefined
+			);
 			setProposal((prev) =>
 				prev ? { ...prev, state: "rejected", rejection_reason: declineReason.trim() } : prev
 			);
 			setActing(null);
 			setDeclineStep("confirm");
 			setDeclineReason("");
+			setSignature(emptySignature);
 		} catch (e) {
 			setError(e instanceof Error ? e.message : "Failed to decline proposal");
 		} finally {
@@ -181,10 +259,11 @@ const DecisionRoomPage: React.FC = () => {
 	const isAuthor = proposal?.author_id === userId;
 	const isOpen = proposal?.state === "open";
 
-	// True when no other workspace member can review (solo workspace or all others are contributors).
+	// True when no other workspace member can accept (solo workspace or no one else's role allows it).
 	// In this case the author is the only person who can adopt their own proposal — allow it.
+	const myRole = members.find((m) => m.user_id === userId)?.role ?? "";
 	const hasOtherReviewers = members.some(
-		(m) => m.user_id !== userId && (m.role === "admin" || m.role === "reviewer")
+		(m) => m.user_id !== userId && roleAllows(roles, m.role, "accept")
 	);
 	const canSelfReview = isAuthor && !hasOtherReviewers;
 
@@ -246,7 +325,10 @@ const DecisionRoomPage: React.FC = () => {
 				<div className="decision-room__main">
 					<header className="decision-room__header">
 						<h1 className="decision-room__title">{proposal.title || "Untitled proposal"}</h1>
-						<p className="decision-room__byline">Proposed {formatRelative(proposal.created_at)}</p>
+						<p className="decision-room__byline">
+							{proposal.author_type === "agent" ? "Proposed by an AI agent" : "Proposed"}{" "}
+							{formatRelative(proposal.created_at)}
+						</p>
 					</header>
 
 					{/* THE CHANGE — semantic first */}
@@ -288,6 +370,12 @@ const DecisionRoomPage: React.FC = () => {
 								<strong>Scope:</strong> {proposal.scope}
 							</p>
 						)}
+						{proposal.agent_reasoning && (
+							<p className="decision-room__scope">
+								<strong>Agent rationale:</strong> {proposal.agent_reasoning.rationale}
+								{proposal.agent_reasoning.model && ` (${proposal.agent_reasoning.model})`}
+							</p>
+						)}
 					</section>
 
 					{/* CONFLICTS */}
@@ -415,12 +503,19 @@ const DecisionRoomPage: React.FC = () => {
 												You are about to make this part of group truth. The reasoning above will be
 												permanently preserved alongside this decision.
 											</p>
+											{requiresSignature && (
+												<SignatureFields
+													meanings={signatureMeanings}
+													value={signature}
+													onChange={setSignature}
+												/>
+											)}
 											<div className="decision-room__confirm-actions">
 												<button
 													type="button"
 													className="decision-room__action-btn decision-room__action-btn--accept"
 													onClick={handleAccept}
-													disabled={submitting}
+													disabled={submitting || !signatureReady}
 												>
 													<CheckCircleIcon className="decision-room__action-icon" />
 													{submitting ? "Adopting…" : "Confirm & Adopt"}
@@ -428,7 +523,10 @@ const DecisionRoomPage: React.FC = () => {
 												<button
 													type="button"
 													className="decision-room__action-btn decision-room__action-btn--cancel"
-													onClick={() => setActing(null)}
+													onClick={() => {
+														setActing(null);
+														setSignature(emptySignature);
+													}}
 												>
 													Cancel
 												</button>
@@ -449,12 +547,19 @@ const DecisionRoomPage: React.FC = () => {
 												onChange={(e) => setDeclineReason(e.target.value)}
 												rows={4}
 											/>
+											{requiresSignature && (
+												<SignatureFields
+													meanings={signatureMeanings}
+													value={signature}
+													onChange={setSignature}
+												/>
+											)}
 											<div className="decision-room__confirm-actions">
 												<button
 													type="button"
 													className="decision-room__action-btn decision-room__action-btn--decline"
 													onClick={handleDecline}
-													disabled={!declineReason.trim() || submitting}
+													disabled={!declineReason.trim() || submitting || !signatureReady}
 												>
 													<XCircleIcon className="decision-room__action-icon" />
 													{submitting ? "Declining…" : "Confirm Decline"}
@@ -466,6 +571,7 @@ const DecisionRoomPage: React.FC = () => {
 														setActing(null);
 														setDeclineStep("confirm");
 														setDeclineReason("");
+														setSignature(emptySignature);
 													}}
 												>
 													Back
@@ -476,6 +582,18 @@ const DecisionRoomPage: React.FC = () => {
 								</>
 							)}
 
+							{proposalId && (
+								<ReviewersPanel
+									proposalId={proposalId}
+									workspaceId={currentWorkspace?.id}
+									members={members}
+									roles={roles}
+									userId={userId}
+									isOpen={isOpen}
+									canRequest={isOpen && (isAuthor || roleAllows(roles, myRole, "review"))}
+								/>
+							)}
+
 							<div className="decision-room__litmus">
 								<p className="decision-room__litmus-heading">Before you decide, ask:</p>
 								<ul className="decision-room__litmus-list">
@@ -0,0 +1,179 @@
+import { CheckIcon, XMarkIcon } from "@heroicons/react/24/solid";
+import type React from "react";
+import { useEffect, useState } from "react";
+import { proposalsApi, type ReviewRequest } from "@/features/proposals/proposals.api";
+import {
+	type Role,
+	roleAllows,
+	type Team,
+	type WorkspaceMember,
+} from "@/features/workspaces/types";
+import { workspacesApi } from "@/features/workspaces/workspaces.api";
+import Button from "@/ui/button";
+
+interface ReviewersPanelProps {
+	proposalId: string;
+	/** Absent for guests, who cannot see the workspace's teams */
+	workspaceId?: string;
+	members: WorkspaceMember[];
+	roles: Role[];
+	userId: string | null;
+	isOpen: boolean;
+	canRequest: boolean;
+}
+
+/**
+ * Shows who has been asked to review, including stewards of the touched blocks,
+ * lets the author or a reviewer ask someone else, and lets a requested reviewer sign off.
+ */
+const ReviewersPanel: React.FC<ReviewersPanelProps> = ({
+	proposalId,
+	workspaceId,
+	members,
+	roles,
+	userId,
+	isOpen,
+	canRequest,
+}) => {
+	const [requests, setRequests] = useState<ReviewRequest[]>([]);
+	const [teams, setTeams] = useState<Team[]>([]);
+	const [target, setTarget] = useState("");
+	const [error, setError] = useState<string | null>(null);
+
+	useEffect(() => {
+		proposalsApi.getReviewRequests(proposalId).then(setRequests).catch(console.error);
+		if (workspaceId) workspacesApi.getTeams(workspaceId).then(setTeams).catch(console.error);
+	}, [proposalId, workspaceId]);
+
+	const reviewers = members.filter(
+		(m) =>
+			roleAllows(roles, m.role, "review") && !requests.some((r) => r.reviewer_user_id === m.user_id)
+	);
+	const availableTeams = teams.filter((t) => !requests.some((r) => r.reviewer_team_id === t.id));
+
+	const handleRequest = async () => {
+		if (!target) return;
+		setError(null);
+		const [kind, id] = target.split(":");
+		try {
+			const created = await proposalsApi.requestReview(
+				proposalId,
+				kind === "team" ? { team_id: id } : { user_id: id }
+			);
+			setRequests((prev) => [...prev, created]);
+			setTarget("");
+		} catch (err) {
+			setError(err instanceof Error ? err.message : "Failed to request review");
+		}
+	};
+
+	const addressedToMe = (r: ReviewRequest) =>
+		r.reviewer_user_id === userId ||
+		teams.some((t) => t.id === r.reviewer_team_id && t.members.some((m) => m.user_id === userId));
+
+	const handleAnswer = async (request: ReviewRequest, approve: boolean) => {
+		setError(null);
+		try {
+			const answered = approve
+				? await proposalsApi.approveReviewRequest(proposalId, request.id)
+				: await proposalsApi.declineReviewRequest(proposalId, request.id);
+			setRequests((prev) => prev.map((r) => (r.id === answered.id ? answered : r)));
+		} catch (err) {
+			setError(err instanceof Error ? err.message : "Failed to answer review request");
+		}
+	};
+
+	const handleCancel = async (request: ReviewRequest) => {
+		setError(null);
+		try {
+			await proposalsApi.cancelReviewRequest(proposalId, request.id);
+			setRequests((prev) => prev.filter((r) => r.id !== request.id));
+		} catch (err) {
+			setError(err instanceof Error ? err.message : "Failed to cancel request");
+		}
+	};
+
+	return (
+		<div className="decision-room__reviewers">
+			<p className="decision-room__litmus-heading">Reviewers</p>
+			{requests.length === 0 && <p className="decision-room__reviewers-empty">No one asked yet.</p>}
+			<ul className="decision-room__reviewers-list">
+				{requests.map((r) => (
+					<li key={r.id} className="decision-room__reviewer">
+						<span>
+							{r.reviewer_team_name ? `${r.reviewer_team_name} (team)` : r.reviewer_username}
+							{r.steward && <span className="decision-room__reviewer-tag">steward</span>}
+							{r.approved_at && <span className="decision-room__reviewer-tag">approved</span>}
+							{r.declined_at && <span className="decision-room__reviewer-tag">declined</span>}
+						</span>
+						{isOpen && !r.approved_at && !r.declined_at && addressedToMe(r) && (
+							<span className="decision-room__reviewer-actions">
+								<Button
+									variant="secondary"
+									size="small"
+									onClick={() => handleAnswer(r, true)}
+									isFullWidth={false}
+								>
+									<CheckIcon style={{ width: 12, height: 12 }} />
+									Approve
+								</Button>
+								<Button
+									variant="secondary"
+									size="small"
+									onClick={() => handleAnswer(r, false)}
+									isFullWidth={false}
+								>
+									Decline
+								</Button>
+							</span>
+						)}
+						{canRequest && !r.steward && (
+							<button
+								type="button"
+								className="decision-room__reviewer-remove"
+								onClick={() => handleCancel(r)}
+								aria-label="Cancel review request"
+								title="Cancel review request"
+							>
+								<XMarkIcon style={{ width: 12, height: 12 }} />
+							</button>
+						)}
+					</li>
+				))}
+			</ul>
+			{canRequest && (reviewers.length > 0 || availableTeams.length > 0) && (
+				<div className="decision-room__reviewers-form">
+					<select
+						className="decision-room__reviewers-select"
+						value={target}
+						onChange={(e) => setTarget(e.target.value)}
+					>
+						<option value="">Ask someone to review…</option>
+						{availableTeams.map((t) => (
+							<option key={t.id} value={`team:${t.id}`}>
+								Team: {t.name}
+							</option>
+						))}
+						{reviewers.map((m) => (
+							<option key={m.user_id} value={`user:${m.user_id}`}>
+								{m.username}
+							</option>
+						))}
+					</select>
+					<Button
+						variant="secondary"
+						size="small"
+						onClick={handleRequest}
+						isDisabled={!target}
+						isFullWidth={false}
+					>
+						Request
+					</Button>
+				</div>
+			)}
+			{error && <p className="decision-room__reviewers-error">{error}</p>}
+		</div>
+	);
+};
+
+export default ReviewersPanel;
@@ -1,5 +1,5 @@
 import { http } from "@/lib/http";
-import type { Document } from "./types";
+import type { Document, Redaction } from "./types";
 
 export const documentsApi = {
 	getAll: () => http.get<Document[]>("/documents/all"),
@@ -15,4 +15,9 @@ export const documentsApi = {
 	update: (id: string, data: Partial<Document>) => http.put<void>(`/documents/${id}`, data),
 
 	delete: (id: string) => http.delete<void>(`/documents/${id}`),
+
+	getRedactions: (id: string) => http.get<Redaction[]>(`/documents/${id}/redactions`),
+
+	redact: (id: string, text: string, reason: string) =>
+		http.post<Redaction>(`/documents/${id}/redactions`, { text, reason }),
 };
@@ -18,3 +18,22 @@ export interface Block {
 	updated_at: string;
 	updated_by: string;
 }
+
+/** A record of text scrubbed from a document. The text itself is not kept. */
+export interface Redaction {
+	id: string;
+	workspace_id?: string;
+	document_id: string;
+	reason: string;
+	text_hash: string;
+	blocks_redacted: number;
+	changes_redacted: number;
+	artifacts_redacted: number;
+	comments_redacted: number;
+	proposals_redacted: number;
+	findings_redacted: number;
+	events_redacted: number;
+	redacted_by: string | null;
+	redacted_by_name?: string;
+	created_at: string;
+}
@@ -12,6 +12,17 @@ export interface Proposal {
 	rejection_reason?: string | null;
 	created_at: string;
 	updated_at: string;
+	author_type: "user" | "agent";
+	agent_account_id?: string;
+	agent_reasoning?: AgentReasoning;
+}
+
+export interface AgentReasoning {
+	rationale: string;
+	evidence?: { source: string; detail: string }[];
+	confidence?: number;
+	model?: string;
+	run_id?: string;
 }
 
 export interface ProposalBlockChange {
@@ -26,6 +37,94 @@ export interface ProposalBlockChange {
 	created_at: string;
 }
 
+/** Electronic signature sent with a decision when the workspace requires it. */
+export interface SignatureInput {
+	meaning: string;
+	password?: string;
+	code?: string;
+}
+
+/** A request for one user, or any member of a team, to review a proposal. */
+export interface ReviewRequest {
+	id: string;
+	proposal_id: string;
+	reviewer_user_id?: string;
+	reviewer_username?: string;
+	reviewer_team_id?: string;
+	reviewer_team_name?: string;
+	requested_by: string | null;
+	/** Requested automatically because the reviewer stewards a touched block */
+	steward: boolean;
+	approved_by: string | null;
+	approved_at: string | null;
+	declined_by: string | null;
+	declined_at: string | null;
+	decline_reason?: string;
+	created_at: string;
+}
+
+/**
+ * Assigns a user or team to part of a document. A rule covers one block, every
+ * block under path_prefix, or the whole document when neither is set.
+ */
+export interface Steward {
+	id: string;
+	document_id: string;
+	block_id?: string;
+	path_prefix?: number[];
+	steward_user_id?: string;
+	steward_username?: string;
+	steward_team_id?: string;
+	steward_team_name?: string;
+	created_by: string | null;
+	created_at: string;
+}
+
+export interface CreateStewardRequest {
+	block_id?: string;
+	path_prefix?: number[];
+	user_id?: string;
+	team_id?: string;
+}
+
+/** One link in a document's history chain */
+export interface ChainEntry {
+	id: string;
+	document_id: string;
+	seq: number;
+	kind: "decision" | "removal" | "redaction";
+	proposal_id: string;
+	decision_id: string;
+	manifest: unknown;
+	content_hash: string;
+	prev_hash: string;
+	entry_hash: string;
+	created_at: string;
+}
+
+export interface ChainVerification {
+	document_id: string;
+	valid: boolean;
+	entries: number;
+	head_hash: string;
+	/** seq of the first entry that failed */
+	broken_at?: number;
+	reason?: string;
+	checked_at: string;
+}
+
+/** A signed JWT pinning the chain head; public_key verifies it after key rotation */
+export interface ChainCheckpoint {
+	id: string;
+	document_id: string;
+	seq: number;
+	head_hash: string;
+	token: string;
+	public_key: unknown;
+	created_by: string | null;
+	created_at: string;
+}
+
 export const proposalsApi = {
 	getForDocument: (documentId: string) => http.get<Proposal[]>(`/proposals/document/${documentId}`),
 
@@ -48,10 +147,11 @@ export const proposalsApi = {
 
 	delete: (id: string) => http.delete<void>(`/proposals/${id}`),
 
-	accept: (id: string) => http.post<void>(`/proposals/${id}/accept`),
+	accept: (id: string, signature?: SignatureInput) =>
+		http.post<void>(`/proposals/${id}/accept`, signature ? { signature } : undefined),
 
-	reject: (id: string, reason?: string) =>
-		http.post<void>(`/proposals/${id}/reject`, reason ? { reason } : {}),
+	reject: (id: string, reason?: string, signature?: SignatureInput) =>
+		http.post<void>(`/proposals/${id}/reject`, { ...(reason ? { reason } : {}), signature }),
 
 	getBlockChanges: (proposalId: string) =>
 		http.get<ProposalBlockChange[]>(`/proposals/${proposalId}/changes`),
@@ -66,4 +166,44 @@ export const proposalsApi = {
 			content: string;
 		}
 	) => http.post<void>(`/proposals/${proposalId}/changes`, data),
+
+	getReviewRequests: (id: string) => http.get<ReviewRequest[]>(`/proposals/${id}/review-requests`),
+
+	/** Open review requests naming the current user directly or through a team */
+	getMyReviewRequests: () => http.get<ReviewRequest[]>("/proposals/review-requests"),
+
+	requestReview: (id: string, reviewer: { user_id: string } | { team_id: string }) =>
+		http.post<ReviewRequest>(`/proposals/${id}/review-requests`, reviewer),
+
+	cancelReviewRequest: (id: string, requestId: string) =>
+		http.delete<void>(`/proposals/${id}/review-requests/${requestId}`),
+
+	/** Signs off on a request addressed to the current user or one of their teams */
+	approveReviewRequest: (id: string, requestId: string) =>
+		http.post<ReviewRequest>(`/proposals/${id}/review-requests/${requestId}/approve`, {}),
+
+	declineReviewRequest: (id: string, requestId: string, reason?: string) =>
+		http.post<ReviewRequest>(`/proposals/${id}/review-requests/${requestId}/decline`, { reason }),
+
+	getStewards: (documentId: string) =>
+		http.get<Steward[]>(`/proposals/stewards/document/${documentId}`),
+
+	createSteward: (documentId: string, rule: CreateStewardRequest) =>
+		http.post<Steward>(`/proposals/stewards/document/${documentId}`, rule),
+
+	deleteSteward: (documentId: string, stewardId: string) =>
+		http.delete<void>(`/proposals/stewards/document/${documentId}/${stewardId}`),
+
+	// History chain
+	getChain: (documentId: string) =>
+		http.get<ChainEntry[]>(`/proposals/chain/document/${documentId}`),
+
+	verifyChain: (documentId: string) =>
+		http.get<ChainVerification>(`/proposals/chain/document/${documentId}/verify`),
+
+	getChainCheckpoints: (documentId: string) =>
+		http.get<ChainCheckpoint[]>(`/proposals/chain/document/${documentId}/checkpoints`),
+
+	createChainCheckpoint: (documentId: string) =>
+		http.post<ChainCheckpoint>(`/proposals/chain/document/${documentId}/checkpoints`, {}),
 };
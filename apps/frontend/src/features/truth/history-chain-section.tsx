@@ -0,0 +1,143 @@
+import { ArrowDownTrayIcon, ShieldCheckIcon } from "@heroicons/react/24/solid";
+import type React from "react";
+import { useEffect, useState } from "react";
+import { useAuth } from "@/features/auth/auth.context";
+import {
+	type ChainCheckpoint,
+	type ChainVerification,
+	proposalsApi,
+} from "@/features/proposals/proposals.api";
+import { roleAllows } from "@/features/workspaces/types";
+import { workspacesApi } from "@/features/workspaces/workspaces.api";
+import Button from "@/ui/button";
+
+interface HistoryChainSectionProps {
+	documentId: string;
+	workspaceId: string;
+}
+
+const shortHash = (hash: string) => `${hash.slice(0, 12)}…`;
+
+/** Saves a checkpoint as a JSON file to keep outside Granth. */
+const download = (checkpoint: ChainCheckpoint) => {
+	const blob = new Blob([JSON.stringify(checkpoint, null, 2)], { type: "application/json" });
+	const url = URL.createObjectURL(blob);
+	const link = document.createElement("a");
+	link.href = url;
+	link.download = `checkpoint-${checkpoint.document_id}-${checkpoint.seq}.json`;
+	link.click();
+	URL.revokeObjectURL(url);
+};
+
+/** Verifies the document's decision history chain and manages signed checkpoints. */
+const HistoryChainSection: React.FC<HistoryChainSectionProps> = ({ documentId, workspaceId }) => {
+	const { userId } = useAuth();
+	const [canExport, setCanExport] = useState(false);
+	const [result, setResult] = useState<ChainVerification | null>(null);
+	const [checkpoints, setCheckpoints] = useState<ChainCheckpoint[]>([]);
+	const [busy, setBusy] = useState(false);
+	const [error, setError] = useState<string | null>(null);
+
+	useEffect(() => {
+		Promise.all([workspacesApi.getMembers(workspaceId), workspacesApi.getRoles(workspaceId)])
+			.then(([members, roles]) => {
+				const myRole = members.find((m) => m.user_id === userId)?.role ?? "";
+				setCanExport(roleAllows(roles, myRole, "export"));
+			})
+			.catch(console.error);
+	}, [workspaceId, userId]);
+
+	useEffect(() => {
+		if (!canExport) return;
+		proposalsApi.getChainCheckpoints(documentId).then(setCheckpoints).catch(console.error);
+	}, [documentId, canExport]);
+
+	const run = async (action: () => Promise<void>, fallback: string) => {
+		setBusy(true);
+		setError(null);
+		try {
+			await action();
+		} catch (err) {
+			setError(err instanceof Error ? err.message : fallback);
+		} finally {
+			setBusy(false);
+		}
+	};
+
+	const handleVerify = () =>
+		run(async () => setResult(await proposalsApi.verifyChain(documentId)), "Failed to verify");
+
+	const handleCheckpoint = () =>
+		run(async () => {
+			const checkpoint = await proposalsApi.createChainCheckpoint(documentId);
+			setCheckpoints((prev) => [checkpoint, ...prev]);
+			download(checkpoint);
+		}, "Failed to create a checkpoint");
+
+	return (
+		<section className="truth-detail__stewards">
+			<h2 className="truth-detail__stewards-title">History integrity</h2>
+			<p className="truth-detail__stewards-hint">
+				Every decision on this document is hash-chained, so edits made behind Granth's back show
+				up when the chain is verified.
+			</p>
+			{result && (
+				<p
+					className={result.valid ? "truth-detail__stewards-hint" : "truth-detail__stewards-error"}
+				>
+					{result.valid
+						? `Intact · ${result.entries} entries · head ${shortHash(result.head_hash)}`
+						: `Broken${result.broken_at ? ` at entry ${result.broken_at}` : ""}: ${result.reason}`}
+				</p>
+			)}
+
+			{checkpoints.length > 0 && (
+				<ul className="truth-detail__stewards-list">
+					{checkpoints.map((c) => (
+						<li key={c.id} className="truth-detail__steward">
+							<span>
+								Checkpoint at entry {c.seq} · {new Date(c.created_at).toLocaleDateString()}
+							</span>
+							<button
+								type="button"
+								className="truth-detail__steward-remove"
+								onClick={() => download(c)}
+								aria-label="Download checkpoint"
+								title="Download checkpoint"
+							>
+								<ArrowDownTrayIcon style={{ width: 12, height: 12 }} />
+							</button>
+						</li>
+					))}
+				</ul>
+			)}
+
+			<div className="truth-detail__stewards-form">
+				<Button
+					variant="secondary"
+					size="small"
+					onClick={handleVerify}
+					isDisabled={busy}
+					isFullWidth={false}
+				>
+					<ShieldCheckIcon style={{ width: 14, height: 14 }} />
+					Verify history
+				</Button>
+				{canExport && (
+					<Button
+						variant="secondary"
+						size="small"
+						onClick={handleCheckpoint}
+						isDisabled={busy}
+						isFullWidth={false}
+					>
+						Create signed checkpoint
+					</Button>
+				)}
+			</div>
+			{error && <p className="truth-detail__stewards-error">{error}</p>}
+		</section>
+	);
+};
+
+export default HistoryChainSection;
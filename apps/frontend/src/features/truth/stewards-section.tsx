@@ -0,0 +1,194 @@
+import { TrashIcon } from "@heroicons/react/24/solid";
+import type React from "react";
+import { useEffect, useState } from "react";
+import { useAuth } from "@/features/auth/auth.context";
+import type { Block } from "@/features/documents/types";
+import { proposalsApi, type Steward } from "@/features/proposals/proposals.api";
+import { type Role, roleAllows, type Team, type WorkspaceMember } from "@/features/workspaces/types";
+import { workspacesApi } from "@/features/workspaces/workspaces.api";
+import Button from "@/ui/button";
+
+interface StewardsSectionProps {
+	documentId: string;
+	workspaceId: string;
+	blocks: Block[];
+}
+
+type Scope = "document" | "block" | "path";
+
+const blockLabel = (block: Block) =>
+	`${block.order_path.join(".")} · ${block.content.slice(0, 40) || block.block_type}`;
+
+/** Parses "3" or "3.1" into an order path prefix. */
+const parsePath = (value: string): number[] | null => {
+	const parts = value.trim().split(".");
+	if (parts.some((p) => !/^\d+$/.test(p))) return null;
+	return parts.map(Number);
+};
+
+/** Lists who stewards which parts of a document; policy managers can edit the rules. */
+const StewardsSection: React.FC<StewardsSectionProps> = ({ documentId, workspaceId, blocks }) => {
+	const { userId } = useAuth();
+	const [stewards, setStewards] = useState<Steward[]>([]);
+	const [members, setMembers] = useState<WorkspaceMember[]>([]);
+	const [roles, setRoles] = useState<Role[]>([]);
+	const [teams, setTeams] = useState<Team[]>([]);
+	const [scope, setScope] = useState<Scope>("document");
+	const [blockId, setBlockId] = useState("");
+	const [path, setPath] = useState("");
+	const [target, setTarget] = useState("");
+	const [error, setError] = useState<string | null>(null);
+
+	useEffect(() => {
+		proposalsApi.getStewards(documentId).then(setStewards).catch(console.error);
+		Promise.all([
+			workspacesApi.getMembers(workspaceId),
+			workspacesApi.getRoles(workspaceId),
+			workspacesApi.getTeams(workspaceId),
+		])
+			.then(([m, r, t]) => {
+				setMembers(m);
+				setRoles(r);
+				setTeams(t);
+			})
+			.catch(console.error);
+	}, [documentId, workspaceId]);
+
+	const myRole = members.find((m) => m.user_id === userId)?.role ?? "";
+	const canManage = roleAllows(roles, myRole, "manage_policy");
+	const reviewers = members.filter((m) => roleAllows(roles, m.role, "review"));
+
+	const describe = (s: Steward) => {
+		if (s.block_id) {
+			const block = blocks.find((b) => b.id === s.block_id);
+			return block ? `Block ${blockLabel(block)}` : "A block";
+		}
+		if (s.path_prefix?.length) return `Everything under ${s.path_prefix.join(".")}`;
+		return "Whole document";
+	};
+
+	const handleAdd = async () => {
+		setError(null);
+		const [kind, id] = target.split(":");
+		const prefix = scope === "path" ? parsePath(path) : null;
+		if (scope === "path" && !prefix) {
+			setError("Enter a section path such as 3 or 3.1");
+			return;
+		}
+		try {
+			const created = await proposalsApi.createSteward(documentId, {
+				...(kind === "team" ? { team_id: id } : { user_id: id }),
+				...(scope === "block" ? { block_id: blockId } : {}),
+				...(prefix ? { path_prefix: prefix } : {}),
+			});
+			const team = teams.find((t) => t.id === created.steward_team_id);
+			const member = members.find((m) => m.user_id === created.steward_user_id);
+			setStewards((prev) => [
+				...prev,
+				{ ...created, steward_team_name: team?.name, steward_username: member?.username },
+			]);
+			setTarget("");
+		} catch (err) {
+			setError(err instanceof Error ? err.message : "Failed to add steward");
+		}
+	};
+
+	const handleDelete = async (steward: Steward) => {
+		setError(null);
+		try {
+			await proposalsApi.deleteSteward(documentId, steward.id);
+			setStewards((prev) => prev.filter((s) => s.id !== steward.id));
+		} catch (err) {
+			setError(err instanceof Error ? err.message : "Failed to remove steward");
+		}
+	};
+
+	if (stewards.length === 0 && !canManage) return null;
+
+	return (
+		<section className="truth-detail__stewards">
+			<h2 className="truth-detail__stewards-title">Stewards</h2>
+			{stewards.length === 0 && (
+				<p className="truth-detail__stewards-hint">
+					No stewards yet. Stewards are asked to review every proposal that touches their part.
+				</p>
+			)}
+			<ul className="truth-detail__stewards-list">
+				{stewards.map((s) => (
+					<li key={s.id} className="truth-detail__steward">
+						<span>
+							{describe(s)} →{" "}
+							<strong>
+								{s.steward_team_name ? `${s.steward_team_name} (team)` : s.steward_username}
+							</strong>
+						</span>
+						{canManage && (
+							<button
+								type="button"
+								className="truth-detail__steward-remove"
+								onClick={() => handleDelete(s)}
+								aria-label="Remove steward"
+								title="Remove steward"
+							>
+								<TrashIcon style={{ width: 12, height: 12 }} />
+							</button>
+						)}
+					</li>
+				))}
+			</ul>
+
+			{canManage && (
+				<div className="truth-detail__stewards-form">
+					<select value={scope} onChange={(e) => setScope(e.target.value as Scope)}>
+						<option value="document">Whole document</option>
+						<option value="block">One block</option>
+						<option value="path">Everything under a section</option>
+					</select>
+					{scope === "block" && (
+						<select value={blockId} onChange={(e) => setBlockId(e.target.value)}>
+							<option value="">Choose a block…</option>
+							{blocks.map((b) => (
+								<option key={b.id} value={b.id}>
+									{blockLabel(b)}
+								</option>
+							))}
+						</select>
+					)}
+					{scope === "path" && (
+						<input
+							className="truth-detail__stewards-path"
+							placeholder="e.g. 3"
+							value={path}
+							onChange={(e) => setPath(e.target.value)}
+						/>
+					)}
+					<select value={target} onChange={(e) => setTarget(e.target.value)}>
+						<option value="">Steward…</option>
+						{teams.map((t) => (
+							<option key={t.id} value={`team:${t.id}`}>
+								Team: {t.name}
+							</option>
+						))}
+						{reviewers.map((m) => (
+							<option key={m.user_id} value={`user:${m.user_id}`}>
+								{m.username}
+							</option>
+						))}
+					</select>
+					<Button
+						variant="secondary"
+						size="small"
+						onClick={handleAdd}
+						isDisabled={!target || (scope === "block" && !blockId)}
+						isFullWidth={false}
+					>
+						Add steward
+					</Button>
+				</div>
+			)}
+			{error && <p className="truth-detail__stewards-error">{error}</p>}
+		</section>
+	);
+};
+
+export default StewardsSection;
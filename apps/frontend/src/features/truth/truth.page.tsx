@@ -12,6 +12,8 @@ import { workspacesApi } from "@/features/workspaces/workspaces.api";
 import Button from "@/ui/button";
 import Card from "@/ui/card";
 import "./truth.page.scss";
+import HistoryChainSection from "./history-chain-section";
+import StewardsSection from "./stewards-section";
 
 const relativeDate = (iso: string): string => {
 	const d = new Date(iso);
@@ -31,6 +33,7 @@ const formatContent = (content: string): string => {
 
 const TruthDetailView: React.FC<{ documentId: string }> = ({ documentId }) => {
 	const navigate = useNavigate();
+	const { current: currentWorkspace } = useWorkspace();
 	const [document, setDocument] = useState<Document | null>(null);
 	const [blocks, setBlocks] = useState<Block[]>([]);
 	const [proposals, setProposals] = useState<Proposal[]>([]);
@@ -216,6 +219,17 @@ const TruthDetailView: React.FC<{ documentId: string }> = ({ documentId }) => {
 						Exploration never risks shared truth
 					</span>
 				</div>
+
+				{currentWorkspace && (
+					<StewardsSection
+						documentId={documentId}
+						workspaceId={currentWorkspace.id}
+						blocks={blocks}
+					/>
+				)}
+				{currentWorkspace && (
+					<HistoryChainSection documentId={documentId} workspaceId={currentWorkspace.id} />
+				)}
 			</article>
 		</div>
 	);
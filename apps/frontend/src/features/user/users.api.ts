@@ -0,0 +1,21 @@
+import { http } from "@/lib/http";
+import type { WorkspaceRole } from "@/features/workspaces/types";
+
+export interface UserProfile {
+	id: string;
+	username: string;
+	/** Only present when the search is scoped to a workspace */
+	role?: WorkspaceRole;
+}
+
+export const usersApi = {
+	/**
+	 * Prefix search over usernames and emails. Results are limited to people who share a
+	 * workspace with the caller, or to the members of workspaceId when given.
+	 */
+	search: (q: string, workspaceId?: string) => {
+		const params = new URLSearchParams({ q });
+		if (workspaceId) params.set("workspace_id", workspaceId);
+		return http.get<UserProfile[]>(`/users/search?${params.toString()}`);
+	},
+};
@@ -0,0 +1,172 @@
+import { ArrowPathIcon } from "@heroicons/react/24/solid";
+import type React from "react";
+import { useCallback, useEffect, useState } from "react";
+import Button from "@/ui/button";
+import type { AuditEvent, AuditFilter, WorkspaceMember } from "./types";
+import { workspacesApi } from "./workspaces.api";
+
+interface AuditSectionProps {
+	workspaceId: string;
+	members: WorkspaceMember[];
+}
+
+const PAGE_SIZE = 50;
+
+const ACTION_GROUPS = [
+	"workspace",
+	"policy",
+	"member",
+	"invitation",
+	"role",
+	"team",
+	"guest_grant",
+	"ownership",
+	"document",
+	"block",
+	"proposal",
+	"review_request",
+	"steward",
+	"comment",
+	"service_account",
+	"api_key",
+];
+
+const formatTime = (iso: string) =>
+	new Date(iso).toLocaleString("en-US", {
+		month: "short",
+		day: "numeric",
+		year: "numeric",
+		hour: "numeric",
+		minute: "2-digit",
+	});
+
+const details = (e: AuditEvent) =>
+	JSON.stringify(
+		{
+			...(e.before !== undefined ? { before: e.before } : {}),
+			...(e.after !== undefined ? { after: e.after } : {}),
+			ip: e.ip,
+			request_id: e.request_id,
+		},
+		null,
+		2,
+	);
+
+/** Read-only view of the workspace audit log, newest first. */
+const AuditSection: React.FC<AuditSectionProps> = ({ workspaceId, members }) => {
+	const [events, setEvents] = useState<AuditEvent[]>([]);
+	const [action, setAction] = useState("");
+	const [actorId, setActorId] = useState("");
+	const [expanded, setExpanded] = useState<string | null>(null);
+	const [hasMore, setHasMore] = useState(false);
+	const [loading, setLoading] = useState(false);
+	const [error, setError] = useState<string | null>(null);
+
+	const load = useCallback(
+		async (until?: string) => {
+			setLoading(true);
+			setError(null);
+			const filter: AuditFilter = { action, actor_id: actorId, until, limit: PAGE_SIZE };
+			try {
+				const page = await workspacesApi.getAuditEvents(workspaceId, filter);
+				setEvents((prev) => (until ? [...prev, ...page] : page));
+				setHasMore(page.length === PAGE_SIZE);
+			} catch (err) {
+				setError(err instanceof Error ? err.message : "Failed to load the audit log");
+			} finally {
+				setLoading(false);
+			}
+		},
+		[workspaceId, action, actorId],
+	);
+
+	useEffect(() => {
+		load();
+	}, [load]);
+
+	return (
+		<section className="ws-settings__section">
+			<h2 className="ws-settings__section-title">Audit log</h2>
+			<p className="ws-settings__hint">
+				Every change made in this workspace, by people and agents. Entries cannot be edited or
+				removed.
+			</p>
+
+			<div className="ws-settings__role-select-row">
+				<select
+					className="ws-settings__role-select ws-settings__role-select--inline"
+					value={action}
+					onChange={(e) => setAction(e.target.value)}
+				>
+					<option value="">All actions</option>
+					{ACTION_GROUPS.map((a) => (
+						<option key={a} value={a}>
+							{a.replace("_", " ")}
+						</option>
+					))}
+				</select>
+				<select
+					className="ws-settings__role-select ws-settings__role-select--inline"
+					value={actorId}
+					onChange={(e) => setActorId(e.target.value)}
+				>
+					<option value="">Anyone</option>
+					{members.map((m) => (
+						<option key={m.user_id} value={m.user_id}>
+							{m.username}
+						</option>
+					))}
+				</select>
+				<Button
+					variant="secondary"
+					size="small"
+					onClick={() => load()}
+					isDisabled={loading}
+					isFullWidth={false}
+				>
+					<ArrowPathIcon style={{ width: 14, height: 14 }} />
+					Refresh
+				</Button>
+			</div>
+
+			<ul className="ws-settings__member-list">
+				{events.map((e) => (
+					<li key={e.id} className="ws-settings__role-item">
+						<div className="ws-settings__member-info">
+							<span className="ws-settings__member-name">{e.action}</span>
+							<span className="ws-settings__hint">
+								{formatTime(e.created_at)} · {e.actor_name ?? e.actor_type}
+								{e.target_id && ` · ${e.target_type} ${e.target_id.slice(0, 8)}`}
+							</span>
+							<button
+								type="button"
+								className="ws-settings__remove-btn"
+								onClick={() => setExpanded(expanded === e.id ? null : e.id)}
+							>
+								{expanded === e.id ? "Hide" : "Details"}
+							</button>
+						</div>
+						{expanded === e.id && <pre className="ws-settings__audit-details">{details(e)}</pre>}
+					</li>
+				))}
+			</ul>
+			{!loading && events.length === 0 && <p className="ws-settings__hint">No matching events.</p>}
+
+			{hasMore && (
+				<Button
+					variant="secondary"
+					size="small"
+					onClick={() => load(events[events.length - 1]?.created_at)}
+					isDisabled={loading}
+					isFullWidth={false}
+				>
+					Load older
+				</Button>
+			)}
+
+			{error && <p className="ws-settings__error">{error}</p>}
+		</section>
+	);
+};
+
+export default AuditSection;
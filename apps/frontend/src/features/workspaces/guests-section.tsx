@@ -0,0 +1,230 @@
+import { NoSymbolIcon, UserPlusIcon } from "@heroicons/react/24/solid";
+import type React from "react";
+import { useEffect, useState } from "react";
+import type { Document } from "@/features/documents/types";
+import { type Proposal, proposalsApi } from "@/features/proposals/proposals.api";
+import Button from "@/ui/button";
+import Input from "@/ui/input";
+import type { GuestGrant, GuestGrantEvent, GuestPermission } from "./types";
+import { workspacesApi } from "./workspaces.api";
+
+interface GuestsSectionProps {
+	workspaceId: string;
+}
+
+const GRANT_DAYS = ["7", "14", "30", "90"];
+
+const formatDate = (iso: string) =>
+	new Date(iso).toLocaleDateString("en-US", { month: "short", day: "numeric", year: "numeric" });
+
+/** Grants outside reviewers time-limited access to one document or proposal. */
+const GuestsSection: React.FC<GuestsSectionProps> = ({ workspaceId }) => {
+	const [grants, setGrants] = useState<GuestGrant[]>([]);
+	const [documents, setDocuments] = useState<Document[]>([]);
+	const [proposals, setProposals] = useState<Proposal[]>([]);
+	const [email, setEmail] = useState("");
+	const [documentId, setDocumentId] = useState("");
+	const [proposalId, setProposalId] = useState("");
+	const [permissions, setPermissions] = useState<GuestPermission[]>(["comment", "review"]);
+	const [days, setDays] = useState("14");
+	const [note, setNote] = useState("");
+	const [events, setEvents] = useState<Record<string, GuestGrantEvent[]>>({});
+	const [error, setError] = useState<string | null>(null);
+
+	useEffect(() => {
+		workspacesApi.getGuestGrants(workspaceId).then(setGrants).catch(console.error);
+		workspacesApi.getDocuments(workspaceId).then(setDocuments).catch(console.error);
+	}, [workspaceId]);
+
+	useEffect(() => {
+		setProposalId("");
+		if (!documentId) {
+			setProposals([]);
+			return;
+		}
+		proposalsApi
+			.getForDocument(documentId)
+			.then((all) => setProposals(all.filter((p) => p.state === "open")))
+			.catch(console.error);
+	}, [documentId]);
+
+	const togglePermission = (p: GuestPermission) =>
+		setPermissions((prev) => (prev.includes(p) ? prev.filter((x) => x !== p) : [...prev, p]));
+
+	const handleGrant = async () => {
+		setError(null);
+		try {
+			const grant = await workspacesApi.createGuestGrant(workspaceId, {
+				email: email.trim(),
+				...(proposalId ? { proposal_id: proposalId } : { document_id: documentId }),
+				permissions,
+				expires_in_days: Number(days),
+				note: note.trim(),
+			});
+			setGrants((prev) => [grant, ...prev]);
+			setEmail("");
+			setNote("");
+		} catch (err) {
+			setError(err instanceof Error ? err.message : "Failed to grant access");
+		}
+	};
+
+	const handleRevoke = async (grant: GuestGrant) => {
+		setError(null);
+		try {
+			await workspacesApi.revokeGuestGrant(workspaceId, grant.id);
+			setGrants((prev) => prev.map((g) => (g.id === grant.id ? { ...g, status: "revoked" } : g)));
+		} catch (err) {
+			setError(err instanceof Error ? err.message : "Failed to revoke access");
+		}
+	};
+
+	const toggleEvents = async (grant: GuestGrant) => {
+		if (events[grant.id]) {
+			setEvents(({ [grant.id]: _, ...rest }) => rest);
+			return;
+		}
+		try {
+			const list = await workspacesApi.getGuestGrantEvents(workspaceId, grant.id);
+			setEvents((prev) => ({ ...prev, [grant.id]: list }));
+		} catch (err) {
+			setError(err instanceof Error ? err.message : "Failed to load activity");
+		}
+	};
+
+	return (
+		<section className="ws-settings__section">
+			<h2 className="ws-settings__section-title">Guests</h2>
+			<p className="ws-settings__hint">
+				Guests can read one document or proposal, comment, and answer reviews requested of them.
+				They need an account with the email address you enter.
+			</p>
+
+			<ul className="ws-settings__member-list">
+				{grants.map((g) => (
+					<li key={g.id} className="ws-settings__role-item">
+						<div className="ws-settings__member-info">
+							<span className="ws-settings__member-name">{g.username}</span>
+							<span className="ws-settings__hint">
+								{g.proposal_id ? "Proposal" : "Document"} “{g.target_title}” ·{" "}
+								{g.permissions.join(", ")} ·{" "}
+								{g.status === "active" ? `until ${formatDate(g.expires_at)}` : g.status}
+							</span>
+							<button
+								type="button"
+								className="ws-settings__remove-btn"
+								onClick={() => toggleEvents(g)}
+							>
+								{events[g.id] ? "Hide activity" : "Activity"}
+							</button>
+							{g.status === "active" && (
+								<button
+									type="button"
+									className="ws-settings__remove-btn"
+									onClick={() => handleRevoke(g)}
+									title={`Revoke ${g.username}`}
+									aria-label={`Revoke ${g.username}`}
+								>
+									<NoSymbolIcon style={{ width: 14, height: 14 }} />
+								</button>
+							)}
+						</div>
+						{events[g.id] && (
+							<ul className="ws-settings__member-list">
+								{events[g.id].map((e) => (
+									<li key={e.id} className="ws-settings__hint">
+										{formatDate(e.created_at)} · {e.actor_name ?? "someone"} · {e.action}
+										{e.permission && ` (${e.permission})`}
+									</li>
+								))}
+							</ul>
+						)}
+					</li>
+				))}
+			</ul>
+
+			<div className="ws-settings__add-member">
+				<Input label="Guest email" type="email" value={email} onChange={setEmail} />
+				<div className="ws-settings__role-select-row">
+					<select
+						className="ws-settings__role-select ws-settings__role-select--inline"
+						value={documentId}
+						onChange={(e) => setDocumentId(e.target.value)}
+					>
+						<option value="">Choose a document…</option>
+						{documents.map((d) => (
+							<option key={d.id} value={d.id}>
+								{d.title || "Untitled"}
+							</option>
+						))}
+					</select>
+					<select
+						className="ws-settings__role-select ws-settings__role-select--inline"
+						value={proposalId}
+						onChange={(e) => setProposalId(e.target.value)}
+						disabled={!documentId}
+					>
+						<option value="">Whole document</option>
+						{proposals.map((p) => (
+							<option key={p.id} value={p.id}>
+								{p.title || "Untitled proposal"}
+							</option>
+						))}
+					</select>
+				</div>
+				<div className="ws-settings__permissions">
+					<label className="ws-settings__permission">
+						<input type="checkbox" checked disabled />
+						Read
+					</label>
+					<label className="ws-settings__permission">
+						<input
+							type="checkbox"
+							checked={permissions.includes("comment")}
+							onChange={() => togglePermission("comment")}
+						/>
+						Comment
+					</label>
+					<label className="ws-settings__permission">
+						<input
+							type="checkbox"
+							checked={permissions.includes("review")}
+							onChange={() => togglePermission("review")}
+						/>
+						Approve or decline
+					</label>
+				</div>
+				<select
+					className="ws-settings__role-select ws-settings__role-select--inline"
+					value={days}
+					onChange={(e) => setDays(e.target.value)}
+				>
+					{GRANT_DAYS.map((d) => (
+						<option key={d} value={d}>
+							{d} days
+						</option>
+					))}
+				</select>
+				<Input
+					label="Note"
+					placeholder="e.g. Counterparty counsel"
+					value={note}
+					onChange={setNote}
+				/>
+				{error && <p className="ws-settings__error">{error}</p>}
+				<Button
+					variant="primary"
+					size="medium"
+					onClick={handleGrant}
+					isDisabled={!email.trim() || !documentId}
+					isFullWidth={false}
+				>
+					<UserPlusIcon style={{ width: 16, height: 16 }} />
+					Grant access
+				</Button>
+			</div>
+		</section>
+	);
+};
+
+export default GuestsSection;
@@ -0,0 +1,107 @@
+import React, { useEffect, useState } from "react";
+import { Navigate, useNavigate, useSearchParams } from "react-router-dom";
+import { useAuth } from "@/features/auth/auth.context";
+import Button from "@/ui/button";
+import type { Invitation } from "./types";
+import { useWorkspace } from "./workspace.context";
+import { workspacesApi } from "./workspaces.api";
+import "./workspace-settings.page.scss";
+
+const InvitationPage: React.FC = () => {
+	const navigate = useNavigate();
+	const [searchParams] = useSearchParams();
+	const { isAuthenticated } = useAuth();
+	const { refresh, setCurrent } = useWorkspace();
+	const token = searchParams.get("token") ?? "";
+
+	const [invitation, setInvitation] = useState<Invitation | null>(null);
+	const [error, setError] = useState<string | null>(null);
+	const [busy, setBusy] = useState(false);
+
+	useEffect(() => {
+		if (!isAuthenticated || !token) return;
+		workspacesApi
+			.previewInvitation(token)
+			.then(setInvitation)
+			.catch((err) => setError(err instanceof Error ? err.message : "Invitation not found"));
+	}, [isAuthenticated, token]);
+
+	if (!isAuthenticated) {
+		const here = `/invitations/accept?token=${encodeURIComponent(token)}`;
+		return <Navigate to={`/login?redirect=${encodeURIComponent(here)}`} replace />;
+	}
+
+	const handleAccept = async () => {
+		if (!invitation || busy) return;
+		setBusy(true);
+		setError(null);
+		try {
+			await workspacesApi.acceptInvitation({ token });
+			await refresh();
+			setCurrent(invitation.workspace_id);
+			navigate("/inbox");
+		} catch (err) {
+			setError(err instanceof Error ? err.message : "Failed to accept invitation");
+		} finally {
+			setBusy(false);
+		}
+	};
+
+	const handleDecline = async () => {
+		if (!invitation || busy) return;
+		setBusy(true);
+		setError(null);
+		try {
+			await workspacesApi.declineInvitation({ token });
+			navigate("/group");
+		} catch (err) {
+			setError(err instanceof Error ? err.message : "Failed to decline invitation");
+		} finally {
+			setBusy(false);
+		}
+	};
+
+	return (
+		<div className="ws-settings">
+			<header className="ws-settings__header">
+				<h1 className="ws-settings__heading">Workspace invitation</h1>
+			</header>
+			<section className="ws-settings__section">
+				{!token && <p className="ws-settings__error">This invitation link is incomplete.</p>}
+				{invitation && (
+					<>
+						<p>
+							{invitation.inviter_name ?? "Someone"} invited you to join{" "}
+							<strong>{invitation.workspace_name}</strong> as a {invitation.role}.
+						</p>
+						<div className="ws-settings__confirm-actions">
+							{invitation.kind === "email" && (
+								<Button
+									variant="secondary"
+									size="medium"
+									onClick={handleDecline}
+									isDisabled={busy}
+									isFullWidth={false}
+								>
+									Decline
+								</Button>
+							)}
+							<Button
+								variant="primary"
+								size="medium"
+								onClick={handleAccept}
+								isDisabled={busy}
+								isFullWidth={false}
+							>
+								{busy ? "Joining…" : "Join workspace"}
+							</Button>
+						</div>
+					</>
+				)}
+				{error && <p className="ws-settings__error">{error}</p>}
+			</section>
+		</div>
+	);
+};
+
+export default InvitationPage;
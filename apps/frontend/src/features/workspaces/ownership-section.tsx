@@ -0,0 +1,229 @@
+import { ArrowRightStartOnRectangleIcon, KeyIcon } from "@heroicons/react/24/solid";
+import type React from "react";
+import { useEffect, useState } from "react";
+import Button from "@/ui/button";
+import {
+	type OwnershipTransfer,
+	type Role,
+	roleAllows,
+	type Workspace,
+	type WorkspaceMember,
+} from "./types";
+import { workspacesApi } from "./workspaces.api";
+
+interface OwnershipSectionProps {
+	workspace: Workspace;
+	members: WorkspaceMember[];
+	roles: Role[];
+	userId: string | null;
+	onOwnerChanged: () => Promise<void>;
+	onLeft: () => Promise<void>;
+}
+
+/** Offers ownership to another admin, answers an offer, or leaves the workspace. */
+const OwnershipSection: React.FC<OwnershipSectionProps> = ({
+	workspace,
+	members,
+	roles,
+	userId,
+	onOwnerChanged,
+	onLeft,
+}) => {
+	const [transfer, setTransfer] = useState<OwnershipTransfer | null>(null);
+	const [newOwnerId, setNewOwnerId] = useState("");
+	const [successorId, setSuccessorId] = useState("");
+	const [confirmLeave, setConfirmLeave] = useState(false);
+	const [busy, setBusy] = useState(false);
+	const [error, setError] = useState<string | null>(null);
+
+	const isOwner = workspace.owner_id === userId;
+	const others = members.filter((m) => m.user_id !== userId);
+	const candidates = others.filter((m) => roleAllows(roles, m.role, "manage_members"));
+	const ownerName = members.find((m) => m.user_id === workspace.owner_id)?.username ?? "the owner";
+
+	useEffect(() => {
+		workspacesApi.getOwnershipTransfer(workspace.id).then(setTransfer).catch(console.error);
+	}, [workspace.id]);
+
+	const run = async (action: () => Promise<void>, fallback: string) => {
+		setBusy(true);
+		setError(null);
+		try {
+			await action();
+		} catch (err) {
+			setError(err instanceof Error ? err.message : fallback);
+		} finally {
+			setBusy(false);
+		}
+	};
+
+	const handleOffer = () =>
+		run(async () => {
+			setTransfer(await workspacesApi.transferOwnership(workspace.id, newOwnerId));
+			setNewOwnerId("");
+		}, "Failed to offer ownership");
+
+	const handleCancel = () =>
+		run(async () => {
+			await workspacesApi.cancelOwnershipTransfer(workspace.id);
+			setTransfer(null);
+		}, "Failed to cancel the transfer");
+
+	const handleAnswer = (accept: boolean) =>
+		run(async () => {
+			if (accept) {
+				await workspacesApi.acceptOwnershipTransfer(workspace.id);
+				await onOwnerChanged();
+			} else {
+				await workspacesApi.declineOwnershipTransfer(workspace.id);
+			}
+			setTransfer(null);
+		}, "Failed to answer the transfer");
+
+	const handleLeave = () =>
+		run(async () => {
+			await workspacesApi.leave(workspace.id, successorId || undefined);
+			await onLeft();
+		}, "Failed to leave the workspace");
+
+	return (
+		<section className="ws-settings__section">
+			<h2 className="ws-settings__section-title">Ownership</h2>
+
+			{transfer && transfer.to_user_id === userId && (
+				<div className="ws-settings__confirm-delete">
+					<p className="ws-settings__confirm-text">
+						{transfer.from_username ?? "The owner"} offered you ownership of{" "}
+						<strong>{workspace.name}</strong>.
+					</p>
+					<div className="ws-settings__confirm-actions">
+						<Button
+							variant="secondary"
+							size="medium"
+							onClick={() => handleAnswer(false)}
+							isDisabled={busy}
+							isFullWidth={false}
+						>
+							Decline
+						</Button>
+						<Button
+							variant="primary"
+							size="medium"
+							onClick={() => handleAnswer(true)}
+							isDisabled={busy}
+							isFullWidth={false}
+						>
+							Accept ownership
+						</Button>
+					</div>
+				</div>
+			)}
+
+			{isOwner ? (
+				transfer ? (
+					<div className="ws-settings__role-select-row">
+						<span className="ws-settings__hint">
+							Waiting for {transfer.to_username} to accept ownership.
+						</span>
+						<Button
+							variant="secondary"
+							size="small"
+							onClick={handleCancel}
+							isDisabled={busy}
+							isFullWidth={false}
+						>
+							Cancel offer
+						</Button>
+					</div>
+				) : (
+					<>
+						<p className="ws-settings__hint">
+							You own this workspace. Offer it to another member who can manage members; you stay
+							a member after they accept, and can leave then.
+						</p>
+						<div className="ws-settings__role-select-row">
+							<select
+								className="ws-settings__role-select ws-settings__role-select--inline"
+								value={newOwnerId}
+								onChange={(e) => setNewOwnerId(e.target.value)}
+							>
+								<option value="">Choose the new owner…</option>
+								{candidates.map((m) => (
+									<option key={m.user_id} value={m.user_id}>
+										{m.username}
+									</option>
+								))}
+							</select>
+							<Button
+								variant="primary"
+								size="medium"
+								onClick={handleOffer}
+								isDisabled={busy || !newOwnerId}
+								isFullWidth={false}
+							>
+								<KeyIcon style={{ width: 14, height: 14 }} />
+								Offer ownership
+							</Button>
+						</div>
+					</>
+				)
+			) : (
+				<>
+					<p className="ws-settings__hint">
+						Owned by {ownerName}. When you leave, your open proposals and steward duties are handed
+						to the member you choose.
+					</p>
+					{!confirmLeave ? (
+						<Button
+							variant="secondary"
+							size="medium"
+							onClick={() => setConfirmLeave(true)}
+							isFullWidth={false}
+						>
+							<ArrowRightStartOnRectangleIcon style={{ width: 14, height: 14 }} />
+							Leave workspace
+						</Button>
+					) : (
+						<div className="ws-settings__role-select-row">
+							<select
+								className="ws-settings__role-select ws-settings__role-select--inline"
+								value={successorId}
+								onChange={(e) => setSuccessorId(e.target.value)}
+							>
+								<option value="">Hand my work to {ownerName}</option>
+								{others
+									.filter((m) => m.user_id !== workspace.owner_id)
+									.map((m) => (
+										<option key={m.user_id} value={m.user_id}>
+											Hand my work to {m.username}
+										</option>
+									))}
+							</select>
+							<Button
+								variant="secondary"
+								size="medium"
+								onClick={() => setConfirmLeave(false)}
+								isFullWidth={false}
+							>
+								Cancel
+							</Button>
+							<Button
+								variant="primary"
+								size="medium"
+								onClick={handleLeave}
+								isDisabled={busy}
+								isFullWidth={false}
+							>
+								{busy ? "Leaving…" : "Leave"}
+							</Button>
+						</div>
+					)}
+				</>
+			)}
+
+			{error && <p className="ws-settings__error">{error}</p>}
+		</section>
+	);
+};
+
+export default OwnershipSection;
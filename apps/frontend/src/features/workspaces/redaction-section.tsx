@@ -0,0 +1,137 @@
+import { EyeSlashIcon } from "@heroicons/react/24/solid";
+import type React from "react";
+import { useEffect, useState } from "react";
+import { documentsApi } from "@/features/documents/documents.api";
+import type { Document, Redaction } from "@/features/documents/types";
+import Button from "@/ui/button";
+import Input from "@/ui/input";
+import { workspacesApi } from "./workspaces.api";
+
+interface RedactionSectionProps {
+	workspaceId: string;
+}
+
+const formatDate = (iso: string) =>
+	new Date(iso).toLocaleDateString("en-US", { month: "short", day: "numeric", year: "numeric" });
+
+const summarize = (r: Redaction) =>
+	[
+		`${r.blocks_redacted} blocks`,
+		`${r.proposals_redacted} proposals`,
+		`${r.changes_redacted} proposed changes`,
+		`${r.artifacts_redacted} summaries`,
+		`${r.comments_redacted} comments`,
+		`${r.findings_redacted} conflict findings`,
+		`${r.events_redacted} audit events`,
+	].join(", ");
+
+/** Scrubs sensitive text from a document and everything that recorded it. */
+const RedactionSection: React.FC<RedactionSectionProps> = ({ workspaceId }) => {
+	const [documents, setDocuments] = useState<Document[]>([]);
+	const [documentId, setDocumentId] = useState("");
+	const [redactions, setRedactions] = useState<Redaction[]>([]);
+	const [text, setText] = useState("");
+	const [reason, setReason] = useState("");
+	const [confirming, setConfirming] = useState(false);
+	const [error, setError] = useState<string | null>(null);
+
+	useEffect(() => {
+		workspacesApi.getDocuments(workspaceId).then(setDocuments).catch(console.error);
+	}, [workspaceId]);
+
+	useEffect(() => {
+		setRedactions([]);
+		if (!documentId) return;
+		documentsApi.getRedactions(documentId).then(setRedactions).catch(console.error);
+	}, [documentId]);
+
+	const handleRedact = async () => {
+		// Redaction cannot be undone, so it takes a second click
+		if (!confirming) {
+			setConfirming(true);
+			return;
+		}
+		setConfirming(false);
+		setError(null);
+		try {
+			const redaction = await documentsApi.redact(documentId, text, reason.trim());
+			setRedactions((prev) => [redaction, ...prev]);
+			setText("");
+			setReason("");
+		} catch (err) {
+			setError(err instanceof Error ? err.message : "Failed to redact");
+		}
+	};
+
+	return (
+		<section className="ws-settings__section">
+			<h2 className="ws-settings__section-title">Redaction</h2>
+			<p className="ws-settings__hint">
+				Replaces text with [REDACTED] in a document, its proposals, their summaries, comments and
+				conflict findings, and the audit log. Decision reasons stay intact. This cannot be undone.
+			</p>
+
+			<div className="ws-settings__add-member">
+				<select
+					className="ws-settings__role-select ws-settings__role-select--inline"
+					value={documentId}
+					onChange={(e) => {
+						setDocumentId(e.target.value);
+						setConfirming(false);
+					}}
+				>
+					<option value="">Choose a document</option>
+					{documents.map((d) => (
+						<option key={d.id} value={d.id}>
+							{d.title || "Untitled"}
+						</option>
+					))}
+				</select>
+				<Input
+					label="Text to redact"
+					placeholder="Exactly as it appears"
+					value={text}
+					onChange={(v) => {
+						setText(v);
+						setConfirming(false);
+					}}
+				/>
+				<Input
+					label="Reason"
+					placeholder="e.g. Personal data removed on request"
+					value={reason}
+					onChange={setReason}
+				/>
+				{error && <p className="ws-settings__error">{error}</p>}
+				<Button
+					variant="primary"
+					size="medium"
+					onClick={handleRedact}
+					isDisabled={!documentId || text.trim().length < 3 || !reason.trim()}
+					isFullWidth={false}
+				>
+					<EyeSlashIcon style={{ width: 16, height: 16 }} />
+					{confirming ? "Redact for good?" : "Redact"}
+				</Button>
+			</div>
+
+			{redactions.length > 0 && (
+				<ul className="ws-settings__member-list">
+					{redactions.map((r) => (
+						<li key={r.id} className="ws-settings__role-item">
+							<div className="ws-settings__member-info">
+								<span className="ws-settings__member-name">{r.reason}</span>
+								<span className="ws-settings__hint">
+									{formatDate(r.created_at)}
+									{r.redacted_by_name && ` by ${r.redacted_by_name}`} · {summarize(r)}
+								</span>
+							</div>
+						</li>
+					))}
+				</ul>
+			)}
+		</section>
+	);
+};
+
+export default RedactionSection;
@@ -0,0 +1,245 @@
+import { LockClosedIcon, LockOpenIcon } from "@heroicons/react/24/solid";
+import type React from "react";
+import { useEffect, useState } from "react";
+import type { Document } from "@/features/documents/types";
+import Button from "@/ui/button";
+import Input from "@/ui/input";
+import type { LegalHold, RetentionPolicy, RetentionTarget } from "./types";
+import { workspacesApi } from "./workspaces.api";
+
+interface RetentionSectionProps {
+	workspaceId: string;
+}
+
+const TARGETS: { target: RetentionTarget; label: string }[] = [
+	{ target: "documents", label: "Documents" },
+	{ target: "open_proposals", label: "Open proposals" },
+	{ target: "accepted_proposals", label: "Accepted proposals" },
+	{ target: "rejected_proposals", label: "Rejected proposals" },
+];
+
+interface PolicyDraft {
+	keepDays: string;
+	dispose: boolean;
+}
+
+const formatDate = (iso: string) =>
+	new Date(iso).toLocaleDateString("en-US", { month: "short", day: "numeric", year: "numeric" });
+
+const toDraft = (p?: RetentionPolicy): PolicyDraft => ({
+	keepDays: p ? String(p.keep_days) : "",
+	dispose: p?.dispose ?? false,
+});
+
+/** Retention policies and legal holds; both stop records from being deleted. */
+const RetentionSection: React.FC<RetentionSectionProps> = ({ workspaceId }) => {
+	const [policies, setPolicies] = useState<RetentionPolicy[]>([]);
+	const [drafts, setDrafts] = useState<Record<string, PolicyDraft>>({});
+	const [holds, setHolds] = useState<LegalHold[]>([]);
+	const [documents, setDocuments] = useState<Document[]>([]);
+	const [documentId, setDocumentId] = useState("");
+	const [reason, setReason] = useState("");
+	const [error, setError] = useState<string | null>(null);
+
+	useEffect(() => {
+		workspacesApi
+			.getRetention(workspaceId)
+			.then((settings) => {
+				setPolicies(settings.policies);
+				setHolds(settings.holds);
+			})
+			.catch(console.error);
+		workspacesApi.getDocuments(workspaceId).then(setDocuments).catch(console.error);
+	}, [workspaceId]);
+
+	const policyFor = (target: RetentionTarget) => policies.find((p) => p.target === target);
+	const draftFor = (target: RetentionTarget) => drafts[target] ?? toDraft(policyFor(target));
+	const setDraft = (target: RetentionTarget, patch: Partial<PolicyDraft>) =>
+		setDrafts((prev) => ({ ...prev, [target]: { ...draftFor(target), ...patch } }));
+
+	const handleSave = async (target: RetentionTarget) => {
+		setError(null);
+		const draft = draftFor(target);
+		try {
+			const saved = await workspacesApi.setRetentionPolicy(
+				workspaceId,
+				target,
+				Number(draft.keepDays),
+				draft.dispose,
+			);
+			setPolicies((prev) => [...prev.filter((p) => p.target !== target), saved]);
+			setDrafts(({ [target]: _, ...rest }) => rest);
+		} catch (err) {
+			setError(err instanceof Error ? err.message : "Failed to save retention policy");
+		}
+	};
+
+	const handleRemove = async (target: RetentionTarget) => {
+		setError(null);
+		try {
+			await workspacesApi.deleteRetentionPolicy(workspaceId, target);
+			setPolicies((prev) => prev.filter((p) => p.target !== target));
+			setDrafts(({ [target]: _, ...rest }) => rest);
+		} catch (err) {
+			setError(err instanceof Error ? err.message : "Failed to remove retention policy");
+		}
+	};
+
+	const handlePlaceHold = async () => {
+		setError(null);
+		try {
+			const hold = await workspacesApi.placeLegalHold(
+				workspaceId,
+				reason.trim(),
+				documentId || undefined,
+			);
+			setHolds((prev) => [hold, ...prev]);
+			setReason("");
+			setDocumentId("");
+		} catch (err) {
+			setError(err instanceof Error ? err.message : "Failed to place legal hold");
+		}
+	};
+
+	const handleRelease = async (hold: LegalHold) => {
+		setError(null);
+		try {
+			await workspacesApi.releaseLegalHold(workspaceId, hold.id);
+			const releasedAt = new Date().toISOString();
+			setHolds((prev) =>
+				prev.map((h) => (h.id === hold.id ? { ...h, released_at: releasedAt } : h)),
+			);
+		} catch (err) {
+			setError(err instanceof Error ? err.message : "Failed to release legal hold");
+		}
+	};
+
+	return (
+		<section className="ws-settings__section">
+			<h2 className="ws-settings__section-title">Retention & legal hold</h2>
+			<p className="ws-settings__hint">
+				Records cannot be deleted until their retention period is over. Proposals count from
+				their decision, documents and open proposals from their last change. With “Purge
+				afterwards”, expired records are deleted automatically; signed proposals never are.
+			</p>
+
+			<ul className="ws-settings__member-list">
+				{TARGETS.map(({ target, label }) => {
+					const policy = policyFor(target);
+					const draft = draftFor(target);
+					return (
+						<li key={target} className="ws-settings__role-item">
+							<div className="ws-settings__member-info">
+								<span className="ws-settings__member-name">{label}</span>
+								<span className="ws-settings__hint">
+									{policy
+										? `kept ${policy.keep_days} days${policy.dispose ? ", then purged" : ""}`
+										: "no policy"}
+								</span>
+							</div>
+							<div className="ws-settings__role-select-row">
+								<Input
+									label="Keep for (days)"
+									placeholder="e.g. 2555 for 7 years"
+									value={draft.keepDays}
+									onChange={(v) => setDraft(target, { keepDays: v })}
+								/>
+								<label className="ws-settings__permission">
+									<input
+										type="checkbox"
+										checked={draft.dispose}
+										onChange={(e) => setDraft(target, { dispose: e.target.checked })}
+									/>
+									Purge afterwards
+								</label>
+								<Button
+									variant="secondary"
+									size="small"
+									onClick={() => handleSave(target)}
+									isDisabled={!draft.keepDays}
+									isFullWidth={false}
+								>
+									Save
+								</Button>
+								{policy && (
+									<button
+										type="button"
+										className="ws-settings__remove-btn"
+										onClick={() => handleRemove(target)}
+									>
+										Remove
+									</button>
+								)}
+							</div>
+						</li>
+					);
+				})}
+			</ul>
+
+			<h3 className="ws-settings__section-title">Legal holds</h3>
+			<p className="ws-settings__hint">
+				Nothing under an active hold can be deleted, by anyone or by the purge.
+			</p>
+			<ul className="ws-settings__member-list">
+				{holds.map((h) => (
+					<li key={h.id} className="ws-settings__role-item">
+						<div className="ws-settings__member-info">
+							<span className="ws-settings__member-name">
+								{h.document_id ? `“${h.document_title || "Untitled"}”` : "Whole workspace"}
+							</span>
+							<span className="ws-settings__hint">
+								{h.reason} · placed {formatDate(h.placed_at)}
+								{h.released_at && ` · released ${formatDate(h.released_at)}`}
+							</span>
+							{!h.released_at && (
+								<button
+									type="button"
+									className="ws-settings__remove-btn"
+									onClick={() => handleRelease(h)}
+									title="Release hold"
+									aria-label="Release hold"
+								>
+									<LockOpenIcon style={{ width: 14, height: 14 }} />
+								</button>
+							)}
+						</div>
+					</li>
+				))}
+			</ul>
+
+			<div className="ws-settings__add-member">
+				<select
+					className="ws-settings__role-select ws-settings__role-select--inline"
+					value={documentId}
+					onChange={(e) => setDocumentId(e.target.value)}
+				>
+					<option value="">Whole workspace</option>
+					{documents.map((d) => (
+						<option key={d.id} value={d.id}>
+							{d.title || "Untitled"}
+						</option>
+					))}
+				</select>
+				<Input
+					label="Reason"
+					placeholder="e.g. Litigation hold, matter 2026-114"
+					value={reason}
+					onChange={setReason}
+				/>
+				{error && <p className="ws-settings__error">{error}</p>}
+				<Button
+					variant="primary"
+					size="medium"
+					onClick={handlePlaceHold}
+					isDisabled={!reason.trim()}
+					isFullWidth={false}
+				>
+					<LockClosedIcon style={{ width: 16, height: 16 }} />
+					Place hold
+				</Button>
+			</div>
+		</section>
+	);
+};
+
+export default RetentionSection;
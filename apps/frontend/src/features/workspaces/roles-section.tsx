@@ -0,0 +1,146 @@
+import { PlusIcon, TrashIcon } from "@heroicons/react/24/solid";
+import React, { useState } from "react";
+import Button from "@/ui/button";
+import Input from "@/ui/input";
+import { PERMISSIONS, type Permission, type Role } from "./types";
+import { workspacesApi } from "./workspaces.api";
+
+interface RolesSectionProps {
+	workspaceId: string;
+	roles: Role[];
+	onChange: (roles: Role[]) => void;
+}
+
+const permissionLabel = (p: Permission) => p.replace("_", " ");
+
+/** Lists the workspace roles and lets policy managers define custom ones. */
+const RolesSection: React.FC<RolesSectionProps> = ({ workspaceId, roles, onChange }) => {
+	const [name, setName] = useState("");
+	const [description, setDescription] = useState("");
+	const [permissions, setPermissions] = useState<Permission[]>(["read"]);
+	const [saving, setSaving] = useState(false);
+	const [error, setError] = useState<string | null>(null);
+
+	const togglePermission = (p: Permission) =>
+		setPermissions((prev) => (prev.includes(p) ? prev.filter((x) => x !== p) : [...prev, p]));
+
+	const handleCreate = async () => {
+		if (!name.trim() || saving) return;
+		setSaving(true);
+		setError(null);
+		try {
+			const role = await workspacesApi.createRole(workspaceId, {
+				name: name.trim(),
+				description: description.trim(),
+				permissions,
+			});
+			onChange([...roles, role]);
+			setName("");
+			setDescription("");
+			setPermissions(["read"]);
+		} catch (err) {
+			setError(err instanceof Error ? err.message : "Failed to create role");
+		} finally {
+			setSaving(false);
+		}
+	};
+
+	const handleTogglePermission = async (role: Role, p: Permission) => {
+		const next = role.permissions.includes(p)
+			? role.permissions.filter((x) => x !== p)
+			: [...role.permissions, p];
+		setError(null);
+		try {
+			const updated = await workspacesApi.updateRole(workspaceId, role.name, {
+				description: role.description,
+				permissions: next,
+			});
+			onChange(roles.map((r) => (r.name === role.name ? updated : r)));
+		} catch (err) {
+			setError(err instanceof Error ? err.message : "Failed to update role");
+		}
+	};
+
+	const handleDelete = async (role: Role) => {
+		setError(null);
+		try {
+			await workspacesApi.deleteRole(workspaceId, role.name);
+			onChange(roles.filter((r) => r.name !== role.name));
+		} catch (err) {
+			setError(err instanceof Error ? err.message : "Failed to delete role");
+		}
+	};
+
+	return (
+		<section className="ws-settings__section">
+			<h2 className="ws-settings__section-title">Roles</h2>
+
+			<ul className="ws-settings__member-list">
+				{roles.map((role) => (
+					<li key={role.name} className="ws-settings__role-item">
+						<div className="ws-settings__member-info">
+							<span className="ws-settings__member-name">{role.name}</span>
+							{role.built_in && <span className="ws-settings__hint">built-in</span>}
+							{role.description && <span className="ws-settings__hint">{role.description}</span>}
+						</div>
+						<div className="ws-settings__permissions">
+							{PERMISSIONS.map((p) => (
+								<label key={p} className="ws-settings__permission">
+									<input
+										type="checkbox"
+										checked={role.permissions.includes(p)}
+										disabled={role.built_in || p === "read"}
+										onChange={() => handleTogglePermission(role, p)}
+									/>
+									{permissionLabel(p)}
+								</label>
+							))}
+							{!role.built_in && (
+								<button
+									type="button"
+									className="ws-settings__remove-btn"
+									onClick={() => handleDelete(role)}
+									title={`Delete ${role.name}`}
+									aria-label={`Delete ${role.name}`}
+								>
+									<TrashIcon style={{ width: 14, height: 14 }} />
+								</button>
+							)}
+						</div>
+					</li>
+				))}
+			</ul>
+
+			<div className="ws-settings__add-member">
+				<Input label="New role" placeholder="e.g. counsel" value={name} onChange={setName} />
+				<Input label="Description" value={description} onChange={setDescription} />
+				<div className="ws-settings__permissions">
+					{PERMISSIONS.map((p) => (
+						<label key={p} className="ws-settings__permission">
+							<input
+								type="checkbox"
+								checked={p === "read" || permissions.includes(p)}
+								disabled={p === "read"}
+								onChange={() => togglePermission(p)}
+							/>
+							{permissionLabel(p)}
+						</label>
+					))}
+				</div>
+				{error && <p className="ws-settings__error">{error}</p>}
+				<Button
+					variant="primary"
+					size="medium"
+					onClick={handleCreate}
+					isDisabled={saving || !name.trim()}
+					isFullWidth={false}
+				>
+					<PlusIcon style={{ width: 16, height: 16 }} />
+					{saving ? "Creating…" : "Create role"}
+				</Button>
+			</div>
+		</section>
+	);
+};
+
+export default RolesSection;
@@ -0,0 +1,182 @@
+import { PlusIcon, StarIcon, TrashIcon, UserMinusIcon } from "@heroicons/react/24/solid";
+import type React from "react";
+import { useEffect, useState } from "react";
+import Button from "@/ui/button";
+import Input from "@/ui/input";
+import type { Team, WorkspaceMember } from "./types";
+import { workspacesApi } from "./workspaces.api";
+
+interface TeamsSectionProps {
+	workspaceId: string;
+	members: WorkspaceMember[];
+	userId: string | null;
+	canManageMembers: boolean;
+}
+
+/** Lists the workspace teams. Member managers and team leads can edit membership. */
+const TeamsSection: React.FC<TeamsSectionProps> = ({
+	workspaceId,
+	members,
+	userId,
+	canManageMembers,
+}) => {
+	const [teams, setTeams] = useState<Team[]>([]);
+	const [name, setName] = useState("");
+	const [description, setDescription] = useState("");
+	const [addUser, setAddUser] = useState<Record<string, string>>({});
+	const [error, setError] = useState<string | null>(null);
+
+	useEffect(() => {
+		workspacesApi.getTeams(workspaceId).then(setTeams).catch(console.error);
+	}, [workspaceId]);
+
+	const replaceTeam = (team: Team) =>
+		setTeams((prev) => prev.map((t) => (t.id === team.id ? team : t)));
+
+	const run = async (action: () => Promise<void>, failure: string) => {
+		setError(null);
+		try {
+			await action();
+		} catch (err) {
+			setError(err instanceof Error ? err.message : failure);
+		}
+	};
+
+	const handleCreate = () =>
+		run(async () => {
+			if (!name.trim()) return;
+			const team = await workspacesApi.createTeam(workspaceId, name.trim(), description.trim());
+			setTeams((prev) => [...prev, team].sort((a, b) => a.name.localeCompare(b.name)));
+			setName("");
+			setDescription("");
+		}, "Failed to create team");
+
+	const handleDelete = (team: Team) =>
+		run(async () => {
+			await workspacesApi.deleteTeam(workspaceId, team.id);
+			setTeams((prev) => prev.filter((t) => t.id !== team.id));
+		}, "Failed to delete team");
+
+	const handleSetMember = (team: Team, memberId: string, isLead: boolean) =>
+		run(async () => {
+			replaceTeam(await workspacesApi.setTeamMember(workspaceId, team.id, memberId, isLead));
+			setAddUser((prev) => ({ ...prev, [team.id]: "" }));
+		}, "Failed to update team");
+
+	const handleRemoveMember = (team: Team, memberId: string) =>
+		run(async () => {
+			await workspacesApi.removeTeamMember(workspaceId, team.id, memberId);
+			replaceTeam({ ...team, members: team.members.filter((m) => m.user_id !== memberId) });
+		}, "Failed to update team");
+
+	return (
+		<section className="ws-settings__section">
+			<h2 className="ws-settings__section-title">Teams</h2>
+
+			{teams.length === 0 && <p className="ws-settings__hint">No teams yet.</p>}
+			<ul className="ws-settings__member-list">
+				{teams.map((team) => {
+					const canEdit =
+						canManageMembers || team.members.some((m) => m.user_id === userId && m.is_lead);
+					const candidates = members.filter(
+						(m) => !team.members.some((t) => t.user_id === m.user_id)
+					);
+					return (
+						<li key={team.id} className="ws-settings__role-item">
+							<div className="ws-settings__member-info">
+								<span className="ws-settings__member-name">{team.name}</span>
+								{team.description && <span className="ws-settings__hint">{team.description}</span>}
+								{canManageMembers && (
+									<button
+										type="button"
+										className="ws-settings__remove-btn"
+										onClick={() => handleDelete(team)}
+										title={`Delete ${team.name}`}
+										aria-label={`Delete ${team.name}`}
+									>
+										<TrashIcon style={{ width: 14, height: 14 }} />
+									</button>
+								)}
+							</div>
+							<div className="ws-settings__permissions">
+								{team.members.map((m) => (
+									<span key={m.user_id} className="ws-settings__permission">
+										{m.username}
+										{m.is_lead && " (lead)"}
+										{canEdit && (
+											<>
+												<button
+													type="button"
+													className="ws-settings__remove-btn"
+													onClick={() => handleSetMember(team, m.user_id, !m.is_lead)}
+													title={m.is_lead ? "Remove lead" : "Make lead"}
+													aria-label={m.is_lead ? "Remove lead" : "Make lead"}
+												>
+													<StarIcon style={{ width: 12, height: 12 }} />
+												</button>
+												<button
+													type="button"
+													className="ws-settings__remove-btn"
+													onClick={() => handleRemoveMember(team, m.user_id)}
+													title={`Remove ${m.username}`}
+													aria-label={`Remove ${m.username}`}
+												>
+													<UserMinusIcon style={{ width: 12, height: 12 }} />
+												</button>
+											</>
+										)}
+									</span>
+								))}
+							</div>
+							{canEdit && candidates.length > 0 && (
+								<div className="ws-settings__role-select-row">
+									<select
+										className="ws-settings__role-select ws-settings__role-select--inline"
+										value={addUser[team.id] ?? ""}
+										onChange={(e) => setAddUser((prev) => ({ ...prev, [team.id]: e.target.value }))}
+									>
+										<option value="">Add a member…</option>
+										{candidates.map((m) => (
+											<option key={m.user_id} value={m.user_id}>
+												{m.username}
+											</option>
+										))}
+									</select>
+									<Button
+										variant="secondary"
+										size="small"
+										onClick={() => handleSetMember(team, addUser[team.id], false)}
+										isDisabled={!addUser[team.id]}
+										isFullWidth={false}
+									>
+										Add
+									</Button>
+								</div>
+							)}
+						</li>
+					);
+				})}
+			</ul>
+
+			{canManageMembers && (
+				<div className="ws-settings__add-member">
+					<Input label="New team" placeholder="e.g. Security" value={name} onChange={setName} />
+					<Input label="Description" value={description} onChange={setDescription} />
+					<Button
+						variant="primary"
+						size="medium"
+						onClick={handleCreate}
+						isDisabled={!name.trim()}
+						isFullWidth={false}
+					>
+						<PlusIcon style={{ width: 16, height: 16 }} />
+						Create team
+					</Button>
+				</div>
+			)}
+			{error && <p className="ws-settings__error">{error}</p>}
+		</section>
+	);
+};
+
+export default TeamsSection;
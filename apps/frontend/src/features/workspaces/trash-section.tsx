@@ -0,0 +1,117 @@
+import { ArrowUturnLeftIcon, TrashIcon } from "@heroicons/react/24/solid";
+import type React from "react";
+import { useEffect, useState } from "react";
+import type { TrashedItem } from "./types";
+import { workspacesApi } from "./workspaces.api";
+
+interface TrashSectionProps {
+	workspaceId: string;
+	canRestore: boolean;
+	canPurge: boolean;
+}
+
+const formatDate = (iso: string) =>
+	new Date(iso).toLocaleDateString("en-US", { month: "short", day: "numeric", year: "numeric" });
+
+/** Deleted documents and proposals, which can be restored or purged for good. */
+const TrashSection: React.FC<TrashSectionProps> = ({ workspaceId, canRestore, canPurge }) => {
+	const [items, setItems] = useState<TrashedItem[]>([]);
+	const [error, setError] = useState<string | null>(null);
+	// Purging takes a second click on the same item
+	const [confirmKey, setConfirmKey] = useState<string | null>(null);
+
+	useEffect(() => {
+		workspacesApi.getTrash(workspaceId).then(setItems).catch(console.error);
+	}, [workspaceId]);
+
+	const keyOf = (item: TrashedItem) => `${item.kind}:${item.id}`;
+
+	const remove = (item: TrashedItem) =>
+		setItems((prev) => prev.filter((i) => !(i.kind === item.kind && i.id === item.id)));
+
+	const handleRestore = async (item: TrashedItem) => {
+		setError(null);
+		try {
+			await workspacesApi.restoreTrashed(item);
+			remove(item);
+		} catch (err) {
+			setError(err instanceof Error ? err.message : "Failed to restore");
+		}
+	};
+
+	const handlePurge = async (item: TrashedItem) => {
+		if (confirmKey !== keyOf(item)) {
+			setConfirmKey(keyOf(item));
+			return;
+		}
+		setConfirmKey(null);
+		setError(null);
+		try {
+			await workspacesApi.purgeTrashed(item);
+			remove(item);
+		} catch (err) {
+			setError(err instanceof Error ? err.message : "Failed to delete");
+		}
+	};
+
+	return (
+		<section className="ws-settings__section">
+			<h2 className="ws-settings__section-title">Trash</h2>
+			<p className="ws-settings__hint">
+				Deleted documents and proposals stay here until their restore window ends, then they are
+				deleted for good.
+			</p>
+
+			{items.length === 0 ? (
+				<p className="ws-settings__hint">The trash is empty.</p>
+			) : (
+				<ul className="ws-settings__member-list">
+					{items.map((item) => {
+						const restorable = new Date(item.restorable_until) > new Date();
+						return (
+							<li key={keyOf(item)} className="ws-settings__role-item">
+								<div className="ws-settings__member-info">
+									<span className="ws-settings__member-name">{item.title || "Untitled"}</span>
+									<span className="ws-settings__hint">
+										{item.kind === "document" ? "Document" : "Proposal"} · deleted{" "}
+										{formatDate(item.deleted_at)}
+										{item.deleted_by_name && ` by ${item.deleted_by_name}`} ·{" "}
+										{restorable
+											? `restorable until ${formatDate(item.restorable_until)}`
+											: "no longer restorable"}
+									</span>
+									{canRestore && restorable && (
+										<button
+											type="button"
+											className="ws-settings__remove-btn"
+											onClick={() => handleRestore(item)}
+											title="Restore"
+											aria-label={`Restore ${item.title}`}
+										>
+											<ArrowUturnLeftIcon style={{ width: 14, height: 14 }} />
+										</button>
+									)}
+									{canPurge && (
+										<button
+											type="button"
+											className="ws-settings__remove-btn"
+											onClick={() => handlePurge(item)}
+											title="Delete for good"
+											aria-label={`Delete ${item.title} for good`}
+										>
+											<TrashIcon style={{ width: 14, height: 14 }} />
+											{confirmKey === keyOf(item) && " Delete for good?"}
+										</button>
+									)}
+								</div>
+							</li>
+						);
+					})}
+				</ul>
+			)}
+			{error && <p className="ws-settings__error">{error}</p>}
+		</section>
+	);
+};
+
+export default TrashSection;
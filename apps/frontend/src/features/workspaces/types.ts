@@ -3,6 +3,10 @@ export interface Workspace {
 	name: string;
 	description: string;
 	owner_id: string;
+	require_two_factor?: boolean;
+	require_signature?: boolean;
+	signature_meanings?: string[];
+	require_steward_approval?: boolean;
 	created_at: string;
 	updated_at: string;
 }
@@ -17,4 +21,217 @@ export interface WorkspaceMember {
 	joined_at: string;
 }
 
-export type WorkspaceRole = "admin" | "reviewer" | "contributor";
+/** Built-in "admin", "reviewer" and "contributor", or the name of a custom role */
+export type WorkspaceRole = string;
+
+export type Permission =
+	| "read"
+	| "propose"
+	| "comment"
+	| "review"
+	| "accept"
+	| "manage_members"
+	| "manage_policy"
+	| "export";
+
+export const PERMISSIONS: Permission[] = [
+	"read",
+	"propose",
+	"comment",
+	"review",
+	"accept",
+	"manage_members",
+	"manage_policy",
+	"export",
+];
+
+export interface Role {
+	id?: string;
+	name: WorkspaceRole;
+	description: string;
+	permissions: Permission[];
+	built_in: boolean;
+}
+
+/** Permissions granted to role, or none if the role is unknown */
+export const roleAllows = (roles: Role[], role: WorkspaceRole, permission: Permission) =>
+	roles.find((r) => r.name === role)?.permissions.includes(permission) ?? false;
+
+export interface Invitation {
+	id: string;
+	workspace_id: string;
+	workspace_name?: string;
+	kind: "email" | "link";
+	email?: string;
+	role: WorkspaceRole;
+	prefix: string;
+	max_uses?: number;
+	use_count: number;
+	status: "pending" | "accepted" | "declined" | "revoked";
+	invited_by: string | null;
+	inviter_name?: string;
+	expires_at: string;
+	created_at: string;
+}
+
+/** Returned once when an invite link is created; url is not shown again. */
+export interface CreatedInvitation extends Invitation {
+	url?: string;
+}
+
+export interface TeamMember {
+	user_id: string;
+	username: string;
+	is_lead: boolean;
+	added_at: string;
+}
+
+/** A named group of members that can be asked to review in place of a person. */
+export interface Team {
+	id: string;
+	workspace_id: string;
+	name: string;
+	description: string;
+	members: TeamMember[];
+	created_at: string;
+	updated_at: string;
+}
+
+export type GuestPermission = "read" | "comment" | "review";
+
+/** Time-limited access for someone outside the workspace to one document or proposal. */
+export interface GuestGrant {
+	id: string;
+	workspace_id: string;
+	workspace_name?: string;
+	user_id: string;
+	username: string;
+	email: string;
+	document_id?: string;
+	proposal_id?: string;
+	target_title: string;
+	permissions: GuestPermission[];
+	note: string;
+	status: "active" | "expired" | "revoked";
+	granted_by: string | null;
+	expires_at: string;
+	revoked_at?: string;
+	revoked_by?: string;
+	created_at: string;
+}
+
+export interface GuestGrantEvent {
+	id: string;
+	grant_id: string;
+	actor_id: string | null;
+	actor_name?: string;
+	action: "granted" | "revoked" | "used";
+	permission?: string;
+	target_id?: string;
+	created_at: string;
+}
+
+export interface CreateGuestGrantRequest {
+	email: string;
+	document_id?: string;
+	proposal_id?: string;
+	permissions: GuestPermission[];
+	expires_in_days?: number;
+	note?: string;
+}
+
+/** An offer of workspace ownership waiting on the new owner */
+export interface OwnershipTransfer {
+	id: string;
+	workspace_id: string;
+	workspace_name?: string;
+	from_user_id: string | null;
+	from_username?: string;
+	to_user_id: string;
+	to_username: string;
+	status: "pending" | "accepted" | "declined" | "cancelled";
+	created_at: string;
+	responded_at?: string;
+}
+
+export interface LeaveResult {
+	successor_id: string;
+	reassigned_proposals: number;
+	reassigned_stewards: number;
+}
+
+/** One entry of the append-only audit log */
+export interface AuditEvent {
+	id: string;
+	workspace_id: string | null;
+	document_id?: string;
+	actor_id: string | null;
+	actor_name?: string;
+	actor_type: "user" | "agent" | "system";
+	action: string;
+	target_type: string;
+	target_id?: string;
+	before?: unknown;
+	after?: unknown;
+	ip?: string;
+	request_id?: string;
+	created_at: string;
+}
+
+export interface AuditFilter {
+	actor_id?: string;
+	action?: string;
+	target_type?: string;
+	target_id?: string;
+	document_id?: string;
+	since?: string;
+	until?: string;
+	limit?: number;
+}
+
+export type RetentionTarget =
+	| "documents"
+	| "open_proposals"
+	| "accepted_proposals"
+	| "rejected_proposals";
+
+/** Keeps one kind of record for keep_days; with dispose, it is purged afterwards */
+export interface RetentionPolicy {
+	id: string;
+	workspace_id: string;
+	target: RetentionTarget;
+	keep_days: number;
+	dispose: boolean;
+	updated_by: string | null;
+	updated_at: string;
+}
+
+/** Blocks deletion of one document, or the whole workspace when document_id is null */
+export interface LegalHold {
+	id: string;
+	workspace_id: string;
+	document_id: string | null;
+	document_title?: string;
+	reason: string;
+	placed_by: string | null;
+	placed_at: string;
+	released_by?: string;
+	released_at?: string;
+}
+
+export interface RetentionSettings {
+	policies: RetentionPolicy[];
+	holds: LegalHold[];
+}
+
+/** A deleted document or proposal; it can be restored until restorable_until */
+export interface TrashedItem {
+	kind: "document" | "proposal";
+	id: string;
+	document_id: string;
+	title: string;
+	deleted_at: string;
+	deleted_by: string | null;
+	deleted_by_name?: string;
+	restorable_until: string;
+}
@@ -1,8 +1,9 @@
 import { BuildingOffice2Icon, ChevronRightIcon, PlusIcon } from "@heroicons/react/24/solid";
-import React, { useState } from "react";
+import React, { useEffect, useState } from "react";
 import { useNavigate } from "react-router-dom";
 import Button from "@/ui/button";
 import Input from "@/ui/input";
+import type { GuestGrant, Invitation, OwnershipTransfer } from "./types";
 import { useWorkspace } from "./workspace.context";
 import { workspacesApi } from "./workspaces.api";
 import "./workspace-list.page.scss";
@@ -16,6 +17,43 @@ const WorkspaceListPage: React.FC = () => {
 	const [name, setName] = useState("");
 	const [description, setDescription] = useState("");
 	const [error, setError] = useState<string | null>(null);
+	const [invitations, setInvitations] = useState<Invitation[]>([]);
+	const [guestGrants, setGuestGrants] = useState<GuestGrant[]>([]);
+	const [transfers, setTransfers] = useState<OwnershipTransfer[]>([]);
+
+	useEffect(() => {
+		workspacesApi.getMyInvitations().then(setInvitations).catch(console.error);
+		workspacesApi.getMyGuestGrants().then(setGuestGrants).catch(console.error);
+		workspacesApi.getMyOwnershipTransfers().then(setTransfers).catch(console.error);
+	}, []);
+
+	const handleRespond = async (invitation: Invitation, accept: boolean) => {
+		try {
+			if (accept) {
+				await workspacesApi.acceptInvitation({ invitation_id: invitation.id });
+				await refresh();
+			} else {
+				await workspacesApi.declineInvitation({ invitation_id: invitation.id });
+			}
+			setInvitations((prev) => prev.filter((i) => i.id !== invitation.id));
+		} catch (err) {
+			setError(err instanceof Error ? err.message : "Failed to respond to invitation");
+		}
+	};
+
+	const handleTransfer = async (transfer: OwnershipTransfer, accept: boolean) => {
+		try {
+			if (accept) {
+				await workspacesApi.acceptOwnershipTransfer(transfer.workspace_id);
+				await refresh();
+			} else {
+				await workspacesApi.declineOwnershipTransfer(transfer.workspace_id);
+			}
+			setTransfers((prev) => prev.filter((t) => t.id !== transfer.id));
+		} catch (err) {
+			setError(err instanceof Error ? err.message : "Failed to answer the ownership offer");
+		}
+	};
 
 	const handleCreate = async () => {
 		if (!name.trim() || creating) return;
@@ -87,6 +125,96 @@ const WorkspaceListPage: React.FC = () => {
 				</div>
 			)}
 
+			{invitations.length > 0 && (
+				<section className="workspaces-page__invitations">
+					<h2 className="workspaces-page__form-title">Invitations</h2>
+					{invitations.map((inv) => (
+						<div key={inv.id} className="workspaces-page__invitation">
+							<span>
+								<strong>{inv.workspace_name}</strong> · {inv.role}
+								{inv.inviter_name && ` · from ${inv.inviter_name}`}
+							</span>
+							<div className="workspaces-page__form-actions">
+								<Button
+									variant="secondary"
+									size="small"
+									onClick={() => handleRespond(inv, false)}
+									isFullWidth={false}
+								>
+									Decline
+								</Button>
+								<Button
+									variant="primary"
+									size="small"
+									onClick={() => handleRespond(inv, true)}
+									isFullWidth={false}
+								>
+									Accept
+								</Button>
+							</div>
+						</div>
+					))}
+				</section>
+			)}
+
+			{transfers.length > 0 && (
+				<section className="workspaces-page__invitations">
+					<h2 className="workspaces-page__form-title">Ownership offers</h2>
+					{transfers.map((t) => (
+						<div key={t.id} className="workspaces-page__invitation">
+							<span>
+								<strong>{t.workspace_name}</strong>
+								{t.from_username && ` · from ${t.from_username}`}
+							</span>
+							<div className="workspaces-page__form-actions">
+								<Button
+									variant="secondary"
+									size="small"
+									onClick={() => handleTransfer(t, false)}
+									isFullWidth={false}
+								>
+									Decline
+								</Button>
+								<Button
+									variant="primary"
+									size="small"
+									onClick={() => handleTransfer(t, true)}
+									isFullWidth={false}
+								>
+									Accept
+								</Button>
+							</div>
+						</div>
+					))}
+				</section>
+			)}
+
+			{guestGrants.length > 0 && (
+				<section className="workspaces-page__invitations">
+					<h2 className="workspaces-page__form-title">Shared with you</h2>
+					{guestGrants.map((g) => (
+						<div key={g.id} className="workspaces-page__invitation">
+							<span>
+								<strong>{g.target_title || "Untitled"}</strong> · {g.workspace_name} · until{" "}
+								{new Date(g.expires_at).toLocaleDateString()}
+							</span>
+							<Button
+								variant="secondary"
+								size="small"
+								onClick={() =>
+									navigate(
+										g.proposal_id ? `/proposals/${g.proposal_id}` : `/truth/${g.document_id}`
+									)
+								}
+								isFullWidth={false}
+							>
+								Open
+							</Button>
+						</div>
+					))}
+				</section>
+			)}
+
 			<main className="workspaces-page__content">
 				{loading ? (
 					<div className="workspaces-page__loading">
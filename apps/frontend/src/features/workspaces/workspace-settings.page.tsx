@@ -1,5 +1,6 @@
 import {
 	ArrowLeftIcon,
+	LinkIcon,
 	TrashIcon,
 	UserMinusIcon,
 	UserPlusIcon,
@@ -7,14 +8,33 @@ import {
 import React, { useEffect, useState } from "react";
 import { useNavigate, useParams } from "react-router-dom";
 import { useAuth } from "@/features/auth/auth.context";
+import { type UserProfile, usersApi } from "@/features/user/users.api";
 import Button from "@/ui/button";
 import Input from "@/ui/input";
 import { useWorkspace } from "./workspace.context";
-import type { WorkspaceMember, WorkspaceRole } from "./types";
+import AuditSection from "./audit-section";
+import GuestsSection from "./guests-section";
+import OwnershipSection from "./ownership-section";
+import RedactionSection from "./redaction-section";
+import RetentionSection from "./retention-section";
+import RolesSection from "./roles-section";
+import TeamsSection from "./teams-section";
+import TrashSection from "./trash-section";
+import {
+	type Invitation,
+	type Role,
+	roleAllows,
+	type WorkspaceMember,
+	type WorkspaceRole,
+} from "./types";
 import { workspacesApi } from "./workspaces.api";
 import "./workspace-settings.page.scss";
 
-const ROLES: WorkspaceRole[] = ["admin", "reviewer", "contributor"];
+// Shareable links cannot grant management permissions
+const isLinkRole = (r: Role) =>
+	!r.permissions.includes("manage_members") && !r.permissions.includes("manage_policy");
+
+const roleLabel = (name: string) => name.charAt(0).toUpperCase() + name.slice(1);
 
 const roleBadgeClass = (role: WorkspaceRole) => {
 	if (role === "admin") return "ws-settings__badge ws-settings__badge--admin";
@@ -31,6 +51,7 @@ const WorkspaceSettingsPage: React.FC = () => {
 	const workspace = workspaces.find((w) => w.id === id) ?? current;
 
 	const [members, setMembers] = useState<WorkspaceMember[]>([]);
+	const [roles, setRoles] = useState<Role[]>([]);
 	const [loadingMembers, setLoadingMembers] = useState(false);
 
 	// Edit name/description
@@ -39,11 +60,19 @@ const WorkspaceSettingsPage: React.FC = () => {
 	const [saving, setSaving] = useState(false);
 	const [saveError, setSaveError] = useState<string | null>(null);
 
-	// Add member
-	const [addUserId, setAddUserId] = useState("");
-	const [addRole, setAddRole] = useState<WorkspaceRole>("contributor");
-	const [adding, setAdding] = useState(false);
-	const [addError, setAddError] = useState<string | null>(null);
+	// Invitations
+	const [invitations, setInvitations] = useState<Invitation[]>([]);
+	const [inviteEmail, setInviteEmail] = useState("");
+	const [inviteRole, setInviteRole] = useState<WorkspaceRole>("contributor");
+	const [inviting, setInviting] = useState(false);
+	const [inviteError, setInviteError] = useState<string | null>(null);
+	const [linkRole, setLinkRole] = useState<WorkspaceRole>("contributor");
+	const [linkUrl, setLinkUrl] = useState<string | null>(null);
+	const [creatingLink, setCreatingLink] = useState(false);
+
+	// Add people the admin already shares another workspace with
+	const [userQuery, setUserQuery] = useState("");
+	const [userResults, setUserResults] = useState<UserProfile[]>([]);
 
 	// Delete workspace
 	const [deleting, setDeleting] = useState(false);
@@ -66,9 +95,22 @@ const WorkspaceSettingsPage: React.FC = () => {
 			.finally(() => setLoadingMembers(false));
 	}, [id]);
 
-	const isAdmin = members.some((m) => m.user_id === userId && m.role === "admin");
+	useEffect(() => {
+		if (!id) return;
+		workspacesApi.getRoles(id).then(setRoles).catch(console.error);
+	}, [id]);
+
+	const myRole = members.find((m) => m.user_id === userId)?.role ?? "";
+	const canManageMembers = roleAllows(roles, myRole, "manage_members");
+	const canPropose = roleAllows(roles, myRole, "propose");
+	const canManagePolicy = roleAllows(roles, myRole, "manage_policy");
 	const isOwner = workspace?.owner_id === userId;
 
+	useEffect(() => {
+		if (!id || !canManageMembers) return;
+		workspacesApi.getInvitations(id).then(setInvitations).catch(console.error);
+	}, [id, canManageMembers]);
+
 	const handleSave = async () => {
 		if (!id || !name.trim() || saving) return;
 		setSaving(true);
@@ -83,18 +125,71 @@ const WorkspaceSettingsPage: React.FC = () => {
 		}
 	};
 
-	const handleAddMember = async () => {
-		if (!id || !addUserId.trim() || adding) return;
-		setAdding(true);
-		setAddError(null);
+	useEffect(() => {
+		const q = userQuery.trim();
+		if (q.length < 2) {
+			setUserResults([]);
+			return;
+		}
+		const timer = setTimeout(() => {
+			usersApi.search(q).then(setUserResults).catch(console.error);
+		}, 250);
+		return () => clearTimeout(timer);
+	}, [userQuery]);
+
+	const handleAddMember = async (profile: UserProfile) => {
+		if (!id) return;
+		setInviteError(null);
 		try {
-			const member = await workspacesApi.addMember(id, addUserId.trim(), addRole);
+			const member = await workspacesApi.addMember(id, profile.id, inviteRole);
 			setMembers((prev) => [...prev, member]);
-			setAddUserId("");
+			setUserQuery("");
+		} catch (err) {
+			setInviteError(err instanceof Error ? err.message : "Failed to add member");
+		}
+	};
+
+	const handleInvite = async () => {
+		if (!id || !inviteEmail.trim() || inviting) return;
+		setInviting(true);
+		setInviteError(null);
+		try {
+			const invitation = await workspacesApi.inviteByEmail(id, inviteEmail.trim(), inviteRole);
+			// A new invitation replaces any earlier one for the same address
+			setInvitations((prev) => [
+				invitation,
+				...prev.filter((i) => i.kind !== "email" || i.email !== invitation.email),
+			]);
+			setInviteEmail("");
 		} catch (err) {
-			setAddError(err instanceof Error ? err.message : "Failed to add member");
+			setInviteError(err instanceof Error ? err.message : "Failed to send invitation");
 		} finally {
-			setAdding(false);
+			setInviting(false);
+		}
+	};
+
+	const handleCreateLink = async () => {
+		if (!id || creatingLink) return;
+		setCreatingLink(true);
+		setInviteError(null);
+		try {
+			const { url, ...invitation } = await workspacesApi.createInviteLink(id, { role: linkRole });
+			setInvitations((prev) => [invitation, ...prev]);
+			setLinkUrl(url ?? null);
+		} catch (err) {
+			setInviteError(err instanceof Error ? err.message : "Failed to create invite link");
+		} finally {
+			setCreatingLink(false);
+		}
+	};
+
+	const handleRevokeInvitation = async (invitation: Invitation) => {
+		if (!id) return;
+		try {
+			await workspacesApi.revokeInvitation(id, invitation.id);
+			setInvitations((prev) => prev.filter((i) => i.id !== invitation.id));
+		} catch (err) {
+			console.error("Failed to revoke invitation:", err);
 		}
 	};
 
@@ -138,6 +233,15 @@ const WorkspaceSettingsPage: React.FC = () => {
 		}
 	};
 
+	const handleLeft = async () => {
+		await refresh();
+		if (current?.id === id) {
+			const remaining = workspaces.filter((w) => w.id !== id);
+			if (remaining[0]) setCurrent(remaining[0].id);
+		}
+		navigate("/group");
+	};
+
 	if (!workspace) {
 		return (
 			<div className="ws-settings">
@@ -162,8 +266,8 @@ const WorkspaceSettingsPage: React.FC = () => {
 				<p className="ws-settings__subheading">Settings</p>
 			</header>
 
-			{/* Details section — admin only */}
-			{isAdmin && (
+			{/* Details section — manage_policy only */}
+			{canManagePolicy && (
 				<section className="ws-settings__section">
 					<h2 className="ws-settings__section-title">Details</h2>
 					<div className="ws-settings__fields">
@@ -187,39 +291,134 @@ const WorkspaceSettingsPage: React.FC = () => {
 			<section className="ws-settings__section">
 				<h2 className="ws-settings__section-title">Members</h2>
 
-				{isAdmin && (
+				{canManageMembers && (
 					<div className="ws-settings__add-member">
 						<Input
-							label="User ID"
-							placeholder="Paste a user ID to add"
-							value={addUserId}
-							onChange={setAddUserId}
+							label="Invite by email"
+							placeholder="name@example.com"
+							value={inviteEmail}
+							onChange={setInviteEmail}
 						/>
 						<div className="ws-settings__role-select-row">
 							<label className="ws-settings__role-label">Role</label>
 							<select
 								className="ws-settings__role-select"
-								value={addRole}
-								onChange={(e) => setAddRole(e.target.value as WorkspaceRole)}
+								value={inviteRole}
+								onChange={(e) => setInviteRole(e.target.value as WorkspaceRole)}
 							>
-								{ROLES.map((r) => (
-									<option key={r} value={r}>
-										{r.charAt(0).toUpperCase() + r.slice(1)}
+								{roles.map((r) => (
+									<option key={r.name} value={r.name}>
+										{roleLabel(r.name)}
 									</option>
 								))}
 							</select>
 						</div>
-						{addError && <p className="ws-settings__error">{addError}</p>}
+						<Input
+							label="Or add someone you already work with"
+							placeholder="Search by username or email"
+							value={userQuery}
+							onChange={setUserQuery}
+						/>
+						{userResults.length > 0 && (
+							<ul className="ws-settings__member-list">
+								{userResults
+									.filter((u) => !members.some((m) => m.user_id === u.id))
+									.map((u) => (
+										<li key={u.id} className="ws-settings__member-item">
+											<span className="ws-settings__member-name">{u.username}</span>
+											<button
+												type="button"
+												className="ws-settings__remove-btn"
+												onClick={() => handleAddMember(u)}
+												title={`Add ${u.username} as ${inviteRole}`}
+												aria-label={`Add ${u.username}`}
+											>
+												<UserPlusIcon style={{ width: 14, height: 14 }} />
+											</button>
+										</li>
+									))}
+							</ul>
+						)}
 						<Button
 							variant="primary"
 							size="medium"
-							onClick={handleAddMember}
-							isDisabled={adding || !addUserId.trim()}
+							onClick={handleInvite}
+							isDisabled={inviting || !inviteEmail.trim()}
 							isFullWidth={false}
 						>
 							<UserPlusIcon style={{ width: 16, height: 16 }} />
-							{adding ? "Adding…" : "Add member"}
+							{inviting ? "Sending…" : "Send invitation"}
 						</Button>
+
+						<div className="ws-settings__role-select-row">
+							<label className="ws-settings__role-label">Link</label>
+							<select
+								className="ws-settings__role-select"
+								value={linkRole}
+								onChange={(e) => setLinkRole(e.target.value as WorkspaceRole)}
+							>
+								{roles.filter(isLinkRole).map((r) => (
+									<option key={r.name} value={r.name}>
+										{roleLabel(r.name)}
+									</option>
+								))}
+							</select>
+							<Button
+								variant="secondary"
+								size="medium"
+								onClick={handleCreateLink}
+								isDisabled={creatingLink}
+								isFullWidth={false}
+							>
+								<LinkIcon style={{ width: 14, height: 14 }} />
+								{creatingLink ? "Creating…" : "Create invite link"}
+							</Button>
+						</div>
+						{linkUrl && (
+							<div className="ws-settings__invite-link">
+								<p className="ws-settings__hint">
+									Copy this link now — it won't be shown again.
+								</p>
+								<input
+									className="ws-settings__invite-link-input"
+									value={linkUrl}
+									readOnly
+									onFocus={(e) => e.target.select()}
+								/>
+							</div>
+						)}
+						{inviteError && <p className="ws-settings__error">{inviteError}</p>}
+
+						{invitations.length > 0 && (
+							<ul className="ws-settings__member-list">
+								{invitations.map((inv) => (
+									<li key={inv.id} className="ws-settings__member-item">
+										<div className="ws-settings__member-info">
+											<span className="ws-settings__member-name">
+												{inv.kind === "email"
+													? inv.email
+													: `Invite link ${inv.prefix}…${inv.max_uses ? ` (${inv.use_count}/${inv.max_uses})` : ""}`}
+											</span>
+											<span className={roleBadgeClass(inv.role)}>{inv.role}</span>
+											<span className="ws-settings__hint">
+												Expires {new Date(inv.expires_at).toLocaleDateString()}
+											</span>
+										</div>
+										<div className="ws-settings__member-actions">
+											<button
+												type="button"
+												className="ws-settings__remove-btn"
+												onClick={() => handleRevokeInvitation(inv)}
+												title="Revoke invitation"
+												aria-label="Revoke invitation"
+											>
+												<TrashIcon style={{ width: 14, height: 14 }} />
+											</button>
+										</div>
+									</li>
+								))}
+							</ul>
+						)}
 					</div>
 				)}
 
@@ -236,9 +435,9 @@ const WorkspaceSettingsPage: React.FC = () => {
 							<li key={m.user_id} className="ws-settings__member-item">
 								<div className="ws-settings__member-info">
 									<span className="ws-settings__member-name">{m.username}</span>
-									<span className={roleBadgeClass(m.role as WorkspaceRole)}>{m.role}</span>
+									<span className={roleBadgeClass(m.role)}>{m.role}</span>
 								</div>
-								{isAdmin && m.user_id !== userId && (
+								{canManageMembers && m.user_id !== userId && (
 									<div className="ws-settings__member-actions">
 										<select
 											className="ws-settings__role-select ws-settings__role-select--inline"
@@ -247,9 +446,9 @@ const WorkspaceSettingsPage: React.FC = () => {
 												handleRoleChange(m, e.target.value as WorkspaceRole)
 											}
 										>
-											{ROLES.map((r) => (
-												<option key={r} value={r}>
-													{r.charAt(0).toUpperCase() + r.slice(1)}
+											{roles.map((r) => (
+												<option key={r.name} value={r.name}>
+													{roleLabel(r.name)}
 												</option>
 											))}
 										</select>
@@ -270,6 +469,40 @@ const WorkspaceSettingsPage: React.FC = () => {
 				)}
 			</section>
 
+			{id && (
+				<TeamsSection
+					workspaceId={id}
+					members={members}
+					userId={userId}
+					canManageMembers={canManageMembers}
+				/>
+			)}
+
+			{canManageMembers && id && <GuestsSection workspaceId={id} />}
+
+			{canManagePolicy && id && (
+				<RolesSection workspaceId={id} roles={roles} onChange={setRoles} />
+			)}
+
+			{canManagePolicy && id && <RetentionSection workspaceId={id} />}
+
+			{canManagePolicy && id && <RedactionSection workspaceId={id} />}
+
+			{id && (
+				<TrashSection workspaceId={id} canRestore={canPropose} canPurge={canManagePolicy} />
+			)}
+
+			{canManagePolicy && id && <AuditSection workspaceId={id} members={members} />}
+
+			<OwnershipSection
+				workspace={workspace}
+				members={members}
+				roles={roles}
+				userId={userId}
+				onOwnerChanged={refresh}
+				onLeft={handleLeft}
+			/>
+
 			{/* Danger zone — owner only */}
 			{isOwner && (
 				<section className="ws-settings__section ws-settings__section--danger">
@@ -1,6 +1,27 @@
 import { http } from "@/lib/http";
 import type { Document } from "@/features/documents/types";
-import type { Workspace, WorkspaceMember, WorkspaceRole } from "./types";
+import type {
+	AuditEvent,
+	AuditFilter,
+	CreatedInvitation,
+	CreateGuestGrantRequest,
+	GuestGrant,
+	GuestGrantEvent,
+	Invitation,
+	LeaveResult,
+	LegalHold,
+	OwnershipTransfer,
+	Permission,
+	RetentionPolicy,
+	RetentionSettings,
+	RetentionTarget,
+	Role,
+	Team,
+	TrashedItem,
+	Workspace,
+	WorkspaceMember,
+	WorkspaceRole,
+} from "./types";
 
 export const workspacesApi = {
 	getAll: () => http.get<Workspace[]>("/workspaces"),
@@ -28,6 +49,163 @@ export const workspacesApi = {
 	removeMember: (workspaceId: string, userId: string) =>
 		http.delete<void>(`/workspaces/${workspaceId}/members/${userId}`),
 
+	// Roles
+	getRoles: (workspaceId: string) => http.get<Role[]>(`/workspaces/${workspaceId}/roles`),
+
+	createRole: (
+		workspaceId: string,
+		data: { name: string; description: string; permissions: Permission[] }
+	) => http.post<Role>(`/workspaces/${workspaceId}/roles`, data),
+
+	updateRole: (
+		workspaceId: string,
+		name: string,
+		data: { description: string; permissions: Permission[] }
+	) => http.put<Role>(`/workspaces/${workspaceId}/roles/${encodeURIComponent(name)}`, data),
+
+	deleteRole: (workspaceId: string, name: string) =>
+		http.delete<void>(`/workspaces/${workspaceId}/roles/${encodeURIComponent(name)}`),
+
+	// Teams
+	getTeams: (workspaceId: string) => http.get<Team[]>(`/workspaces/${workspaceId}/teams`),
+
+	createTeam: (workspaceId: string, name: string, description: string) =>
+		http.post<Team>(`/workspaces/${workspaceId}/teams`, { name, description }),
+
+	updateTeam: (workspaceId: string, teamId: string, name: string, description: string) =>
+		http.put<Team>(`/workspaces/${workspaceId}/teams/${teamId}`, { name, description }),
+
+	deleteTeam: (workspaceId: string, teamId: string) =>
+		http.delete<void>(`/workspaces/${workspaceId}/teams/${teamId}`),
+
+	/** Adds the member to the team, or changes whether they lead it */
+	setTeamMember: (workspaceId: string, teamId: string, userId: string, isLead: boolean) =>
+		http.put<Team>(`/workspaces/${workspaceId}/teams/${teamId}/members/${userId}`, {
+			is_lead: isLead,
+		}),
+
+	removeTeamMember: (workspaceId: string, teamId: string, userId: string) =>
+		http.delete<void>(`/workspaces/${workspaceId}/teams/${teamId}/members/${userId}`),
+
+	// Invitations (admin)
+	getInvitations: (workspaceId: string) =>
+		http.get<Invitation[]>(`/workspaces/${workspaceId}/invitations`),
+
+	inviteByEmail: (workspaceId: string, email: string, role: WorkspaceRole) =>
+		http.post<Invitation>(`/workspaces/${workspaceId}/invitations`, { email, role }),
+
+	createInviteLink: (
+		workspaceId: string,
+		data: { role: WorkspaceRole; expires_in_days?: number; max_uses?: number }
+	) => http.post<CreatedInvitation>(`/workspaces/${workspaceId}/invite-links`, data),
+
+	revokeInvitation: (workspaceId: string, invitationId: string) =>
+		http.delete<void>(`/workspaces/${workspaceId}/invitations/${invitationId}`),
+
+	// Invitations addressed to the current user
+	getMyInvitations: () => http.get<Invitation[]>("/workspaces/invitations"),
+
+	previewInvitation: (token: string) =>
+		http.post<Invitation>("/workspaces/invitations/preview", { token }),
+
+	acceptInvitation: (ref: { token?: string; invitation_id?: string }) =>
+		http.post<WorkspaceMember>("/workspaces/invitations/accept", ref),
+
+	declineInvitation: (ref: { token?: string; invitation_id?: string }) =>
+		http.post<void>("/workspaces/invitations/decline", ref),
+
+	// Guest grants
+	getGuestGrants: (workspaceId: string) =>
+		http.get<GuestGrant[]>(`/workspaces/${workspaceId}/guests`),
+
+	createGuestGrant: (workspaceId: string, grant: CreateGuestGrantRequest) =>
+		http.post<GuestGrant>(`/workspaces/${workspaceId}/guests`, grant),
+
+	revokeGuestGrant: (workspaceId: string, grantId: string) =>
+		http.delete<void>(`/workspaces/${workspaceId}/guests/${grantId}`),
+
+	getGuestGrantEvents: (workspaceId: string, grantId: string) =>
+		http.get<GuestGrantEvent[]>(`/workspaces/${workspaceId}/guests/${grantId}/events`),
+
+	/** Live guest grants held by the current user */
+	getMyGuestGrants: () => http.get<GuestGrant[]>("/workspaces/guest-grants"),
+
+	// Ownership and leaving
+	/** The pending ownership offer, or null */
+	getOwnershipTransfer: (workspaceId: string) =>
+		http.get<OwnershipTransfer | null>(`/workspaces/${workspaceId}/ownership-transfer`),
+
+	transferOwnership: (workspaceId: string, userId: string) =>
+		http.post<OwnershipTransfer>(`/workspaces/${workspaceId}/ownership-transfer`, {
+			user_id: userId,
+		}),
+
+	cancelOwnershipTransfer: (workspaceId: string) =>
+		http.delete<void>(`/workspaces/${workspaceId}/ownership-transfer`),
+
+	acceptOwnershipTransfer: (workspaceId: string) =>
+		http.post<Workspace>(`/workspaces/${workspaceId}/ownership-transfer/accept`, {}),
+
+	declineOwnershipTransfer: (workspaceId: string) =>
+		http.post<void>(`/workspaces/${workspaceId}/ownership-transfer/decline`, {}),
+
+	/** Ownership offers waiting on the current user */
+	getMyOwnershipTransfers: () => http.get<OwnershipTransfer[]>("/workspaces/ownership-transfers"),
+
+	/** Leaves the workspace; open proposals and steward rules go to the successor, or the owner */
+	leave: (workspaceId: string, successorId?: string) =>
+		http.post<LeaveResult>(`/workspaces/${workspaceId}/leave`, {
+			...(successorId ? { successor_id: successorId } : {}),
+		}),
+
+	// Audit log
+	/** Newest first; action also matches a prefix, so "member" finds every member.* event */
+	getAuditEvents: (workspaceId: string, filter: AuditFilter = {}) => {
+		const params = new URLSearchParams();
+		for (const [key, value] of Object.entries(filter)) {
+			if (value !== undefined && value !== "") params.set(key, String(value));
+		}
+		const query = params.toString();
+		return http.get<AuditEvent[]>(`/workspaces/${workspaceId}/audit${query ? `?${query}` : ""}`);
+	},
+
+	// Retention and legal holds
+	getRetention: (workspaceId: string) =>
+		http.get<RetentionSettings>(`/workspaces/${workspaceId}/retention`),
+
+	setRetentionPolicy: (
+		workspaceId: string,
+		target: RetentionTarget,
+		keepDays: number,
+		dispose: boolean,
+	) =>
+		http.put<RetentionPolicy>(`/workspaces/${workspaceId}/retention-policies/${target}`, {
+			keep_days: keepDays,
+			dispose,
+		}),
+
+	deleteRetentionPolicy: (workspaceId: string, target: RetentionTarget) =>
+		http.delete<void>(`/workspaces/${workspaceId}/retention-policies/${target}`),
+
+	/** Without documentId the hold covers the whole workspace */
+	placeLegalHold: (workspaceId: string, reason: string, documentId?: string) =>
+		http.post<LegalHold>(`/workspaces/${workspaceId}/legal-holds`, {
+			reason,
+			...(documentId ? { document_id: documentId } : {}),
+		}),
+
+	releaseLegalHold: (workspaceId: string, holdId: string) =>
+		http.delete<void>(`/workspaces/${workspaceId}/legal-holds/${holdId}`),
+
+	// Trash
+	getTrash: (workspaceId: string) => http.get<TrashedItem[]>(`/trash/workspace/${workspaceId}`),
+
+	restoreTrashed: (item: TrashedItem) =>
+		http.post<void>(`/trash/${item.kind}s/${item.id}/restore`, {}),
+
+	/** Deletes a trashed item for good */
+	purgeTrashed: (item: TrashedItem) => http.delete<void>(`/trash/${item.kind}s/${item.id}`),
+
 	// Documents scoped to a workspace
 	getDocuments: (workspaceId: string) =>
 		http.get<Document[]>(`/workspaces/${workspaceId}/documents`),